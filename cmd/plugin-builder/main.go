@@ -13,6 +13,7 @@ import (
 const pluginTemplate = `package main
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -22,12 +23,15 @@ import (
 // {{.PluginStruct}} implements the Plugin interface
 type {{.PluginStruct}} struct {
 	deps     *PluginDependencies
+	logger   *slog.Logger
 	settings map[string]interface{}
 }
 
 type PluginDependencies struct {
-	Database interface{}
-	Config   interface{}
+	Database     interface{}
+	Config       interface{}
+	Logger       *slog.Logger
+	Capabilities []string
 }
 
 type Plugin interface {
@@ -65,6 +69,8 @@ type PluginSetting struct {
 	Description string      ` + "`json:\"description,omitempty\"`" + `
 	Options     []string    ` + "`json:\"options,omitempty\"`" + `
 	Required    bool        ` + "`json:\"required\"`" + `
+	Group       string      ` + "`json:\"group,omitempty\"`" + `
+	Order       int         ` + "`json:\"order,omitempty\"`" + `
 }
 
 // NewPlugin is the entry point that will be called by the plugin manager
@@ -86,10 +92,15 @@ func (p *{{.PluginStruct}}) GetInfo() PluginInfo {
 
 func (p *{{.PluginStruct}}) Initialize(deps *PluginDependencies) error {
 	p.deps = deps
+	p.logger = deps.Logger
+	if p.logger == nil {
+		p.logger = slog.Default()
+	}
 	p.setDefaultSettings()
 	
 	// Perform any initialization here
 	// You could set up database connections, load configuration, etc.
+	p.logger.Info("plugin initialized", "version", "{{.Version}}")
 	return nil
 }
 
@@ -118,6 +129,13 @@ func (p *{{.PluginStruct}}) RegisterRoutes(router *gin.RouterGroup) {
 	}
 }
 
+// Optional: implement RegisterPublicRoutes to serve routes without authentication
+// under the same /api/v1/plugins/<name> prefix. Paths must not clash with RegisterRoutes.
+//
+// func (p *{{.PluginStruct}}) RegisterPublicRoutes(router *gin.RouterGroup) {
+// 	router.GET("/public/info", p.handleInfo)
+// }
+
 func (p *{{.PluginStruct}}) GetAdminMenuItems() []AdminMenuItem {
 	return []AdminMenuItem{
 		{
@@ -153,6 +171,8 @@ func (p *{{.PluginStruct}}) GetSettings() []PluginSetting {
 			Value:       p.settings["enabled"],
 			Description: "Enable or disable {{.MenuTitle}} functionality",
 			Required:    false,
+			Group:       "General",
+			Order:       1,
 		},
 		{
 			Key:         "auto_update",
@@ -161,6 +181,8 @@ func (p *{{.PluginStruct}}) GetSettings() []PluginSetting {
 			Value:       p.settings["auto_update"],
 			Description: "Automatically update plugin when new version is available",
 			Required:    false,
+			Group:       "General",
+			Order:       2,
 		},
 		{
 			Key:         "cache_ttl",
@@ -169,6 +191,8 @@ func (p *{{.PluginStruct}}) GetSettings() []PluginSetting {
 			Value:       p.settings["cache_ttl"],
 			Description: "Time to live for cached data in seconds",
 			Required:    true,
+			Group:       "Performance",
+			Order:       1,
 		},
 		{
 			Key:         "debug_mode",
@@ -177,12 +201,17 @@ func (p *{{.PluginStruct}}) GetSettings() []PluginSetting {
 			Value:       p.settings["debug_mode"],
 			Description: "Enable debug logging for this plugin",
 			Required:    false,
+			Group:       "Advanced",
+			Order:       1,
 		},
 	}
 }
 
 func (p *{{.PluginStruct}}) Shutdown() error {
 	// Perform cleanup here
+	if p.logger != nil {
+		p.logger.Info("plugin shutting down")
+	}
 	return nil
 }
 
@@ -234,6 +263,8 @@ func (p *{{.PluginStruct}}) handleAction(c *gin.Context) {
 		return
 	}
 
+	p.logger.Debug("handling action", "action", requestData.Action)
+
 	// Handle different actions
 	switch requestData.Action {
 	case "ping":
@@ -316,6 +347,7 @@ const pluginManifestTemplate = `{
     "go": "1.21",
     "gin": "v1.9.1"
   },
+  "capabilities": ["database"],
   "scripts": {
     "build": "go build -buildmode=plugin -o {{.PluginName}}.so .",
     "test": "go test ./...",
@@ -3,53 +3,169 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"go-cms/internal/admin"
+	"go-cms/internal/auth"
 	"go-cms/internal/config"
 	"go-cms/internal/database"
 	"go-cms/internal/database/migration"
+	"go-cms/internal/events"
 	"go-cms/internal/plugins"
 	"go-cms/internal/router"
+	"go-cms/internal/settings"
+	"go-cms/internal/themes"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	resetAdmin := flag.Bool("reset-admin-password", false,
+		"set a new super admin password (from "+resetPasswordEnv+" or a prompt) and exit")
+	resetEmail := flag.String("admin-email", "", "super admin to reset; defaults to the oldest super admin")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatal("Failed to load config:", err)
 	}
+
+	// Admin recovery runs against the database directly and never starts the server
+	if *resetAdmin {
+		if err := runResetAdminPassword(cfg, *resetEmail); err != nil {
+			log.Fatal("Failed to reset admin password: ", err)
+		}
+		return
+	}
+
+	// Listen straight away so probes get answers during startup; /health/ready
+	// stays 503 until migrations have run and plugins are loaded
+	ready := &atomic.Bool{}
+	handler := &startupHandler{}
+	srv := &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      handler,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
 	// Initialize database connection
-	db, err := database.Connect(cfg.MongoURI, cfg.DatabaseName)
+	db, err := database.Connect(cfg.MongoURI, cfg.DatabaseName, cfg.Consistency())
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	defer db.Disconnect(context.Background())
 
 	// Run database migrations
 	log.Println("Checking for database migrations...")
-	migrationManager := migration.NewManager(db)
+	adminAccount := migration.AdminAccount{Email: cfg.AdminEmail, Password: cfg.AdminPassword}
+	// Migrations use majority writes so a failover cannot roll back a recorded step
+	migrationManager := migration.NewManager(db.Critical(), cfg.MigrationTimeout, adminAccount)
 	if err := migrationManager.Run(); err != nil {
 		log.Fatal("Failed to run database migrations:", err)
 	}
 
+	// Multi-tenancy: every tenant database gets the same migrations
+	var tenants *database.TenantRegistry
+	if cfg.TenantsFile != "" {
+		tenants, err = database.LoadTenantRegistry(db, cfg.TenantsFile)
+		if err != nil {
+			log.Fatal("Failed to load tenants:", err)
+		}
+		for _, tenantID := range tenants.IDs() {
+			tenantDB, err := tenants.DB(tenantID)
+			if err != nil {
+				log.Fatal("Failed to open tenant database:", err)
+			}
+			log.Printf("Checking migrations for tenant %s...", tenantID)
+			if err := migration.NewManager(tenantDB.Critical(), cfg.MigrationTimeout, adminAccount).Run(); err != nil {
+				log.Fatalf("Failed to run migrations for tenant %s: %v", tenantID, err)
+			}
+		}
+	}
+
+	// Load site settings
+	settingDefaults := map[string]interface{}{
+		settings.KeyMaxContentSize:        cfg.MaxContentSize,
+		settings.KeyMaintenanceMode:       false,
+		settings.KeyMaintenanceMessage:    settings.DefaultMaintenanceMessage,
+		settings.KeyMaintenanceRetryAfter: settings.DefaultMaintenanceRetryAfter,
+		settings.KeyRegistrationEnabled:   cfg.RegistrationOpen,
+		settings.KeyRegistrationInvite:    cfg.InviteOnly,
+	}
+	for key, value := range settings.PasswordDefaults(auth.DefaultPasswordPolicy) {
+		settingDefaults[key] = value
+	}
+	settingsManager := settings.NewManager(db, settingDefaults)
+	if err := settingsManager.Load(); err != nil {
+		log.Printf("Warning: Failed to load site settings: %v", err)
+	}
+
+	// Event bus for lifecycle notifications
+	eventBus := events.NewBus()
+
 	// Initialize plugin manager
 	pluginManager := plugins.NewManager()
+	if cfg.PluginBuildLimit > 0 {
+		pluginManager.SetMaxConcurrentBuilds(cfg.PluginBuildLimit)
+	}
+	pluginManager.SetCacheBackend(plugins.NewMemoryCache(cfg.PluginCacheEntries))
+	pluginManager.SetLogBufferSize(cfg.PluginLogBufferSize)
+	pluginManager.SetInitTimeout(cfg.PluginInitTimeout)
+	pluginManager.SetReloadCooldown(cfg.PluginReloadCooldown)
+	pluginManager.SetDrainTimeout(cfg.PluginDrainTimeout)
+	pluginManager.SetCMSVersion(cfg.Version)
+
+	// Restore activation state so deactivated plugins stay deactivated across
+	// restarts. Plugins whose "enabled" setting is off stay loaded but their
+	// routes answer 503.
+	activationCtx, cancelActivation := context.WithTimeout(context.Background(), 10*time.Second)
+	inactive, switchedOff, err := admin.PluginActivationState(activationCtx, db)
+	if err != nil {
+		log.Printf("Warning: Failed to read plugin activation state: %v", err)
+	}
+	pluginManager.RestoreActivation(inactive, switchedOff)
+
+	// Saved debug_mode values apply from the plugin's first log line, and win
+	// over what the plugin itself reports once initialized
+	debugModes, err := admin.PluginDebugModes(activationCtx, db)
+	cancelActivation()
+	if err != nil {
+		log.Printf("Warning: Failed to read plugin debug modes: %v", err)
+	}
+	for name, enabled := range debugModes {
+		pluginManager.SetPluginDebug(name, enabled)
+	}
+
+	// Installed plugins silently fail to load on platforms without Go plugin support
+	if err := pluginManager.CheckPlatform(cfg.PluginsDir); err != nil {
+		if cfg.RequirePlugins {
+			log.Fatalf("Cannot start with REQUIRE_PLUGINS set: %v", err)
+		}
+		log.Printf("⚠️  WARNING: %v", err)
+	}
+
 	if err := pluginManager.LoadPlugins(cfg.PluginsDir); err != nil {
 		log.Printf("Warning: Failed to load some plugins: %v", err)
 	}
 
-	// // Initialize theme manager
-	// themeManager := themes.NewManager(cfg.ThemePath, db)
-	// if err := themeManager.LoadThemes(); err != nil {
-	// 	log.Printf("Warning: Failed to load themes: %v", err)
-	// }
+	// Initialize theme manager
+	themeManager := themes.NewManager(cfg.ThemePath, db)
+	if err := themeManager.LoadThemes(); err != nil {
+		log.Printf("Warning: Failed to load themes: %v", err)
+	}
 
 	// Setup Gin router
 	if cfg.Environment == "production" {
@@ -58,30 +174,24 @@ func main() {
 
 	// Create router with dependencies
 	r := router.Setup(&router.Dependencies{
-		Config:        cfg,
-		Database:      db,
-		PluginManager: pluginManager,
-		//ThemeManager:  themeManager,
+		Config:          cfg,
+		Database:        db,
+		PluginManager:   pluginManager,
+		SettingsManager: settingsManager,
+		Events:          eventBus,
+		Tenants:         tenants,
+		Ready:           ready,
+		ThemeManager:    themeManager,
+		Build:           router.BuildInfo{Commit: gitCommit, Time: buildTime},
 	})
 
-	// Start server
-	srv := &http.Server{
-		Addr:         ":" + cfg.Port,
-		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-	}
-
-	// Graceful shutdown
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
-		}
-	}()
+	// Startup is complete: serve the full router and report ready
+	handler.router.Store(r)
+	ready.Store(true)
 
-	log.Printf("🚀 Server started on port %s", cfg.Port)
+	log.Printf("🚀 Server ready on port %s", cfg.Port)
 	log.Printf("📊 Admin interface available at: http://localhost:%s/admin", cfg.Port)
-	log.Printf("🔑 Default admin credentials: admin@example.com / admin123")
+	log.Printf("🔑 Default admin account: %s", cfg.AdminEmail)
 	log.Printf("⚠️  Remember to change the default admin password!")
 
 	// Wait for interrupt signal
@@ -90,12 +200,45 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Stop advertising readiness so load balancers drain this instance
+	ready.Store(false)
+
+	shutdown(srv, pluginManager, db, cfg.ShutdownTimeout)
+
+	log.Println("Server exited")
+}
+
+// shutdown tears the server down in dependency order so that in-flight
+// uploads and compilations never leave the plugin build directory half-written
+func shutdown(srv *http.Server, pluginManager *plugins.Manager, db *database.DB, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+
+	// Phase 1: refuse new plugin operations
+	log.Println("[SHUTDOWN] Rejecting new plugin operations")
+	pluginManager.StopAccepting()
+
+	// Phase 2: stop accepting connections and wait for in-flight requests
+	log.Println("[SHUTDOWN] Stopping HTTP server")
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		log.Printf("[SHUTDOWN] HTTP server forced to shutdown: %v", err)
 	}
 
-	log.Println("Server exited")
+	// Phase 3: wait for in-flight compilations to finish
+	log.Println("[SHUTDOWN] Waiting for in-flight plugin operations")
+	if err := pluginManager.WaitForOperations(ctx); err != nil {
+		log.Printf("[SHUTDOWN] Gave up waiting for plugin operations: %v", err)
+	}
+
+	// Phase 4: shut down plugins
+	log.Println("[SHUTDOWN] Shutting down plugins")
+	pluginManager.ShutdownAll()
+
+	// Phase 5: disconnect from the database
+	log.Println("[SHUTDOWN] Disconnecting from database")
+	disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer disconnectCancel()
+	if err := db.Disconnect(disconnectCtx); err != nil {
+		log.Printf("[SHUTDOWN] Failed to disconnect from database: %v", err)
+	}
 }
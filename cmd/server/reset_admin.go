@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go-cms/internal/admin"
+	"go-cms/internal/auth"
+	"go-cms/internal/config"
+	"go-cms/internal/database"
+	"go-cms/internal/database/models"
+	"go-cms/internal/repository"
+	"go-cms/internal/settings"
+
+	"golang.org/x/term"
+)
+
+// resetPasswordEnv supplies the new password non-interactively
+const resetPasswordEnv = "ADMIN_RESET_PASSWORD"
+
+// runResetAdminPassword connects straight to the database, sets a new password for
+// the super admin with the given email (or the oldest super admin when email is
+// empty) and records the reset in the activity log. It never starts the server.
+func runResetAdminPassword(cfg *config.Config, email string) error {
+	db, err := database.Connect(cfg.MongoURI, cfg.DatabaseName, cfg.Consistency())
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Disconnect(context.Background())
+
+	password := os.Getenv(resetPasswordEnv)
+	if password == "" {
+		if password, err = promptPassword(os.Stdin, os.Stdout); err != nil {
+			return err
+		}
+	}
+
+	// Enforce the site's password policy, as the profile endpoints do
+	settingsManager := settings.NewManager(db, settings.PasswordDefaults(auth.DefaultPasswordPolicy))
+	if err := settingsManager.Load(); err != nil {
+		return fmt.Errorf("failed to load password policy: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	user, err := resetAdminPassword(ctx, repository.NewMongoUserRepository(db), email, password, settingsManager.PasswordPolicy())
+	if err != nil {
+		return err
+	}
+
+	err = admin.RecordActivity(ctx, db, models.ActivityEntry{
+		Type:        "user.password_reset",
+		Description: fmt.Sprintf("Password of super admin %s reset from the command line", user.Email),
+		Details:     map[string]interface{}{"user_id": user.ID.Hex(), "source": "cli"},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record the reset in the activity log: %v\n", err)
+	}
+
+	fmt.Printf("Password reset for super admin %s\n", user.Email)
+	return nil
+}
+
+// resetAdminPassword finds the super admin, replaces its password with a hash of
+// password and reactivates the account if it had been deactivated
+func resetAdminPassword(ctx context.Context, users repository.UserRepository, email, password string, policy auth.PasswordPolicy) (*models.User, error) {
+	if unmet := auth.ValidatePassword(password, policy); len(unmet) > 0 {
+		return nil, fmt.Errorf("password %s", strings.Join(unmet, "; "))
+	}
+
+	var user *models.User
+	var err error
+	if email != "" {
+		user, err = users.FindByEmail(ctx, models.NormalizeEmail(email))
+	} else {
+		user, err = users.FindOldestByRole(ctx, "super_admin")
+	}
+	if err == repository.ErrNotFound {
+		return nil, fmt.Errorf("no super admin account found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up super admin: %w", err)
+	}
+	if user.Role != "super_admin" {
+		return nil, fmt.Errorf("%s is not a super admin", user.Email)
+	}
+
+	hashed := models.User{Password: password}
+	if err := hashed.HashPassword(); err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	err = users.UpdateFields(ctx, user.ID.Hex(), map[string]interface{}{
+		"password":   hashed.Password,
+		"is_active":  true,
+		"updated_at": time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save password: %w", err)
+	}
+	return user, nil
+}
+
+// promptPassword reads a new password from in. On a terminal the password is
+// not echoed; piped input is read up to the end of the first line.
+func promptPassword(in *os.File, out io.Writer) (string, error) {
+	fmt.Fprintf(out, "New admin password (or set %s): ", resetPasswordEnv)
+
+	var password string
+	if fd := int(in.Fd()); term.IsTerminal(fd) {
+		secret, err := term.ReadPassword(fd)
+		fmt.Fprintln(out)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		password = string(secret)
+	} else {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	if password == "" {
+		return "", fmt.Errorf("password must not be empty")
+	}
+	return password, nil
+}
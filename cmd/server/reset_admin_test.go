@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go-cms/internal/auth"
+	"go-cms/internal/database/models"
+	"go-cms/internal/repository/repotest"
+)
+
+const newPassword = "Correct-Horse-42"
+
+func adminUsers(t *testing.T) *repotest.Users {
+	t.Helper()
+	now := time.Now()
+	return repotest.NewUsers(
+		&models.User{Username: "root", Email: "root@example.com", Role: "super_admin", IsActive: false, CreatedAt: now.Add(-2 * time.Hour)},
+		&models.User{Username: "second", Email: "second@example.com", Role: "super_admin", IsActive: true, CreatedAt: now.Add(-time.Hour)},
+		&models.User{Username: "editor", Email: "editor@example.com", Role: "admin", IsActive: true, CreatedAt: now.Add(-3 * time.Hour)},
+	)
+}
+
+func userByEmail(t *testing.T, users *repotest.Users, email string) *models.User {
+	t.Helper()
+	user, err := users.FindByEmail(context.Background(), email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestResetAdminPasswordDefaultsToOldestSuperAdmin(t *testing.T) {
+	users := adminUsers(t)
+
+	user, err := resetAdminPassword(context.Background(), users, "", newPassword, auth.DefaultPasswordPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "root@example.com" {
+		t.Fatalf("reset %s, want the oldest super admin", user.Email)
+	}
+
+	stored := userByEmail(t, users, "root@example.com")
+	if !stored.CheckPassword(newPassword) {
+		t.Error("new password does not verify")
+	}
+	if !stored.IsActive {
+		t.Error("deactivated super admin was not reactivated")
+	}
+}
+
+func TestResetAdminPasswordByEmail(t *testing.T) {
+	users := adminUsers(t)
+
+	if _, err := resetAdminPassword(context.Background(), users, " Second@Example.com ", newPassword, auth.DefaultPasswordPolicy); err != nil {
+		t.Fatal(err)
+	}
+	if !userByEmail(t, users, "second@example.com").CheckPassword(newPassword) {
+		t.Error("password of the named super admin was not reset")
+	}
+	if userByEmail(t, users, "root@example.com").CheckPassword(newPassword) {
+		t.Error("another super admin's password changed")
+	}
+}
+
+func TestResetAdminPasswordRefusals(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  string
+	}{
+		{"not a super admin", "editor@example.com", newPassword, "not a super admin"},
+		{"unknown email", "nobody@example.com", newPassword, "no super admin"},
+		{"weak password", "", "short", "password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := adminUsers(t)
+			_, err := resetAdminPassword(context.Background(), users, tt.email, tt.password, auth.DefaultPasswordPolicy)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+			for _, user := range users.All() {
+				if (&user).CheckPassword(tt.password) {
+					t.Fatalf("password of %s changed", user.Email)
+				}
+			}
+		})
+	}
+}
+
+func TestResetAdminPasswordNoSuperAdmin(t *testing.T) {
+	users := repotest.NewUsers(&models.User{Email: "a@example.com", Role: "user"})
+	if _, err := resetAdminPassword(context.Background(), users, "", newPassword, auth.DefaultPasswordPolicy); err == nil {
+		t.Fatal("reset succeeded without a super admin")
+	}
+}
+
+func TestPromptPasswordFromPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteString(newPassword + "\r\n")
+	w.Close()
+	defer r.Close()
+
+	var out strings.Builder
+	got, err := promptPassword(r, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != newPassword {
+		t.Fatalf("password %q, want %q", got, newPassword)
+	}
+	if strings.Contains(out.String(), newPassword) {
+		t.Fatal("password was echoed")
+	}
+}
+
+func TestPromptPasswordEmpty(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteString("\n")
+	w.Close()
+	defer r.Close()
+
+	if _, err := promptPassword(r, &strings.Builder{}); err == nil {
+		t.Fatal("empty password was accepted")
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startupHandler lets the server listen while migrations run and plugins load.
+// Until the router is installed, /health reports the process alive and every
+// other path, including /health/ready, answers 503.
+type startupHandler struct {
+	router atomic.Pointer[gin.Engine]
+}
+
+func (h *startupHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if router := h.router.Load(); router != nil {
+		router.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.URL.Path == "/health" {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"service":"go-cms","status":"ok"}`))
+		return
+	}
+	w.Header().Set("Retry-After", "5")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write([]byte(`{"service":"go-cms","status":"starting"}`))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStartupHandlerBeforeRouter(t *testing.T) {
+	h := &startupHandler{}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("/health: status %d, want 200", w.Code)
+	}
+
+	for _, path := range []string{"/health/ready", "/api/v1/content"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%s: status %d, want 503", path, w.Code)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Fatalf("%s: missing Retry-After", path)
+		}
+	}
+}
+
+func TestStartupHandlerServesRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/health/ready", func(c *gin.Context) { c.String(http.StatusOK, "ready") })
+
+	h := &startupHandler{}
+	h.router.Store(router)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ready" {
+		t.Fatalf("status %d body %q, want the router's answer", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,9 @@
+package main
+
+// Build metadata reported on /api/v1/version, set at link time:
+//
+//	go build -ldflags "-X main.gitCommit=$(git rev-parse --short HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/server
+var (
+	gitCommit = "unknown"
+	buildTime = "unknown"
+)
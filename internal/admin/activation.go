@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"context"
+
+	"go-cms/internal/database"
+	"go-cms/internal/database/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// PluginActivationState returns the persisted activation state of plugins: those
+// an admin deactivated, and those whose "enabled" setting is false. Plugins with
+// no database record are treated as active.
+func PluginActivationState(ctx context.Context, db *database.DB) (inactive, switchedOff []string, err error) {
+	inactive, err = pluginNames(ctx, db, bson.M{"is_active": false})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switchedOff, err = pluginNames(ctx, db, bson.M{"settings": bson.M{"$elemMatch": bson.M{"key": "enabled", "value": false}}})
+	if err != nil {
+		return nil, nil, err
+	}
+	return inactive, switchedOff, nil
+}
+
+// pluginNames returns the names of the plugin records matching filter
+func pluginNames(ctx context.Context, db *database.DB, filter bson.M) ([]string, error) {
+	cursor, err := db.Collection("plugins").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []models.PluginMetadata
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(records))
+	for _, record := range records {
+		names = append(names, record.Name)
+	}
+	return names, nil
+}
+
+// PluginDebugModes returns the debug_mode each plugin record has saved. Plugins
+// without a saved value are left out.
+func PluginDebugModes(ctx context.Context, db *database.DB) (map[string]bool, error) {
+	cursor, err := db.Collection("plugins").Find(ctx, bson.M{"settings.key": "debug_mode"})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []models.PluginMetadata
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	modes := make(map[string]bool, len(records))
+	for _, record := range records {
+		for _, setting := range record.Settings {
+			if enabled, ok := setting.Value.(bool); ok && setting.Key == "debug_mode" {
+				modes[record.Name] = enabled
+			}
+		}
+	}
+	return modes, nil
+}
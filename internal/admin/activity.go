@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-cms/internal/auth"
+	"go-cms/internal/database"
+	"go-cms/internal/database/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// activityCollection stores the administrative activity log
+const activityCollection = "activity_log"
+
+// recordActivity appends an entry attributed to the current user to the activity
+// log. Failures are logged and do not fail the request.
+func (h *Handler) recordActivity(c *gin.Context, activityType, description string, details map[string]interface{}) {
+	entry := models.ActivityEntry{
+		Type:        activityType,
+		Description: description,
+		Details:     details,
+		CreatedAt:   time.Now(),
+	}
+	if userContext, exists := auth.GetUserFromContext(c); exists {
+		entry.UserID = userContext.UserID
+		entry.Username = userContext.Username
+	}
+
+	if err := RecordActivity(context.Background(), h.db, entry); err != nil {
+		log.Printf("[ACTIVITY] Failed to record %s: %v", activityType, err)
+	}
+}
+
+// RecordActivity appends entry to the activity log, stamping it with the current
+// time when CreatedAt is unset
+func RecordActivity(ctx context.Context, db *database.DB, entry models.ActivityEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := db.Collection(activityCollection).InsertOne(ctx, entry)
+	return err
+}
@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go-cms/internal/database/models"
+	"go-cms/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// installBundle installs every plugin in an uploaded bundle zip and records their
+// metadata. Any member failing rolls the whole bundle back; the response reports
+// each member either way.
+func (h *Handler) installBundle(c *gin.Context, zipPath, filename, contentHash string, manifest *plugins.BundleManifest) {
+	log.Printf("[PLUGIN_UPLOAD] Installing bundle %s with %d plugins", manifest.Name, len(manifest.Plugins))
+
+	for _, member := range manifest.Plugins {
+		if !isValidPluginName(member) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid plugin name %s in bundle. Use only lowercase letters, numbers, and hyphens", member),
+			})
+			return
+		}
+	}
+
+	result, err := h.pluginManager.InstallBundleFromZip(zipPath, manifest)
+	if err != nil {
+		log.Printf("[PLUGIN_UPLOAD] Bundle installation failed: %v", err)
+		if errors.Is(err, plugins.ErrShuttingDown) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server is shutting down"})
+			return
+		}
+		var reservedErr *plugins.ReservedNameError
+		if errors.As(err, &reservedErr) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    err.Error(),
+				"conflict": reservedErr.Name,
+			})
+			return
+		}
+		response := gin.H{"error": fmt.Sprintf("Bundle installation failed: %v", err)}
+		if result != nil {
+			response["members"] = result.Members
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if err := h.saveBundleMetadata(result, filename, contentHash); err != nil {
+		log.Printf("[PLUGIN_UPLOAD] Database error saving bundle metadata, rolling back bundle %s: %v", manifest.Name, err)
+		h.pluginManager.RollbackBundle(result)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save plugin metadata; the bundle was rolled back",
+			"members": result.Members,
+		})
+		return
+	}
+
+	h.dashboard.Invalidate()
+
+	log.Printf("[PLUGIN_UPLOAD] Bundle upload completed successfully: %s v%s", manifest.Name, manifest.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Plugin bundle uploaded and installed successfully",
+		"bundle":       result.Name,
+		"version":      result.Version,
+		"filename":     filename,
+		"members":      result.Members,
+		"content_hash": contentHash,
+	})
+}
+
+// saveBundleMetadata records every member of an installed bundle, or none of them.
+// Without transaction support, records already inserted are deleted again when a
+// later one fails.
+func (h *Handler) saveBundleMetadata(result *plugins.BundleResult, filename, contentHash string) error {
+	collection := h.db.Collection("plugins")
+
+	records := make([]models.PluginMetadata, 0, len(result.Members))
+	for _, member := range result.Members {
+		var settings []models.PluginSetting
+		if plugin, exists := h.pluginManager.GetPlugin(member.Name); exists {
+			settings = convertToModelSettings(plugins.SafeSettings(member.Name, plugin))
+		}
+
+		pluginMetadata := models.PluginMetadata{
+			Name:         member.Name,
+			Version:      member.Version,
+			Filename:     filename,
+			ContentHash:  contentHash,
+			IsActive:     true,
+			Capabilities: member.Result.Capabilities,
+			Settings:     settings,
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		}
+		if info, err := h.pluginManager.GetPluginInfo(member.Plugin); err == nil {
+			pluginMetadata.Description = info.Description
+			pluginMetadata.Author = info.Author
+			pluginMetadata.Website = info.Website
+		}
+		records = append(records, pluginMetadata)
+	}
+
+	var inserted []string
+	err := h.db.WithTransaction(context.Background(), func(sc mongo.SessionContext) error {
+		inserted = inserted[:0] // The transaction may be retried
+		for _, record := range records {
+			res, err := collection.ReplaceOne(sc, bson.M{"name": record.Name}, record, options.Replace().SetUpsert(true))
+			if err != nil {
+				return fmt.Errorf("failed to save metadata for %s: %w", record.Name, err)
+			}
+			if res.UpsertedID != nil {
+				inserted = append(inserted, record.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil && len(inserted) > 0 {
+		if _, deleteErr := collection.DeleteMany(context.Background(), bson.M{"name": bson.M{"$in": inserted}}); deleteErr != nil {
+			log.Printf("[PLUGIN_UPLOAD] Failed to remove partial bundle metadata: %v", deleteErr)
+		}
+	}
+	return err
+}
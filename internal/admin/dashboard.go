@@ -3,29 +3,40 @@ package admin
 import (
 	"context"
 	"fmt"
+	"log"
+	"runtime"
+	"sync"
 	"time"
 
+	"go-cms/internal/clock"
+	"go-cms/internal/config"
 	"go-cms/internal/database"
 	"go-cms/internal/plugins"
+	"go-cms/internal/repository"
 	"go-cms/internal/themes"
 
 	"go.mongodb.org/mongo-driver/bson"
 )
 
 type DashboardData struct {
-	Stats          SystemStats    `json:"stats"`
-	RecentActivity []Activity     `json:"recent_activity"`
-	SystemInfo     SystemInfo     `json:"system_info"`
-	PluginStatus   []PluginStatus `json:"plugin_status"`
+	Stats          SystemStats           `json:"stats"`
+	RecentActivity []Activity            `json:"recent_activity"`
+	SystemInfo     SystemInfo            `json:"system_info"`
+	PluginStatus   []PluginStatus        `json:"plugin_status"`
+	TopPlugins     []plugins.PluginStats `json:"top_plugins"` // Plugins spending the most time serving requests
 }
 
+// SystemStats counts are nested: every active plugin is loaded and every loaded
+// plugin is installed, and likewise for themes
 type SystemStats struct {
 	TotalUsers    int64  `json:"total_users"`
 	ActiveUsers   int64  `json:"active_users"`
-	TotalPlugins  int    `json:"total_plugins"`
-	ActivePlugins int    `json:"active_plugins"`
-	TotalThemes   int    `json:"total_themes"`
-	ActiveTheme   string `json:"active_theme"`
+	TotalPlugins  int    `json:"total_plugins"`  // Installed on disk or recorded in the database
+	LoadedPlugins int    `json:"loaded_plugins"` // Running in this process
+	ActivePlugins int    `json:"active_plugins"` // Loaded and not deactivated
+	TotalThemes   int    `json:"total_themes"`   // Loaded from disk or recorded in the database
+	LoadedThemes  int    `json:"loaded_themes"`  // Loaded from disk
+	ActiveTheme   string `json:"active_theme"`   // Empty when the active theme is not loaded
 	DatabaseSize  int64  `json:"database_size"`
 	SystemUptime  string `json:"system_uptime"`
 }
@@ -45,6 +56,7 @@ type SystemInfo struct {
 	StartTime      time.Time `json:"start_time"`
 	DatabaseStatus string    `json:"database_status"`
 	Environment    string    `json:"environment"`
+	PluginWarning  string    `json:"plugin_warning,omitempty"` // Why installed plugins cannot load, if they cannot
 }
 
 type PluginStatus struct {
@@ -55,25 +67,126 @@ type PluginStatus struct {
 	LastError string `json:"last_error,omitempty"`
 }
 
+// dashboardTopPlugins is how many plugins the dashboard lists by request time
+const dashboardTopPlugins = 5
+
+// dashboardQueryTimeout bounds how long a background refresh may spend querying the database
+const dashboardQueryTimeout = 5 * time.Second
+
 type DashboardManager struct {
+	config        *config.Config
 	db            *database.DB
+	users         repository.UserRepository
 	pluginManager *plugins.Manager
 	themeManager  *themes.Manager
 	startTime     time.Time
+	clock         clock.Clock
+	compute       func(ctx context.Context) (*DashboardData, error) // Computes fresh data; computeDashboardData outside tests
+
+	// Cached dashboard data, recomputed once older than cacheTTL. generation
+	// counts invalidations, so a computation that started before one is not cached.
+	cacheTTL   time.Duration
+	cacheMu    sync.Mutex
+	cached     *DashboardData
+	cachedAt   time.Time
+	generation uint64
+	refreshing bool
 }
 
-func NewDashboardManager(db *database.DB, pluginManager *plugins.Manager, themeManager *themes.Manager) *DashboardManager {
-	return &DashboardManager{
+func NewDashboardManager(cfg *config.Config, db *database.DB, users repository.UserRepository, pluginManager *plugins.Manager, themeManager *themes.Manager) *DashboardManager {
+	d := &DashboardManager{
+		config:        cfg,
 		db:            db,
+		users:         users,
 		pluginManager: pluginManager,
 		themeManager:  themeManager,
 		startTime:     time.Now(),
+		clock:         clock.Real(),
+		cacheTTL:      cfg.DashboardCacheTTL,
+	}
+	d.compute = d.computeDashboardData
+
+	if themeManager != nil {
+		themeManager.OnChange(d.Invalidate)
+	}
+
+	return d
+}
+
+// GetDashboardData returns cached dashboard data while it is fresh. Stale data is
+// returned immediately while a background refresh runs; forceRefresh bypasses the cache,
+// as does a request scoped to a tenant database.
+func (d *DashboardManager) GetDashboardData(ctx context.Context, forceRefresh bool) (*DashboardData, error) {
+	// The cache holds the default database's figures; tenants are computed per request
+	if database.FromContext(ctx, d.db) != d.db {
+		return d.compute(ctx)
+	}
+
+	d.cacheMu.Lock()
+	cached, age, generation := d.cached, d.clock.Now().Sub(d.cachedAt), d.generation
+	if !forceRefresh && d.cacheTTL > 0 && cached != nil {
+		if age >= d.cacheTTL && !d.refreshing {
+			d.refreshing = true
+			go d.refreshInBackground(generation)
+		}
+		d.cacheMu.Unlock()
+		return cached, nil
+	}
+	d.cacheMu.Unlock()
+
+	data, err := d.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	d.store(data, generation)
+	return data, nil
+}
+
+// Invalidate drops the cached dashboard data so the next request recomputes it.
+// Computations already running when it is called are not cached.
+func (d *DashboardManager) Invalidate() {
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+
+	d.cached = nil
+	d.generation++
+}
+
+func (d *DashboardManager) refreshInBackground(generation uint64) {
+	defer func() {
+		d.cacheMu.Lock()
+		d.refreshing = false
+		d.cacheMu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), dashboardQueryTimeout)
+	defer cancel()
+
+	data, err := d.compute(ctx)
+	if err != nil {
+		log.Printf("[DASHBOARD] Background refresh failed: %v", err)
+		return
 	}
+	d.store(data, generation)
 }
 
-func (d *DashboardManager) GetDashboardData() (*DashboardData, error) {
+// store caches data computed in the given generation, unless the cache was
+// invalidated since
+func (d *DashboardManager) store(data *DashboardData, generation uint64) {
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+
+	if generation != d.generation {
+		return
+	}
+	d.cached = data
+	d.cachedAt = d.clock.Now()
+}
+
+func (d *DashboardManager) computeDashboardData(ctx context.Context) (*DashboardData, error) {
 	// Get system statistics
-	stats, err := d.getSystemStats()
+	stats, err := d.getSystemStats(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -85,56 +198,48 @@ func (d *DashboardManager) GetDashboardData() (*DashboardData, error) {
 	}
 
 	// Get system information
-	systemInfo := d.getSystemInfo()
+	systemInfo := d.getSystemInfo(ctx)
 
 	// Get plugin status
 	pluginStatus := d.getPluginStatus()
 
+	// Get the plugins spending the most time serving requests
+	topPlugins := d.pluginManager.PluginStats()
+	if len(topPlugins) > dashboardTopPlugins {
+		topPlugins = topPlugins[:dashboardTopPlugins]
+	}
+
 	return &DashboardData{
 		Stats:          *stats,
 		RecentActivity: activity,
 		SystemInfo:     systemInfo,
 		PluginStatus:   pluginStatus,
+		TopPlugins:     topPlugins,
 	}, nil
 }
 
-func (d *DashboardManager) getSystemStats() (*SystemStats, error) {
-	ctx := context.Background()
-
+func (d *DashboardManager) getSystemStats(ctx context.Context) (*SystemStats, error) {
 	// Count total users
-	userCollection := d.db.Collection("users")
-	totalUsers, err := userCollection.CountDocuments(ctx, bson.M{})
+	totalUsers, err := d.users.Count(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Count active users (logged in within last 30 days)
 	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
-	activeUsers, err := userCollection.CountDocuments(ctx, bson.M{
-		"last_login_at": bson.M{"$gte": thirtyDaysAgo},
-	})
+	activeUsers, err := d.users.CountActiveSince(ctx, thirtyDaysAgo)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get plugin statistics
-	allPlugins := d.pluginManager.GetAllPlugins()
-	totalPlugins := len(allPlugins)
-	activePlugins := 0
-
-	// Count active plugins from database
-	pluginCollection := d.db.Collection("plugins")
-	activePluginCount, err := pluginCollection.CountDocuments(ctx, bson.M{"is_active": true})
-	if err == nil {
-		activePlugins = int(activePluginCount)
+	pluginCounts, err := d.countPlugins(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get theme statistics
-	allThemes := d.themeManager.GetAllThemes()
-	totalThemes := len(allThemes)
-	activeTheme := ""
-	if activeThemeName := d.themeManager.GetActiveTheme(); activeThemeName != "" {
-		activeTheme = activeThemeName
+	themeCounts, err := d.countThemes(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// Calculate uptime
@@ -144,14 +249,98 @@ func (d *DashboardManager) getSystemStats() (*SystemStats, error) {
 	return &SystemStats{
 		TotalUsers:    totalUsers,
 		ActiveUsers:   activeUsers,
-		TotalPlugins:  totalPlugins,
-		ActivePlugins: activePlugins,
-		TotalThemes:   totalThemes,
-		ActiveTheme:   activeTheme,
+		TotalPlugins:  pluginCounts.total,
+		LoadedPlugins: pluginCounts.loaded,
+		ActivePlugins: pluginCounts.active,
+		TotalThemes:   themeCounts.total,
+		LoadedThemes:  themeCounts.loaded,
+		ActiveTheme:   themeCounts.activeName,
 		SystemUptime:  uptimeStr,
 	}, nil
 }
 
+// componentCounts reports installed, loaded and active plugins or themes
+type componentCounts struct {
+	total      int
+	loaded     int
+	active     int
+	activeName string
+}
+
+// countPlugins reconciles the plugin manager's state with the plugins collection.
+// A plugin recorded in the database but missing from disk still counts as
+// installed; whether it is active follows the manager, which has already applied
+// the recorded activation state to everything it loaded.
+func (d *DashboardManager) countPlugins(ctx context.Context) (componentCounts, error) {
+	installed, err := d.pluginManager.ListInstalledPlugins()
+	if err != nil {
+		return componentCounts{}, err
+	}
+	names := make(map[string]bool, len(installed))
+	for _, name := range installed {
+		names[name] = true
+	}
+
+	recorded, err := d.recordedNames(ctx, "plugins")
+	if err != nil {
+		return componentCounts{}, err
+	}
+	for _, name := range recorded {
+		names[name] = true
+	}
+
+	counts := componentCounts{total: len(names)}
+	for name := range d.pluginManager.GetAllPlugins() {
+		counts.loaded++
+		if !d.pluginManager.IsDisabled(name) {
+			counts.active++
+		}
+	}
+	return counts, nil
+}
+
+// countThemes reconciles the loaded themes with the themes collection. The
+// active theme is only reported when it is loaded.
+func (d *DashboardManager) countThemes(ctx context.Context) (componentCounts, error) {
+	loaded := d.themeManager.GetAllThemes()
+	names := make(map[string]bool, len(loaded))
+	for name := range loaded {
+		names[name] = true
+	}
+
+	recorded, err := d.recordedNames(ctx, "themes")
+	if err != nil {
+		return componentCounts{}, err
+	}
+	for _, name := range recorded {
+		names[name] = true
+	}
+
+	counts := componentCounts{total: len(names), loaded: len(loaded)}
+	if active := d.themeManager.GetActiveTheme(); active != "" {
+		if _, exists := loaded[active]; exists {
+			counts.active = 1
+			counts.activeName = active
+		}
+	}
+	return counts, nil
+}
+
+// recordedNames returns the distinct names in a metadata collection
+func (d *DashboardManager) recordedNames(ctx context.Context, collection string) ([]string, error) {
+	values, err := database.FromContext(ctx, d.db).Collection(collection).Distinct(ctx, "name", bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", collection, err)
+	}
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		if name, ok := value.(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
 func (d *DashboardManager) getRecentActivity() ([]Activity, error) {
 	// In a real implementation, you would query an activity log collection
 	// For now, we'll return mock data
@@ -183,22 +372,21 @@ func (d *DashboardManager) getRecentActivity() ([]Activity, error) {
 	}, nil
 }
 
-func (d *DashboardManager) getSystemInfo() SystemInfo {
+func (d *DashboardManager) getSystemInfo(ctx context.Context) SystemInfo {
 	// Test database connection
 	dbStatus := "connected"
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
 
 	if err := d.db.Client.Ping(ctx, nil); err != nil {
 		dbStatus = "disconnected"
 	}
 
 	return SystemInfo{
-		Version:        "1.0.0",
-		GoVersion:      "1.21",
+		Version:        d.config.Version,
+		GoVersion:      runtime.Version(),
 		StartTime:      d.startTime,
 		DatabaseStatus: dbStatus,
-		Environment:    "development", // This should come from config
+		Environment:    d.config.Environment,
+		PluginWarning:  d.pluginManager.PlatformWarning(),
 	}
 }
 
@@ -206,14 +394,20 @@ func (d *DashboardManager) getPluginStatus() []PluginStatus {
 	var status []PluginStatus
 
 	allPlugins := d.pluginManager.GetAllPlugins()
-	for _, plugin := range allPlugins {
+	loadTimes := d.pluginManager.GetLoadTimes()
+	for name, plugin := range allPlugins {
 		info := plugin.GetInfo()
 
+		state := "active"
+		if d.pluginManager.IsDisabled(name) {
+			state = "inactive"
+		}
+
 		pluginStatus := PluginStatus{
 			Name:     info.Name,
 			Version:  info.Version,
-			Status:   "active",
-			LoadTime: "< 1ms", // In a real implementation, you'd track this
+			Status:   state,
+			LoadTime: formatLoadTime(loadTimes[name]),
 		}
 
 		status = append(status, pluginStatus)
@@ -222,6 +416,14 @@ func (d *DashboardManager) getPluginStatus() []PluginStatus {
 	return status
 }
 
+// formatLoadTime renders a plugin's load time to the millisecond
+func formatLoadTime(d time.Duration) string {
+	if d < time.Millisecond {
+		return "< 1ms"
+	}
+	return d.Round(time.Millisecond).String()
+}
+
 func formatDuration(d time.Duration) string {
 	if d < time.Hour {
 		return d.Round(time.Minute).String()
@@ -0,0 +1,168 @@
+package admin
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"go-cms/internal/clock"
+	"go-cms/internal/config"
+	"go-cms/internal/database"
+	"go-cms/internal/plugins"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// countingDashboard is a dashboard whose computations are numbered through
+// TotalUsers. Computations wait on gate while it is not nil.
+type countingDashboard struct {
+	*DashboardManager
+	mu    sync.Mutex
+	calls int64
+	gate  chan struct{}
+}
+
+func newCountingDashboard(ttl time.Duration) (*countingDashboard, *clock.Fake) {
+	clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	d := &countingDashboard{DashboardManager: NewDashboardManager(&config.Config{DashboardCacheTTL: ttl}, nil, nil, nil, nil)}
+	d.clock = clk
+	d.DashboardManager.compute = func(ctx context.Context) (*DashboardData, error) {
+		d.mu.Lock()
+		d.calls++
+		data := &DashboardData{Stats: SystemStats{TotalUsers: d.calls}}
+		gate := d.gate
+		d.mu.Unlock()
+		if gate != nil {
+			<-gate
+		}
+		return data, nil
+	}
+	return d, clk
+}
+
+func (d *countingDashboard) get(t *testing.T, forceRefresh bool) int64 {
+	t.Helper()
+	data, err := d.GetDashboardData(context.Background(), forceRefresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data.Stats.TotalUsers
+}
+
+// waitForRefresh waits until no background refresh is running
+func (d *countingDashboard) waitForRefresh(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		d.cacheMu.Lock()
+		refreshing := d.refreshing
+		d.cacheMu.Unlock()
+		if !refreshing {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("background refresh did not finish")
+}
+
+func TestDashboardCacheTTL(t *testing.T) {
+	d, clk := newCountingDashboard(10 * time.Second)
+
+	if got := d.get(t, false); got != 1 {
+		t.Fatalf("first request got computation %d, want 1", got)
+	}
+	clk.Advance(9 * time.Second)
+	if got := d.get(t, false); got != 1 {
+		t.Errorf("request within the TTL got computation %d, want the cached 1", got)
+	}
+
+	// Stale data is served while it is recomputed in the background
+	clk.Advance(time.Second)
+	if got := d.get(t, false); got != 1 {
+		t.Errorf("request after the TTL got computation %d, want the stale 1", got)
+	}
+	d.waitForRefresh(t)
+	if got := d.get(t, false); got != 2 {
+		t.Errorf("request after the refresh got computation %d, want 2", got)
+	}
+
+	if got := d.get(t, true); got != 3 {
+		t.Errorf("forced refresh got computation %d, want 3", got)
+	}
+	if got := d.get(t, false); got != 3 {
+		t.Errorf("request after a forced refresh got computation %d, want 3", got)
+	}
+}
+
+func TestDashboardInvalidate(t *testing.T) {
+	d, _ := newCountingDashboard(10 * time.Second)
+
+	d.get(t, false)
+	d.Invalidate()
+	if got := d.get(t, false); got != 2 {
+		t.Errorf("request after Invalidate got computation %d, want 2", got)
+	}
+}
+
+func TestDashboardInvalidateDuringRefresh(t *testing.T) {
+	d, clk := newCountingDashboard(10 * time.Second)
+	d.get(t, false)
+
+	// Start a background refresh and hold it inside its computation
+	gate := make(chan struct{})
+	d.mu.Lock()
+	d.gate = gate
+	d.mu.Unlock()
+	clk.Advance(time.Minute)
+	d.get(t, false)
+	for {
+		d.mu.Lock()
+		started := d.calls == 2
+		d.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	d.Invalidate()
+	d.mu.Lock()
+	d.gate = nil
+	d.mu.Unlock()
+	close(gate)
+	d.waitForRefresh(t)
+
+	// The refresh began before the invalidation, so its result is not cached
+	if got := d.get(t, false); got != 3 {
+		t.Errorf("request after Invalidate got computation %d, want a fresh 3", got)
+	}
+}
+
+func TestSystemInfoReportsConfig(t *testing.T) {
+	// Nothing listens on port 1, so the ping fails quickly
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+	db := &database.DB{Client: client, Database: client.Database("cms_test")}
+
+	cfg := &config.Config{Environment: "staging", Version: "2.3.4"}
+	d := NewDashboardManager(cfg, db, nil, plugins.NewManager(), nil)
+
+	info := d.getSystemInfo(context.Background())
+	if info.Environment != "staging" || info.Version != "2.3.4" {
+		t.Errorf("environment %q and version %q, want the injected staging and 2.3.4", info.Environment, info.Version)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("Go version %q, want the running %q", info.GoVersion, runtime.Version())
+	}
+	if info.DatabaseStatus != "disconnected" {
+		t.Errorf("database status %q with no server, want disconnected", info.DatabaseStatus)
+	}
+}
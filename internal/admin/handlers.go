@@ -2,20 +2,31 @@ package admin
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"go-cms/internal/config"
 	"go-cms/internal/database"
+	"go-cms/internal/database/migration"
 	"go-cms/internal/database/models"
 	"go-cms/internal/plugins"
+	"go-cms/internal/registry"
+	"go-cms/internal/repository"
+	"go-cms/internal/semver"
 	"go-cms/internal/themes"
+	"go-cms/internal/validation"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -24,24 +35,31 @@ import (
 )
 
 type Handler struct {
+	config        *config.Config
 	db            *database.DB
 	pluginManager *plugins.Manager
 	themeManager  *themes.Manager
 	dashboard     *DashboardManager
+	registry      *registry.Client
 }
 
-func NewHandler(db *database.DB, pluginManager *plugins.Manager, themeManager *themes.Manager) *Handler {
+func NewHandler(cfg *config.Config, db *database.DB, users repository.UserRepository, pluginManager *plugins.Manager, themeManager *themes.Manager) *Handler {
 	return &Handler{
+		config:        cfg,
 		db:            db,
 		pluginManager: pluginManager,
 		themeManager:  themeManager,
-		dashboard:     NewDashboardManager(db, pluginManager, themeManager),
+		dashboard:     NewDashboardManager(cfg, db, users, pluginManager, themeManager),
+		registry:      registry.NewClient(cfg.RegistryURL, cfg.RegistryCacheTTL),
 	}
 }
 
-// GetDashboard returns dashboard statistics
+// GetDashboard returns dashboard statistics. Pass ?refresh=true to bypass the cache.
 func (h *Handler) GetDashboard(c *gin.Context) {
-	dashboardData, err := h.dashboard.GetDashboardData()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), dashboardQueryTimeout)
+	defer cancel()
+
+	dashboardData, err := h.dashboard.GetDashboardData(ctx, c.Query("refresh") == "true")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dashboard data"})
 		return
@@ -56,7 +74,8 @@ func (h *Handler) GetMenu(c *gin.Context) {
 	menu := menuManager.GetFullMenu()
 
 	c.JSON(http.StatusOK, gin.H{
-		"menu": menu,
+		"menu":      menu,
+		"conflicts": menuManager.ValidateMenu(),
 	})
 }
 
@@ -80,27 +99,34 @@ func (h *Handler) GetPlugins(c *gin.Context) {
 		return
 	}
 
+	initFailures := h.pluginManager.InitFailures()
+
 	// Merge loaded plugins with database metadata
 	var responsePlugins []map[string]interface{}
 
 	for _, dbPlugin := range dbPlugins {
 		pluginData := map[string]interface{}{
-			"name":        dbPlugin.Name,
-			"version":     dbPlugin.Version,
-			"description": dbPlugin.Description,
-			"author":      dbPlugin.Author,
-			"website":     dbPlugin.Website,
-			"is_active":   dbPlugin.IsActive,
-			"created_at":  dbPlugin.CreatedAt,
-			"updated_at":  dbPlugin.UpdatedAt,
-			"is_loaded":   false,
+			"name":         dbPlugin.Name,
+			"version":      dbPlugin.Version,
+			"description":  dbPlugin.Description,
+			"author":       dbPlugin.Author,
+			"website":      dbPlugin.Website,
+			"content_hash": dbPlugin.ContentHash,
+			"capabilities": dbPlugin.Capabilities,
+			"is_active":    dbPlugin.IsActive,
+			"created_at":   dbPlugin.CreatedAt,
+			"updated_at":   dbPlugin.UpdatedAt,
+			"is_loaded":    false,
 		}
 
 		// Check if plugin is currently loaded
 		if plugin, exists := loadedPlugins[dbPlugin.Name]; exists {
 			pluginData["is_loaded"] = true
 			pluginData["info"] = plugin.GetInfo()
-			pluginData["settings"] = plugin.GetSettings()
+			pluginData["settings"] = plugins.SafeSettings(dbPlugin.Name, plugin)
+		} else if reason, failed := initFailures[dbPlugin.Name]; failed {
+			pluginData["init_failed"] = true
+			pluginData["init_error"] = reason
 		}
 
 		responsePlugins = append(responsePlugins, pluginData)
@@ -121,13 +147,13 @@ func (h *Handler) GetPlugins(c *gin.Context) {
 
 // UploadPlugin handles plugin zip file uploads with improved error handling
 func (h *Handler) UploadPlugin(c *gin.Context) {
-	var tempPath string
+	var uploadDir string
 
-	// Cleanup function to ensure temp files are always removed
+	// Cleanup function to ensure the upload's temp directory is always removed
 	defer func() {
-		if tempPath != "" {
-			if err := os.Remove(tempPath); err != nil && !os.IsNotExist(err) {
-				log.Printf("Warning: Failed to remove temp file %s: %v", tempPath, err)
+		if uploadDir != "" {
+			if err := os.RemoveAll(uploadDir); err != nil {
+				log.Printf("Warning: Failed to remove temp directory %s: %v", uploadDir, err)
 			}
 		}
 	}()
@@ -160,40 +186,34 @@ func (h *Handler) UploadPlugin(c *gin.Context) {
 		return
 	}
 
-	// Create temporary directory for upload
-	tempDir := "./temp"
-	if err := os.MkdirAll(tempDir, 0755); err != nil {
+	// Save uploaded file temporarily
+	uploadDir, err = os.MkdirTemp(h.config.TempDir, "plugin-upload-*")
+	if err != nil {
 		log.Printf("[PLUGIN_UPLOAD] Failed to create temp directory: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp directory"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save uploaded file"})
 		return
 	}
 
-	// Generate unique temp filename to avoid conflicts
-	timestamp := time.Now().Unix()
-	tempFilename := fmt.Sprintf("%d_%s", timestamp, header.Filename)
-	tempPath = filepath.Join(tempDir, tempFilename)
-
-	log.Printf("[PLUGIN_UPLOAD] Saving to temp path: %s", tempPath)
-
-	// Save uploaded file temporarily
-	dst, err := os.Create(tempPath)
+	tempPath, bytesWritten, contentHash, err := saveUploadToTemp(uploadDir, file, header.Filename)
 	if err != nil {
-		log.Printf("[PLUGIN_UPLOAD] Failed to create temp file: %v", err)
+		log.Printf("[PLUGIN_UPLOAD] Failed to save uploaded file: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save uploaded file"})
 		return
 	}
 
-	// Copy file contents
-	bytesWritten, err := io.Copy(dst, file)
-	dst.Close() // Close immediately after copy
+	log.Printf("[PLUGIN_UPLOAD] Successfully saved %d bytes to temp file", bytesWritten)
 
+	// A top-level bundle.json makes this a bundle of several plugins
+	bundle, err := plugins.ReadBundleManifest(tempPath)
 	if err != nil {
-		log.Printf("[PLUGIN_UPLOAD] Failed to copy file contents: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy uploaded file"})
+		log.Printf("[PLUGIN_UPLOAD] Invalid plugin bundle: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid plugin bundle: %v", err)})
+		return
+	}
+	if bundle != nil {
+		h.installBundle(c, tempPath, header.Filename, contentHash, bundle)
 		return
 	}
-
-	log.Printf("[PLUGIN_UPLOAD] Successfully saved %d bytes to temp file", bytesWritten)
 
 	// Extract plugin name from filename (remove .zip extension)
 	pluginName := strings.TrimSuffix(header.Filename, ".zip")
@@ -207,7 +227,16 @@ func (h *Handler) UploadPlugin(c *gin.Context) {
 		return
 	}
 
-	log.Printf("[PLUGIN_UPLOAD] Plugin name: %s", pluginName)
+	if err := plugins.CheckReservedName(pluginName); err != nil {
+		log.Printf("[PLUGIN_UPLOAD] Reserved plugin name: %s", pluginName)
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    err.Error(),
+			"conflict": pluginName,
+		})
+		return
+	}
+
+	log.Printf("[PLUGIN_UPLOAD] Plugin name: %s, sha256: %s", pluginName, contentHash)
 
 	// Check if plugin already exists
 	collection := h.db.Collection("plugins")
@@ -221,6 +250,22 @@ func (h *Handler) UploadPlugin(c *gin.Context) {
 		return
 	}
 
+	// Skip reinstalling an identical archive unless ?force=true
+	if existingPlugin.ContentHash == contentHash && c.Query("force") != "true" {
+		if _, loaded := h.pluginManager.GetPlugin(existingPlugin.Name); loaded {
+			log.Printf("[PLUGIN_UPLOAD] Plugin %s is unchanged, skipping install", pluginName)
+			c.JSON(http.StatusOK, gin.H{
+				"message":      "Plugin is already up to date",
+				"plugin_name":  existingPlugin.Name,
+				"filename":     header.Filename,
+				"version":      existingPlugin.Version,
+				"content_hash": contentHash,
+				"up_to_date":   true,
+			})
+			return
+		}
+	}
+
 	// Validate the zip file
 	log.Printf("[PLUGIN_UPLOAD] Validating plugin zip file")
 	validationResult, err := h.pluginManager.ValidatePlugin(tempPath)
@@ -245,25 +290,69 @@ func (h *Handler) UploadPlugin(c *gin.Context) {
 
 	log.Printf("[PLUGIN_UPLOAD] Plugin validation successful")
 
-	// If plugin exists and is active, deactivate it first
-	if existingPlugin.Name != "" && existingPlugin.IsActive {
-		log.Printf("[PLUGIN_UPLOAD] Deactivating existing plugin for update")
-		if err := h.pluginManager.UnloadPlugin(pluginName); err != nil {
-			log.Printf("[PLUGIN_UPLOAD] Warning: Failed to unload existing plugin: %v", err)
-		}
-	}
-
-	// Install the plugin
+	// Install the plugin, replacing the running version when this is an update.
+	// Rolling back to an older version needs ?allow_downgrade=true.
 	log.Printf("[PLUGIN_UPLOAD] Installing plugin")
-	if err := h.pluginManager.InstallPluginFromZip(tempPath, pluginName); err != nil {
+	installResult, err := h.pluginManager.UpdatePluginFromZip(tempPath, pluginName, c.Query("allow_downgrade") == "true")
+	if err != nil {
 		log.Printf("[PLUGIN_UPLOAD] Plugin installation failed: %v", err)
+		var downgradeErr *plugins.DowngradeError
+		if errors.As(err, &downgradeErr) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": fmt.Sprintf("Uploaded version %s is older than the installed version %s; pass allow_downgrade=true to install it anyway",
+					downgradeErr.Uploaded, downgradeErr.Installed),
+				"current_version":  downgradeErr.Installed,
+				"uploaded_version": downgradeErr.Uploaded,
+			})
+			return
+		}
+		if errors.Is(err, plugins.ErrShuttingDown) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server is shutting down"})
+			return
+		}
+		var reservedErr *plugins.ReservedNameError
+		if errors.As(err, &reservedErr) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    err.Error(),
+				"conflict": reservedErr.Name,
+			})
+			return
+		}
+		var compatErr *plugins.IncompatibleCMSError
+		if errors.As(err, &compatErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":           compatErr.Error(),
+				"cms_version":     compatErr.CMSVersion,
+				"min_cms_version": compatErr.MinCMSVersion,
+				"max_cms_version": compatErr.MaxCMSVersion,
+				"cms_constraint":  compatErr.Constraint,
+			})
+			return
+		}
+		var manifestErr *plugins.ManifestError
+		if errors.As(err, &manifestErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "Invalid plugin.json",
+				"details": manifestErr.Problems,
+			})
+			return
+		}
+		var dependencyErr *plugins.DependencyError
+		if errors.As(err, &dependencyErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "Plugin dependencies are not satisfied",
+				"details": dependencyErr.Failures,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("Plugin installation failed: %v", err),
 		})
 		return
 	}
 
-	log.Printf("[PLUGIN_UPLOAD] Plugin installation successful")
+	log.Printf("[PLUGIN_UPLOAD] Plugin installation successful (recompiled: %t, warnings: %d)",
+		installResult.Recompiled, len(installResult.Warnings))
 
 	// Get plugin info for database storage
 	pluginInfo, err := h.pluginManager.GetPluginInfo(pluginName)
@@ -278,22 +367,24 @@ func (h *Handler) UploadPlugin(c *gin.Context) {
 	// Get plugin instance for settings
 	var settings []models.PluginSetting
 	if plugin, exists := h.pluginManager.GetPlugin(pluginInfo.Name); exists {
-		pluginSettings := plugin.GetSettings()
+		pluginSettings := plugins.SafeSettings(pluginInfo.Name, plugin)
 		settings = convertToModelSettings(pluginSettings)
 	}
 
 	// Save plugin metadata to database
 	pluginMetadata := models.PluginMetadata{
-		Name:        pluginInfo.Name,
-		Version:     pluginInfo.Version,
-		Description: pluginInfo.Description,
-		Author:      pluginInfo.Author,
-		Website:     pluginInfo.Website,
-		Filename:    header.Filename,
-		IsActive:    true,
-		Settings:    settings,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		Name:         pluginInfo.Name,
+		Version:      pluginInfo.Version,
+		Description:  pluginInfo.Description,
+		Author:       pluginInfo.Author,
+		Website:      pluginInfo.Website,
+		Filename:     header.Filename,
+		ContentHash:  contentHash,
+		IsActive:     true,
+		Capabilities: installResult.Capabilities,
+		Settings:     settings,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
 	}
 
 	// If plugin existed, preserve creation date
@@ -306,7 +397,10 @@ func (h *Handler) UploadPlugin(c *gin.Context) {
 	filter := bson.M{"name": pluginInfo.Name}
 	opts := options.Replace().SetUpsert(true)
 
-	_, err = collection.ReplaceOne(context.Background(), filter, pluginMetadata, opts)
+	err = h.db.WithTransaction(context.Background(), func(sc mongo.SessionContext) error {
+		_, err := collection.ReplaceOne(sc, filter, pluginMetadata, opts)
+		return err
+	})
 	if err != nil {
 		log.Printf("[PLUGIN_UPLOAD] Database error saving plugin metadata: %v", err)
 		// Try to clean up the installed plugin since DB save failed
@@ -319,20 +413,132 @@ func (h *Handler) UploadPlugin(c *gin.Context) {
 		return
 	}
 
+	h.dashboard.Invalidate()
+	h.recordVersionChange(c, pluginInfo.Name, existingPlugin.Version, pluginInfo.Version)
+
 	log.Printf("[PLUGIN_UPLOAD] Plugin upload completed successfully: %s v%s",
 		pluginInfo.Name, pluginInfo.Version)
 
+	message := "Plugin uploaded and installed successfully"
+	if installResult.Updated {
+		message = "Plugin uploaded and updated successfully"
+	}
+
 	// Return success response
 	c.JSON(http.StatusOK, gin.H{
-		"message":     "Plugin uploaded and installed successfully",
-		"plugin_name": pluginInfo.Name,
+		"message":      message,
+		"updated":      installResult.Updated,
+		"plugin_name":  pluginInfo.Name,
+		"filename":     header.Filename,
+		"version":      pluginInfo.Version,
+		"author":       pluginInfo.Author,
+		"description":  pluginInfo.Description,
+		"warnings":     installResult.Warnings,
+		"recompiled":   installResult.Recompiled,
+		"capabilities": installResult.Capabilities,
+		"content_hash": contentHash,
+		"up_to_date":   false,
+	})
+}
+
+// recordVersionChange logs an upload that changed a plugin's installed version
+func (h *Handler) recordVersionChange(c *gin.Context, pluginName, from, to string) {
+	if from == "" || from == to {
+		return
+	}
+
+	activityType := "plugin.version_changed"
+	if cmp, err := semver.Compare(to, from); err == nil {
+		activityType = "plugin.upgraded"
+		if cmp < 0 {
+			activityType = "plugin.downgraded"
+		}
+	}
+	h.recordActivity(c, activityType, fmt.Sprintf("Plugin %s changed from version %s to %s", pluginName, from, to),
+		map[string]interface{}{
+			"plugin":       pluginName,
+			"from_version": from,
+			"to_version":   to,
+		})
+}
+
+// ValidatePluginUpload runs the install pipeline on an uploaded zip without installing it.
+// Pass ?compile=true to also run a trial compile.
+func (h *Handler) ValidatePluginUpload(c *gin.Context) {
+	file, header, err := c.Request.FormFile("plugin")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No plugin file provided"})
+		return
+	}
+	defer file.Close()
+
+	if !strings.HasSuffix(strings.ToLower(header.Filename), ".zip") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only .zip files are allowed"})
+		return
+	}
+
+	pluginName := strings.TrimSuffix(header.Filename, ".zip")
+	if !isValidPluginName(pluginName) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid plugin name. Use only lowercase letters, numbers, and hyphens",
+		})
+		return
+	}
+
+	uploadDir, err := os.MkdirTemp(h.config.TempDir, "plugin-validate-*")
+	if err != nil {
+		log.Printf("[PLUGIN_VALIDATE] Failed to create temp directory: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save uploaded file"})
+		return
+	}
+	defer os.RemoveAll(uploadDir)
+
+	tempPath, _, _, err := saveUploadToTemp(uploadDir, file, header.Filename)
+	if err != nil {
+		log.Printf("[PLUGIN_VALIDATE] Failed to save uploaded file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save uploaded file"})
+		return
+	}
+
+	compile := c.Query("compile") == "true"
+	result, err := h.pluginManager.DryRunPlugin(tempPath, pluginName, compile)
+	if err != nil {
+		if errors.Is(err, plugins.ErrShuttingDown) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server is shutting down"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Plugin validation failed: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plugin_name": pluginName,
 		"filename":    header.Filename,
-		"version":     pluginInfo.Version,
-		"author":      pluginInfo.Author,
-		"description": pluginInfo.Description,
+		"result":      result,
 	})
 }
 
+// saveUploadToTemp copies an uploaded file into uploadDir, a directory owned by a
+// single upload, and returns its path, size and hex-encoded SHA-256
+func saveUploadToTemp(uploadDir string, src io.Reader, filename string) (string, int64, string, error) {
+	tempPath := filepath.Join(uploadDir, filepath.Base(filename))
+
+	dst, err := os.Create(tempPath)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	hash := sha256.New()
+	bytesWritten, err := io.Copy(io.MultiWriter(dst, hash), src)
+	dst.Close()
+	if err != nil {
+		os.Remove(tempPath)
+		return "", 0, "", fmt.Errorf("failed to copy file contents: %w", err)
+	}
+
+	return tempPath, bytesWritten, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 // Helper function to validate plugin names
 func isValidPluginName(name string) bool {
 	if name == "" || len(name) > 50 {
@@ -374,6 +580,9 @@ func (h *Handler) TogglePlugin(c *gin.Context) {
 		return
 	}
 
+	// Keep the manager in sync so reloads and restarts honor the new state
+	h.pluginManager.SetDisabled(pluginName, !newStatus)
+
 	// If deactivating, unload the plugin
 	if !newStatus {
 		if err := h.pluginManager.UnloadPlugin(pluginName); err != nil {
@@ -388,111 +597,382 @@ func (h *Handler) TogglePlugin(c *gin.Context) {
 		}
 	}
 
+	h.dashboard.Invalidate()
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":   "Plugin status updated",
 		"is_active": newStatus,
 	})
 }
 
-// DeletePlugin removes a plugin completely
+// DeletePlugin removes a plugin completely. Files are removed before the
+// database record so a failed uninstall never leaves orphaned files without metadata.
 func (h *Handler) DeletePlugin(c *gin.Context) {
 	pluginName := c.Param("name")
 
-	// Remove from database
+	// Uninstall the plugin first (removes files and unloads)
+	if err := h.pluginManager.UninstallPlugin(pluginName); err != nil {
+		log.Printf("[PLUGIN_DELETE] Failed to uninstall plugin %s, keeping database record: %v", pluginName, err)
+		if errors.Is(err, plugins.ErrShuttingDown) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server is shutting down"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to uninstall plugin: %v. The plugin record was kept; fix the problem and retry the delete", err),
+		})
+		return
+	}
+
+	// Remove from database only after the files are gone
 	collection := h.db.Collection("plugins")
 	_, err := collection.DeleteOne(context.Background(), bson.M{"name": pluginName})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove plugin from database"})
+		log.Printf("[PLUGIN_DELETE] Inconsistent state: plugin %s files removed but database record remains: %v", pluginName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Plugin files were removed but the database record could not be deleted. Retry the delete to finish cleanup",
+		})
 		return
 	}
 
-	// Uninstall the plugin (removes files and unloads)
-	if err := h.pluginManager.UninstallPlugin(pluginName); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to uninstall plugin: %v", err)})
-		return
-	}
+	h.pluginManager.SetDisabled(pluginName, false)
+	h.dashboard.Invalidate()
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Plugin deleted successfully",
 	})
 }
 
-// ReloadPlugin recompiles and reloads a plugin
+// ReloadPlugin recompiles and reloads a plugin. Pass ?cached=true to reuse the
+// cached build when the sources have not changed since; recompiled in the
+// response reports whether a compile ran.
 func (h *Handler) ReloadPlugin(c *gin.Context) {
 	pluginName := c.Param("name")
 
-	if err := h.pluginManager.ReloadPlugin(pluginName); err != nil {
+	recompiled, err := h.pluginManager.ReloadPlugin(pluginName, c.Query("cached") == "true")
+	if err != nil {
+		if errors.Is(err, plugins.ErrShuttingDown) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server is shutting down"})
+			return
+		}
+		if reloadThrottled(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to reload plugin: %v", err)})
 		return
 	}
 
+	h.dashboard.Invalidate()
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Plugin reloaded successfully",
+		"message":    "Plugin reloaded successfully",
+		"recompiled": recompiled,
 	})
 }
 
-// GetPluginSettings returns settings for a specific plugin
+// GetPluginSettings returns the effective settings for a specific plugin: the plugin's
+// setting definitions with any saved values applied
 func (h *Handler) GetPluginSettings(c *gin.Context) {
 	pluginName := c.Param("name")
 
-	settings, err := h.pluginManager.GetPluginSettings(pluginName)
+	defaults, err := h.pluginManager.GetPluginSettings(pluginName)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Metadata comes from the plugin, values from what an admin saved
+	settings, err := h.savedPluginSettings(pluginName, defaults)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load saved settings"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"plugin":   pluginName,
+		"groups":   plugins.SettingGroups(settings),
 		"settings": settings,
 	})
 }
 
-// UpdatePluginSettings updates settings for a specific plugin
+// GetPluginSettingsSchema returns the settings schema of a plugin without values,
+// alongside the effective values, so the admin can render a generic form
+func (h *Handler) GetPluginSettingsSchema(c *gin.Context) {
+	pluginName := c.Param("name")
+
+	defaults, err := h.pluginManager.GetPluginSettings(pluginName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Metadata comes from the plugin, values from what an admin saved
+	settings, err := h.savedPluginSettings(pluginName, defaults)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load saved settings"})
+		return
+	}
+
+	schema, values := plugins.BuildSettingsSchema(settings)
+
+	c.JSON(http.StatusOK, gin.H{
+		"plugin": pluginName,
+		"groups": plugins.SettingGroups(settings),
+		"schema": schema,
+		"values": values,
+	})
+}
+
+// Settings update modes
+const (
+	settingsModeMerge   = "merge"
+	settingsModeReplace = "replace"
+)
+
+// UpdatePluginSettings updates settings for a specific plugin.
+//
+// With ?mode=merge (the default) only the keys present in the body change and every
+// other setting keeps its saved value. With ?mode=replace the body is the complete
+// settings set: omitted settings are reset to their defaults, and every required
+// setting must be present or the request is rejected with 400.
 func (h *Handler) UpdatePluginSettings(c *gin.Context) {
 	pluginName := c.Param("name")
 
+	mode := c.DefaultQuery("mode", settingsModeMerge)
+	if mode != settingsModeMerge && mode != settingsModeReplace {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be merge or replace"})
+		return
+	}
+
 	var newSettings map[string]interface{}
 	if err := c.ShouldBindJSON(&newSettings); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		validation.BindError(c, err)
 		return
 	}
 
-	// Get current plugin settings
-	currentSettings, err := h.pluginManager.GetPluginSettings(pluginName)
+	// Defaults come from the plugin, saved values from the database
+	defaults, err := h.pluginManager.GetPluginSettings(pluginName)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Validate and update settings
-	updatedSettings := make([]plugins.PluginSetting, len(currentSettings))
-	copy(updatedSettings, currentSettings)
+	saved, err := h.savedPluginSettings(pluginName, defaults)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load saved settings"})
+		return
+	}
+
+	updatedSettings := defaults
+	if mode == settingsModeMerge {
+		updatedSettings = append([]plugins.PluginSetting(nil), saved...)
+	}
 
+	// Validate and apply the new values
+	var validationErrors []string
 	for i, setting := range updatedSettings {
-		if newValue, exists := newSettings[setting.Key]; exists {
-			updatedSettings[i].Value = newValue
+		newValue, exists := newSettings[setting.Key]
+		if !exists {
+			if mode == settingsModeReplace && setting.Required {
+				validationErrors = append(validationErrors, fmt.Sprintf("%s is required", setting.Key))
+			}
+			continue
 		}
+		if err := plugins.ValidateSettingValue(setting, newValue); err != nil {
+			validationErrors = append(validationErrors, err.Error())
+			continue
+		}
+		updatedSettings[i].Value = newValue
 	}
 
-	// Save to database
-	collection := h.db.Collection("plugins")
-	update := bson.M{
-		"$set": bson.M{
-			"settings":   convertToModelSettings(updatedSettings),
-			"updated_at": time.Now(),
-		},
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid settings",
+			"details": validationErrors,
+		})
+		return
 	}
 
-	_, err = collection.UpdateOne(context.Background(), bson.M{"name": pluginName}, update)
+	if err := h.savePluginSettings(pluginName, updatedSettings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+		return
+	}
+	h.recordSettingsHistory(c, pluginName, diffSettings(saved, updatedSettings))
+
+	response := gin.H{
+		"message":  "Settings updated successfully",
+		"mode":     mode,
+		"settings": updatedSettings,
+	}
+	h.applyPluginSettings(pluginName, updatedSettings, response)
+	c.JSON(http.StatusOK, response)
+}
+
+// PatchPluginSettings applies a partial settings update on top of the saved values.
+// Keys absent from the body are left unchanged and keys set to null are reset to
+// the plugin's default.
+func (h *Handler) PatchPluginSettings(c *gin.Context) {
+	pluginName := c.Param("name")
+
+	var patchSettings map[string]interface{}
+	if err := c.ShouldBindJSON(&patchSettings); err != nil {
+		validation.BindError(c, err)
+		return
+	}
+
+	defaults, err := h.pluginManager.GetPluginSettings(pluginName)
 	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	defaultValues := make(map[string]interface{}, len(defaults))
+	for _, setting := range defaults {
+		defaultValues[setting.Key] = setting.Value
+	}
+
+	saved, err := h.savedPluginSettings(pluginName, defaults)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load saved settings"})
+		return
+	}
+	updatedSettings := append([]plugins.PluginSetting(nil), saved...)
+
+	positions := make(map[string]int, len(updatedSettings))
+	for i, setting := range updatedSettings {
+		positions[setting.Key] = i
+	}
+
+	var validationErrors []string
+	for key, newValue := range patchSettings {
+		i, known := positions[key]
+		if !known {
+			validationErrors = append(validationErrors, fmt.Sprintf("unknown setting: %s", key))
+			continue
+		}
+		if newValue == nil {
+			updatedSettings[i].Value = defaultValues[key]
+			continue
+		}
+		if err := plugins.ValidateSettingValue(updatedSettings[i], newValue); err != nil {
+			validationErrors = append(validationErrors, err.Error())
+			continue
+		}
+		updatedSettings[i].Value = newValue
+	}
+
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid settings",
+			"details": validationErrors,
+		})
+		return
+	}
+
+	if err := h.savePluginSettings(pluginName, updatedSettings); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
 		return
 	}
+	h.recordSettingsHistory(c, pluginName, diffSettings(saved, updatedSettings))
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message":  "Settings updated successfully",
 		"settings": updatedSettings,
-	})
+	}
+	h.applyPluginSettings(pluginName, updatedSettings, response)
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPluginSetting resets a single setting to the default value reported by the plugin
+func (h *Handler) ResetPluginSetting(c *gin.Context) {
+	pluginName := c.Param("name")
+	key := c.Param("key")
+
+	defaults, err := h.pluginManager.GetPluginSettings(pluginName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	saved, err := h.savedPluginSettings(pluginName, defaults)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load saved settings"})
+		return
+	}
+	settings := append([]plugins.PluginSetting(nil), saved...)
+
+	found := false
+	for i := range settings {
+		if settings[i].Key == key {
+			settings[i].Value = defaults[i].Value
+			found = true
+			break
+		}
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("setting %s not found", key)})
+		return
+	}
+
+	if err := h.savePluginSettings(pluginName, settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+		return
+	}
+	h.recordSettingsHistory(c, pluginName, diffSettings(saved, settings))
+
+	response := gin.H{
+		"message":  "Setting reset to default",
+		"key":      key,
+		"settings": settings,
+	}
+	h.applyPluginSettings(pluginName, settings, response)
+	c.JSON(http.StatusOK, response)
+}
+
+// savedPluginSettings overlays the values saved in the database onto the plugin's
+// default settings. The result is a new slice in the same order as defaults.
+func (h *Handler) savedPluginSettings(pluginName string, defaults []plugins.PluginSetting) ([]plugins.PluginSetting, error) {
+	var metadata models.PluginMetadata
+	err := h.db.Collection("plugins").FindOne(context.Background(), bson.M{"name": pluginName}).Decode(&metadata)
+	if err == mongo.ErrNoDocuments {
+		return mergeSavedSettings(defaults, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeSavedSettings(defaults, metadata.Settings), nil
+}
+
+// mergeSavedSettings returns a copy of defaults with the values of saved applied.
+// Saved keys the plugin no longer declares are dropped.
+func mergeSavedSettings(defaults []plugins.PluginSetting, saved []models.PluginSetting) []plugins.PluginSetting {
+	settings := make([]plugins.PluginSetting, len(defaults))
+	copy(settings, defaults)
+
+	values := make(map[string]interface{}, len(saved))
+	for _, setting := range saved {
+		values[setting.Key] = setting.Value
+	}
+	for i := range settings {
+		if value, exists := values[settings[i].Key]; exists {
+			settings[i].Value = value
+		}
+	}
+
+	return settings
+}
+
+// savePluginSettings stores the full settings list on the plugin's metadata
+func (h *Handler) savePluginSettings(pluginName string, settings []plugins.PluginSetting) error {
+	update := bson.M{
+		"$set": bson.M{
+			"settings":   convertToModelSettings(settings),
+			"updated_at": time.Now(),
+		},
+	}
+
+	_, err := h.db.Collection("plugins").UpdateOne(context.Background(), bson.M{"name": pluginName}, update)
+	return err
 }
 
 // GetSystemInfo returns system information for plugin development
@@ -506,28 +986,150 @@ func (h *Handler) GetSystemInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, systemInfo)
 }
 
-// CleanupCache removes old compiled plugin files
+// migrations returns a migration manager for the database, creating the same
+// admin account as a startup migration would
+func (h *Handler) migrations() *migration.Manager {
+	admin := migration.AdminAccount{Email: h.config.AdminEmail, Password: h.config.AdminPassword}
+	return migration.NewManager(h.db.Critical(), h.config.MigrationTimeout, admin)
+}
+
+// GetMigrations lists recorded database migrations and any this binary still has pending
+func (h *Handler) GetMigrations(c *gin.Context) {
+	status, err := h.migrations().Status()
+	if err != nil {
+		log.Printf("[ADMIN_MIGRATIONS] Failed to load migration status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get migration status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// migrationRequest confirms a single migration being applied or rolled back
+type migrationRequest struct {
+	Confirm bool `json:"confirm"`
+}
+
+// ApplyMigration runs a single pending migration without a restart. The body must
+// be {"confirm": true}. Earlier pending migrations are reported as warnings.
+func (h *Handler) ApplyMigration(c *gin.Context) {
+	h.runMigration(c, "apply", func(m *migration.Manager, version string) (*migration.Result, error) {
+		return m.Apply(version)
+	})
+}
+
+// RollbackMigration runs the Down of a single applied migration. The body must be
+// {"confirm": true}. Later migrations still applied are reported as warnings.
+func (h *Handler) RollbackMigration(c *gin.Context) {
+	h.runMigration(c, "rollback", func(m *migration.Manager, version string) (*migration.Result, error) {
+		return m.Rollback(version)
+	})
+}
+
+func (h *Handler) runMigration(c *gin.Context, action string, run func(*migration.Manager, string) (*migration.Result, error)) {
+	version := c.Param("version")
+
+	var req migrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validation.BindError(c, err)
+		return
+	}
+	if !req.Confirm {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "This changes the database directly; send {\"confirm\": true} to proceed",
+		})
+		return
+	}
+
+	result, err := run(h.migrations(), version)
+	if err != nil {
+		switch {
+		case errors.Is(err, migration.ErrUnknownMigration):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Migration not found"})
+		case errors.Is(err, migration.ErrAlreadyApplied), errors.Is(err, migration.ErrNotApplied), errors.Is(err, migration.ErrLocked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Printf("[ADMIN_MIGRATIONS] Failed to %s migration %s: %v", action, version, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	for _, warning := range result.Warnings {
+		log.Printf("[ADMIN_MIGRATIONS] Warning for %s of %s: %s", action, version, warning)
+	}
+	h.recordActivity(c, "migration."+action, fmt.Sprintf("Migration %s: %s", version, action),
+		map[string]interface{}{
+			"version":  version,
+			"warnings": result.Warnings,
+		})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   fmt.Sprintf("Migration %s completed successfully", action),
+		"migration": result,
+	})
+}
+
+// GetCacheStats returns the size and age of the compiled plugin cache along
+// with the limits CleanupCache applies
+func (h *Handler) GetCacheStats(c *gin.Context) {
+	stats, err := h.pluginManager.GetCacheStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cache stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stats":    stats,
+		"max_age":  h.config.BuildCacheMaxAge.String(),
+		"max_size": h.config.BuildCacheMaxSize,
+	})
+}
+
+// CleanupCache removes compiled plugin files older than the configured max age
+// and trims the cache to the configured size limit
 func (h *Handler) CleanupCache(c *gin.Context) {
-	// Default to 7 days
-	maxAge := 7 * 24 * time.Hour
+	before, _ := h.pluginManager.GetCacheStats()
 
-	if err := h.pluginManager.CleanupCache(maxAge); err != nil {
+	if err := h.pluginManager.CleanupCache(h.config.BuildCacheMaxAge, h.config.BuildCacheMaxSize); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cleanup cache"})
 		return
 	}
 
+	after, _ := h.pluginManager.GetCacheStats()
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Cache cleaned up successfully",
+		"message":       "Cache cleaned up successfully",
+		"removed_files": before.FileCount - after.FileCount,
+		"freed_bytes":   before.TotalSize - after.TotalSize,
+		"stats":         after,
 	})
 }
 
-// HotReloadAll reloads all plugins without restarting the server
+// HotReloadAll reloads all plugins without restarting the server. The persisted
+// activation state is restored first, so deactivated plugins stay deactivated.
 func (h *Handler) HotReloadAll(c *gin.Context) {
+	inactive, switchedOff, err := PluginActivationState(c.Request.Context(), h.db)
+	if err != nil {
+		log.Printf("[PLUGIN_RELOAD] Failed to read plugin activation state, keeping the current one: %v", err)
+	} else {
+		h.pluginManager.RestoreActivation(inactive, switchedOff)
+	}
+
 	if err := h.pluginManager.HotReload(); err != nil {
+		if errors.Is(err, plugins.ErrShuttingDown) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server is shutting down"})
+			return
+		}
+		if reloadThrottled(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Hot reload failed: %v", err)})
 		return
 	}
 
+	h.dashboard.Invalidate()
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "All plugins reloaded successfully",
 	})
@@ -535,6 +1137,21 @@ func (h *Handler) HotReloadAll(c *gin.Context) {
 
 // Utility functions
 
+// reloadThrottled responds 429 with Retry-After when err is a ReloadThrottledError
+func reloadThrottled(c *gin.Context, err error) bool {
+	var throttledErr *plugins.ReloadThrottledError
+	if !errors.As(err, &throttledErr) {
+		return false
+	}
+	seconds := int(math.Ceil(throttledErr.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Reload was run too recently",
+		"retry_after": seconds,
+	})
+	return true
+}
+
 func convertToModelSettings(pluginSettings []plugins.PluginSetting) []models.PluginSetting {
 	modelSettings := make([]models.PluginSetting, len(pluginSettings))
 	for i, setting := range pluginSettings {
@@ -546,6 +1163,12 @@ func convertToModelSettings(pluginSettings []plugins.PluginSetting) []models.Plu
 			Description: setting.Description,
 			Options:     setting.Options,
 			Required:    setting.Required,
+			Group:       setting.Group,
+			Order:       setting.Order,
+			Min:         setting.Min,
+			Max:         setting.Max,
+			Pattern:     setting.Pattern,
+			Placeholder: setting.Placeholder,
 		}
 	}
 	return modelSettings
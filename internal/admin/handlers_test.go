@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-cms/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReloadThrottledResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	err := fmt.Errorf("reload: %w", &plugins.ReloadThrottledError{RetryAfter: 1500 * time.Millisecond})
+	if !reloadThrottled(c, err) {
+		t.Fatal("throttled error not handled")
+	}
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("status %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want the wait rounded up to 2", got)
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	if reloadThrottled(c, errors.New("compile failed")) {
+		t.Error("other errors were answered as throttled")
+	}
+}
@@ -1,6 +1,8 @@
 package admin
 
 import (
+	"fmt"
+	"log"
 	"sort"
 
 	"go-cms/internal/plugins"
@@ -19,29 +21,189 @@ func NewMenuManager(pluginManager *plugins.Manager) *MenuManager {
 }
 
 func (m *MenuManager) GetFullMenu() []plugins.AdminMenuItem {
-	// Start with base menu items
-	allItems := make([]plugins.AdminMenuItem, len(m.baseMenuItems))
-	copy(allItems, m.baseMenuItems)
+	allItems, conflicts := m.assembleMenu()
+	for _, conflict := range conflicts {
+		log.Printf("[ADMIN_MENU] Warning: %s", conflict)
+	}
+	return allItems
+}
+
+// ValidateMenu returns the conflicts detected while merging plugin menu items
+func (m *MenuManager) ValidateMenu() []string {
+	_, conflicts := m.assembleMenu()
+	return conflicts
+}
+
+// assembleMenu merges base and plugin menu items. Plugins are applied in name order;
+// items reusing a core ID are skipped, and a top-level item whose ID another plugin
+// already took replaces the earlier one (last wins).
+func (m *MenuManager) assembleMenu() ([]plugins.AdminMenuItem, []string) {
+	conflicts := []string{}
 
-	// Add plugin menu items
-	pluginItems := m.pluginManager.GetAdminMenuItems()
-	allItems = append(allItems, pluginItems...)
+	// Start with base menu items, deep-copied since children are modified below
+	allItems := cloneMenuItems(m.baseMenuItems)
+
+	owners := make(map[string]string)
+	positions := make(map[string]int)
+	for i, item := range allItems {
+		positions[item.ID] = i
+		recordMenuOwners(item, "core", owners, &conflicts)
+	}
+
+	// Add plugin menu items in a deterministic order
+	pluginItems := m.pluginManager.GetAdminMenuItemsByPlugin()
+	pluginNames := make([]string, 0, len(pluginItems))
+	for name := range pluginItems {
+		pluginNames = append(pluginNames, name)
+	}
+	sort.Strings(pluginNames)
+
+	// Core IDs cannot be taken over by plugins
+	coreIDs := make(map[string]bool, len(owners))
+	for id := range owners {
+		coreIDs[id] = true
+	}
+
+	for _, name := range pluginNames {
+		for _, item := range pluginItems[name] {
+			item, skipped := withoutCoreIDs(item, coreIDs)
+			for _, id := range skipped {
+				conflicts = append(conflicts, fmt.Sprintf("menu item %q from plugin %s uses a core ID and was skipped", id, name))
+			}
+			if item == nil {
+				continue
+			}
+			recordMenuOwners(*item, "plugin "+name, owners, &conflicts)
 
-	// Sort by order
-	sort.Slice(allItems, func(i, j int) bool {
-		return allItems[i].Order < allItems[j].Order
+			if i, exists := positions[item.ID]; exists {
+				allItems[i] = *item
+				continue
+			}
+			positions[item.ID] = len(allItems)
+			allItems = append(allItems, *item)
+		}
+	}
+
+	allItems, orphans := nestMenuItems(allItems)
+	for _, item := range orphans {
+		conflicts = append(conflicts, fmt.Sprintf("menu item %q has unknown parent %q and was placed at the top level", item.ID, item.Parent))
+	}
+
+	sortMenuItems(allItems)
+	return allItems, conflicts
+}
+
+// nestMenuItems moves items that declare a Parent into that parent's children so
+// plugins can extend existing menus. Items whose parent cannot be found stay at
+// the top level and are returned as orphans.
+func nestMenuItems(items []plugins.AdminMenuItem) ([]plugins.AdminMenuItem, []plugins.AdminMenuItem) {
+	var roots, pending []plugins.AdminMenuItem
+	for _, item := range items {
+		if item.Parent == "" {
+			roots = append(roots, item)
+		} else {
+			pending = append(pending, item)
+		}
+	}
+
+	// Repeat until no more items attach, so items may nest under other nested items
+	for len(pending) > 0 {
+		var remaining []plugins.AdminMenuItem
+		for _, item := range pending {
+			if !attachMenuItem(roots, item) {
+				remaining = append(remaining, item)
+			}
+		}
+		if len(remaining) == len(pending) {
+			break
+		}
+		pending = remaining
+	}
+
+	return append(roots, pending...), pending
+}
+
+// attachMenuItem appends item to the children of the item matching its Parent
+func attachMenuItem(items []plugins.AdminMenuItem, item plugins.AdminMenuItem) bool {
+	for i := range items {
+		if items[i].ID == item.Parent {
+			items[i].Children = append(items[i].Children, item)
+			return true
+		}
+		if attachMenuItem(items[i].Children, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutCoreIDs returns a deep copy of item with every entry whose ID belongs to
+// the core menu removed, along with the removed IDs. It returns nil when the item
+// itself uses a core ID.
+func withoutCoreIDs(item plugins.AdminMenuItem, coreIDs map[string]bool) (*plugins.AdminMenuItem, []string) {
+	if coreIDs[item.ID] {
+		return nil, []string{item.ID}
+	}
+
+	var skipped []string
+	children := make([]plugins.AdminMenuItem, 0, len(item.Children))
+	for _, child := range item.Children {
+		kept, childSkipped := withoutCoreIDs(child, coreIDs)
+		skipped = append(skipped, childSkipped...)
+		if kept != nil {
+			children = append(children, *kept)
+		}
+	}
+
+	item.Children = nil
+	if len(children) > 0 {
+		item.Children = children
+	}
+	return &item, skipped
+}
+
+// recordMenuOwners tracks which source declared each menu item ID, including
+// children, and notes every ID declared more than once
+func recordMenuOwners(item plugins.AdminMenuItem, source string, owners map[string]string, conflicts *[]string) {
+	if previous, exists := owners[item.ID]; exists {
+		*conflicts = append(*conflicts, fmt.Sprintf("menu item %q from %s duplicates one from %s", item.ID, source, previous))
+	}
+	owners[item.ID] = source
+
+	for _, child := range item.Children {
+		recordMenuOwners(child, source, owners, conflicts)
+	}
+}
+
+// sortMenuItems sorts items and their children by order, then title, so equal
+// orders still produce a stable menu
+func sortMenuItems(items []plugins.AdminMenuItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Order != items[j].Order {
+			return items[i].Order < items[j].Order
+		}
+		return items[i].Title < items[j].Title
 	})
 
-	// Sort children within each parent
-	for i := range allItems {
-		if len(allItems[i].Children) > 0 {
-			sort.Slice(allItems[i].Children, func(a, b int) bool {
-				return allItems[i].Children[a].Order < allItems[i].Children[b].Order
-			})
+	for i := range items {
+		if len(items[i].Children) > 0 {
+			sortMenuItems(items[i].Children)
 		}
 	}
+}
 
-	return allItems
+// cloneMenuItems deep-copies items so the copy can be modified freely
+func cloneMenuItems(items []plugins.AdminMenuItem) []plugins.AdminMenuItem {
+	if items == nil {
+		return nil
+	}
+
+	cloned := make([]plugins.AdminMenuItem, len(items))
+	for i, item := range items {
+		cloned[i] = item
+		cloned[i].Children = cloneMenuItems(item.Children)
+	}
+	return cloned
 }
 
 func (m *MenuManager) GetMenuByRole(role string) []plugins.AdminMenuItem {
@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-cms/internal/plugins"
+)
+
+// menuIDs renders a menu as nested IDs, e.g. "tools(tools-export,seo(seo-sitemap))"
+func menuIDs(items []plugins.AdminMenuItem) string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+		if len(item.Children) > 0 {
+			ids[i] += "(" + menuIDs(item.Children) + ")"
+		}
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestNestMenuItems(t *testing.T) {
+	items := []plugins.AdminMenuItem{
+		{ID: "dashboard"},
+		// Declared before the parent it nests under
+		{ID: "seo-sitemap", Parent: "seo"},
+		{ID: "tools", Children: []plugins.AdminMenuItem{{ID: "tools-export"}}},
+		{ID: "seo", Parent: "tools"},
+		{ID: "backup", Parent: "tools-export"},
+	}
+
+	nested, orphans := nestMenuItems(items)
+	if want := "dashboard,tools(tools-export(backup),seo(seo-sitemap))"; menuIDs(nested) != want {
+		t.Errorf("menu = %s, want %s", menuIDs(nested), want)
+	}
+	if len(orphans) != 0 {
+		t.Errorf("orphans = %v, want none", orphans)
+	}
+}
+
+func TestNestMenuItemsOrphans(t *testing.T) {
+	items := []plugins.AdminMenuItem{
+		{ID: "tools"},
+		{ID: "lost", Parent: "missing"},
+		// Items forming a cycle have no reachable parent either
+		{ID: "a", Parent: "b"},
+		{ID: "b", Parent: "a"},
+	}
+
+	nested, orphans := nestMenuItems(items)
+	if want := "tools,lost,a,b"; menuIDs(nested) != want {
+		t.Errorf("menu = %s, want %s", menuIDs(nested), want)
+	}
+	var orphanIDs []string
+	for _, item := range orphans {
+		orphanIDs = append(orphanIDs, item.ID)
+	}
+	if want := []string{"lost", "a", "b"}; !reflect.DeepEqual(orphanIDs, want) {
+		t.Errorf("orphans = %v, want %v", orphanIDs, want)
+	}
+}
+
+func TestAssembleMenuNestsAndReportsOrphans(t *testing.T) {
+	base := []plugins.AdminMenuItem{
+		{ID: "tools", Order: 1, Children: []plugins.AdminMenuItem{{ID: "tools-export", Order: 2}}},
+		{ID: "tools-import", Parent: "tools", Order: 1},
+		{ID: "stray", Parent: "nowhere", Order: 0},
+	}
+	m := &MenuManager{pluginManager: plugins.NewManager(), baseMenuItems: base}
+
+	menu, conflicts := m.assembleMenu()
+	if want := "stray,tools(tools-import,tools-export)"; menuIDs(menu) != want {
+		t.Errorf("menu = %s, want %s sorted by order", menuIDs(menu), want)
+	}
+	if len(conflicts) != 1 || !strings.Contains(conflicts[0], `"stray" has unknown parent "nowhere"`) {
+		t.Errorf("conflicts = %q, want the orphan reported", conflicts)
+	}
+
+	// Nesting works on a copy, so assembling again gives the same menu
+	if len(base[0].Children) != 1 {
+		t.Errorf("base menu was modified: %+v", base[0])
+	}
+	if again, _ := m.assembleMenu(); menuIDs(again) != menuIDs(menu) {
+		t.Errorf("second assembly = %s, want %s", menuIDs(again), menuIDs(menu))
+	}
+}
@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPluginLogLines caps ?lines on the plugin logs endpoint
+const maxPluginLogLines = 5000
+
+// GetPluginLogs returns a plugin's most recent captured log entries, oldest first.
+// ?lines caps the number of entries (default 100) and ?level (debug, info, warn
+// or error) drops entries below that level.
+func (h *Handler) GetPluginLogs(c *gin.Context) {
+	pluginName := c.Param("name")
+
+	lines, err := strconv.Atoi(c.DefaultQuery("lines", "100"))
+	if err != nil || lines < 1 || lines > maxPluginLogLines {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lines must be between 1 and " + strconv.Itoa(maxPluginLogLines)})
+		return
+	}
+
+	minLevel := slog.LevelDebug
+	if level := c.Query("level"); level != "" {
+		if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "level must be one of: debug, info, warn, error"})
+			return
+		}
+	}
+
+	entries, err := h.pluginManager.PluginLogs(pluginName, lines, minLevel)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plugin":  pluginName,
+		"level":   minLevel.String(),
+		"entries": entries,
+	})
+}
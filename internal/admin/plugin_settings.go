@@ -0,0 +1,142 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-cms/internal/database/models"
+	"go-cms/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// maxSettingsBatch caps ?limit on the batch plugin settings endpoint
+const maxSettingsBatch = 200
+
+// GetAllPluginSettings returns the effective settings of every loaded plugin,
+// keyed by plugin name, in one response. ?plugins=a,b limits it to the named
+// plugins; ?limit (default 50) and ?offset page through plugins in name order.
+func (h *Handler) GetAllPluginSettings(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > maxSettingsBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxSettingsBatch)})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+		return
+	}
+
+	loaded := h.pluginManager.GetAllPlugins()
+
+	var names []string
+	if filter := c.Query("plugins"); filter != "" {
+		for _, name := range strings.Split(filter, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if _, exists := loaded[name]; !exists {
+				c.JSON(http.StatusNotFound, gin.H{"error": "plugin " + name + " not found"})
+				return
+			}
+			names = append(names, name)
+		}
+	} else {
+		for name := range loaded {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	total := len(names)
+	if offset > total {
+		offset = total
+	}
+	page := names[offset:min(offset+limit, total)]
+
+	// One query for the saved values of the whole page
+	saved := make(map[string][]models.PluginSetting, len(page))
+	if len(page) > 0 {
+		cursor, err := h.db.Collection("plugins").Find(context.Background(), bson.M{"name": bson.M{"$in": page}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load saved settings"})
+			return
+		}
+		var metadata []models.PluginMetadata
+		if err := cursor.All(context.Background(), &metadata); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load saved settings"})
+			return
+		}
+		for _, plugin := range metadata {
+			saved[plugin.Name] = plugin.Settings
+		}
+	}
+
+	settings := make(map[string][]plugins.PluginSetting, len(page))
+	for _, name := range page {
+		defaults := plugins.SafeSettings(name, loaded[name])
+		settings[name] = mergeSavedSettings(defaults, saved[name])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings": settings,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+// RefreshPluginSettings re-reads a plugin's saved settings and applies them to the
+// running plugin without recompiling it. This is the fast path after settings were
+// changed outside the settings endpoints, e.g. directly in the database; use the
+// reload endpoint when the plugin's code changed.
+func (h *Handler) RefreshPluginSettings(c *gin.Context) {
+	pluginName := c.Param("name")
+
+	defaults, err := h.pluginManager.GetPluginSettings(pluginName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.savedPluginSettings(pluginName, defaults)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load saved settings"})
+		return
+	}
+
+	applied, err := h.pluginManager.RefreshPluginSettings(pluginName, settings)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "Plugin settings refreshed"
+	if !applied {
+		message = "Host settings refreshed; the plugin applies its own settings only on reload"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  message,
+		"plugin":   pluginName,
+		"applied":  applied,
+		"settings": settings,
+	})
+}
+
+// applyPluginSettings applies just-saved settings to the running plugin, as
+// RefreshPluginSettings does, so a save takes effect without a reload. The
+// outcome is added to response: "applied" reports whether the plugin took its own
+// settings, and a plugin rejecting them is a warning since the values are saved.
+func (h *Handler) applyPluginSettings(pluginName string, settings []plugins.PluginSetting, response gin.H) {
+	applied, err := h.pluginManager.RefreshPluginSettings(pluginName, settings)
+	response["applied"] = applied
+	if err != nil {
+		response["warnings"] = []string{err.Error()}
+	}
+}
@@ -0,0 +1,13 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPluginStats returns request counts and handler latency for each plugin's
+// routes since startup, busiest first
+func (h *Handler) GetPluginStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"plugins": h.pluginManager.PluginStats()})
+}
@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"go-cms/internal/auth"
+	"go-cms/internal/database/models"
+	"go-cms/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// settingsHistoryCollection stores one document per settings update
+	settingsHistoryCollection = "plugin_settings_history"
+
+	// settingsHistoryLimit is how many updates are kept per plugin
+	settingsHistoryLimit = 200
+
+	// changeSourceHeader lets the admin UI identify itself; other callers are recorded as api
+	changeSourceHeader = "X-Change-Source"
+	changeSourceUI     = "admin_ui"
+	changeSourceAPI    = "api"
+)
+
+// diffSettings returns the keys whose values differ between before and after,
+// sorted by key. Values are compared by their JSON encoding so numbers decoded
+// from BSON and from JSON compare equal.
+func diffSettings(before, after []plugins.PluginSetting) []models.SettingChange {
+	oldValues := make(map[string]interface{}, len(before))
+	for _, setting := range before {
+		oldValues[setting.Key] = setting.Value
+	}
+	newValues := make(map[string]interface{}, len(after))
+	for _, setting := range after {
+		newValues[setting.Key] = setting.Value
+	}
+
+	var changes []models.SettingChange
+	for key, oldValue := range oldValues {
+		newValue, exists := newValues[key]
+		if !exists {
+			changes = append(changes, models.SettingChange{Key: key, OldValue: oldValue})
+			continue
+		}
+		if !sameJSON(oldValue, newValue) {
+			changes = append(changes, models.SettingChange{Key: key, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	for key, newValue := range newValues {
+		if _, exists := oldValues[key]; !exists {
+			changes = append(changes, models.SettingChange{Key: key, NewValue: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// sameJSON reports whether a and b have the same JSON encoding
+func sameJSON(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+// recordSettingsHistory stores the changes made by the current request and prunes
+// entries beyond the per-plugin limit. Failures are logged and do not fail the update.
+func (h *Handler) recordSettingsHistory(c *gin.Context, pluginName string, changes []models.SettingChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	entry := models.PluginSettingsChange{
+		Plugin:    pluginName,
+		Changes:   changes,
+		Source:    changeSourceAPI,
+		CreatedAt: time.Now(),
+	}
+	if c.GetHeader(changeSourceHeader) == changeSourceUI {
+		entry.Source = changeSourceUI
+	}
+	if userContext, exists := auth.GetUserFromContext(c); exists {
+		entry.UserID = userContext.UserID
+		entry.Username = userContext.Username
+	}
+
+	collection := h.db.Collection(settingsHistoryCollection)
+	if _, err := collection.InsertOne(context.Background(), entry); err != nil {
+		log.Printf("[PLUGIN_SETTINGS] Failed to record settings history for %s: %v", pluginName, err)
+		return
+	}
+
+	// Drop everything older than the newest settingsHistoryLimit entries
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(settingsHistoryLimit)
+	var oldest models.PluginSettingsChange
+	if err := collection.FindOne(context.Background(), bson.M{"plugin": pluginName}, opts).Decode(&oldest); err != nil {
+		return
+	}
+	_, err := collection.DeleteMany(context.Background(), bson.M{
+		"plugin":     pluginName,
+		"created_at": bson.M{"$lte": oldest.CreatedAt},
+	})
+	if err != nil {
+		log.Printf("[PLUGIN_SETTINGS] Failed to prune settings history for %s: %v", pluginName, err)
+	}
+}
+
+// GetPluginSettingsHistory returns the most recent settings changes of a plugin,
+// newest first. ?limit caps the number of entries (default 50).
+func (h *Handler) GetPluginSettingsHistory(c *gin.Context) {
+	pluginName := c.Param("name")
+
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	if err != nil || limit < 1 || limit > settingsHistoryLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(settingsHistoryLimit)})
+		return
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(limit)
+	cursor, err := h.db.Collection(settingsHistoryCollection).Find(context.Background(), bson.M{"plugin": pluginName}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings history"})
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	history := []models.PluginSettingsChange{}
+	if err := cursor.All(context.Background(), &history); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode settings history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plugin":  pluginName,
+		"history": history,
+	})
+}
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go-cms/internal/database/models"
+	"go-cms/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDiffSettings(t *testing.T) {
+	before := []plugins.PluginSetting{
+		{Key: "enabled", Value: true},
+		{Key: "debug_mode", Value: false},
+		{Key: "cache_ttl", Value: int32(300)}, // As decoded from BSON
+		{Key: "api_key", Value: "old"},
+		{Key: "channels", Value: []interface{}{"email"}},
+	}
+	after := []plugins.PluginSetting{
+		{Key: "enabled", Value: true},
+		{Key: "debug_mode", Value: true},
+		{Key: "cache_ttl", Value: float64(300)}, // As decoded from JSON
+		{Key: "channels", Value: []interface{}{"email", "sms"}},
+		{Key: "webhook", Value: "https://hooks.example"},
+	}
+
+	want := []models.SettingChange{
+		{Key: "api_key", OldValue: "old"},
+		{Key: "channels", OldValue: []interface{}{"email"}, NewValue: []interface{}{"email", "sms"}},
+		{Key: "debug_mode", OldValue: false, NewValue: true},
+		{Key: "webhook", NewValue: "https://hooks.example"},
+	}
+	if got := diffSettings(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffSettings =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := diffSettings(after, after); len(got) != 0 {
+		t.Errorf("diffSettings of unchanged settings = %+v, want none", got)
+	}
+	if got := diffSettings(nil, nil); len(got) != 0 {
+		t.Errorf("diffSettings of no settings = %+v, want none", got)
+	}
+}
+
+func TestPluginSettingsHistoryLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+	r := gin.New()
+	r.GET("/plugins/:name/settings/history", h.GetPluginSettingsHistory)
+
+	// Invalid limits are rejected before the database is queried
+	for _, limit := range []string{"0", "-1", "201", "ten"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/plugins/seo/settings/history?limit="+limit, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("limit=%s: status %d, want 400", limit, w.Code)
+		}
+	}
+}
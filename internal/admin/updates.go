@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"go-cms/internal/registry"
+
+	"github.com/gin-gonic/gin"
+)
+
+// updateCheckTimeout bounds all registry lookups made by one request; packages
+// not checked in time are reported as unknown
+const updateCheckTimeout = 10 * time.Second
+
+// GetThemeUpdates lists installed themes with a newer version in the registry.
+// Themes that could not be checked are listed under "unknown".
+func (h *Handler) GetThemeUpdates(c *gin.Context) {
+	installed := make(map[string]string)
+	for name, theme := range h.themeManager.GetAllThemes() {
+		installed[name] = theme.Version
+	}
+	h.respondWithUpdates(c, registry.KindTheme, installed)
+}
+
+// GetPluginUpdates lists loaded plugins with a newer version in the registry.
+// Plugins that could not be checked are listed under "unknown".
+func (h *Handler) GetPluginUpdates(c *gin.Context) {
+	installed := make(map[string]string)
+	for name, plugin := range h.pluginManager.GetAllPlugins() {
+		installed[name] = plugin.GetInfo().Version
+	}
+	h.respondWithUpdates(c, registry.KindPlugin, installed)
+}
+
+// respondWithUpdates checks each installed package against the registry
+func (h *Handler) respondWithUpdates(c *gin.Context, kind string, installed map[string]string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), updateCheckTimeout)
+	defer cancel()
+
+	names := make([]string, 0, len(installed))
+	for name := range installed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	updates := []registry.Update{}
+	unknown := []registry.Update{}
+	for _, name := range names {
+		update := h.registry.Check(ctx, kind, name, installed[name])
+		switch update.Status {
+		case registry.StatusAvailable:
+			updates = append(updates, update)
+		case registry.StatusUnknown:
+			unknown = append(unknown, update)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"updates": updates,
+		"unknown": unknown,
+		"checked": len(names),
+	})
+}
@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"go-cms/internal/config"
+	"go-cms/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pluginUpload returns a multipart body uploading a plugin zip as filename
+func pluginUpload(t *testing.T, filename, name, version string) (*bytes.Buffer, string) {
+	t.Helper()
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	files := map[string]string{
+		"plugin.json": fmt.Sprintf(`{"name":%q,"version":%q}`, name, version),
+		"main.go":     "package main\n\nfunc NewPlugin() interface{} { return nil }\n",
+	}
+	for file, content := range files {
+		w, err := zw.Create(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("plugin", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(archive.Bytes())
+	mw.Close()
+	return &body, mw.FormDataContentType()
+}
+
+func TestConcurrentUploadsOfTheSameFilename(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tempDir := t.TempDir()
+	h := &Handler{config: &config.Config{TempDir: tempDir}, pluginManager: plugins.NewManager()}
+	r := gin.New()
+	r.POST("/plugins/validate", h.ValidatePluginUpload)
+
+	// Every upload is named seo.zip; each must be validated from its own contents
+	const uploads = 8
+	versions := make([]string, uploads)
+	var wg sync.WaitGroup
+	for i := range versions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, contentType := pluginUpload(t, "seo.zip", "seo", fmt.Sprintf("1.0.%d", i))
+			req := httptest.NewRequest(http.MethodPost, "/plugins/validate", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Errorf("upload %d: status %d: %s", i, w.Code, w.Body)
+				return
+			}
+
+			var response struct {
+				Result plugins.PluginDryRunResult `json:"result"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Result.Info == nil {
+				t.Errorf("upload %d: response %s", i, w.Body)
+				return
+			}
+			versions[i] = response.Result.Info.Version
+		}()
+	}
+	wg.Wait()
+
+	for i, version := range versions {
+		if want := fmt.Sprintf("1.0.%d", i); version != want {
+			t.Errorf("upload %d was validated as version %q, want %q", i, version, want)
+		}
+	}
+
+	// Each upload removed its own directory
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("temp directory still holds %d entries", len(entries))
+	}
+}
@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go-cms/internal/clock"
+	"go-cms/internal/database/models"
+	"go-cms/internal/httputil"
+	"go-cms/internal/repository"
+	"go-cms/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APITokenPrefix starts every personal access token, which is how they are told
+// apart from JWTs in the Authorization header
+const APITokenPrefix = "gcms_"
+
+// apiTokenIDLength is how many characters of a token, after APITokenPrefix, are
+// kept in the clear so users can tell their tokens apart
+const apiTokenIDLength = 8
+
+// apiTokenTouchInterval limits how often a token's last use is written back
+const apiTokenTouchInterval = time.Minute
+
+// Scopes a personal access token can be limited to. A token without scopes may
+// do everything its user may do.
+const (
+	ScopeRead  = "read"  // GET and HEAD requests
+	ScopeWrite = "write" // Every other method
+	ScopeAdmin = "admin" // The admin API, on top of read or write
+)
+
+var knownScopes = []string{ScopeRead, ScopeWrite, ScopeAdmin}
+
+// Context keys set when a request is authenticated by a personal access token
+const (
+	contextAPITokenID = "api_token_id"
+	contextScopes     = "token_scopes"
+)
+
+// SetAPITokens sets the store personal access tokens are kept in. Without it the
+// token endpoints answer 503.
+func (h *Handler) SetAPITokens(tokens repository.APITokenRepository) {
+	h.apiTokens = tokens
+}
+
+// hashAPIToken returns the stored form of a personal access token
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken generates a personal access token for the current user. The
+// token is only returned here; the database keeps its hash and prefix.
+func (h *Handler) CreateAPIToken(c *gin.Context) {
+	userContext, ok := h.apiTokenOwner(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name          string   `json:"name" binding:"required,max=100"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validation.BindError(c, err)
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(knownScopes, scope) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Unknown scope %q; use one of %s", scope, strings.Join(knownScopes, ", ")),
+			})
+			return
+		}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	secret := APITokenPrefix + hex.EncodeToString(raw)
+
+	now := h.clock.Now()
+	token := models.APIToken{
+		UserID:    userContext.UserID,
+		Name:      req.Name,
+		Prefix:    secret[:len(APITokenPrefix)+apiTokenIDLength],
+		TokenHash: hashAPIToken(secret),
+		Scopes:    req.Scopes,
+		CreatedAt: now,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := h.apiTokens.Create(c.Request.Context(), &token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	log.Printf("[AUTH] User %s created API token %s (%s)", userContext.UserID, token.Prefix, token.Name)
+
+	httputil.Created(c, "/api/v1/tokens/"+token.ID.Hex(), gin.H{
+		"message":   "Token created successfully; it will not be shown again",
+		"token":     secret,
+		"api_token": token,
+	})
+}
+
+// ListAPITokens returns the current user's tokens without their secrets
+func (h *Handler) ListAPITokens(c *gin.Context) {
+	userContext, ok := h.apiTokenOwner(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.apiTokens.ListByUser(c.Request.Context(), userContext.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// RevokeAPIToken deletes one of the current user's tokens, which stops it
+// working immediately
+func (h *Handler) RevokeAPIToken(c *gin.Context) {
+	userContext, ok := h.apiTokenOwner(c)
+	if !ok {
+		return
+	}
+
+	err := h.apiTokens.Delete(c.Request.Context(), userContext.UserID, c.Param("id"))
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	log.Printf("[AUTH] User %s revoked API token %s", userContext.UserID, c.Param("id"))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked successfully"})
+}
+
+// apiTokenOwner returns the user managing their tokens. Tokens can only be
+// managed with a login session, so a leaked token cannot mint more. It writes
+// the error response and returns false otherwise.
+func (h *Handler) apiTokenOwner(c *gin.Context) (UserContext, bool) {
+	if h.apiTokens == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "API tokens are not available"})
+		return UserContext{}, false
+	}
+
+	userContext, exists := GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User context not found"})
+		return UserContext{}, false
+	}
+
+	if _, viaToken := c.Get(contextAPITokenID); viaToken {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API tokens cannot be managed with an API token"})
+		return UserContext{}, false
+	}
+	return userContext, true
+}
+
+// Authenticate accepts either a JWT or a personal access token as the bearer
+// token. A personal access token acts as its user, with the role the user has
+// now, limited to the token's scopes; ScopeRead or ScopeWrite is required by
+// the request method. Deactivated users' tokens are refused. Token expiry and
+// use are timed by clk.
+func Authenticate(secret string, tokens repository.APITokenRepository, users repository.UserRepository, clk clock.Clock) gin.HandlerFunc {
+	jwt := JWTMiddleware(secret)
+
+	return func(c *gin.Context) {
+		raw, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || !strings.HasPrefix(raw, APITokenPrefix) {
+			jwt(c)
+			return
+		}
+
+		ctx := c.Request.Context()
+		now := clk.Now()
+
+		token, err := tokens.FindByHash(ctx, hashAPIToken(raw), now)
+		if err != nil {
+			if err != repository.ErrNotFound {
+				log.Printf("[AUTH] Failed to look up API token: %v", err)
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+
+		user, err := users.FindByID(ctx, token.UserID)
+		if err != nil || !user.IsActive {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+
+		if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval {
+			if err := tokens.Touch(ctx, token.ID, now); err != nil {
+				log.Printf("[AUTH] Failed to record use of API token %s: %v", token.Prefix, err)
+			}
+		}
+
+		c.Set("user_id", user.ID.Hex())
+		c.Set("username", user.Username)
+		c.Set("email", user.Email)
+		c.Set("role", user.Role)
+		c.Set(contextAPITokenID, token.ID.Hex())
+		c.Set(contextScopes, token.Scopes)
+
+		scope := ScopeWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = ScopeRead
+		}
+		if !hasScope(c, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Token lacks the %s scope", scope)})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ScopeRequired refuses requests authenticated by a personal access token that
+// lacks scope. Requests authenticated by a JWT pass.
+func ScopeRequired(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasScope(c, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Token lacks the %s scope", scope)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// hasScope reports whether the request's token grants scope. Requests not made
+// with a personal access token, and tokens without scopes, are unrestricted.
+func hasScope(c *gin.Context, scope string) bool {
+	value, exists := c.Get(contextScopes)
+	if !exists {
+		return true
+	}
+	scopes, _ := value.([]string)
+	return len(scopes) == 0 || slices.Contains(scopes, scope)
+}
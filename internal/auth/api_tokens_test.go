@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-cms/internal/clock"
+	"go-cms/internal/database/models"
+	"go-cms/internal/repository/repotest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenEngine serves the token endpoints and a few protected routes behind
+// Authenticate, as the router does. Tokens are created and checked by clk.
+func tokenEngine(users *repotest.Users, tokens *repotest.APITokens, clk clock.Clock) *gin.Engine {
+	h := NewHandler(users, testSecret)
+	h.SetAPITokens(tokens)
+	h.SetClock(clk)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	protected := r.Group("/api/v1", Authenticate(testSecret, tokens, users, clk))
+	protected.POST("/tokens", h.CreateAPIToken)
+	protected.GET("/tokens", h.ListAPITokens)
+	protected.DELETE("/tokens/:id", h.RevokeAPIToken)
+	me := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("user_id")) }
+	protected.GET("/me", me)
+	protected.POST("/me", me)
+	protected.GET("/admin/stats", ScopeRequired(ScopeAdmin), me)
+	return r
+}
+
+func bearer(r http.Handler, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// createToken creates a personal access token with a login session and returns
+// its secret and ID
+func createToken(t *testing.T, r http.Handler, session string, body gin.H) (string, string) {
+	t.Helper()
+	w := bearer(r, http.MethodPost, "/api/v1/tokens", session, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create token: status %d: %s", w.Code, w.Body)
+	}
+	var created struct {
+		Token    string          `json:"token"`
+		APIToken models.APIToken `json:"api_token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if want := "http://example.com/api/v1/tokens/" + created.APIToken.ID.Hex(); w.Header().Get("Location") != want {
+		t.Errorf("Location = %q, want %q", w.Header().Get("Location"), want)
+	}
+	return created.Token, created.APIToken.ID.Hex()
+}
+
+func sessionFor(t *testing.T, user models.User) string {
+	t.Helper()
+	pair, err := GenerateTokenPair(user.ID.Hex(), user.Username, user.Email, user.Role, "", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pair.AccessToken
+}
+
+func TestAPITokenLifecycle(t *testing.T) {
+	users := repotest.NewUsers(&models.User{Username: "alice", Email: "alice@example.com", Role: "admin", IsActive: true})
+	alice := users.All()[0]
+	tokens := repotest.NewAPITokens()
+	r := tokenEngine(users, tokens, clock.Real())
+	session := sessionFor(t, alice)
+
+	secret, id := createToken(t, r, session, gin.H{"name": "ci"})
+	if !strings.HasPrefix(secret, APITokenPrefix) {
+		t.Fatalf("token %q lacks the %s prefix", secret, APITokenPrefix)
+	}
+
+	// Only the hash and the prefix are stored
+	stored := tokens.All()[0]
+	if stored.TokenHash == secret || stored.TokenHash != hashAPIToken(secret) || !strings.HasPrefix(secret, stored.Prefix) {
+		t.Errorf("stored token = %+v", stored)
+	}
+
+	// The token acts as its user and its use is recorded
+	if w := bearer(r, http.MethodGet, "/api/v1/me", secret, nil); w.Code != http.StatusOK || w.Body.String() != alice.ID.Hex() {
+		t.Fatalf("authenticate with token: status %d, body %q", w.Code, w.Body)
+	}
+	if tokens.All()[0].LastUsedAt == nil {
+		t.Error("token use was not recorded")
+	}
+
+	// The listing never shows the secret
+	w := bearer(r, http.MethodGet, "/api/v1/tokens", session, nil)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), secret) || strings.Contains(w.Body.String(), stored.TokenHash) {
+		t.Errorf("list tokens: status %d, body %s", w.Code, w.Body)
+	}
+
+	// A token cannot manage tokens
+	if w := bearer(r, http.MethodPost, "/api/v1/tokens", secret, gin.H{"name": "more"}); w.Code != http.StatusForbidden {
+		t.Errorf("create with a token: status %d, want 403", w.Code)
+	}
+
+	// Revoking stops the token working at once
+	if w := bearer(r, http.MethodDelete, "/api/v1/tokens/"+id, session, nil); w.Code != http.StatusOK {
+		t.Fatalf("revoke: status %d: %s", w.Code, w.Body)
+	}
+	if w := bearer(r, http.MethodGet, "/api/v1/me", secret, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: status %d, want 401", w.Code)
+	}
+	if w := bearer(r, http.MethodDelete, "/api/v1/tokens/"+id, session, nil); w.Code != http.StatusNotFound {
+		t.Errorf("second revoke: status %d, want 404", w.Code)
+	}
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	users := repotest.NewUsers(&models.User{Username: "alice", Email: "alice@example.com", Role: "admin", IsActive: true})
+	r := tokenEngine(users, repotest.NewAPITokens(), clock.Real())
+	session := sessionFor(t, users.All()[0])
+
+	readOnly, _ := createToken(t, r, session, gin.H{"name": "dashboard", "scopes": []string{ScopeRead}})
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/v1/me", http.StatusOK},
+		{http.MethodPost, "/api/v1/me", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/admin/stats", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if w := bearer(r, tt.method, tt.path, readOnly, nil); w.Code != tt.want {
+			t.Errorf("read-only token %s %s: status %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+
+	admin, _ := createToken(t, r, session, gin.H{"name": "ops", "scopes": []string{ScopeRead, ScopeAdmin}})
+	if w := bearer(r, http.MethodGet, "/api/v1/admin/stats", admin, nil); w.Code != http.StatusOK {
+		t.Errorf("admin-scoped token: status %d, want 200", w.Code)
+	}
+
+	// A login session is not limited by scopes
+	if w := bearer(r, http.MethodGet, "/api/v1/admin/stats", session, nil); w.Code != http.StatusOK {
+		t.Errorf("session: status %d, want 200", w.Code)
+	}
+
+	if w := bearer(r, http.MethodPost, "/api/v1/tokens", session, gin.H{"name": "x", "scopes": []string{"delete"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown scope: status %d, want 400", w.Code)
+	}
+}
+
+func TestAPITokenRefusedForUnknownOrDeactivatedUser(t *testing.T) {
+	users := repotest.NewUsers(&models.User{Username: "alice", Email: "alice@example.com", Role: "user", IsActive: true})
+	alice := users.All()[0]
+	r := tokenEngine(users, repotest.NewAPITokens(), clock.Real())
+
+	secret, _ := createToken(t, r, sessionFor(t, alice), gin.H{"name": "ci"})
+	if w := bearer(r, http.MethodGet, "/api/v1/me", APITokenPrefix+"not-a-real-token", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown token: status %d, want 401", w.Code)
+	}
+
+	users.UpdateFields(context.Background(), alice.ID.Hex(), map[string]interface{}{"is_active": false})
+	if w := bearer(r, http.MethodGet, "/api/v1/me", secret, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("deactivated user's token: status %d, want 401", w.Code)
+	}
+}
+
+func TestAPITokenExpiryFollowsClock(t *testing.T) {
+	users := repotest.NewUsers(&models.User{Username: "alice", Email: "alice@example.com", Role: "user", IsActive: true})
+	tokens := repotest.NewAPITokens()
+	created := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(created)
+	r := tokenEngine(users, tokens, clk)
+
+	secret, _ := createToken(t, r, sessionFor(t, users.All()[0]), gin.H{"name": "ci", "expires_in_days": 1})
+
+	clk.Set(created.Add(24*time.Hour - time.Second))
+	if w := bearer(r, http.MethodGet, "/api/v1/me", secret, nil); w.Code != http.StatusOK {
+		t.Fatalf("token before expiry: status %d, want 200", w.Code)
+	}
+	if used := tokens.All()[0].LastUsedAt; used == nil || !used.Equal(clk.Now()) {
+		t.Errorf("last used = %v, want the clock's %s", used, clk.Now())
+	}
+
+	clk.Set(created.Add(24 * time.Hour))
+	if w := bearer(r, http.MethodGet, "/api/v1/me", secret, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("token at expiry: status %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,10 @@
+package auth
+
+import "net/mail"
+
+// validEmail reports whether email is a bare address, without a display name
+// or angle brackets
+func validEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
@@ -1,90 +1,154 @@
 package auth
 
 import (
-	"context"
+	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
-	"time"
+	"sync"
 
-	"go-cms/internal/database"
+	"go-cms/internal/clock"
 	"go-cms/internal/database/models"
+	"go-cms/internal/httputil"
+	"go-cms/internal/repository"
+	"go-cms/internal/validation"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Handler struct {
-	db        *database.DB
+	users     repository.UserRepository
 	jwtSecret string
+	clock     clock.Clock
+
+	// explicitLoginErrors tells users with the right password that their account
+	// is deactivated instead of returning the generic error
+	explicitLoginErrors bool
+
+	// Registration mode and the invites checked in invite-only mode
+	registrationMode func() RegistrationMode
+	invites          repository.InviteRepository
+
+	// Policy new passwords are checked against
+	passwordPolicy func() PasswordPolicy
+
+	// Personal access tokens managed through the token endpoints
+	apiTokens repository.APITokenRepository
 }
 
-func NewHandler(db *database.DB, jwtSecret string) *Handler {
+func NewHandler(users repository.UserRepository, jwtSecret string) *Handler {
 	return &Handler{
-		db:        db,
+		users:     users,
 		jwtSecret: jwtSecret,
+		clock:     clock.Real(),
 	}
 }
 
+// SetClock replaces the clock used for timestamps and token expiry
+func (h *Handler) SetClock(clk clock.Clock) {
+	h.clock = clk
+}
+
+// SetExplicitLoginErrors controls whether a deactivated account is reported as
+// such. By default every failed login gets the same response so accounts cannot
+// be enumerated.
+func (h *Handler) SetExplicitLoginErrors(enabled bool) {
+	h.explicitLoginErrors = enabled
+}
+
+// dummyUser has a real bcrypt hash so logins for unknown emails spend as long
+// comparing passwords as logins for existing ones
+var (
+	dummyUserOnce sync.Once
+	dummyUser     models.User
+)
+
+// compareDummyPassword runs a bcrypt comparison whose result is discarded
+func compareDummyPassword(password string) {
+	dummyUserOnce.Do(func() {
+		dummyUser = models.User{Password: "dummy-password-for-timing"}
+		if err := dummyUser.HashPassword(); err != nil {
+			log.Printf("[AUTH] Failed to prepare dummy password hash: %v", err)
+		}
+	})
+	dummyUser.CheckPassword(password)
+}
+
 // Register handles user registration
 func (h *Handler) Register(c *gin.Context) {
+	mode := h.currentRegistrationMode()
+	if mode == RegistrationClosed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Registration is closed"})
+		return
+	}
+
 	var req models.UserRegistration
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		validation.BindError(c, err)
 		return
 	}
 
-	// Check if user already exists
-	collection := h.db.Collection("users")
+	req.Email = models.NormalizeEmail(req.Email)
 
-	existingUser := models.User{}
-	err := collection.FindOne(context.Background(), bson.M{
-		"$or": []bson.M{
-			{"email": req.Email},
-			{"username": req.Username},
-		},
-	}).Decode(&existingUser)
+	if !h.checkPassword(c, req.Password) {
+		return
+	}
+
+	// Invite-only registration consumes the invite up front so it cannot be used twice
+	var invite *models.Invite
+	if mode == RegistrationInviteOnly {
+		var ok bool
+		if invite, ok = h.consumeInvite(c, req); !ok {
+			return
+		}
+	}
 
+	// Check if user already exists
+	_, err := h.users.FindByEmailOrUsername(c.Request.Context(), req.Email, req.Username)
 	if err == nil {
+		h.releaseInvite(c, invite)
 		c.JSON(http.StatusConflict, gin.H{"error": "User with this email or username already exists"})
 		return
-	} else if err != mongo.ErrNoDocuments {
+	} else if err != repository.ErrNotFound {
+		h.releaseInvite(c, invite)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
 	// Create new user
+	now := h.clock.Now()
 	user := models.User{
 		Username:  req.Username,
 		Email:     req.Email,
 		Password:  req.Password,
 		Role:      "user",
 		IsActive:  true,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 
 	// Hash password
 	if err := user.HashPassword(); err != nil {
+		h.releaseInvite(c, invite)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
 	}
 
 	// Insert user
-	result, err := collection.InsertOne(context.Background(), user)
-	if err != nil {
+	if err := h.users.Create(c.Request.Context(), &user); err != nil {
+		h.releaseInvite(c, invite)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
-	user.ID = result.InsertedID.(primitive.ObjectID)
-
 	// Generate tokens
-	tokens, err := GenerateTokenPair(
+	tokens, err := generateTokenPair(
+		h.clock,
 		user.ID.Hex(),
 		user.Username,
 		user.Email,
 		user.Role,
+		c.GetString("tenant"),
 		h.jwtSecret,
 	)
 	if err != nil {
@@ -92,7 +156,8 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	// The new account is served at /profile to whoever holds its tokens
+	httputil.Created(c, "/api/v1/profile", gin.H{
 		"message": "User registered successfully",
 		"user": gin.H{
 			"id":       user.ID.Hex(),
@@ -108,16 +173,16 @@ func (h *Handler) Register(c *gin.Context) {
 func (h *Handler) Login(c *gin.Context) {
 	var req models.UserLogin
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		validation.BindError(c, err)
 		return
 	}
 
 	// Find user by email
-	collection := h.db.Collection("users")
-	var user models.User
-	err := collection.FindOne(context.Background(), bson.M{"email": req.Email}).Decode(&user)
+	user, err := h.users.FindByEmail(c.Request.Context(), models.NormalizeEmail(req.Email))
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if err == repository.ErrNotFound {
+			// Spend the same time as a real password check
+			compareDummyPassword(req.Password)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
@@ -125,34 +190,34 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	// Check if user is active
-	if !user.IsActive {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Account is deactivated"})
+	// Verify password before revealing anything about the account
+	if !user.CheckPassword(req.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	// Verify password
-	if !user.CheckPassword(req.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	// Check if user is active
+	if !user.IsActive {
+		log.Printf("[AUTH] Login rejected for user %s: account is deactivated", user.ID.Hex())
+		message := "Invalid credentials"
+		if h.explicitLoginErrors {
+			message = "Account is deactivated"
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": message})
 		return
 	}
 
 	// Update last login time
-	now := time.Now()
-	update := bson.M{
-		"$set": bson.M{
-			"last_login_at": now,
-			"updated_at":    now,
-		},
-	}
-	collection.UpdateOne(context.Background(), bson.M{"_id": user.ID}, update)
+	h.users.UpdateLastLogin(c.Request.Context(), user.ID.Hex(), h.clock.Now())
 
 	// Generate tokens
-	tokens, err := GenerateTokenPair(
+	tokens, err := generateTokenPair(
+		h.clock,
 		user.ID.Hex(),
 		user.Username,
 		user.Email,
 		user.Role,
+		c.GetString("tenant"),
 		h.jwtSecret,
 	)
 	if err != nil {
@@ -179,22 +244,19 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		validation.BindError(c, err)
 		return
 	}
 
 	// Validate refresh token
-	claims, err := ValidateToken(req.RefreshToken, h.jwtSecret)
-	if err != nil {
+	claims, err := validateToken(h.clock, req.RefreshToken, h.jwtSecret)
+	if err != nil || claims.Tenant != c.GetString("tenant") {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
 		return
 	}
 
 	// Get user from database to ensure they still exist and are active
-	collection := h.db.Collection("users")
-	userID, _ := primitive.ObjectIDFromHex(claims.UserID)
-	var user models.User
-	err = collection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user)
+	user, err := h.users.FindByID(c.Request.Context(), claims.UserID)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
@@ -206,11 +268,13 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 	}
 
 	// Generate new tokens
-	tokens, err := GenerateTokenPair(
+	tokens, err := generateTokenPair(
+		h.clock,
 		user.ID.Hex(),
 		user.Username,
 		user.Email,
 		user.Role,
+		c.GetString("tenant"),
 		h.jwtSecret,
 	)
 	if err != nil {
@@ -233,10 +297,7 @@ func (h *Handler) GetProfile(c *gin.Context) {
 	}
 
 	// Get full user data from database
-	collection := h.db.Collection("users")
-	userID, _ := primitive.ObjectIDFromHex(userContext.UserID)
-	var user models.User
-	err := collection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user)
+	user, err := h.users.FindByID(c.Request.Context(), userContext.UserID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -271,65 +332,135 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		validation.BindError(c, err)
 		return
 	}
 
-	collection := h.db.Collection("users")
-	userID, _ := primitive.ObjectIDFromHex(userContext.UserID)
+	// Empty fields are left unchanged
+	var changes profileChanges
+	if req.Username != "" {
+		changes.Username = &req.Username
+	}
+	if req.Email != "" {
+		changes.Email = &req.Email
+	}
+	if req.Password != "" {
+		changes.Password = &req.Password
+	}
 
-	// Build update document
-	update := bson.M{
-		"$set": bson.M{
-			"updated_at": time.Now(),
-		},
+	if !h.applyProfileChanges(c, userContext.UserID, changes) {
+		return
 	}
 
-	if req.Username != "" {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Profile updated successfully",
+	})
+}
+
+// PatchProfile applies a partial profile update. Fields absent from the body are
+// left unchanged; null is rejected because none of the fields can be cleared.
+func (h *Handler) PatchProfile(c *gin.Context) {
+	userContext, exists := GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User context not found"})
+		return
+	}
+
+	var body map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&body); err != nil {
+		validation.BindError(c, err)
+		return
+	}
+
+	var changes profileChanges
+	fields := map[string]**string{
+		"username": &changes.Username,
+		"email":    &changes.Email,
+		"password": &changes.Password,
+	}
+
+	for key, raw := range body {
+		field, known := fields[key]
+		if !known {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown field: %s", key)})
+			return
+		}
+		if string(raw) == "null" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s cannot be cleared", key)})
+			return
+		}
+
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil || value == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be a non-empty string", key)})
+			return
+		}
+		*field = &value
+	}
+
+	if !h.applyProfileChanges(c, userContext.UserID, changes) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Profile updated successfully",
+	})
+}
+
+// profileChanges holds the profile fields to update; nil fields are left unchanged
+type profileChanges struct {
+	Username *string
+	Email    *string
+	Password *string
+}
+
+// applyProfileChanges checks uniqueness, hashes the password and saves the
+// changes. It writes the error response and returns false on failure.
+func (h *Handler) applyProfileChanges(c *gin.Context, userID string, changes profileChanges) bool {
+	update := map[string]interface{}{
+		"updated_at": h.clock.Now(),
+	}
+
+	if changes.Username != nil {
 		// Check if username is already taken
-		existingUser := models.User{}
-		err := collection.FindOne(context.Background(), bson.M{
-			"username": req.Username,
-			"_id":      bson.M{"$ne": userID},
-		}).Decode(&existingUser)
-		if err == nil {
+		if taken, _ := h.users.ExistsOtherWithUsername(c.Request.Context(), *changes.Username, userID); taken {
 			c.JSON(http.StatusConflict, gin.H{"error": "Username already taken"})
-			return
+			return false
 		}
-		update["$set"].(bson.M)["username"] = req.Username
+		update["username"] = *changes.Username
 	}
 
-	if req.Email != "" {
+	if changes.Email != nil {
+		email := models.NormalizeEmail(*changes.Email)
+		if !validEmail(email) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Email is not a valid email address"})
+			return false
+		}
 		// Check if email is already taken
-		existingUser := models.User{}
-		err := collection.FindOne(context.Background(), bson.M{
-			"email": req.Email,
-			"_id":   bson.M{"$ne": userID},
-		}).Decode(&existingUser)
-		if err == nil {
+		if taken, _ := h.users.ExistsOtherWithEmail(c.Request.Context(), email, userID); taken {
 			c.JSON(http.StatusConflict, gin.H{"error": "Email already taken"})
-			return
+			return false
 		}
-		update["$set"].(bson.M)["email"] = req.Email
+		update["email"] = email
 	}
 
-	if req.Password != "" {
-		user := models.User{Password: req.Password}
+	if changes.Password != nil {
+		if !h.checkPassword(c, *changes.Password) {
+			return false
+		}
+		user := models.User{Password: *changes.Password}
 		if err := user.HashPassword(); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-			return
+			return false
 		}
-		update["$set"].(bson.M)["password"] = user.Password
+		update["password"] = user.Password
 	}
 
 	// Update user
-	_, err := collection.UpdateOne(context.Background(), bson.M{"_id": userID}, update)
-	if err != nil {
+	if err := h.users.UpdateFields(c.Request.Context(), userID, update); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
-		return
+		return false
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Profile updated successfully",
-	})
+	return true
 }
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-cms/internal/database/models"
+	"go-cms/internal/repository/repotest"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testPassword = "Correct-Horse-42"
+
+func authEngine(h *Handler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/register", h.Register)
+	r.POST("/login", h.Login)
+	r.POST("/refresh", h.RefreshToken)
+	return r
+}
+
+func postJSON(r http.Handler, path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRegisterSetsLocation(t *testing.T) {
+	r := authEngine(NewHandler(repotest.NewUsers(), testSecret))
+
+	w := postJSON(r, "/register", gin.H{"username": "alice", "email": "alice@example.com", "password": testPassword})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: status %d: %s", w.Code, w.Body.String())
+	}
+	if got, want := w.Header().Get("Location"), "http://example.com/api/v1/profile"; got != want {
+		t.Errorf("Location = %q, want %q", got, want)
+	}
+}
+
+func TestEmailsAreMatchedWithoutCase(t *testing.T) {
+	users := repotest.NewUsers()
+	r := authEngine(NewHandler(users, testSecret))
+
+	w := postJSON(r, "/register", gin.H{"username": "alice", "email": "Alice@Example.COM", "password": testPassword})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: status %d: %s", w.Code, w.Body.String())
+	}
+	if stored := users.All()[0].Email; stored != "alice@example.com" {
+		t.Errorf("stored email %q, want alice@example.com", stored)
+	}
+
+	for _, email := range []string{"alice@example.com", "ALICE@example.com", "Alice@Example.Com"} {
+		if w := postJSON(r, "/login", gin.H{"email": email, "password": testPassword}); w.Code != http.StatusOK {
+			t.Errorf("login as %s: status %d, want 200", email, w.Code)
+		}
+	}
+
+	// The same address in another case is the same account
+	w = postJSON(r, "/register", gin.H{"username": "alice2", "email": "ALICE@EXAMPLE.COM", "password": testPassword})
+	if w.Code != http.StatusConflict {
+		t.Errorf("second registration: status %d, want 409", w.Code)
+	}
+	if n := len(users.All()); n != 1 {
+		t.Errorf("%d users stored, want 1", n)
+	}
+}
+
+func TestPatchProfileLeavesAbsentFields(t *testing.T) {
+	users := repotest.NewUsers(
+		&models.User{Username: "alice", Email: "alice@example.com", Role: "user", IsActive: true},
+		&models.User{Username: "bob", Email: "bob@example.com", Role: "user", IsActive: true},
+	)
+	alice := users.All()[0]
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", alice.ID.Hex())
+		c.Set("username", alice.Username)
+		c.Set("email", alice.Email)
+		c.Set("role", alice.Role)
+	})
+	r.PATCH("/profile", NewHandler(users, testSecret).PatchProfile)
+
+	patchProfile := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/profile", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := patchProfile(`{"email": "Alice@New.Example"}`); w.Code != http.StatusOK {
+		t.Fatalf("patch email: status %d: %s", w.Code, w.Body)
+	}
+	updated := users.All()[0]
+	if updated.Email != "alice@new.example" || updated.Username != "alice" {
+		t.Errorf("after patch: username %q, email %q", updated.Username, updated.Email)
+	}
+
+	tests := map[string]struct {
+		body string
+		want int
+	}{
+		"null clears nothing":  {`{"username": null}`, http.StatusBadRequest},
+		"empty string":         {`{"username": ""}`, http.StatusBadRequest},
+		"unknown field":        {`{"role": "admin"}`, http.StatusBadRequest},
+		"taken username":       {`{"username": "bob"}`, http.StatusConflict},
+		"not an object":        {`["alice"]`, http.StatusBadRequest},
+		"wrong type for field": {`{"username": 7}`, http.StatusBadRequest},
+	}
+	for name, tt := range tests {
+		if w := patchProfile(tt.body); w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", name, w.Code, tt.want)
+		}
+	}
+	if after := users.All()[0]; after.Username != "alice" || after.Role != "user" {
+		t.Errorf("rejected patches changed the user: %+v", after)
+	}
+}
+
+// newUser returns an active user with testPassword hashed, ready to be stored
+func newUser(t *testing.T, username, role string) *models.User {
+	t.Helper()
+	user := &models.User{Username: username, Email: username + "@example.com", Password: testPassword, Role: role, IsActive: true}
+	if err := user.HashPassword(); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestLoginAgainstRepository(t *testing.T) {
+	users := repotest.NewUsers(newUser(t, "alice", "admin"))
+	h := NewHandler(users, testSecret)
+	r := authEngine(h)
+
+	w := postJSON(r, "/login", gin.H{"email": "alice@example.com", "password": testPassword})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: status %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		User   map[string]string `json:"user"`
+		Tokens TokenPair         `json:"tokens"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body.User["role"] != "admin" || body.Tokens.AccessToken == "" {
+		t.Errorf("login response = %s", w.Body)
+	}
+	if users.All()[0].LastLoginAt == nil {
+		t.Error("last login was not recorded")
+	}
+
+	// Failures look the same whether or not the account exists
+	for name, req := range map[string]gin.H{
+		"wrong password": {"email": "alice@example.com", "password": "Wrong-Horse-42"},
+		"unknown email":  {"email": "bob@example.com", "password": testPassword},
+	} {
+		w := postJSON(r, "/login", req)
+		if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "Invalid credentials") {
+			t.Errorf("%s: status %d, body %s", name, w.Code, w.Body)
+		}
+	}
+}
+
+func TestLoginOfDeactivatedUser(t *testing.T) {
+	user := newUser(t, "alice", "user")
+	user.IsActive = false
+	h := NewHandler(repotest.NewUsers(user), testSecret)
+	r := authEngine(h)
+
+	login := gin.H{"email": "alice@example.com", "password": testPassword}
+	if w := postJSON(r, "/login", login); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "Invalid credentials") {
+		t.Errorf("deactivated login: status %d, body %s", w.Code, w.Body)
+	}
+
+	h.SetExplicitLoginErrors(true)
+	if w := postJSON(r, "/login", login); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "Account is deactivated") {
+		t.Errorf("deactivated login with explicit errors: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestLoginFailuresLookAlike(t *testing.T) {
+	inactive := newUser(t, "bob", "user")
+	inactive.IsActive = false
+	r := authEngine(NewHandler(repotest.NewUsers(newUser(t, "alice", "user"), inactive), testSecret))
+
+	attempts := map[string]gin.H{
+		"unknown email":       {"email": "nobody@example.com", "password": testPassword},
+		"wrong password":      {"email": "alice@example.com", "password": "not-the-password"},
+		"deactivated account": {"email": "bob@example.com", "password": testPassword},
+	}
+	for name, login := range attempts {
+		w := postJSON(r, "/login", login)
+		if w.Code != http.StatusUnauthorized || w.Body.String() != `{"error":"Invalid credentials"}` {
+			t.Errorf("%s: status %d, body %s, want the generic 401", name, w.Code, w.Body)
+		}
+	}
+}
+
+func TestLoginOfUnknownEmailComparesAPassword(t *testing.T) {
+	r := authEngine(NewHandler(repotest.NewUsers(), testSecret))
+	postJSON(r, "/login", gin.H{"email": "nobody@example.com", "password": testPassword})
+
+	// The unknown email was checked against a real bcrypt hash, as an existing
+	// account would have been
+	if !strings.HasPrefix(dummyUser.Password, "$2") {
+		t.Errorf("dummy password = %q, want a bcrypt hash", dummyUser.Password)
+	}
+	if dummyUser.CheckPassword(testPassword) {
+		t.Error("dummy hash matches a real password")
+	}
+}
+
+func TestRegisterAgainstRepository(t *testing.T) {
+	users := repotest.NewUsers(newUser(t, "alice", "user"))
+	r := authEngine(NewHandler(users, testSecret))
+
+	if w := postJSON(r, "/register", gin.H{"username": "alice", "email": "other@example.com", "password": testPassword}); w.Code != http.StatusConflict {
+		t.Errorf("taken username: status %d, want 409", w.Code)
+	}
+
+	if w := postJSON(r, "/register", gin.H{"username": "bob", "email": "bob@example.com", "password": testPassword}); w.Code != http.StatusCreated {
+		t.Fatalf("register: status %d: %s", w.Code, w.Body)
+	}
+	bob := users.All()[1]
+	if bob.Role != "user" || !bob.IsActive || bob.Password == testPassword || !bob.CheckPassword(testPassword) {
+		t.Errorf("stored user = %+v, want an active user with a hashed password", bob)
+	}
+}
+
+func TestRefreshTokenChecksRepository(t *testing.T) {
+	users := repotest.NewUsers(newUser(t, "alice", "user"))
+	alice := users.All()[0]
+	r := authEngine(NewHandler(users, testSecret))
+
+	pair, err := GenerateTokenPair(alice.ID.Hex(), alice.Username, alice.Email, alice.Role, "", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := postJSON(r, "/refresh", gin.H{"refresh_token": pair.RefreshToken}); w.Code != http.StatusOK {
+		t.Fatalf("refresh: status %d: %s", w.Code, w.Body)
+	}
+
+	users.UpdateFields(context.Background(), alice.ID.Hex(), map[string]interface{}{"is_active": false})
+	if w := postJSON(r, "/refresh", gin.H{"refresh_token": pair.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("refresh of a deactivated user: status %d, want 401", w.Code)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"time"
 
+	"go-cms/internal/clock"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -12,6 +14,7 @@ type Claims struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Role     string `json:"role"`
+	Tenant   string `json:"tenant,omitempty"` // Tenant the user signed in to; empty in single-tenant mode
 	jwt.RegisteredClaims
 }
 
@@ -20,16 +23,25 @@ type TokenPair struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
-func GenerateTokenPair(userID, username, email, role, secret string) (*TokenPair, error) {
+// GenerateTokenPair issues an access and a refresh token for a user of the given
+// tenant, which is empty in single-tenant mode
+func GenerateTokenPair(userID, username, email, role, tenant, secret string) (*TokenPair, error) {
+	return generateTokenPair(clock.Real(), userID, username, email, role, tenant, secret)
+}
+
+func generateTokenPair(clk clock.Clock, userID, username, email, role, tenant, secret string) (*TokenPair, error) {
+	now := clk.Now()
+
 	// Access token (15 minutes)
 	accessClaims := Claims{
 		UserID:   userID,
 		Username: username,
 		Email:    email,
 		Role:     role,
+		Tenant:   tenant,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(15 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   userID,
 		},
 	}
@@ -43,9 +55,10 @@ func GenerateTokenPair(userID, username, email, role, secret string) (*TokenPair
 	// Refresh token (7 days)
 	refreshClaims := Claims{
 		UserID: userID,
+		Tenant: tenant,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(7 * 24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   userID,
 		},
 	}
@@ -63,9 +76,13 @@ func GenerateTokenPair(userID, username, email, role, secret string) (*TokenPair
 }
 
 func ValidateToken(tokenString, secret string) (*Claims, error) {
+	return validateToken(clock.Real(), tokenString, secret)
+}
+
+func validateToken(clk clock.Clock, tokenString, secret string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithTimeFunc(clk.Now))
 
 	if err != nil {
 		return nil, err
@@ -7,7 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// JWTMiddleware validates JWT tokens for protected routes
+// JWTMiddleware validates JWT tokens for protected routes. With multi-tenancy on
+// it must run after middleware.Tenant, and only accepts tokens issued for the
+// resolved tenant.
 func JWTMiddleware(secret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
@@ -42,6 +44,14 @@ func JWTMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
+		// Every tenant signs with the same secret, so a token is only good for
+		// the tenant it was issued by. The tenant middleware must run first.
+		if claims.Tenant != c.GetString("tenant") {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token was issued for another tenant"})
+			c.Abort()
+			return
+		}
+
 		// Set user info in context
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
@@ -53,6 +63,21 @@ func JWTMiddleware(secret string) gin.HandlerFunc {
 	}
 }
 
+// IsAdminRequest reports whether the request carries a valid bearer token for an
+// admin or super admin. Unlike JWTMiddleware it never aborts the request.
+func IsAdminRequest(c *gin.Context, secret string) bool {
+	tokenString, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || tokenString == "" {
+		return false
+	}
+
+	claims, err := ValidateToken(tokenString, secret)
+	if err != nil {
+		return false
+	}
+	return claims.Role == "admin" || claims.Role == "super_admin"
+}
+
 // AdminRequired middleware ensures user has admin role
 func AdminRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -80,6 +105,12 @@ func AdminRequired() gin.HandlerFunc {
 	}
 }
 
+// IsAdmin reports whether the authenticated user of the request is an admin or super admin
+func IsAdmin(c *gin.Context) bool {
+	role := c.GetString("role")
+	return role == "admin" || role == "super_admin"
+}
+
 func SuperAdminRequired() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
 		// Get user context
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-cms/internal/clock"
+	"go-cms/internal/repository/repotest"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testSecret = "test-secret"
+
+// tenantEngine mimics middleware.Tenant by pinning the resolved tenant
+func tenantEngine(tenant string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("tenant", tenant) })
+	r.GET("/me", JWTMiddleware(testSecret), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_id"))
+	})
+	return r
+}
+
+func getWithToken(r http.Handler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestJWTMiddlewareRejectsOtherTenant(t *testing.T) {
+	pair, err := GenerateTokenPair("u1", "alice", "alice@example.com", "admin", "acme", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w := getWithToken(tenantEngine("acme"), pair.AccessToken); w.Code != http.StatusOK {
+		t.Fatalf("same tenant: status %d, want 200", w.Code)
+	}
+	if w := getWithToken(tenantEngine("globex"), pair.AccessToken); w.Code != http.StatusUnauthorized {
+		t.Fatalf("other tenant: status %d, want 401", w.Code)
+	}
+	if w := getWithToken(tenantEngine(""), pair.AccessToken); w.Code != http.StatusUnauthorized {
+		t.Fatalf("no tenant: status %d, want 401", w.Code)
+	}
+}
+
+func TestJWTMiddlewareSingleTenant(t *testing.T) {
+	pair, err := GenerateTokenPair("u1", "alice", "alice@example.com", "user", "", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := getWithToken(tenantEngine(""), pair.AccessToken); w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+}
+
+func TestRefreshTokenCarriesTenant(t *testing.T) {
+	pair, err := GenerateTokenPair("u1", "alice", "alice@example.com", "user", "acme", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateToken(pair.RefreshToken, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Tenant != "acme" {
+		t.Fatalf("refresh token tenant %q, want acme", claims.Tenant)
+	}
+}
+
+func TestTokenExpiryFollowsClock(t *testing.T) {
+	issued := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(issued)
+	pair, err := generateTokenPair(clk, "u1", "alice", "alice@example.com", "user", "", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		at    time.Duration
+		valid bool
+	}{
+		{"access token before expiry", pair.AccessToken, 14 * time.Minute, true},
+		{"access token after expiry", pair.AccessToken, 15*time.Minute + time.Second, false},
+		{"refresh token before expiry", pair.RefreshToken, 7*24*time.Hour - time.Second, true},
+		{"refresh token after expiry", pair.RefreshToken, 7*24*time.Hour + time.Second, false},
+	}
+	for _, tt := range tests {
+		clk.Set(issued.Add(tt.at))
+		if _, err := validateToken(clk, tt.token, testSecret); (err == nil) != tt.valid {
+			t.Errorf("%s: err = %v, want valid=%v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestLoginUsesHandlerClock(t *testing.T) {
+	users := repotest.NewUsers(newUser(t, "alice", "user"))
+	h := NewHandler(users, testSecret)
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	h.SetClock(clock.NewFake(now))
+
+	w := postJSON(authEngine(h), "/login", gin.H{"email": "alice@example.com", "password": testPassword})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: status %d: %s", w.Code, w.Body)
+	}
+
+	if last := users.All()[0].LastLoginAt; last == nil || !last.Equal(now) {
+		t.Errorf("last login = %v, want the clock's %s", last, now)
+	}
+
+	// The access token was issued by the fake clock, so it has long expired
+	var body struct {
+		Tokens TokenPair `json:"tokens"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if _, err := validateToken(clock.Real(), body.Tokens.AccessToken, testSecret); err == nil {
+		t.Error("token issued in the past by the fake clock is still valid now")
+	}
+}
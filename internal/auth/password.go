@@ -0,0 +1,111 @@
+package auth
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordPolicy lists the requirements a new password must meet
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	BlockCommon   bool `json:"block_common"`
+}
+
+// DefaultPasswordPolicy applies when no policy has been configured
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:   8,
+	BlockCommon: true,
+}
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// commonPasswords is the lowercased denylist checked when BlockCommon is set
+var commonPasswords = func() map[string]bool {
+	passwords := make(map[string]bool)
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			passwords[strings.ToLower(line)] = true
+		}
+	}
+	return passwords
+}()
+
+// ValidatePassword returns a description of each requirement of policy that pw
+// does not meet, or nil when it is acceptable
+func ValidatePassword(pw string, policy PasswordPolicy) []string {
+	var unmet []string
+
+	if utf8.RuneCountInString(pw) < policy.MinLength {
+		unmet = append(unmet, fmt.Sprintf("must be at least %d characters", policy.MinLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if policy.RequireUpper && !hasUpper {
+		unmet = append(unmet, "must contain an uppercase letter")
+	}
+	if policy.RequireLower && !hasLower {
+		unmet = append(unmet, "must contain a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		unmet = append(unmet, "must contain a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		unmet = append(unmet, "must contain a symbol")
+	}
+	if policy.BlockCommon && commonPasswords[strings.ToLower(pw)] {
+		unmet = append(unmet, "is too common")
+	}
+
+	return unmet
+}
+
+// SetPasswordPolicy sets the function reporting the password policy in effect.
+// Without it DefaultPasswordPolicy applies.
+func (h *Handler) SetPasswordPolicy(policy func() PasswordPolicy) {
+	h.passwordPolicy = policy
+}
+
+// checkPassword validates pw against the current policy. It writes the error
+// response and returns false when requirements are unmet.
+func (h *Handler) checkPassword(c *gin.Context, pw string) bool {
+	policy := DefaultPasswordPolicy
+	if h.passwordPolicy != nil {
+		policy = h.passwordPolicy()
+	}
+
+	unmet := ValidatePassword(pw, policy)
+	if len(unmet) == 0 {
+		return true
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":        "Password does not meet the password policy",
+		"fields":       map[string]string{"password": strings.Join(unmet, "; ")},
+		"requirements": unmet,
+	})
+	return false
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go-cms/internal/clock"
+	"go-cms/internal/repository/repotest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidatePassword(t *testing.T) {
+	strict := PasswordPolicy{MinLength: 10, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true, BlockCommon: true}
+	tests := []struct {
+		name     string
+		password string
+		policy   PasswordPolicy
+		want     []string
+	}{
+		{"meets everything", "Tr0ub4dor&3x", strict, nil},
+		{"too short", "Ab1!", strict, []string{"must be at least 10 characters"}},
+		{"length counts characters", "pässwörd", PasswordPolicy{MinLength: 8}, nil},
+		{"no uppercase", "tr0ub4dor&3x", strict, []string{"must contain an uppercase letter"}},
+		{"no lowercase", "TR0UB4DOR&3X", strict, []string{"must contain a lowercase letter"}},
+		{"no digit", "Troubador&xx", strict, []string{"must contain a digit"}},
+		{"no symbol", "Tr0ub4dor3xy", strict, []string{"must contain a symbol"}},
+		{"space counts as a symbol", "Tr0ub4dor 3x", strict, nil},
+		{"common", "password", DefaultPasswordPolicy, []string{"is too common"}},
+		{"common in any case", "PassWord", DefaultPasswordPolicy, []string{"is too common"}},
+		{"common allowed when not blocked", "password", PasswordPolicy{MinLength: 8}, nil},
+		{"several unmet", "abc", PasswordPolicy{MinLength: 8, RequireUpper: true, RequireDigit: true}, []string{
+			"must be at least 8 characters",
+			"must contain an uppercase letter",
+			"must contain a digit",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidatePassword(tt.password, tt.policy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidatePassword(%q) = %q, want %q", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommonPasswordList(t *testing.T) {
+	if len(commonPasswords) < 50 {
+		t.Errorf("denylist has %d entries, want the embedded list loaded", len(commonPasswords))
+	}
+	if commonPasswords[""] {
+		t.Error("denylist contains an empty entry")
+	}
+}
+
+// passwordRejected checks that w is the password policy error listing want
+func passwordRejected(t *testing.T, w *httptest.ResponseRecorder, want []string) {
+	t.Helper()
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400: %s", w.Code, w.Body)
+	}
+	var response struct {
+		Requirements []string `json:"requirements"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || !reflect.DeepEqual(response.Requirements, want) {
+		t.Errorf("requirements = %q, want %q", response.Requirements, want)
+	}
+}
+
+func TestPasswordPolicyOnRegisterAndProfile(t *testing.T) {
+	users := repotest.NewUsers(newUser(t, "alice", "user"))
+	h := NewHandler(users, testSecret)
+	policy := PasswordPolicy{MinLength: 12, RequireDigit: true}
+	h.SetPasswordPolicy(func() PasswordPolicy { return policy })
+
+	r := authEngine(h)
+	protected := r.Group("/", Authenticate(testSecret, repotest.NewAPITokens(), users, clock.Real()))
+	protected.PUT("/profile", h.UpdateProfile)
+	protected.PATCH("/profile", h.PatchProfile)
+
+	w := postJSON(r, "/register", gin.H{"username": "bob", "email": "bob@example.com", "password": "short"})
+	passwordRejected(t, w, []string{"must be at least 12 characters", "must contain a digit"})
+	if len(users.All()) != 1 {
+		t.Error("a user was registered with a rejected password")
+	}
+	if w := postJSON(r, "/register", gin.H{"username": "bob", "email": "bob@example.com", "password": "long-enough-1"}); w.Code != http.StatusCreated {
+		t.Errorf("register with a valid password: status %d: %s", w.Code, w.Body)
+	}
+
+	session := sessionFor(t, users.All()[0])
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		w := bearer(r, method, "/profile", session, gin.H{"password": "no-digits-here"})
+		passwordRejected(t, w, []string{"must contain a digit"})
+	}
+	if !users.All()[0].CheckPassword(testPassword) {
+		t.Error("a rejected password replaced the old one")
+	}
+
+	// The policy is read on every request, so changing the setting applies at once
+	policy = PasswordPolicy{MinLength: 4}
+	if w := bearer(r, http.MethodPatch, "/profile", session, gin.H{"password": "abcd"}); w.Code != http.StatusOK {
+		t.Errorf("password meeting the relaxed policy: status %d: %s", w.Code, w.Body)
+	}
+}
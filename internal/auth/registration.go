@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"go-cms/internal/database/models"
+	"go-cms/internal/repository"
+	"go-cms/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegistrationMode controls who may create an account through /register
+type RegistrationMode string
+
+const (
+	RegistrationOpen       RegistrationMode = "open"
+	RegistrationClosed     RegistrationMode = "closed"
+	RegistrationInviteOnly RegistrationMode = "invite_only"
+)
+
+// defaultInviteLifetime applies when an invite is created without expires_in_hours
+const defaultInviteLifetime = 7 * 24 * time.Hour
+
+// SetRegistration sets the function reporting the current registration mode and
+// the store invites are checked against. Without it registration is open.
+func (h *Handler) SetRegistration(mode func() RegistrationMode, invites repository.InviteRepository) {
+	h.registrationMode = mode
+	h.invites = invites
+}
+
+// currentRegistrationMode returns the registration mode in effect
+func (h *Handler) currentRegistrationMode() RegistrationMode {
+	if h.registrationMode == nil {
+		return RegistrationOpen
+	}
+	return h.registrationMode()
+}
+
+// hashInviteToken returns the stored form of an invite token
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateInvite generates a single-use registration invite. The token is only
+// returned here; the database keeps its hash.
+func (h *Handler) CreateInvite(c *gin.Context) {
+	var req struct {
+		Email          string `json:"email" binding:"omitempty,email"`
+		ExpiresInHours int    `json:"expires_in_hours" binding:"omitempty,min=1,max=8760"`
+	}
+	// The body is optional
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		validation.BindError(c, err)
+		return
+	}
+
+	if h.invites == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Invites are not available"})
+		return
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate invite token"})
+		return
+	}
+	token := hex.EncodeToString(raw)
+
+	lifetime := defaultInviteLifetime
+	if req.ExpiresInHours > 0 {
+		lifetime = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	now := h.clock.Now()
+	invite := models.Invite{
+		TokenHash: hashInviteToken(token),
+		Email:     models.NormalizeEmail(req.Email),
+		CreatedAt: now,
+		ExpiresAt: now.Add(lifetime),
+	}
+	if userContext, exists := GetUserFromContext(c); exists {
+		invite.CreatedBy = userContext.Username
+	}
+
+	if err := h.invites.Create(c.Request.Context(), &invite); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Invite created successfully",
+		"token":   token,
+		"invite":  invite,
+	})
+}
+
+// consumeInvite marks the request's invite as used and returns it so it can be
+// released if the account is not created. It writes the error response and
+// returns false when the token is missing or not valid for the email.
+func (h *Handler) consumeInvite(c *gin.Context, req models.UserRegistration) (*models.Invite, bool) {
+	if req.InviteToken == "" || h.invites == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "A valid invite token is required"})
+		return nil, false
+	}
+
+	invite, err := h.invites.Consume(c.Request.Context(), hashInviteToken(req.InviteToken), req.Email, h.clock.Now())
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusForbidden, gin.H{"error": "A valid invite token is required"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return invite, true
+}
+
+// releaseInvite makes an invite usable again after a failed registration
+func (h *Handler) releaseInvite(c *gin.Context, invite *models.Invite) {
+	if invite == nil {
+		return
+	}
+	if err := h.invites.Release(c.Request.Context(), invite.ID); err != nil {
+		log.Printf("[AUTH] Failed to release invite %s: %v", invite.ID.Hex(), err)
+	}
+}
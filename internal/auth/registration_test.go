@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-cms/internal/clock"
+	"go-cms/internal/repository/repotest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registrationEngine serves /register in the given mode and /invites for creating
+// invites, returning the engine and the stores behind it
+func registrationEngine(mode RegistrationMode) (*gin.Engine, *repotest.Users, *repotest.Invites, *clock.Fake) {
+	users := repotest.NewUsers()
+	invites := repotest.NewInvites()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	h := NewHandler(users, testSecret)
+	h.SetClock(clk)
+	h.SetRegistration(func() RegistrationMode { return mode }, invites)
+
+	r := authEngine(h)
+	r.POST("/invites", h.CreateInvite)
+	return r, users, invites, clk
+}
+
+// createInvite creates an invite and returns its token
+func createInvite(t *testing.T, r http.Handler, body gin.H) string {
+	t.Helper()
+	w := postJSON(r, "/invites", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create invite: status %d: %s", w.Code, w.Body)
+	}
+	var created struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || created.Token == "" {
+		t.Fatalf("create invite: body %s", w.Body)
+	}
+	return created.Token
+}
+
+func registration(username, token string) gin.H {
+	return gin.H{"username": username, "email": username + "@example.com", "password": testPassword, "invite_token": token}
+}
+
+func TestRegistrationModes(t *testing.T) {
+	r, users, _, _ := registrationEngine(RegistrationClosed)
+	if w := postJSON(r, "/register", registration("alice", "")); w.Code != http.StatusForbidden {
+		t.Errorf("closed: status %d, want 403", w.Code)
+	}
+	if len(users.All()) != 0 {
+		t.Error("closed registration created a user")
+	}
+
+	r, users, _, _ = registrationEngine(RegistrationOpen)
+	if w := postJSON(r, "/register", registration("alice", "")); w.Code != http.StatusCreated {
+		t.Errorf("open: status %d, want 201: %s", w.Code, w.Body)
+	}
+	if len(users.All()) != 1 {
+		t.Error("open registration did not create the user")
+	}
+
+	// Without SetRegistration registration is open
+	if w := postJSON(authEngine(NewHandler(repotest.NewUsers(), testSecret)), "/register", registration("alice", "")); w.Code != http.StatusCreated {
+		t.Errorf("default: status %d, want 201", w.Code)
+	}
+}
+
+func TestRegistrationInviteOnly(t *testing.T) {
+	r, users, invites, _ := registrationEngine(RegistrationInviteOnly)
+
+	for _, token := range []string{"", "not-an-invite"} {
+		if w := postJSON(r, "/register", registration("alice", token)); w.Code != http.StatusForbidden {
+			t.Errorf("invite %q: status %d, want 403", token, w.Code)
+		}
+	}
+
+	token := createInvite(t, r, gin.H{})
+	if stored := invites.All()[0]; stored.TokenHash == token || stored.TokenHash != hashInviteToken(token) {
+		t.Errorf("stored invite = %+v, want only the token's hash", stored)
+	}
+	if w := postJSON(r, "/register", registration("alice", token)); w.Code != http.StatusCreated {
+		t.Fatalf("with invite: status %d: %s", w.Code, w.Body)
+	}
+	if used := invites.All()[0]; used.UsedAt == nil || used.UsedBy != "alice@example.com" {
+		t.Errorf("used invite = %+v, want it marked used by alice", used)
+	}
+
+	// Invites are single-use
+	if w := postJSON(r, "/register", registration("bob", token)); w.Code != http.StatusForbidden {
+		t.Errorf("reused invite: status %d, want 403", w.Code)
+	}
+	if len(users.All()) != 1 {
+		t.Errorf("%d users registered, want 1", len(users.All()))
+	}
+}
+
+func TestRegistrationInviteRestrictions(t *testing.T) {
+	r, _, _, clk := registrationEngine(RegistrationInviteOnly)
+
+	// An invite for one address cannot be used by another
+	token := createInvite(t, r, gin.H{"email": "Carol@Example.com"})
+	if w := postJSON(r, "/register", registration("dave", token)); w.Code != http.StatusForbidden {
+		t.Errorf("other address: status %d, want 403", w.Code)
+	}
+	if w := postJSON(r, "/register", registration("carol", token)); w.Code != http.StatusCreated {
+		t.Errorf("invited address: status %d, want 201: %s", w.Code, w.Body)
+	}
+
+	// A failed registration gives the invite back
+	token = createInvite(t, r, gin.H{})
+	taken := registration("carol", token)
+	taken["email"] = "carol2@example.com"
+	if w := postJSON(r, "/register", taken); w.Code != http.StatusConflict {
+		t.Fatalf("taken username: status %d, want 409", w.Code)
+	}
+	if w := postJSON(r, "/register", registration("erin", token)); w.Code != http.StatusCreated {
+		t.Errorf("invite after a failed registration: status %d, want 201: %s", w.Code, w.Body)
+	}
+
+	// Invites expire
+	token = createInvite(t, r, gin.H{"expires_in_hours": 1})
+	clk.Advance(time.Hour)
+	if w := postJSON(r, "/register", registration("frank", token)); w.Code != http.StatusForbidden {
+		t.Errorf("expired invite: status %d, want 403", w.Code)
+	}
+}
@@ -0,0 +1,33 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	clk := NewFake(start)
+	if !clk.Now().Equal(start) {
+		t.Fatalf("Now = %s, want %s", clk.Now(), start)
+	}
+
+	clk.Advance(90 * time.Minute)
+	if want := start.Add(90 * time.Minute); !clk.Now().Equal(want) {
+		t.Errorf("after Advance: Now = %s, want %s", clk.Now(), want)
+	}
+
+	later := time.Date(2027, 6, 1, 0, 0, 0, 0, time.UTC)
+	clk.Set(later)
+	if !clk.Now().Equal(later) {
+		t.Errorf("after Set: Now = %s, want %s", clk.Now(), later)
+	}
+}
+
+func TestReal(t *testing.T) {
+	before := time.Now()
+	now := Real().Now()
+	if now.Before(before) || now.After(time.Now()) {
+		t.Errorf("Real().Now() = %s, not the current time", now)
+	}
+}
@@ -3,9 +3,14 @@ package config
 import (
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"go-cms/internal/database"
 )
 
 type Config struct {
@@ -14,48 +19,186 @@ type Config struct {
 	Environment string `json:"environment"`
 	Version     string `json:"version"`
 
+	// ShutdownTimeout bounds how long shutdown waits for HTTP requests and plugin builds
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
+
 	// Database settings
 	MongoURI     string `json:"mongo_uri"`
 	DatabaseName string `json:"database_name"`
 
+	// Default consistency for replica sets. Empty values keep the driver default
+	// (primary reads, server default read concern, w:1) or what MONGO_URI sets.
+	// Migrations and credential lookups always use primary/majority.
+	ReadPreference string `json:"read_preference"` // primary, primaryPreferred, secondary, secondaryPreferred, nearest
+	ReadConcern    string `json:"read_concern"`    // local, available, majority, linearizable, snapshot
+	WriteConcern   string `json:"write_concern"`   // majority or a node count
+
+	// Multi-tenancy is enabled when TenantsFile is set. The tenant is taken from
+	// TenantHeader, falling back to the request host.
+	TenantsFile  string `json:"tenants_file"`
+	TenantHeader string `json:"tenant_header"`
+
+	// MigrationTimeout bounds each startup migration step so an unreachable database fails fast
+	MigrationTimeout time.Duration `json:"migration_timeout"`
+
 	// Security settings
-	JWTSecret string `json:"jwt_secret"`
+	JWTSecret          string `json:"jwt_secret"`
+	JWTMinSecretLength int    `json:"jwt_min_secret_length"`
+
+	// Credentials of the super admin created on first start when no users exist
+	AdminEmail    string `json:"admin_email"`
+	AdminPassword string `json:"admin_password"`
+
+	// CORSAllowedOrigins lists the origins allowed to make credentialed requests; "*" allows any
+	CORSAllowedOrigins []string `json:"cors_allowed_origins"`
+
+	// CORSAdminOrigins overrides CORSAllowedOrigins for /api/v1/admin; empty uses it unchanged
+	CORSAdminOrigins []string `json:"cors_admin_origins"`
+
+	// CORSPluginOrigins overrides CORSAllowedOrigins for /api/v1/plugins so plugin
+	// widgets can be embedded on other sites. Requests from these origins are
+	// never sent credentials; empty uses CORSAllowedOrigins.
+	CORSPluginOrigins []string `json:"cors_plugin_origins"`
+
+	// TrustedProxies lists the reverse proxies, as IPs or CIDRs, whose
+	// X-Forwarded-* headers are believed; empty believes none
+	TrustedProxies []string `json:"trusted_proxies"`
+
+	// LoginErrorDetail reports deactivated accounts to users who know the password,
+	// at the cost of revealing that the account exists
+	LoginErrorDetail bool `json:"login_error_detail"`
+
+	// Registration defaults for the registration_enabled and registration_invite_only site settings
+	RegistrationOpen bool `json:"registration_open"`
+	InviteOnly       bool `json:"invite_only"`
 
 	// Upload settings
 	MaxUploadSize int64         `json:"max_upload_size"`
 	UploadTimeout time.Duration `json:"upload_timeout"`
 	TempDir       string        `json:"temp_dir"`
 
+	// UploadRateLimit caps plugin and theme uploads per user per minute; 0 disables it
+	UploadRateLimit int `json:"upload_rate_limit"`
+
+	// Request settings
+	MaxRequestBodySize int64 `json:"max_request_body_size"` // Limit for non-upload request bodies
+
+	// IdempotencyKeyTTL is how long responses to admin requests sent with an
+	// Idempotency-Key header are kept for replay
+	IdempotencyKeyTTL time.Duration `json:"idempotency_key_ttl"`
+
+	// Content settings
+	MaxContentSize int64 `json:"max_content_size"` // Default for the max_content_size site setting
+
+	// Static file cache max-age per mount
+	AdminCacheMaxAge   time.Duration `json:"admin_cache_max_age"`
+	ThemeCacheMaxAge   time.Duration `json:"theme_cache_max_age"`
+	UploadsCacheMaxAge time.Duration `json:"uploads_cache_max_age"`
+	PluginAssetsMaxAge time.Duration `json:"plugin_assets_max_age"` // Files under a plugin's assets/ directory
+
+	// Dashboard settings
+	DashboardCacheTTL time.Duration `json:"dashboard_cache_ttl"`
+
 	// Logging settings
 	LogLevel    string `json:"log_level"`
 	EnableDebug bool   `json:"enable_debug"`
-	ThemePath   string
-	AdminPath   string
+
+	// Debug body logging records redacted request and response bodies for paths
+	// under DebugBodyLogPaths, keeping at most DebugBodyLogMaxBytes of each
+	DebugBodyLogging     bool     `json:"debug_body_logging"`
+	DebugBodyLogPaths    []string `json:"debug_body_log_paths"`
+	DebugBodyLogMaxBytes int      `json:"debug_body_log_max_bytes"`
+
+	// Directories of installed themes and of the admin interface's static files
+	ThemePath string `json:"theme_path"`
+	AdminPath string `json:"admin_path"`
 	// Plugin settings
 	PluginsDir      string `json:"plugins_dir"`
 	EnableHotReload bool   `json:"enable_hot_reload"`
+
+	// RequirePlugins stops startup when installed plugins cannot load on this platform
+	RequirePlugins bool `json:"require_plugins"`
+
+	// PluginBuildLimit caps simultaneous plugin compilations; 0 uses min(NumCPU, 2)
+	PluginBuildLimit int `json:"plugin_build_limit"`
+
+	// Build cache cleanup removes compiled plugins older than BuildCacheMaxAge,
+	// then the oldest ones until the cache fits in BuildCacheMaxSize (0 for no limit)
+	BuildCacheMaxAge  time.Duration `json:"build_cache_max_age"`
+	BuildCacheMaxSize int64         `json:"build_cache_max_size"`
+
+	// PluginCacheEntries bounds the in-memory cache shared by plugins
+	PluginCacheEntries int `json:"plugin_cache_entries"`
+
+	// RegistryURL is the theme and plugin registry checked for updates; empty disables checks
+	RegistryURL      string        `json:"registry_url"`
+	RegistryCacheTTL time.Duration `json:"registry_cache_ttl"`
+
+	// PluginInitTimeout bounds each plugin's Initialize; slower plugins are skipped
+	PluginInitTimeout time.Duration `json:"plugin_init_timeout"`
+
+	// PluginReloadCooldown is the least time between reloads of the same plugin,
+	// and between hot reloads; 0 disables it
+	PluginReloadCooldown time.Duration `json:"plugin_reload_cooldown"`
+
+	// PluginDrainTimeout bounds how long unloading a plugin waits for its
+	// in-flight requests before shutting it down
+	PluginDrainTimeout time.Duration `json:"plugin_drain_timeout"`
+
+	// PluginLogBufferSize is how many recent log entries are kept per plugin
+	PluginLogBufferSize int `json:"plugin_log_buffer_size"`
 }
 
 func Load() (*Config, error) {
-	config := &Config{
-		Port:            getEnv("PORT", "8080"),
-		Environment:     getEnv("ENVIRONMENT", "development"),
-		Version:         getEnv("VERSION", "1.0.0"),
-		MongoURI:        getEnv("MONGO_URI", "mongodb://localhost:27017"),
-		DatabaseName:    getEnv("DATABASE_NAME", "cms_db"),
-		JWTSecret:       getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-		MaxUploadSize:   getEnvInt64("MAX_UPLOAD_SIZE", 100<<20),
-		UploadTimeout:   getEnvDuration("UPLOAD_TIMEOUT", 5*time.Minute),
-		TempDir:         getEnv("TEMP_DIR", "./temp"),
-		LogLevel:        getEnv("LOG_LEVEL", "info"),
-		EnableDebug:     getEnvBool("ENABLE_DEBUG", true),
-		PluginsDir:      getEnv("PLUGINS_DIR", "./plugins"),
-		EnableHotReload: getEnvBool("ENABLE_HOT_RELOAD", true),
+	config := defaultConfig()
+	config.Environment = getEnv("ENVIRONMENT", config.Environment)
+
+	// Precedence: built-in defaults, then config.<environment>.json, then env vars
+	profile, err := applyProfile(config, getEnv("CONFIG_DIR", "."), config.Environment)
+	if err != nil {
+		return nil, err
+	}
+	applyEnv(config)
+	if profile != "" {
+		log.Printf("[CONFIG] Loaded %s profile from %s", config.Environment, profile)
+	} else {
+		log.Printf("[CONFIG] No profile file for %s, using defaults and environment variables", config.Environment)
 	}
 
 	// Validate critical settings
-	if config.JWTSecret == "your-secret-key-change-in-production" && config.Environment == "production" {
-		return nil, fmt.Errorf("JWT_SECRET must be set in production environment")
+	if config.Environment == "production" {
+		if err := config.checkProductionDefaults(); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.JWTMinSecretLength < minJWTSecretLength {
+		log.Printf("[CONFIG] JWT_MIN_SECRET_LENGTH=%d is below the floor of %d, using %d",
+			config.JWTMinSecretLength, minJWTSecretLength, minJWTSecretLength)
+		config.JWTMinSecretLength = minJWTSecretLength
+	}
+
+	if err := validateJWTSecret(config.JWTSecret, config.JWTMinSecretLength); err != nil {
+		if config.Environment == "production" {
+			return nil, fmt.Errorf("JWT_SECRET is too weak: %w", err)
+		}
+		log.Printf("[CONFIG] Warning: JWT_SECRET is too weak: %v", err)
+	}
+
+	// Report bad consistency settings at startup rather than on first connect
+	if err := config.Consistency().Validate(); err != nil {
+		return nil, fmt.Errorf("invalid MongoDB consistency settings: %w", err)
+	}
+
+	// A record that expires on creation would be deleted by the next retry
+	if config.IdempotencyKeyTTL <= 0 {
+		return nil, fmt.Errorf("IDEMPOTENCY_KEY_TTL must be positive, got %s", config.IdempotencyKeyTTL)
+	}
+
+	for _, proxy := range config.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES entry %q is neither an IP nor a CIDR", proxy)
+		}
 	}
 
 	// Create necessary directories
@@ -70,6 +213,207 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
+// defaultConfig returns the built-in configuration used before any profile or
+// environment variable is applied
+func defaultConfig() *Config {
+	return &Config{
+		Port:               "8080",
+		Environment:        "development",
+		Version:            "1.0.0",
+		ShutdownTimeout:    30 * time.Second,
+		MongoURI:           "mongodb://localhost:27017",
+		DatabaseName:       "cms_db",
+		ReadPreference:     "",
+		ReadConcern:        "",
+		WriteConcern:       "",
+		TenantsFile:        "",
+		TenantHeader:       "X-Tenant",
+		MigrationTimeout:   30 * time.Second,
+		JWTSecret:          defaultJWTSecret,
+		JWTMinSecretLength: defaultJWTSecretLength,
+		AdminEmail:         "admin@example.com",
+		AdminPassword:      defaultAdminPassword,
+		CORSAllowedOrigins: []string{
+			"http://localhost:3000",
+			"http://localhost:3001",
+			"http://localhost:8080",
+			"https://yourdomain.com",
+		},
+		LoginErrorDetail:     false,
+		RegistrationOpen:     true,
+		InviteOnly:           false,
+		MaxUploadSize:        100 << 20,
+		UploadTimeout:        5 * time.Minute,
+		TempDir:              "./temp",
+		UploadRateLimit:      10,
+		MaxRequestBodySize:   10 << 20,
+		IdempotencyKeyTTL:    24 * time.Hour,
+		MaxContentSize:       8 << 20,
+		AdminCacheMaxAge:     5 * time.Minute,
+		ThemeCacheMaxAge:     7 * 24 * time.Hour,
+		UploadsCacheMaxAge:   7 * 24 * time.Hour,
+		PluginAssetsMaxAge:   time.Hour,
+		DashboardCacheTTL:    10 * time.Second,
+		LogLevel:             "info",
+		EnableDebug:          true,
+		DebugBodyLogging:     false,
+		DebugBodyLogPaths:    []string{"/api/v1/plugins"},
+		DebugBodyLogMaxBytes: 4096,
+		ThemePath:            "./themes",
+		AdminPath:            "./web/admin",
+		PluginsDir:           "./plugins",
+		EnableHotReload:      true,
+		PluginBuildLimit:     0,
+		BuildCacheMaxAge:     7 * 24 * time.Hour,
+		BuildCacheMaxSize:    0,
+		PluginCacheEntries:   10000,
+		RegistryURL:          "",
+		RegistryCacheTTL:     time.Hour,
+		PluginInitTimeout:    30 * time.Second,
+		PluginReloadCooldown: 30 * time.Second,
+		PluginDrainTimeout:   10 * time.Second,
+		PluginLogBufferSize:  500,
+	}
+}
+
+// applyEnv overrides config with every environment variable that is set
+func applyEnv(c *Config) {
+	c.Port = getEnv("PORT", c.Port)
+	c.Environment = getEnv("ENVIRONMENT", c.Environment)
+	c.Version = getEnv("VERSION", c.Version)
+	c.ShutdownTimeout = getEnvDuration("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+	c.MongoURI = getEnv("MONGO_URI", c.MongoURI)
+	c.DatabaseName = getEnv("DATABASE_NAME", c.DatabaseName)
+	c.ReadPreference = getEnv("MONGO_READ_PREFERENCE", c.ReadPreference)
+	c.ReadConcern = getEnv("MONGO_READ_CONCERN", c.ReadConcern)
+	c.WriteConcern = getEnv("MONGO_WRITE_CONCERN", c.WriteConcern)
+	c.TenantsFile = getEnv("TENANTS_FILE", c.TenantsFile)
+	c.TenantHeader = getEnv("TENANT_HEADER", c.TenantHeader)
+	c.MigrationTimeout = getEnvDuration("MIGRATION_TIMEOUT", c.MigrationTimeout)
+	c.JWTSecret = getEnv("JWT_SECRET", c.JWTSecret)
+	c.JWTMinSecretLength = int(getEnvInt64("JWT_MIN_SECRET_LENGTH", int64(c.JWTMinSecretLength)))
+	c.AdminEmail = getEnv("ADMIN_EMAIL", c.AdminEmail)
+	c.AdminPassword = getEnv("ADMIN_PASSWORD", c.AdminPassword)
+	c.CORSAllowedOrigins = getEnvList("CORS_ALLOWED_ORIGINS", c.CORSAllowedOrigins)
+	c.CORSAdminOrigins = getEnvList("CORS_ADMIN_ORIGINS", c.CORSAdminOrigins)
+	c.CORSPluginOrigins = getEnvList("CORS_PLUGIN_ORIGINS", c.CORSPluginOrigins)
+	c.TrustedProxies = getEnvList("TRUSTED_PROXIES", c.TrustedProxies)
+	c.LoginErrorDetail = getEnvBool("LOGIN_ERROR_DETAIL", c.LoginErrorDetail)
+	c.RegistrationOpen = getEnvBool("REGISTRATION_ENABLED", c.RegistrationOpen)
+	c.InviteOnly = getEnvBool("REGISTRATION_INVITE_ONLY", c.InviteOnly)
+	c.MaxUploadSize = getEnvInt64("MAX_UPLOAD_SIZE", c.MaxUploadSize)
+	c.UploadTimeout = getEnvDuration("UPLOAD_TIMEOUT", c.UploadTimeout)
+	c.TempDir = getEnv("TEMP_DIR", c.TempDir)
+	c.UploadRateLimit = int(getEnvInt64("UPLOAD_RATE_LIMIT", int64(c.UploadRateLimit)))
+	c.MaxRequestBodySize = getEnvInt64("MAX_REQUEST_BODY_SIZE", c.MaxRequestBodySize)
+	c.IdempotencyKeyTTL = getEnvDuration("IDEMPOTENCY_KEY_TTL", c.IdempotencyKeyTTL)
+	c.MaxContentSize = getEnvInt64("MAX_CONTENT_SIZE", c.MaxContentSize)
+	c.AdminCacheMaxAge = getEnvDuration("ADMIN_CACHE_MAX_AGE", c.AdminCacheMaxAge)
+	c.ThemeCacheMaxAge = getEnvDuration("THEME_CACHE_MAX_AGE", c.ThemeCacheMaxAge)
+	c.UploadsCacheMaxAge = getEnvDuration("UPLOADS_CACHE_MAX_AGE", c.UploadsCacheMaxAge)
+	c.PluginAssetsMaxAge = getEnvDuration("PLUGIN_ASSETS_MAX_AGE", c.PluginAssetsMaxAge)
+	c.DashboardCacheTTL = getEnvDuration("DASHBOARD_CACHE_TTL", c.DashboardCacheTTL)
+	c.LogLevel = getEnv("LOG_LEVEL", c.LogLevel)
+	c.EnableDebug = getEnvBool("ENABLE_DEBUG", c.EnableDebug)
+	c.DebugBodyLogging = getEnvBool("DEBUG_BODY_LOGGING", c.DebugBodyLogging)
+	c.DebugBodyLogPaths = getEnvList("DEBUG_BODY_LOG_PATHS", c.DebugBodyLogPaths)
+	c.DebugBodyLogMaxBytes = int(getEnvInt64("DEBUG_BODY_LOG_MAX_BYTES", int64(c.DebugBodyLogMaxBytes)))
+	c.ThemePath = getEnv("THEME_PATH", c.ThemePath)
+	c.AdminPath = getEnv("ADMIN_PATH", c.AdminPath)
+	c.PluginsDir = getEnv("PLUGINS_DIR", c.PluginsDir)
+	c.EnableHotReload = getEnvBool("ENABLE_HOT_RELOAD", c.EnableHotReload)
+	c.RequirePlugins = getEnvBool("REQUIRE_PLUGINS", c.RequirePlugins)
+	c.PluginBuildLimit = int(getEnvInt64("PLUGIN_BUILD_LIMIT", int64(c.PluginBuildLimit)))
+	c.BuildCacheMaxAge = getEnvDuration("BUILD_CACHE_MAX_AGE", c.BuildCacheMaxAge)
+	c.BuildCacheMaxSize = getEnvInt64("BUILD_CACHE_MAX_SIZE", c.BuildCacheMaxSize)
+	c.PluginCacheEntries = int(getEnvInt64("PLUGIN_CACHE_ENTRIES", int64(c.PluginCacheEntries)))
+	c.RegistryURL = getEnv("REGISTRY_URL", c.RegistryURL)
+	c.RegistryCacheTTL = getEnvDuration("REGISTRY_CACHE_TTL", c.RegistryCacheTTL)
+	c.PluginInitTimeout = getEnvDuration("PLUGIN_INIT_TIMEOUT", c.PluginInitTimeout)
+	c.PluginReloadCooldown = getEnvDuration("PLUGIN_RELOAD_COOLDOWN", c.PluginReloadCooldown)
+	c.PluginDrainTimeout = getEnvDuration("PLUGIN_DRAIN_TIMEOUT", c.PluginDrainTimeout)
+	c.PluginLogBufferSize = int(getEnvInt64("PLUGIN_LOG_BUFFER_SIZE", int64(c.PluginLogBufferSize)))
+}
+
+// checkProductionDefaults rejects insecure defaults that must not reach production
+func (c *Config) checkProductionDefaults() error {
+	if c.JWTSecret == defaultJWTSecret {
+		return fmt.Errorf("JWT_SECRET must be set in production environment")
+	}
+	if c.AdminPassword == defaultAdminPassword {
+		return fmt.Errorf("ADMIN_PASSWORD must be changed from the default in production environment")
+	}
+	for _, origin := range c.CORSAllowedOrigins {
+		// Origins are allowed with credentials, so a wildcard would let any site act as the user
+		if origin == "*" {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS must not contain \"*\" in production environment")
+		}
+	}
+	for _, origin := range c.CORSAdminOrigins {
+		if origin == "*" {
+			return fmt.Errorf("CORS_ADMIN_ORIGINS must not contain \"*\" in production environment")
+		}
+	}
+	// Redaction only masks known field names, so bodies may still carry personal data
+	if c.DebugBodyLogging {
+		return fmt.Errorf("DEBUG_BODY_LOGGING must not be enabled in production environment")
+	}
+	return nil
+}
+
+const (
+	// defaultJWTSecret and defaultAdminPassword are development placeholders
+	defaultJWTSecret     = "your-secret-key-change-in-production"
+	defaultAdminPassword = "admin123"
+	// minJWTSecretLength is the floor JWT_MIN_SECRET_LENGTH cannot be lowered below
+	minJWTSecretLength = 16
+	// defaultJWTSecretLength matches the HS256 key size
+	defaultJWTSecretLength = 32
+	// minJWTSecretUniqueChars rejects secrets like "aaaa...a" that pass the length check
+	minJWTSecretUniqueChars = 8
+)
+
+// validateJWTSecret checks that the secret is long and varied enough to sign tokens
+func validateJWTSecret(secret string, minLength int) error {
+	if len(secret) < minLength {
+		return fmt.Errorf("must be at least %d characters, got %d", minLength, len(secret))
+	}
+
+	unique := make(map[rune]struct{})
+	for _, char := range secret {
+		unique[char] = struct{}{}
+	}
+	if len(unique) < minJWTSecretUniqueChars {
+		return fmt.Errorf("must contain at least %d distinct characters, got %d", minJWTSecretUniqueChars, len(unique))
+	}
+
+	return nil
+}
+
+// Consistency returns the configured MongoDB read preference, read concern and
+// write concern
+func (c *Config) Consistency() database.Consistency {
+	return database.Consistency{
+		ReadPreference: c.ReadPreference,
+		ReadConcern:    c.ReadConcern,
+		WriteConcern:   c.WriteConcern,
+	}
+}
+
+// SlogLevel maps the configured log level onto a slog.Level, defaulting to info
+func (c *Config) SlogLevel() slog.Level {
+	switch strings.ToLower(c.LogLevel) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
 // Helper functions
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -96,6 +440,22 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvList reads a comma-separated list, ignoring empty entries
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if parsed, err := time.ParseDuration(value); err == nil {
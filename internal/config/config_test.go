@@ -0,0 +1,283 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolate points Load at scratch directories so tests do not read or create
+// files in the working tree
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("CONFIG_DIR", dir)
+	t.Setenv("TEMP_DIR", dir+"/temp")
+	t.Setenv("PLUGINS_DIR", dir+"/plugins")
+	t.Setenv("ENVIRONMENT", "development")
+	return dir
+}
+
+// writeProfile writes config.<environment>.json into dir
+func writeProfile(t *testing.T, dir, environment, data string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "config."+environment+".json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdempotencyKeyTTLDefault(t *testing.T) {
+	isolate(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.IdempotencyKeyTTL != 24*time.Hour {
+		t.Fatalf("IdempotencyKeyTTL = %s, want 24h", cfg.IdempotencyKeyTTL)
+	}
+}
+
+func TestMaxRequestBodySize(t *testing.T) {
+	isolate(t)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxRequestBodySize != 10<<20 {
+		t.Errorf("MaxRequestBodySize = %d, want the 10 MiB default", cfg.MaxRequestBodySize)
+	}
+
+	t.Setenv("MAX_REQUEST_BODY_SIZE", "65536")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxRequestBodySize != 65536 {
+		t.Errorf("MaxRequestBodySize = %d, want 65536 from the environment", cfg.MaxRequestBodySize)
+	}
+}
+
+func TestIdempotencyKeyTTLMustBePositive(t *testing.T) {
+	for _, value := range []string{"0s", "-1m"} {
+		t.Run(value, func(t *testing.T) {
+			isolate(t)
+			t.Setenv("IDEMPOTENCY_KEY_TTL", value)
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), "IDEMPOTENCY_KEY_TTL") {
+				t.Fatalf("Load error = %v, want an IDEMPOTENCY_KEY_TTL error", err)
+			}
+		})
+	}
+}
+
+func TestProfileMergePrecedence(t *testing.T) {
+	dir := isolate(t)
+	t.Setenv("ENVIRONMENT", "staging")
+	writeProfile(t, dir, "staging", `{
+		"port": "9000",
+		"log_level": "warn",
+		"upload_timeout": "2m",
+		"environment": "production"
+	}`)
+	t.Setenv("LOG_LEVEL", "error")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Built-in default, untouched by the profile or the environment
+	if cfg.DatabaseName != "cms_db" {
+		t.Errorf("DatabaseName = %q, want the default cms_db", cfg.DatabaseName)
+	}
+	// The profile overrides defaults
+	if cfg.Port != "9000" || cfg.UploadTimeout != 2*time.Minute {
+		t.Errorf("Port = %q, UploadTimeout = %s, want the profile's 9000 and 2m", cfg.Port, cfg.UploadTimeout)
+	}
+	// Environment variables override the profile
+	if cfg.LogLevel != "error" {
+		t.Errorf("LogLevel = %q, want error from the environment", cfg.LogLevel)
+	}
+	// The profile cannot switch the environment that selected it
+	if cfg.Environment != "staging" {
+		t.Errorf("Environment = %q, want staging", cfg.Environment)
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown key":       `{"prot": "9000"}`,
+		"wrong type":        `{"port": 9000}`,
+		"bad duration":      `{"upload_timeout": "soon"}`,
+		"not a JSON object": `["port"]`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := isolate(t)
+			writeProfile(t, dir, "development", data)
+			if _, err := Load(); err == nil || !strings.Contains(err.Error(), "config.development.json") {
+				t.Fatalf("Load error = %v, want an error naming the profile", err)
+			}
+		})
+	}
+}
+
+func TestProductionRejectsInsecureDefaults(t *testing.T) {
+	secure := func(t *testing.T) {
+		t.Setenv("ENVIRONMENT", "production")
+		t.Setenv("JWT_SECRET", "k3y-for-production-0123456789abcdef")
+		t.Setenv("ADMIN_PASSWORD", "a-much-better-password")
+		t.Setenv("CORS_ALLOWED_ORIGINS", "https://example.com")
+	}
+
+	isolate(t)
+	secure(t)
+	if _, err := Load(); err != nil {
+		t.Fatalf("secure production config rejected: %v", err)
+	}
+
+	tests := map[string][2]string{
+		"default JWT secret":     {"JWT_SECRET", defaultJWTSecret},
+		"default admin password": {"ADMIN_PASSWORD", defaultAdminPassword},
+		"wildcard CORS":          {"CORS_ALLOWED_ORIGINS", "*"},
+		"wildcard admin CORS":    {"CORS_ADMIN_ORIGINS", "https://example.com,*"},
+		"body logging":           {"DEBUG_BODY_LOGGING", "true"},
+	}
+	for name, env := range tests {
+		t.Run(name, func(t *testing.T) {
+			isolate(t)
+			secure(t)
+			t.Setenv(env[0], env[1])
+			if _, err := Load(); err == nil || !strings.Contains(err.Error(), env[0]) {
+				t.Fatalf("Load error = %v, want an error naming %s", err, env[0])
+			}
+		})
+	}
+
+	// A production profile is held to the same rules
+	dir := isolate(t)
+	secure(t)
+	t.Setenv("ADMIN_PASSWORD", "")
+	writeProfile(t, dir, "production", `{"admin_password": "admin123"}`)
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "ADMIN_PASSWORD") {
+		t.Fatalf("Load error = %v, want the profile's default admin password rejected", err)
+	}
+}
+
+func TestLoadRejectsInvalidConsistency(t *testing.T) {
+	for env, value := range map[string]string{
+		"MONGO_READ_PREFERENCE": "closest",
+		"MONGO_READ_CONCERN":    "strong",
+		"MONGO_WRITE_CONCERN":   "all",
+	} {
+		t.Run(env, func(t *testing.T) {
+			isolate(t)
+			t.Setenv(env, value)
+			if _, err := Load(); err == nil || !strings.Contains(err.Error(), value) {
+				t.Fatalf("Load error = %v, want %q rejected", err, value)
+			}
+		})
+	}
+}
+
+func TestThemePath(t *testing.T) {
+	isolate(t)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ThemePath != "./themes" {
+		t.Errorf("ThemePath = %q, want ./themes", cfg.ThemePath)
+	}
+
+	t.Setenv("THEME_PATH", "/srv/themes")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ThemePath != "/srv/themes" {
+		t.Errorf("ThemePath = %q, want /srv/themes from THEME_PATH", cfg.ThemePath)
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	isolate(t)
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.5,::1")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.TrustedProxies) != 3 {
+		t.Errorf("TrustedProxies = %v, want 3 entries", cfg.TrustedProxies)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,proxy.internal")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "proxy.internal") {
+		t.Fatalf("Load error = %v, want proxy.internal rejected", err)
+	}
+}
+
+func TestValidateJWTSecret(t *testing.T) {
+	varied := "abcdefghijklmnopqrstuvwxyz0123456789"
+	tests := []struct {
+		name      string
+		secret    string
+		minLength int
+		ok        bool
+	}{
+		{"one short of the minimum", varied[:31], 32, false},
+		{"exactly the minimum", varied[:32], 32, true},
+		{"longer than the minimum", varied, 32, true},
+		{"empty", "", 32, false},
+		{"too few distinct characters", strings.Repeat("abcdefg", 10), 32, false},
+		{"just enough distinct characters", strings.Repeat("abcdefgh", 4), 32, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateJWTSecret(tt.secret, tt.minLength); (err == nil) != tt.ok {
+				t.Errorf("validateJWTSecret(%q, %d) = %v, want ok=%v", tt.secret, tt.minLength, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestJWTSecretStrengthAtLoad(t *testing.T) {
+	production := func(t *testing.T, secret string) {
+		isolate(t)
+		t.Setenv("ENVIRONMENT", "production")
+		t.Setenv("ADMIN_PASSWORD", "a-much-better-password")
+		t.Setenv("CORS_ALLOWED_ORIGINS", "https://example.com")
+		t.Setenv("JWT_SECRET", secret)
+	}
+
+	// A weak secret stops a production server
+	production(t, "s3cr")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "JWT_SECRET is too weak") {
+		t.Fatalf("Load error = %v, want the weak secret rejected", err)
+	}
+
+	// The minimum can be raised, but not lowered below the floor
+	production(t, "abcdefghijklmnopqrstuvwxyz0123456789")
+	t.Setenv("JWT_MIN_SECRET_LENGTH", "40")
+	if _, err := Load(); err == nil {
+		t.Error("secret shorter than a raised minimum was accepted")
+	}
+	production(t, "abcdefghijklmno")
+	t.Setenv("JWT_MIN_SECRET_LENGTH", "4")
+	if _, err := Load(); err == nil {
+		t.Errorf("secret shorter than the floor of %d was accepted", minJWTSecretLength)
+	}
+	production(t, "abcdefghijklmnop")
+	t.Setenv("JWT_MIN_SECRET_LENGTH", "4")
+	if _, err := Load(); err != nil {
+		t.Errorf("secret at the floor rejected: %v", err)
+	}
+
+	// Elsewhere it is only a warning
+	isolate(t)
+	t.Setenv("JWT_SECRET", "s3cr")
+	if _, err := Load(); err != nil {
+		t.Errorf("weak secret stopped a development server: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// applyProfile overlays config.<environment>.json from dir onto c and returns the
+// path it read, or "" when the profile does not exist. Keys are the JSON names of
+// the Config fields; durations may be written as strings such as "30s".
+func applyProfile(c *Config, dir, environment string) (string, error) {
+	path := filepath.Join(dir, "config."+environment+".json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read config profile %s: %w", path, err)
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return "", fmt.Errorf("invalid config profile %s: %w", path, err)
+	}
+
+	fields := profileFields()
+	for key, raw := range values {
+		field, known := fields[key]
+		if !known {
+			return "", fmt.Errorf("invalid config profile %s: unknown key %q", path, key)
+		}
+		if err := decodeProfileValue(reflect.ValueOf(c).Elem().FieldByIndex(field.Index), raw); err != nil {
+			return "", fmt.Errorf("invalid config profile %s: %s: %w", path, key, err)
+		}
+	}
+
+	// The environment selects the profile; a profile cannot switch it
+	c.Environment = environment
+	return path, nil
+}
+
+// profileFields maps JSON names to the Config fields a profile may set
+func profileFields() map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+// decodeProfileValue decodes raw into field, accepting duration strings for
+// time.Duration fields in addition to nanosecond counts
+func decodeProfileValue(field reflect.Value, raw json.RawMessage) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		var text string
+		if err := json.Unmarshal(raw, &text); err == nil {
+			duration, err := time.ParseDuration(text)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(duration))
+			return nil
+		}
+	}
+	return json.Unmarshal(raw, field.Addr().Interface())
+}
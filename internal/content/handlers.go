@@ -0,0 +1,387 @@
+package content
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-cms/internal/auth"
+	"go-cms/internal/database/models"
+	"go-cms/internal/httputil"
+	"go-cms/internal/repository"
+	"go-cms/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxPageSize caps ?limit when listing content
+const maxPageSize = 100
+
+type Handler struct {
+	types repository.ContentTypeRepository
+	items repository.ContentRepository
+}
+
+func NewHandler(types repository.ContentTypeRepository, items repository.ContentRepository) *Handler {
+	return &Handler{
+		types: types,
+		items: items,
+	}
+}
+
+// ListTypes returns every registered content type
+func (h *Handler) ListTypes(c *gin.Context) {
+	contentTypes, err := h.types.List(c.Request.Context())
+	if err != nil {
+		log.Printf("[CONTENT] Failed to list content types: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list content types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"content_types": contentTypes})
+}
+
+// GetType returns a single content type and its field schema
+func (h *Handler) GetType(c *gin.Context) {
+	contentType, ok := h.findType(c, c.Param("name"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"content_type": contentType})
+}
+
+// CreateType registers a custom content type
+func (h *Handler) CreateType(c *gin.Context) {
+	var contentType models.ContentType
+	if err := c.ShouldBindJSON(&contentType); err != nil {
+		validation.BindError(c, err)
+		return
+	}
+	if problems := ValidateType(&contentType); problems != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid content type", "fields": problems})
+		return
+	}
+
+	now := time.Now()
+	contentType.ID = primitive.NilObjectID
+	contentType.BuiltIn = false
+	contentType.CreatedAt = now
+	contentType.UpdatedAt = now
+
+	if err := h.types.Create(c.Request.Context(), &contentType); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Content type " + contentType.Name + " already exists"})
+			return
+		}
+		log.Printf("[CONTENT] Failed to create content type %s: %v", contentType.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create content type"})
+		return
+	}
+
+	httputil.Created(c, "/api/v1/content-types/"+contentType.Name, gin.H{
+		"message":      "Content type created successfully",
+		"content_type": contentType,
+	})
+}
+
+// UpdateType replaces the label, description and field schema of a content
+// type. Existing content is not revalidated; it is checked against the new
+// schema the next time it is saved.
+func (h *Handler) UpdateType(c *gin.Context) {
+	existing, ok := h.findType(c, c.Param("name"))
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Label       string                `json:"label" binding:"required"`
+		Description string                `json:"description"`
+		Fields      []models.ContentField `json:"fields"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validation.BindError(c, err)
+		return
+	}
+
+	existing.Label = req.Label
+	existing.Description = req.Description
+	existing.Fields = req.Fields
+	existing.UpdatedAt = time.Now()
+	if problems := ValidateType(existing); problems != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid content type", "fields": problems})
+		return
+	}
+
+	if err := h.types.Update(c.Request.Context(), existing); err != nil {
+		log.Printf("[CONTENT] Failed to update content type %s: %v", existing.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update content type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Content type updated successfully",
+		"content_type": existing,
+	})
+}
+
+// DeleteType removes a custom content type that no content uses
+func (h *Handler) DeleteType(c *gin.Context) {
+	contentType, ok := h.findType(c, c.Param("name"))
+	if !ok {
+		return
+	}
+	if contentType.BuiltIn {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Built-in content types cannot be deleted"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	count, err := h.items.CountByType(ctx, contentType.Name)
+	if err != nil {
+		log.Printf("[CONTENT] Failed to count content of type %s: %v", contentType.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete content type"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Content type is in use",
+			"count": count,
+		})
+		return
+	}
+
+	if err := h.types.Delete(ctx, contentType.Name); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Printf("[CONTENT] Failed to delete content type %s: %v", contentType.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete content type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Content type deleted successfully"})
+}
+
+// List returns content newest first. ?type and ?status filter it; ?limit
+// (default 20) and ?offset page through it.
+func (h *Handler) List(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > maxPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxPageSize)})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+		return
+	}
+
+	filter := repository.ContentFilter{
+		Type:   c.Query("type"),
+		Status: c.Query("status"),
+		Limit:  int64(limit),
+		Offset: int64(offset),
+	}
+
+	// Drafts are only listed to editors; everyone else sees published content
+	if !auth.IsAdmin(c) {
+		if filter.Status != "" && filter.Status != StatusPublished {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only editors can list drafts"})
+			return
+		}
+		filter.Status = StatusPublished
+	}
+	if filter.Type != "" {
+		if _, ok := h.findType(c, filter.Type); !ok {
+			return
+		}
+	}
+
+	items, total, err := h.items.List(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("[CONTENT] Failed to list content: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list content"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"content": items,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// Get returns a single content item. Drafts are only shown to editors.
+func (h *Handler) Get(c *gin.Context) {
+	item, err := h.items.FindByID(c.Request.Context(), c.Param("id"))
+	if err == nil && item.Status != StatusPublished && !auth.IsAdmin(c) {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		h.itemError(c, err, "Failed to get content")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"content": item})
+}
+
+// contentRequest is the body of a content create or update
+type contentRequest struct {
+	Type   string                 `json:"type"`
+	Title  string                 `json:"title" binding:"required"`
+	Slug   string                 `json:"slug" binding:"required"`
+	Status string                 `json:"status"`
+	Body   string                 `json:"body"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// Create adds content of the requested type, defaulting to post, after checking
+// its custom fields against the type's schema
+func (h *Handler) Create(c *gin.Context) {
+	var req contentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validation.BindError(c, err)
+		return
+	}
+	if req.Type == "" {
+		req.Type = DefaultType
+	}
+
+	contentType, ok := h.findType(c, req.Type)
+	if !ok || !validateRequest(c, contentType, &req) {
+		return
+	}
+
+	now := time.Now()
+	item := models.Content{
+		Type:      contentType.Name,
+		Title:     req.Title,
+		Slug:      req.Slug,
+		Status:    req.Status,
+		Body:      req.Body,
+		Fields:    req.Fields,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if userContext, exists := auth.GetUserFromContext(c); exists {
+		item.AuthorID = userContext.UserID
+	}
+
+	if err := h.items.Create(c.Request.Context(), &item); err != nil {
+		h.itemError(c, err, "Failed to create content")
+		return
+	}
+
+	httputil.Created(c, "/api/v1/content/"+item.ID.Hex(), gin.H{
+		"message": "Content created successfully",
+		"content": item,
+	})
+}
+
+// Update replaces an existing content item. Its type cannot change; the fields
+// are checked against the type's current schema.
+func (h *Handler) Update(c *gin.Context) {
+	ctx := c.Request.Context()
+	item, err := h.items.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		h.itemError(c, err, "Failed to update content")
+		return
+	}
+
+	var req contentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validation.BindError(c, err)
+		return
+	}
+	if req.Type != "" && req.Type != item.Type {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The type of existing content cannot be changed"})
+		return
+	}
+
+	contentType, ok := h.findType(c, item.Type)
+	if !ok || !validateRequest(c, contentType, &req) {
+		return
+	}
+
+	item.Title = req.Title
+	item.Slug = req.Slug
+	item.Status = req.Status
+	item.Body = req.Body
+	item.Fields = req.Fields
+	item.UpdatedAt = time.Now()
+
+	if err := h.items.Update(ctx, item); err != nil {
+		h.itemError(c, err, "Failed to update content")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Content updated successfully",
+		"content": item,
+	})
+}
+
+// Delete removes a content item
+func (h *Handler) Delete(c *gin.Context) {
+	if err := h.items.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.itemError(c, err, "Failed to delete content")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Content deleted successfully"})
+}
+
+// findType loads a content type, responding 404 or 500 and returning false when
+// it cannot
+func (h *Handler) findType(c *gin.Context, name string) (*models.ContentType, bool) {
+	contentType, err := h.types.FindByName(c.Request.Context(), name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Content type " + name + " not found"})
+			return nil, false
+		}
+		log.Printf("[CONTENT] Failed to load content type %s: %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load content type"})
+		return nil, false
+	}
+	return contentType, true
+}
+
+// validateRequest defaults the status of a content request and checks it
+// against its type, responding 400 and returning false when it is invalid
+func validateRequest(c *gin.Context, contentType *models.ContentType, req *contentRequest) bool {
+	if req.Status == "" {
+		req.Status = StatusDraft
+	}
+
+	problems := ValidateFields(contentType, req.Fields)
+	if req.Status != StatusDraft && req.Status != StatusPublished {
+		if problems == nil {
+			problems = make(map[string]string)
+		}
+		problems["status"] = "must be draft or published"
+	}
+	if problems != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Validation failed",
+			"fields": problems,
+		})
+		return false
+	}
+	return true
+}
+
+// itemError responds to a content repository error
+func (h *Handler) itemError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content not found"})
+	case errors.Is(err, repository.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "Content with this slug already exists for its type"})
+	default:
+		log.Printf("[CONTENT] %s: %v", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,81 @@
+package content
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-cms/internal/database/models"
+	"go-cms/internal/repository/repotest"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// contentEngine serves the content routes to a user with the given role
+func contentEngine(h *Handler, role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("role", role) })
+	r.GET("/content", h.List)
+	r.GET("/content/:id", h.Get)
+	return r
+}
+
+func get(r http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestDraftsAreOnlyShownToEditors(t *testing.T) {
+	draft := models.Content{ID: primitive.NewObjectID(), Type: DefaultType, Slug: "wip", Status: StatusDraft}
+	items := repotest.NewContent(models.Content{Type: DefaultType, Slug: "live", Status: StatusPublished}, draft)
+	h := NewHandler(repotest.NewContentTypes(), items)
+
+	tests := []struct {
+		role, path string
+		status     int
+		total      int64
+	}{
+		{"user", "/content", http.StatusOK, 1},
+		{"user", "/content?status=published", http.StatusOK, 1},
+		{"user", "/content?status=draft", http.StatusForbidden, 0},
+		{"admin", "/content", http.StatusOK, 2},
+		{"super_admin", "/content?status=draft", http.StatusOK, 1},
+	}
+	for _, tt := range tests {
+		w := get(contentEngine(h, tt.role), tt.path)
+		if w.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.role, tt.path, w.Code, tt.status)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var body struct {
+			Content []models.Content `json:"content"`
+			Total   int64            `json:"total"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if body.Total != tt.total || int64(len(body.Content)) != tt.total {
+			t.Errorf("%s %s: %d items of %d, want %d", tt.role, tt.path, len(body.Content), body.Total, tt.total)
+		}
+		if tt.role == "user" {
+			for _, item := range body.Content {
+				if item.Status != StatusPublished {
+					t.Errorf("%s %s listed %s content", tt.role, tt.path, item.Status)
+				}
+			}
+		}
+	}
+
+	draftPath := "/content/" + draft.ID.Hex()
+	if w := get(contentEngine(h, "user"), draftPath); w.Code != http.StatusNotFound {
+		t.Errorf("user getting a draft: status %d, want 404", w.Code)
+	}
+	if w := get(contentEngine(h, "admin"), draftPath); w.Code != http.StatusOK {
+		t.Errorf("admin getting a draft: status %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,172 @@
+// Package content serves core content items and the content types that define
+// their custom fields.
+package content
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"go-cms/internal/database/models"
+)
+
+// DefaultType is the built-in content type used when content names no type
+const DefaultType = "post"
+
+// Content statuses
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+)
+
+// fieldTypes are the field types a content type may declare
+var fieldTypes = map[string]bool{
+	"text":     true,
+	"textarea": true,
+	"richtext": true,
+	"number":   true,
+	"boolean":  true,
+	"date":     true,
+	"url":      true,
+	"email":    true,
+	"select":   true,
+}
+
+var (
+	typeNamePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	fieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// ValidateType checks a content type definition and returns the problems found,
+// keyed by the offending field, or nil when it is valid
+func ValidateType(contentType *models.ContentType) map[string]string {
+	problems := make(map[string]string)
+
+	if !typeNamePattern.MatchString(contentType.Name) {
+		problems["name"] = "must contain only lowercase letters, numbers, and hyphens"
+	}
+	if contentType.Label == "" {
+		problems["label"] = "is required"
+	}
+
+	seen := make(map[string]bool, len(contentType.Fields))
+	for i, field := range contentType.Fields {
+		path := fmt.Sprintf("fields[%d]", i)
+		switch {
+		case !fieldKeyPattern.MatchString(field.Key):
+			problems[path+".key"] = "must start with a lowercase letter and contain only lowercase letters, numbers, and underscores"
+		case seen[field.Key]:
+			problems[path+".key"] = fmt.Sprintf("%s is declared more than once", field.Key)
+		}
+		seen[field.Key] = true
+
+		if !fieldTypes[field.Type] {
+			problems[path+".type"] = fmt.Sprintf("unknown field type %q", field.Type)
+		}
+		if field.Type == "select" && len(field.Options) == 0 {
+			problems[path+".options"] = "a select field needs at least one option"
+		}
+		if field.MaxLength < 0 {
+			problems[path+".max_length"] = "must not be negative"
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+// ValidateFields checks custom field values against a content type's schema and
+// returns the problems found, keyed by field, or nil when they are valid. Values
+// for fields the type does not declare are rejected.
+func ValidateFields(contentType *models.ContentType, values map[string]interface{}) map[string]string {
+	problems := make(map[string]string)
+
+	declared := make(map[string]bool, len(contentType.Fields))
+	for _, field := range contentType.Fields {
+		declared[field.Key] = true
+		if err := validateFieldValue(field, values[field.Key]); err != nil {
+			problems[field.Key] = err.Error()
+		}
+	}
+	for key := range values {
+		if !declared[key] {
+			problems[key] = fmt.Sprintf("is not a field of content type %s", contentType.Name)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+// validateFieldValue checks a single value decoded from JSON against its field
+func validateFieldValue(field models.ContentField, value interface{}) error {
+	if value == nil || value == "" {
+		if field.Required {
+			return fmt.Errorf("is required")
+		}
+		return nil
+	}
+
+	switch field.Type {
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("must be a number")
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("must be true or false")
+		}
+
+	case "select":
+		s, ok := value.(string)
+		if !ok || !containsString(field.Options, s) {
+			return fmt.Errorf("must be one of %v", field.Options)
+		}
+
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be text")
+		}
+		if field.MaxLength > 0 && utf8.RuneCountInString(s) > field.MaxLength {
+			return fmt.Errorf("must be at most %d characters", field.MaxLength)
+		}
+
+		switch field.Type {
+		case "date":
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				if _, err := time.Parse(time.DateOnly, s); err != nil {
+					return fmt.Errorf("must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+				}
+			}
+		case "url":
+			u, err := url.Parse(s)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("must be an http or https URL")
+			}
+		case "email":
+			if _, err := mail.ParseAddress(s); err != nil {
+				return fmt.Errorf("must be an email address")
+			}
+		}
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package content
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"go-cms/internal/database/models"
+)
+
+func TestValidateType(t *testing.T) {
+	valid := models.ContentType{
+		Name:  "product-page",
+		Label: "Product page",
+		Fields: []models.ContentField{
+			{Key: "price", Type: "number"},
+			{Key: "size", Type: "select", Options: []string{"s", "m"}},
+		},
+	}
+	if problems := ValidateType(&valid); problems != nil {
+		t.Errorf("valid type: %v", problems)
+	}
+
+	invalid := models.ContentType{
+		Name: "Product Page",
+		Fields: []models.ContentField{
+			{Key: "price", Type: "currency"},
+			{Key: "price", Type: "number"},
+			{Key: "Size", Type: "select"},
+			{Key: "notes", Type: "text", MaxLength: -1},
+		},
+	}
+	want := []string{"fields[0].type", "fields[1].key", "fields[2].key", "fields[2].options", "fields[3].max_length", "label", "name"}
+	problems := ValidateType(&invalid)
+	if got := sortedKeys(problems); !reflect.DeepEqual(got, want) {
+		t.Errorf("problems at %v, want %v: %v", got, want, problems)
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	contentType := &models.ContentType{
+		Name: "event",
+		Fields: []models.ContentField{
+			{Key: "title", Type: "text", Required: true, MaxLength: 5},
+			{Key: "seats", Type: "number"},
+			{Key: "free", Type: "boolean"},
+			{Key: "level", Type: "select", Options: []string{"beginner", "expert"}},
+			{Key: "starts", Type: "date"},
+			{Key: "link", Type: "url"},
+			{Key: "contact", Type: "email"},
+		},
+	}
+
+	valid := map[string]interface{}{
+		"title":   "Ünïcø", // Five characters, more bytes
+		"seats":   float64(40),
+		"free":    true,
+		"level":   "expert",
+		"starts":  "2026-03-01",
+		"link":    "https://example.com/event",
+		"contact": "host@example.com",
+	}
+	if problems := ValidateFields(contentType, valid); problems != nil {
+		t.Errorf("valid values: %v", problems)
+	}
+	if problems := ValidateFields(contentType, map[string]interface{}{"title": "Hi", "starts": "2026-03-01T10:00:00Z"}); problems != nil {
+		t.Errorf("optional fields left out: %v", problems)
+	}
+
+	tests := map[string]struct {
+		key   string
+		value interface{}
+	}{
+		"missing required": {"title", ""},
+		"too long":         {"title", "Sixsix"},
+		"text as number":   {"title", float64(1)},
+		"number as text":   {"seats", "40"},
+		"boolean as text":  {"free", "yes"},
+		"unknown option":   {"level", "novice"},
+		"bad date":         {"starts", "March 1st"},
+		"relative url":     {"link", "/event"},
+		"ftp url":          {"link", "ftp://example.com"},
+		"bad email":        {"contact", "host at example"},
+		"undeclared field": {"venue", "Hall"},
+	}
+	for name, tt := range tests {
+		values := map[string]interface{}{"title": "Hi", tt.key: tt.value}
+		problems := ValidateFields(contentType, values)
+		if _, found := problems[tt.key]; !found || len(problems) != 1 {
+			t.Errorf("%s: problems = %v, want one for %s", name, problems, tt.key)
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
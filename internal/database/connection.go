@@ -11,13 +11,23 @@ import (
 type DB struct {
 	Client   *mongo.Client
 	Database *mongo.Database
+
+	// Cached result of the transaction support check. It describes the
+	// deployment, so every handle on the same client shares it.
+	tx *txSupport
 }
 
-func Connect(mongoURL, dbName string) (*DB, error) {
+// Connect opens a client with the given default consistency. Invalid
+// consistency settings are reported before connecting.
+func Connect(mongoURL, dbName string, consistency Consistency) (*DB, error) {
+	clientOptions, err := consistency.apply(options.Client().ApplyURI(mongoURL))
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	clientOptions := options.Client().ApplyURI(mongoURL)
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, err
@@ -31,6 +41,7 @@ func Connect(mongoURL, dbName string) (*DB, error) {
 	return &DB{
 		Client:   client,
 		Database: client.Database(dbName),
+		tx:       &txSupport{},
 	}, nil
 }
 
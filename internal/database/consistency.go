@@ -0,0 +1,97 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Consistency holds the default read preference, read concern and write concern
+// of the client. Empty fields keep the driver defaults (primary, the server's
+// default read concern and w:1) or whatever MONGO_URI specifies.
+type Consistency struct {
+	ReadPreference string // primary, primaryPreferred, secondary, secondaryPreferred or nearest
+	ReadConcern    string // local, available, majority, linearizable or snapshot
+	WriteConcern   string // majority or a number of nodes, e.g. 1
+}
+
+var readConcernLevels = map[string]bool{
+	"local":        true,
+	"available":    true,
+	"majority":     true,
+	"linearizable": true,
+	"snapshot":     true,
+}
+
+// Validate reports the first invalid setting
+func (c Consistency) Validate() error {
+	_, err := c.apply(options.Client())
+	return err
+}
+
+// apply sets the configured concerns on opts
+func (c Consistency) apply(opts *options.ClientOptions) (*options.ClientOptions, error) {
+	if c.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(c.ReadPreference)
+		if err != nil {
+			return nil, fmt.Errorf("invalid read preference %q: use primary, primaryPreferred, secondary, secondaryPreferred or nearest", c.ReadPreference)
+		}
+		pref, err := readpref.New(mode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid read preference %q: %w", c.ReadPreference, err)
+		}
+		opts.SetReadPreference(pref)
+	}
+
+	if c.ReadConcern != "" {
+		level := strings.ToLower(c.ReadConcern)
+		if !readConcernLevels[level] {
+			return nil, fmt.Errorf("invalid read concern %q: use local, available, majority, linearizable or snapshot", c.ReadConcern)
+		}
+		opts.SetReadConcern(&readconcern.ReadConcern{Level: level})
+	}
+
+	if c.WriteConcern != "" {
+		wc, err := parseWriteConcern(c.WriteConcern)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetWriteConcern(wc)
+	}
+
+	return opts, nil
+}
+
+// parseWriteConcern accepts "majority" or a non-negative node count
+func parseWriteConcern(value string) (*writeconcern.WriteConcern, error) {
+	if strings.EqualFold(value, "majority") {
+		return writeconcern.Majority(), nil
+	}
+	nodes, err := strconv.Atoi(value)
+	if err != nil || nodes < 0 {
+		return nil, fmt.Errorf("invalid write concern %q: use majority or a number of nodes", value)
+	}
+	return &writeconcern.WriteConcern{W: nodes}, nil
+}
+
+// Critical returns a handle on the same database for operations that must not
+// read stale data or lose acknowledged writes, such as migrations and
+// credential checks. It reads from the primary with majority read concern and
+// writes with majority write concern, regardless of the client defaults.
+func (db *DB) Critical() *DB {
+	opts := options.Database().
+		SetReadPreference(readpref.Primary()).
+		SetReadConcern(readconcern.Majority()).
+		SetWriteConcern(writeconcern.Majority())
+
+	return &DB{
+		Client:   db.Client,
+		Database: db.Client.Database(db.Database.Name(), opts),
+		tx:       db.tx,
+	}
+}
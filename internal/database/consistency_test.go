@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestConsistencyValidate(t *testing.T) {
+	valid := []Consistency{
+		{},
+		{ReadPreference: "secondaryPreferred", ReadConcern: "Majority", WriteConcern: "majority"},
+		{ReadPreference: "nearest", ReadConcern: "local", WriteConcern: "2"},
+	}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("%+v: %v", c, err)
+		}
+	}
+
+	invalid := []Consistency{
+		{ReadPreference: "closest"},
+		{ReadConcern: "strong"},
+		{WriteConcern: "all"},
+		{WriteConcern: "-1"},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%+v was accepted", c)
+		}
+	}
+}
+
+// unconnectedDB returns a handle on a client that never dials a server
+func unconnectedDB(t *testing.T) *DB {
+	t.Helper()
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+	return &DB{Client: client, Database: client.Database("cms_db"), tx: &txSupport{}}
+}
+
+func TestHandlesShareTransactionSupport(t *testing.T) {
+	db := unconnectedDB(t)
+	db.tx.checked, db.tx.supported = true, true
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tenants.json")
+	os.WriteFile(path, []byte(`{"tenants": {"site-a": {"database": "site_a"}}}`), 0644)
+	tenants, err := LoadTenantRegistry(db, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant, err := tenants.DB("site-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cached answer is used without asking the (unreachable) server again
+	for name, handle := range map[string]*DB{"critical": db.Critical(), "tenant": tenant, "tenant critical": tenant.Critical()} {
+		if handle.tx != db.tx {
+			t.Errorf("%s handle has its own transaction support state", name)
+		}
+		if !handle.supportsTransactions(context.Background()) {
+			t.Errorf("%s handle does not see the cached transaction support", name)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-cms/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// seed returns a migration that inserts a widget named after version on Up and
+// removes it on Down
+func seed(version string) Migration {
+	return Migration{
+		Version:     version,
+		Description: "seed " + version,
+		Up: func(ctx context.Context, db *database.DB) error {
+			_, err := db.Collection("widgets").InsertOne(ctx, bson.M{"name": version})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.DB) error {
+			_, err := db.Collection("widgets").DeleteOne(ctx, bson.M{"name": version})
+			return err
+		},
+	}
+}
+
+func TestApplyAlreadyApplied(t *testing.T) {
+	db := testDB(t)
+	m := testManager(db, seed("001_a"))
+
+	if _, err := m.Apply("001_a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Apply("001_a"); !errors.Is(err, ErrAlreadyApplied) {
+		t.Fatalf("second Apply error = %v, want ErrAlreadyApplied", err)
+	}
+	if n, _ := db.Collection("widgets").CountDocuments(context.Background(), bson.M{}); n != 1 {
+		t.Errorf("Up ran %d times, want once", n)
+	}
+	if _, err := m.Apply("999_missing"); !errors.Is(err, ErrUnknownMigration) {
+		t.Errorf("Apply of an unknown version: %v, want ErrUnknownMigration", err)
+	}
+}
+
+func TestApplyAndRollbackOutOfOrder(t *testing.T) {
+	db := testDB(t)
+	m := testManager(db, seed("001_a"), seed("002_b"), seed("003_c"))
+
+	result, err := m.Apply("003_c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "earlier migration 001_a is not applied|earlier migration 002_b is not applied"
+	if got := strings.Join(result.Warnings, "|"); got != want {
+		t.Errorf("Apply warnings %q, want %q", got, want)
+	}
+
+	if _, err := m.Apply("001_a"); err != nil {
+		t.Fatal(err)
+	}
+	result, err = m.Rollback("001_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(result.Warnings, "|"); got != "later migration 003_c is still applied" {
+		t.Errorf("Rollback warnings %q", got)
+	}
+	if _, err := m.Rollback("001_a"); !errors.Is(err, ErrNotApplied) {
+		t.Errorf("second Rollback error = %v, want ErrNotApplied", err)
+	}
+
+	applied, err := m.getAppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied["001_a"] || applied["002_b"] || !applied["003_c"] {
+		t.Errorf("applied = %v, want only 003_c", applied)
+	}
+}
+
+func TestRollbackIndexMigrationOutsideTransaction(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	if _, err := db.Collection("widgets").InsertOne(ctx, bson.M{"name": "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := testManager(db, Migration{
+		Version:     "001_widgets_indexes",
+		Description: "widget indexes",
+		Up: func(ctx context.Context, db *database.DB) error {
+			_, err := db.Collection("widgets").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "name", Value: 1}},
+			})
+			return err
+		},
+		// Dropping an index is not allowed inside a transaction
+		Down: func(ctx context.Context, db *database.DB) error {
+			_, err := db.Collection("widgets").Indexes().DropOne(ctx, "name_1")
+			return err
+		},
+		NoTransaction: true,
+	})
+	if _, err := m.Apply("001_widgets_indexes"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Rollback("001_widgets_indexes"); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+}
+
+func TestApplyIsRefusedWhileLocked(t *testing.T) {
+	db := testDB(t)
+	m := testManager(db, seed("001_a"))
+
+	release, err := m.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Apply("001_a"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Apply error = %v, want ErrLocked", err)
+	}
+	if _, err := m.Rollback("001_a"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Rollback error = %v, want ErrLocked", err)
+	}
+
+	release()
+	if _, err := m.Apply("001_a"); err != nil {
+		t.Fatalf("Apply after release: %v", err)
+	}
+}
+
+func TestExpiredLockIsTakenOver(t *testing.T) {
+	db := testDB(t)
+	m := testManager(db, seed("001_a"))
+
+	// A lock left by an instance that crashed mid-migration
+	_, err := db.Collection("_migration_lock").InsertOne(context.Background(), bson.M{
+		"_id":        migrationLockID,
+		"expires_at": time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Apply("001_a"); err != nil {
+		t.Fatalf("Apply with an expired lock: %v", err)
+	}
+}
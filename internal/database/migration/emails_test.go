@@ -0,0 +1,54 @@
+package migration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestEmailConflicts(t *testing.T) {
+	conflicts := emailConflicts(map[string][]string{
+		"bob@example.com":   {"b1", "b2"},
+		"alice@example.com": {"a1"},
+		"ann@example.com":   {"n1", "n2", "n3"},
+	})
+	want := []string{
+		"ann@example.com (users n1, n2, n3)",
+		"bob@example.com (users b1, b2)",
+	}
+	if strings.Join(conflicts, "|") != strings.Join(want, "|") {
+		t.Errorf("conflicts = %q, want %q", conflicts, want)
+	}
+}
+
+func TestNormalizeEmailsRefusesConflicts(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	users := db.Collection("users")
+	users.InsertMany(ctx, []interface{}{
+		bson.M{"username": "bob", "email": "Bob@Example.com"},
+		bson.M{"username": "bobby", "email": "bob@example.com"},
+		bson.M{"username": "alice", "email": " Alice@Example.com"},
+	})
+
+	err := migration010Up(ctx, db)
+	if err == nil || !strings.Contains(err.Error(), "bob@example.com (users ") {
+		t.Fatalf("err = %v, want the conflicting address listed", err)
+	}
+	// Nothing is changed until the conflict is resolved
+	if n, _ := users.CountDocuments(ctx, bson.M{"email": " Alice@Example.com"}); n != 1 {
+		t.Error("emails were changed despite the conflict")
+	}
+
+	users.DeleteOne(ctx, bson.M{"username": "bobby"})
+	if err := migration010Up(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	for _, email := range []string{"bob@example.com", "alice@example.com"} {
+		if n, _ := users.CountDocuments(ctx, bson.M{"email": email}); n != 1 {
+			t.Errorf("no user with normalized email %s", email)
+		}
+	}
+}
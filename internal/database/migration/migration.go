@@ -3,9 +3,11 @@ package migration
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
+	"strings"
 	"time"
 
 	"go-cms/internal/database"
@@ -21,31 +23,72 @@ import (
 type Migration struct {
 	Version     string
 	Description string
-	Up          func(db *database.DB) error
-	Down        func(db *database.DB) error
+	Up          func(ctx context.Context, db *database.DB) error
+	Down        func(ctx context.Context, db *database.DB) error
+
+	// NoTransaction runs the migration outside a transaction. Set it on
+	// migrations that create or drop indexes, which MongoDB refuses inside a
+	// transaction once the collection exists.
+	NoTransaction bool
 }
 
 // MigrationRecord represents a migration record in the database
 type MigrationRecord struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty"`
-	Version     string             `bson:"version"`
-	Description string             `bson:"description"`
-	AppliedAt   time.Time          `bson:"applied_at"`
-	Success     bool               `bson:"success"`
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Version     string             `bson:"version" json:"version"`
+	Description string             `bson:"description" json:"description"`
+	AppliedAt   time.Time          `bson:"applied_at" json:"applied_at"`
+	Success     bool               `bson:"success" json:"success"`
+}
+
+// PendingMigration describes a migration known to this binary that has not been applied
+type PendingMigration struct {
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+// Status reports the recorded migrations alongside the ones still to run
+type Status struct {
+	Applied    []MigrationRecord  `json:"applied"`
+	Pending    []PendingMigration `json:"pending"`
+	HasPending bool               `json:"has_pending"`
+}
+
+// AdminAccount holds the credentials of the super admin the initial-data
+// migration creates when the users collection is empty
+type AdminAccount struct {
+	Email    string
+	Password string
 }
 
 // Manager handles database migrations
 type Manager struct {
 	db         *database.DB
 	migrations []Migration
+	timeout    time.Duration // Per-operation deadline
 }
 
-// NewManager creates a new migration manager
-func NewManager(db *database.DB) *Manager {
+// NewManager creates a new migration manager. Each migration step must finish
+// within timeout. admin is the account created by the initial-data migration.
+func NewManager(db *database.DB, timeout time.Duration, admin AdminAccount) *Manager {
 	return &Manager{
 		db:         db,
-		migrations: getMigrations(),
+		migrations: getMigrations(admin),
+		timeout:    timeout,
+	}
+}
+
+// withTimeout runs fn with a context bounded by the manager's timeout and turns
+// a missed deadline into a descriptive error
+func (m *Manager) withTimeout(operation string, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	err := fn(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s timed out after %s (is the database reachable?): %w", operation, m.timeout, err)
 	}
+	return err
 }
 
 // Run executes all pending migrations
@@ -88,45 +131,248 @@ func (m *Manager) Run() error {
 	return nil
 }
 
-// applyMigration applies a single migration
-func (m *Manager) applyMigration(migration Migration) error {
-	ctx := context.Background()
+// Status returns every recorded migration in version order and the known
+// migrations that have not been applied successfully
+func (m *Manager) Status() (*Status, error) {
+	collection := m.db.Collection("_migrations")
+	status := &Status{
+		Applied: []MigrationRecord{},
+		Pending: []PendingMigration{},
+	}
 
-	// Start transaction if possible (for replica sets)
-	session, err := m.db.Client.StartSession()
-	if err != nil {
-		// If sessions are not supported, continue without transaction
-		if err := migration.Up(m.db); err != nil {
+	err := m.withTimeout("loading migration records", func(ctx context.Context) error {
+		opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+		cursor, err := collection.Find(ctx, bson.M{}, opts)
+		if err != nil {
 			return err
 		}
-		return m.recordMigration(migration, true)
+		return cursor.All(ctx, &status.Applied)
+	})
+	if err != nil {
+		return nil, err
 	}
-	defer session.EndSession(ctx)
 
-	// Use transaction
-	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
-		if err := session.StartTransaction(); err != nil {
-			return err
+	applied := make(map[string]bool)
+	for _, record := range status.Applied {
+		if record.Success {
+			applied[record.Version] = true
 		}
+	}
 
-		if err := migration.Up(m.db); err != nil {
-			session.AbortTransaction(sc)
-			return err
+	known := append([]Migration(nil), m.migrations...)
+	sort.Slice(known, func(i, j int) bool {
+		return known[i].Version < known[j].Version
+	})
+	for _, migration := range known {
+		if !applied[migration.Version] {
+			status.Pending = append(status.Pending, PendingMigration{
+				Version:     migration.Version,
+				Description: migration.Description,
+			})
+		}
+	}
+	status.HasPending = len(status.Pending) > 0
+
+	return status, nil
+}
+
+// Errors returned by Apply and Rollback
+var (
+	ErrUnknownMigration = errors.New("unknown migration")
+	ErrAlreadyApplied   = errors.New("migration is already applied")
+	ErrNotApplied       = errors.New("migration is not applied")
+	ErrLocked           = errors.New("another migration is being applied or rolled back")
+)
+
+// Result reports a migration applied or rolled back on demand
+type Result struct {
+	Version     string   `json:"version"`
+	Description string   `json:"description"`
+	Warnings    []string `json:"warnings"`
+}
+
+// Apply runs the Up of a single migration and records it. Earlier migrations that
+// are still pending do not stop it but are reported as warnings, since the
+// migration may depend on them. It fails with ErrLocked while another Apply or
+// Rollback is running.
+func (m *Manager) Apply(version string) (*Result, error) {
+	release, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	migration, applied, err := m.lookup(version)
+	if err != nil {
+		return nil, err
+	}
+	if applied[version] {
+		return nil, ErrAlreadyApplied
+	}
+
+	result := &Result{Version: migration.Version, Description: migration.Description, Warnings: []string{}}
+	for _, other := range m.migrations {
+		if other.Version < version && !applied[other.Version] {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("earlier migration %s is not applied", other.Version))
+		}
+	}
+
+	log.Printf("Applying migration %s on demand: %s", migration.Version, migration.Description)
+	if err := m.applyMigration(migration); err != nil {
+		return nil, fmt.Errorf("failed to apply migration %s: %w", version, err)
+	}
+	return result, nil
+}
+
+// Rollback runs the Down of a single applied migration and removes its record.
+// Later migrations that are still applied are reported as warnings, since they
+// may depend on it. Like Apply, it fails with ErrLocked while another is running.
+func (m *Manager) Rollback(version string) (*Result, error) {
+	release, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	migration, applied, err := m.lookup(version)
+	if err != nil {
+		return nil, err
+	}
+	if !applied[version] {
+		return nil, ErrNotApplied
+	}
+	if migration.Down == nil {
+		return nil, fmt.Errorf("migration %s cannot be rolled back", version)
+	}
+
+	result := &Result{Version: migration.Version, Description: migration.Description, Warnings: []string{}}
+	for _, other := range m.migrations {
+		if other.Version > version && applied[other.Version] {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("later migration %s is still applied", other.Version))
+		}
+	}
+
+	log.Printf("Rolling back migration %s: %s", migration.Version, migration.Description)
+	if err := m.rollbackMigration(migration); err != nil {
+		return nil, fmt.Errorf("failed to roll back migration %s: %w", version, err)
+	}
+	return result, nil
+}
+
+// rollbackMigration runs a migration's Down and removes its record, inside a
+// transaction where supported unless the migration opts out
+func (m *Manager) rollbackMigration(migration Migration) error {
+	forget := func(ctx context.Context) error {
+		_, err := m.db.Collection("_migrations").DeleteOne(ctx, bson.M{"version": migration.Version})
+		return err
+	}
+	return m.withTimeout("rollback of migration "+migration.Version, func(ctx context.Context) error {
+		if migration.NoTransaction {
+			if err := migration.Down(ctx, m.db); err != nil {
+				return err
+			}
+			return forget(ctx)
 		}
+		return m.db.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+			if err := migration.Down(sc, m.db); err != nil {
+				return err
+			}
+			return forget(sc)
+		})
+	})
+}
+
+// migrationLockID is the _id of the document in _migration_lock held while a
+// migration is applied or rolled back on demand
+const migrationLockID = "migrations"
+
+// lock takes the migration lock so only one Apply or Rollback runs at a time,
+// across every instance sharing the database. A lock left behind by a crashed
+// instance expires after twice the manager's timeout. The returned function
+// releases the lock.
+func (m *Manager) lock() (func(), error) {
+	collection := m.db.Collection("_migration_lock")
+	holder := primitive.NewObjectID()
+
+	err := m.withTimeout("taking the migration lock", func(ctx context.Context) error {
+		now := time.Now()
+		// Matches only an expired lock; with none, the upsert inserts a new one,
+		// which fails on the duplicate _id while another holder's lock is live
+		_, err := collection.UpdateOne(ctx,
+			bson.M{"_id": migrationLockID, "expires_at": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"holder": holder, "expires_at": now.Add(2 * m.timeout)}},
+			options.Update().SetUpsert(true),
+		)
+		return err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		if err := m.recordMigration(migration, true); err != nil {
-			session.AbortTransaction(sc)
+	return func() {
+		err := m.withTimeout("releasing the migration lock", func(ctx context.Context) error {
+			_, err := collection.DeleteOne(ctx, bson.M{"_id": migrationLockID, "holder": holder})
 			return err
+		})
+		if err != nil {
+			log.Printf("Failed to release the migration lock: %v", err)
 		}
+	}, nil
+}
 
-		return session.CommitTransaction(sc)
+// lookup returns the named migration and the set of applied versions, with the
+// known migrations sorted by version
+func (m *Manager) lookup(version string) (Migration, map[string]bool, error) {
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
 	})
 
-	return err
+	var migration Migration
+	found := false
+	for _, candidate := range m.migrations {
+		if candidate.Version == version {
+			migration, found = candidate, true
+			break
+		}
+	}
+	if !found {
+		return Migration{}, nil, ErrUnknownMigration
+	}
+
+	if err := m.ensureMigrationsCollection(); err != nil {
+		return Migration{}, nil, fmt.Errorf("failed to ensure migrations collection: %w", err)
+	}
+	applied, err := m.getAppliedMigrations()
+	if err != nil {
+		return Migration{}, nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	return migration, applied, nil
+}
+
+// applyMigration applies a single migration, inside a transaction where supported
+// unless the migration opts out
+func (m *Manager) applyMigration(migration Migration) error {
+	return m.withTimeout("migration "+migration.Version, func(ctx context.Context) error {
+		if migration.NoTransaction {
+			if err := migration.Up(ctx, m.db); err != nil {
+				return err
+			}
+			return m.recordMigration(ctx, migration, true)
+		}
+		return m.db.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+			if err := migration.Up(sc, m.db); err != nil {
+				return err
+			}
+			return m.recordMigration(sc, migration, true)
+		})
+	})
 }
 
 // recordMigration records the migration in the database
-func (m *Manager) recordMigration(migration Migration, success bool) error {
+func (m *Manager) recordMigration(ctx context.Context, migration Migration, success bool) error {
 	collection := m.db.Collection("_migrations")
 
 	record := MigrationRecord{
@@ -136,30 +382,36 @@ func (m *Manager) recordMigration(migration Migration, success bool) error {
 		Success:     success,
 	}
 
-	_, err := collection.InsertOne(context.Background(), record)
+	_, err := collection.InsertOne(ctx, record)
 	return err
 }
 
 // getAppliedMigrations returns a map of applied migration versions
 func (m *Manager) getAppliedMigrations() (map[string]bool, error) {
 	collection := m.db.Collection("_migrations")
+	applied := make(map[string]bool)
 
-	cursor, err := collection.Find(context.Background(), bson.M{"success": true})
+	err := m.withTimeout("loading applied migrations", func(ctx context.Context) error {
+		cursor, err := collection.Find(ctx, bson.M{"success": true})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var record MigrationRecord
+			if err := cursor.Decode(&record); err != nil {
+				return err
+			}
+			applied[record.Version] = true
+		}
+		return cursor.Err()
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
 
-	applied := make(map[string]bool)
-	for cursor.Next(context.Background()) {
-		var record MigrationRecord
-		if err := cursor.Decode(&record); err != nil {
-			return nil, err
-		}
-		applied[record.Version] = true
-	}
-
-	return applied, cursor.Err()
+	return applied, nil
 }
 
 // ensureMigrationsCollection ensures the migrations collection exists
@@ -172,7 +424,10 @@ func (m *Manager) ensureMigrationsCollection() error {
 		Options: options.Index().SetUnique(true),
 	}
 
-	_, err := collection.Indexes().CreateOne(context.Background(), indexModel)
+	err := m.withTimeout("creating migrations index", func(ctx context.Context) error {
+		_, err := collection.Indexes().CreateOne(ctx, indexModel)
+		return err
+	})
 	if err != nil {
 		// Ignore error if index already exists
 		if !mongo.IsDuplicateKeyError(err) {
@@ -184,43 +439,88 @@ func (m *Manager) ensureMigrationsCollection() error {
 }
 
 // getMigrations returns all available migrations
-func getMigrations() []Migration {
+func getMigrations(admin AdminAccount) []Migration {
 	return []Migration{
 		{
-			Version:     "001_initial_setup",
-			Description: "Create initial collections and indexes",
-			Up:          migration001Up,
-			Down:        migration001Down,
+			Version:       "001_initial_setup",
+			Description:   "Create initial collections and indexes",
+			Up:            migration001Up,
+			Down:          migration001Down,
+			NoTransaction: true,
 		},
 		{
-			Version:     "002_users_indexes",
-			Description: "Create user collection indexes",
-			Up:          migration002Up,
-			Down:        migration002Down,
+			Version:       "002_users_indexes",
+			Description:   "Create user collection indexes",
+			Up:            migration002Up,
+			Down:          migration002Down,
+			NoTransaction: true,
 		},
 		{
-			Version:     "003_plugins_indexes",
-			Description: "Create plugin collection indexes",
-			Up:          migration003Up,
-			Down:        migration003Down,
+			Version:       "003_plugins_indexes",
+			Description:   "Create plugin collection indexes",
+			Up:            migration003Up,
+			Down:          migration003Down,
+			NoTransaction: true,
 		},
 		{
-			Version:     "004_themes_indexes",
-			Description: "Create theme collection indexes",
-			Up:          migration004Up,
-			Down:        migration004Down,
+			Version:       "004_themes_indexes",
+			Description:   "Create theme collection indexes",
+			Up:            migration004Up,
+			Down:          migration004Down,
+			NoTransaction: true,
 		},
 		{
 			Version:     "005_initial_data",
 			Description: "Insert initial data",
-			Up:          migration005Up,
+			Up:          migration005Up(admin),
 			Down:        migration005Down,
 		},
+		{
+			Version:       "006_plugin_settings_history_indexes",
+			Description:   "Create plugin settings history indexes",
+			Up:            migration006Up,
+			Down:          migration006Down,
+			NoTransaction: true,
+		},
+		{
+			Version:       "007_invites_indexes",
+			Description:   "Create registration invite indexes",
+			Up:            migration007Up,
+			Down:          migration007Down,
+			NoTransaction: true,
+		},
+		{
+			Version:       "008_idempotency_keys_indexes",
+			Description:   "Create idempotency key indexes",
+			Up:            migration008Up,
+			Down:          migration008Down,
+			NoTransaction: true,
+		},
+		{
+			Version:       "009_content_types",
+			Description:   "Create content type and content indexes and the default post type",
+			Up:            migration009Up,
+			Down:          migration009Down,
+			NoTransaction: true,
+		},
+		{
+			Version:     "010_normalize_emails",
+			Description: "Lowercase and trim stored user and invite emails",
+			Up:          migration010Up,
+			Down:        migration010Down,
+		},
+		{
+			Version:       "011_api_tokens_indexes",
+			Description:   "Create personal access token indexes",
+			Up:            migration011Up,
+			Down:          migration011Down,
+			NoTransaction: true,
+		},
 	}
 }
 
 // Migration 001: Initial setup
-func migration001Up(db *database.DB) error {
+func migration001Up(ctx context.Context, db *database.DB) error {
 	log.Println("Setting up initial database structure...")
 
 	// Collections will be created automatically when first document is inserted
@@ -234,13 +534,13 @@ func migration001Up(db *database.DB) error {
 
 		// Create a temporary document to ensure collection exists, then remove it
 		tempDoc := bson.M{"_temp": true}
-		result, err := collection.InsertOne(context.Background(), tempDoc)
+		result, err := collection.InsertOne(ctx, tempDoc)
 		if err != nil {
 			return fmt.Errorf("failed to create collection %s: %w", collName, err)
 		}
 
 		// Remove the temporary document
-		_, err = collection.DeleteOne(context.Background(), bson.M{"_id": result.InsertedID})
+		_, err = collection.DeleteOne(ctx, bson.M{"_id": result.InsertedID})
 		if err != nil {
 			return fmt.Errorf("failed to clean up temp document in %s: %w", collName, err)
 		}
@@ -251,7 +551,7 @@ func migration001Up(db *database.DB) error {
 	return nil
 }
 
-func migration001Down(db *database.DB) error {
+func migration001Down(ctx context.Context, db *database.DB) error {
 	// In a real scenario, you might want to drop collections
 	// For safety, we'll leave them as is
 	log.Println("Migration 001 rollback - collections left intact")
@@ -259,7 +559,7 @@ func migration001Down(db *database.DB) error {
 }
 
 // Migration 002: Users indexes
-func migration002Up(db *database.DB) error {
+func migration002Up(ctx context.Context, db *database.DB) error {
 	log.Println("Creating user collection indexes...")
 
 	collection := db.Collection("users")
@@ -284,7 +584,7 @@ func migration002Up(db *database.DB) error {
 		},
 	}
 
-	_, err := collection.Indexes().CreateMany(context.Background(), indexes)
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
 	if err != nil {
 		return fmt.Errorf("failed to create user indexes: %w", err)
 	}
@@ -293,14 +593,14 @@ func migration002Up(db *database.DB) error {
 	return nil
 }
 
-func migration002Down(db *database.DB) error {
+func migration002Down(ctx context.Context, db *database.DB) error {
 	collection := db.Collection("users")
-	_, err := collection.Indexes().DropAll(context.Background())
+	_, err := collection.Indexes().DropAll(ctx)
 	return err
 }
 
 // Migration 003: Plugins indexes
-func migration003Up(db *database.DB) error {
+func migration003Up(ctx context.Context, db *database.DB) error {
 	log.Println("Creating plugin collection indexes...")
 
 	collection := db.Collection("plugins")
@@ -321,7 +621,7 @@ func migration003Up(db *database.DB) error {
 		},
 	}
 
-	_, err := collection.Indexes().CreateMany(context.Background(), indexes)
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
 	if err != nil {
 		return fmt.Errorf("failed to create plugin indexes: %w", err)
 	}
@@ -330,14 +630,14 @@ func migration003Up(db *database.DB) error {
 	return nil
 }
 
-func migration003Down(db *database.DB) error {
+func migration003Down(ctx context.Context, db *database.DB) error {
 	collection := db.Collection("plugins")
-	_, err := collection.Indexes().DropAll(context.Background())
+	_, err := collection.Indexes().DropAll(ctx)
 	return err
 }
 
 // Migration 004: Themes indexes
-func migration004Up(db *database.DB) error {
+func migration004Up(ctx context.Context, db *database.DB) error {
 	log.Println("Creating theme collection indexes...")
 
 	// Themes collection
@@ -355,7 +655,7 @@ func migration004Up(db *database.DB) error {
 		},
 	}
 
-	_, err := themesCollection.Indexes().CreateMany(context.Background(), themeIndexes)
+	_, err := themesCollection.Indexes().CreateMany(ctx, themeIndexes)
 	if err != nil {
 		return fmt.Errorf("failed to create theme indexes: %w", err)
 	}
@@ -372,7 +672,7 @@ func migration004Up(db *database.DB) error {
 		},
 	}
 
-	_, err = configsCollection.Indexes().CreateMany(context.Background(), configIndexes)
+	_, err = configsCollection.Indexes().CreateMany(ctx, configIndexes)
 	if err != nil {
 		return fmt.Errorf("failed to create theme config indexes: %w", err)
 	}
@@ -389,7 +689,7 @@ func migration004Up(db *database.DB) error {
 		},
 	}
 
-	_, err = customizationsCollection.Indexes().CreateMany(context.Background(), customizationIndexes)
+	_, err = customizationsCollection.Indexes().CreateMany(ctx, customizationIndexes)
 	if err != nil {
 		return fmt.Errorf("failed to create theme customization indexes: %w", err)
 	}
@@ -398,11 +698,11 @@ func migration004Up(db *database.DB) error {
 	return nil
 }
 
-func migration004Down(db *database.DB) error {
+func migration004Down(ctx context.Context, db *database.DB) error {
 	collections := []string{"themes", "theme_configs", "theme_customizations"}
 	for _, collName := range collections {
 		collection := db.Collection(collName)
-		_, err := collection.Indexes().DropAll(context.Background())
+		_, err := collection.Indexes().DropAll(ctx)
 		if err != nil {
 			return err
 		}
@@ -411,23 +711,32 @@ func migration004Down(db *database.DB) error {
 }
 
 // Migration 005: Initial data
-func migration005Up(db *database.DB) error {
+func migration005Up(admin AdminAccount) func(ctx context.Context, db *database.DB) error {
+	return func(ctx context.Context, db *database.DB) error {
+		return insertInitialData(ctx, db, admin)
+	}
+}
+
+func insertInitialData(ctx context.Context, db *database.DB, admin AdminAccount) error {
 	log.Println("Inserting initial data...")
 
 	// Create default admin user if no users exist
 	usersCollection := db.Collection("users")
-	userCount, err := usersCollection.CountDocuments(context.Background(), bson.M{})
+	userCount, err := usersCollection.CountDocuments(ctx, bson.M{})
 	if err != nil {
 		return fmt.Errorf("failed to count users: %w", err)
 	}
 
 	if userCount == 0 {
+		if admin.Email == "" || admin.Password == "" {
+			return fmt.Errorf("no admin credentials were given for the initial admin user")
+		}
 		log.Println("Creating default admin user...")
 
 		adminUser := models.User{
 			Username:  "admin",
-			Email:     "admin@example.com",
-			Password:  "admin123", // This will be hashed
+			Email:     models.NormalizeEmail(admin.Email),
+			Password:  admin.Password, // This will be hashed
 			Role:      "super_admin",
 			IsActive:  true,
 			CreatedAt: time.Now(),
@@ -439,18 +748,18 @@ func migration005Up(db *database.DB) error {
 			return fmt.Errorf("failed to hash admin password: %w", err)
 		}
 
-		_, err := usersCollection.InsertOne(context.Background(), adminUser)
+		_, err := usersCollection.InsertOne(ctx, adminUser)
 		if err != nil {
 			return fmt.Errorf("failed to create admin user: %w", err)
 		}
 
-		log.Println("Default admin user created (email: admin@example.com, password: admin123)")
+		log.Printf("Default admin user created (email: %s)", adminUser.Email)
 		log.Println("⚠️  IMPORTANT: Change the default admin password after first login!")
 	}
 
 	// Create default theme if no themes exist
 	themesCollection := db.Collection("themes")
-	themeCount, err := themesCollection.CountDocuments(context.Background(), bson.M{})
+	themeCount, err := themesCollection.CountDocuments(ctx, bson.M{})
 	if err != nil {
 		return fmt.Errorf("failed to count themes: %w", err)
 	}
@@ -467,7 +776,7 @@ func migration005Up(db *database.DB) error {
 			UpdatedAt:   time.Now(),
 		}
 
-		_, err := themesCollection.InsertOne(context.Background(), defaultTheme)
+		_, err := themesCollection.InsertOne(ctx, defaultTheme)
 		if err != nil {
 			return fmt.Errorf("failed to create default theme: %w", err)
 		}
@@ -479,15 +788,265 @@ func migration005Up(db *database.DB) error {
 	return nil
 }
 
-func migration005Down(db *database.DB) error {
+func migration005Down(ctx context.Context, db *database.DB) error {
 	// Remove default data
 	usersCollection := db.Collection("users")
-	_, err := usersCollection.DeleteOne(context.Background(), bson.M{"username": "admin"})
+	_, err := usersCollection.DeleteOne(ctx, bson.M{"username": "admin"})
 	if err != nil {
 		return err
 	}
 
 	themesCollection := db.Collection("themes")
-	_, err = themesCollection.DeleteOne(context.Background(), bson.M{"name": "default"})
+	_, err = themesCollection.DeleteOne(ctx, bson.M{"name": "default"})
+	return err
+}
+
+// Migration 006: Plugin settings history indexes
+func migration006Up(ctx context.Context, db *database.DB) error {
+	log.Println("Creating plugin settings history indexes...")
+
+	collection := db.Collection("plugin_settings_history")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "plugin", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("failed to create plugin settings history indexes: %w", err)
+	}
+
+	log.Println("Plugin settings history indexes created successfully")
+	return nil
+}
+
+func migration006Down(ctx context.Context, db *database.DB) error {
+	return db.Collection("plugin_settings_history").Drop(ctx)
+}
+
+// Migration 007: Registration invite indexes
+func migration007Up(ctx context.Context, db *database.DB) error {
+	log.Println("Creating invite indexes...")
+
+	collection := db.Collection("invites")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "expires_at", Value: 1}},
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("failed to create invite indexes: %w", err)
+	}
+
+	log.Println("Invite indexes created successfully")
+	return nil
+}
+
+func migration007Down(ctx context.Context, db *database.DB) error {
+	return db.Collection("invites").Drop(ctx)
+}
+
+// Migration 008: Idempotency key indexes
+func migration008Up(ctx context.Context, db *database.DB) error {
+	log.Println("Creating idempotency key indexes...")
+
+	collection := db.Collection("idempotency_keys")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "endpoint", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency key indexes: %w", err)
+	}
+
+	log.Println("Idempotency key indexes created successfully")
+	return nil
+}
+
+func migration008Down(ctx context.Context, db *database.DB) error {
+	return db.Collection("idempotency_keys").Drop(ctx)
+}
+
+// Migration 009: Content types
+func migration009Up(ctx context.Context, db *database.DB) error {
+	log.Println("Creating content type indexes...")
+
+	_, err := db.Collection("content_types").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create content type indexes: %w", err)
+	}
+
+	contentIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "type", Value: 1}, {Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	}
+	if _, err := db.Collection("content").Indexes().CreateMany(ctx, contentIndexes); err != nil {
+		return fmt.Errorf("failed to create content indexes: %w", err)
+	}
+
+	// Content created before types existed has no type; it becomes a post
+	_, err = db.Collection("content").UpdateMany(ctx,
+		bson.M{"type": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"type": "post"}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to assign default content type: %w", err)
+	}
+
+	now := time.Now()
+	postType := models.ContentType{
+		Name:        "post",
+		Label:       "Posts",
+		Description: "Blog posts and articles",
+		Fields:      []models.ContentField{},
+		BuiltIn:     true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	_, err = db.Collection("content_types").UpdateOne(ctx,
+		bson.M{"name": postType.Name},
+		bson.M{"$setOnInsert": postType},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create default content type: %w", err)
+	}
+
+	log.Println("Content types created successfully")
+	return nil
+}
+
+func migration009Down(ctx context.Context, db *database.DB) error {
+	if _, err := db.Collection("content").Indexes().DropAll(ctx); err != nil {
+		return err
+	}
+	return db.Collection("content_types").Drop(ctx)
+}
+
+// Migration 010: Normalize emails
+func migration010Up(ctx context.Context, db *database.DB) error {
+	log.Println("Normalizing stored emails...")
+
+	users := db.Collection("users")
+	cursor, err := users.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"email": 1}))
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	var accounts []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Email string             `bson:"email"`
+	}
+	if err := cursor.All(ctx, &accounts); err != nil {
+		return fmt.Errorf("failed to read users: %w", err)
+	}
+
+	// Accounts whose addresses differ only in case cannot all keep their address.
+	// Refuse to change anything until an administrator has resolved them.
+	owners := make(map[string][]string, len(accounts))
+	for _, account := range accounts {
+		email := models.NormalizeEmail(account.Email)
+		owners[email] = append(owners[email], account.ID.Hex())
+	}
+	if conflicts := emailConflicts(owners); len(conflicts) > 0 {
+		return fmt.Errorf("cannot normalize emails; these accounts differ only in case and must be renamed or merged first: %s",
+			strings.Join(conflicts, "; "))
+	}
+
+	for _, account := range accounts {
+		email := models.NormalizeEmail(account.Email)
+		if email == account.Email {
+			continue
+		}
+		if _, err := users.UpdateByID(ctx, account.ID, bson.M{"$set": bson.M{"email": email}}); err != nil {
+			return fmt.Errorf("failed to normalize email of user %s: %w", account.ID.Hex(), err)
+		}
+	}
+
+	// Invites restricted to an address are matched against the normalized email
+	_, err = db.Collection("invites").UpdateMany(ctx,
+		bson.M{"email": bson.M{"$exists": true, "$ne": ""}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"email": bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$email"}}},
+		}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to normalize invite emails: %w", err)
+	}
+
+	log.Println("Emails normalized successfully")
+	return nil
+}
+
+// emailConflicts lists the normalized addresses shared by more than one
+// account, with the IDs of those accounts, in a stable order
+func emailConflicts(owners map[string][]string) []string {
+	var conflicts []string
+	for email, ids := range owners {
+		if len(ids) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("%s (users %s)", email, strings.Join(ids, ", ")))
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+func migration010Down(ctx context.Context, db *database.DB) error {
+	// The original casing is not kept, so there is nothing to restore
+	log.Println("Migration 010 rollback - emails left normalized")
+	return nil
+}
+
+// Migration 011: Personal access token indexes
+func migration011Up(ctx context.Context, db *database.DB) error {
+	log.Println("Creating API token indexes...")
+
+	collection := db.Collection("api_tokens")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	}
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return fmt.Errorf("failed to create API token indexes: %w", err)
+	}
+
+	log.Println("API token indexes created successfully")
+	return nil
+}
+
+func migration011Down(ctx context.Context, db *database.DB) error {
+	_, err := db.Collection("api_tokens").Indexes().DropAll(ctx)
 	return err
 }
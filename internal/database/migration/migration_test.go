@@ -0,0 +1,159 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go-cms/internal/database"
+	"go-cms/internal/database/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// testDB connects to MONGO_TEST_URI and returns a scratch database that is
+// dropped when the test ends. Tests using it are skipped without the variable.
+func testDB(t *testing.T) *database.DB {
+	t.Helper()
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	db, err := database.Connect(uri, fmt.Sprintf("gocms_test_%d", time.Now().UnixNano()), database.Consistency{})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		db.Database.Drop(ctx)
+		db.Disconnect(ctx)
+	})
+	return db
+}
+
+func testManager(db *database.DB, migrations ...Migration) *Manager {
+	return &Manager{db: db, migrations: migrations, timeout: 30 * time.Second}
+}
+
+func noop(context.Context, *database.DB) error { return nil }
+
+func TestRunIndexMigrationOutsideTransaction(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	// Index creation inside a transaction fails once the collection exists
+	if _, err := db.Collection("widgets").InsertOne(ctx, bson.M{"name": "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := testManager(db, Migration{
+		Version:     "001_widgets_indexes",
+		Description: "widget indexes",
+		Up: func(ctx context.Context, db *database.DB) error {
+			_, err := db.Collection("widgets").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "name", Value: 1}}},
+			})
+			return err
+		},
+		Down:          noop,
+		NoTransaction: true,
+	})
+	if err := m.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	applied, err := m.getAppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !applied["001_widgets_indexes"] {
+		t.Fatal("index migration was not recorded")
+	}
+}
+
+func TestRunDataMigrationInTransaction(t *testing.T) {
+	db := testDB(t)
+
+	m := testManager(db, Migration{
+		Version:     "001_seed",
+		Description: "seed widgets",
+		Up: func(ctx context.Context, db *database.DB) error {
+			_, err := db.Collection("widgets").InsertOne(ctx, bson.M{"name": "seeded"})
+			return err
+		},
+		Down: noop,
+	})
+	if err := m.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	count, err := db.Collection("widgets").CountDocuments(context.Background(), bson.M{"name": "seeded"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("seeded %d documents, want 1", count)
+	}
+}
+
+func TestRunFailedMigrationIsNotRecorded(t *testing.T) {
+	for _, noTx := range []bool{false, true} {
+		t.Run(fmt.Sprintf("NoTransaction=%v", noTx), func(t *testing.T) {
+			db := testDB(t)
+			boom := errors.New("boom")
+
+			m := testManager(db, Migration{
+				Version:       "001_broken",
+				Description:   "always fails",
+				Up:            func(context.Context, *database.DB) error { return boom },
+				Down:          noop,
+				NoTransaction: noTx,
+			})
+			if err := m.Run(); !errors.Is(err, boom) {
+				t.Fatalf("Run error = %v, want %v", err, boom)
+			}
+
+			applied, err := m.getAppliedMigrations()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if applied["001_broken"] {
+				t.Fatal("failed migration was recorded")
+			}
+		})
+	}
+}
+
+func TestInitialDataCreatesGivenAdmin(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	admin := AdminAccount{Email: " Owner@Example.com", Password: "a-long-admin-password"}
+	if err := migration005Up(admin)(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	var user models.User
+	if err := db.Collection("users").FindOne(ctx, bson.M{"role": "super_admin"}).Decode(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "owner@example.com" {
+		t.Errorf("admin email %q, want owner@example.com", user.Email)
+	}
+	if !user.CheckPassword(admin.Password) {
+		t.Error("admin password does not match the given one")
+	}
+}
+
+func TestInitialDataNeedsAdminCredentials(t *testing.T) {
+	db := testDB(t)
+	if err := migration005Up(AdminAccount{})(context.Background(), db); err == nil {
+		t.Fatal("initial data was inserted without admin credentials")
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ActivityEntry records an administrative action for the activity log
+type ActivityEntry struct {
+	ID          primitive.ObjectID     `bson:"_id,omitempty" json:"id,omitempty"`
+	Type        string                 `bson:"type" json:"type"` // e.g. plugin.upgraded, plugin.downgraded
+	Description string                 `bson:"description" json:"description"`
+	Details     map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"`
+	UserID      string                 `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Username    string                 `bson:"username,omitempty" json:"username,omitempty"`
+	CreatedAt   time.Time              `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	rgbColorPattern = regexp.MustCompile(`^rgba?\(\s*([^,()]+?)\s*,\s*([^,()]+?)\s*,\s*([^,()]+?)\s*(?:,\s*([^,()]+?)\s*)?\)$`)
+)
+
+// namedColors holds the CSS named colors plus the transparent and currentcolor keywords
+var namedColors = map[string]bool{
+	"aliceblue": true, "antiquewhite": true, "aqua": true, "aquamarine": true, "azure": true,
+	"beige": true, "bisque": true, "black": true, "blanchedalmond": true, "blue": true,
+	"blueviolet": true, "brown": true, "burlywood": true, "cadetblue": true, "chartreuse": true,
+	"chocolate": true, "coral": true, "cornflowerblue": true, "cornsilk": true, "crimson": true,
+	"cyan": true, "darkblue": true, "darkcyan": true, "darkgoldenrod": true, "darkgray": true,
+	"darkgreen": true, "darkgrey": true, "darkkhaki": true, "darkmagenta": true, "darkolivegreen": true,
+	"darkorange": true, "darkorchid": true, "darkred": true, "darksalmon": true, "darkseagreen": true,
+	"darkslateblue": true, "darkslategray": true, "darkslategrey": true, "darkturquoise": true, "darkviolet": true,
+	"deeppink": true, "deepskyblue": true, "dimgray": true, "dimgrey": true, "dodgerblue": true,
+	"firebrick": true, "floralwhite": true, "forestgreen": true, "fuchsia": true, "gainsboro": true,
+	"ghostwhite": true, "gold": true, "goldenrod": true, "gray": true, "green": true,
+	"greenyellow": true, "grey": true, "honeydew": true, "hotpink": true, "indianred": true,
+	"indigo": true, "ivory": true, "khaki": true, "lavender": true, "lavenderblush": true,
+	"lawngreen": true, "lemonchiffon": true, "lightblue": true, "lightcoral": true, "lightcyan": true,
+	"lightgoldenrodyellow": true, "lightgray": true, "lightgreen": true, "lightgrey": true, "lightpink": true,
+	"lightsalmon": true, "lightseagreen": true, "lightskyblue": true, "lightslategray": true, "lightslategrey": true,
+	"lightsteelblue": true, "lightyellow": true, "lime": true, "limegreen": true, "linen": true,
+	"magenta": true, "maroon": true, "mediumaquamarine": true, "mediumblue": true, "mediumorchid": true,
+	"mediumpurple": true, "mediumseagreen": true, "mediumslateblue": true, "mediumspringgreen": true, "mediumturquoise": true,
+	"mediumvioletred": true, "midnightblue": true, "mintcream": true, "mistyrose": true, "moccasin": true,
+	"navajowhite": true, "navy": true, "oldlace": true, "olive": true, "olivedrab": true,
+	"orange": true, "orangered": true, "orchid": true, "palegoldenrod": true, "palegreen": true,
+	"paleturquoise": true, "palevioletred": true, "papayawhip": true, "peachpuff": true, "peru": true,
+	"pink": true, "plum": true, "powderblue": true, "purple": true, "rebeccapurple": true,
+	"red": true, "rosybrown": true, "royalblue": true, "saddlebrown": true, "salmon": true,
+	"sandybrown": true, "seagreen": true, "seashell": true, "sienna": true, "silver": true,
+	"skyblue": true, "slateblue": true, "slategray": true, "slategrey": true, "snow": true,
+	"springgreen": true, "steelblue": true, "tan": true, "teal": true, "thistle": true,
+	"tomato": true, "turquoise": true, "violet": true, "wheat": true, "white": true,
+	"whitesmoke": true, "yellow": true, "yellowgreen": true,
+	"transparent": true, "currentcolor": true,
+}
+
+// IsValidColor reports whether s is a hex color (#RGB or #RRGGBB), an rgb()/rgba()
+// color with in-range components, or a named CSS color
+func IsValidColor(s string) bool {
+	s = strings.TrimSpace(s)
+	if hexColorPattern.MatchString(s) {
+		return true
+	}
+	if namedColors[strings.ToLower(s)] {
+		return true
+	}
+	return isValidRGBColor(strings.ToLower(s))
+}
+
+// NormalizeColor trims s and rewrites hex colors in lowercase #rrggbb form,
+// expanding the #rgb shorthand. Other values are returned trimmed but otherwise unchanged.
+func NormalizeColor(s string) string {
+	s = strings.TrimSpace(s)
+	if !hexColorPattern.MatchString(s) {
+		return s
+	}
+	s = strings.ToLower(s)
+	if len(s) == 4 {
+		return "#" + strings.Repeat(s[1:2], 2) + strings.Repeat(s[2:3], 2) + strings.Repeat(s[3:4], 2)
+	}
+	return s
+}
+
+// isValidRGBColor checks rgb(r, g, b) and rgba(r, g, b, a). Channels are 0-255 or
+// percentages; alpha is 0-1 or a percentage and is required only by rgba().
+func isValidRGBColor(s string) bool {
+	match := rgbColorPattern.FindStringSubmatch(s)
+	if match == nil {
+		return false
+	}
+	hasAlpha := match[4] != ""
+	if strings.HasPrefix(s, "rgba(") != hasAlpha {
+		return false
+	}
+
+	for _, channel := range match[1:4] {
+		if !inRange(channel, 255) {
+			return false
+		}
+	}
+	return !hasAlpha || inRange(match[4], 1)
+}
+
+// inRange reports whether value is a number in [0, limit] or a percentage in [0%, 100%]
+func inRange(value string, limit float64) bool {
+	if strings.HasSuffix(value, "%") {
+		value, limit = strings.TrimSuffix(value, "%"), 100
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || strings.ContainsAny(value, "eEnN+") {
+		return false
+	}
+	return n >= 0 && n <= limit
+}
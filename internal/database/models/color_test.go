@@ -0,0 +1,65 @@
+package models
+
+import "testing"
+
+func TestIsValidColor(t *testing.T) {
+	tests := map[string]bool{
+		"#FFF":                     true,
+		"#fff":                     true,
+		"#1a2B3c":                  true,
+		" #abc ":                   true,
+		"#ffff":                    false, // #RGBA is not accepted
+		"#12345":                   false,
+		"#GGG":                     false,
+		"fff":                      false,
+		"#":                        false,
+		"rgb(0,0,0)":               true,
+		"rgb( 255 , 128 , 0 )":     true,
+		"RGB(255, 255, 255)":       true,
+		"rgb(100%, 50%, 0%)":       true,
+		"rgba(0,0,0,0.5)":          true,
+		"rgba(0, 0, 0, 0)":         true,
+		"rgba(0, 0, 0, 1)":         true,
+		"rgba(0, 0, 0, 50%)":       true,
+		"rgb(256, 0, 0)":           false,
+		"rgb(-1, 0, 0)":            false,
+		"rgb(0, 0)":                false,
+		"rgb(0, 0, 0, 0.5)":        false, // Alpha needs rgba()
+		"rgba(0, 0, 0)":            false, // rgba() needs alpha
+		"rgba(0, 0, 0, 1.5)":       false,
+		"rgb(101%, 0, 0)":          false,
+		"rgb(NaN, 0, 0)":           false,
+		"rgb(1e2, 0, 0)":           false,
+		"rgb(0, 0, 0); color: red": false,
+		"red":                      true,
+		"RebeccaPurple":            true,
+		"transparent":              true,
+		"currentColor":             true,
+		"reddish":                  false,
+		"":                         false,
+		"url(javascript:alert(1))": false,
+	}
+
+	for input, want := range tests {
+		if got := IsValidColor(input); got != want {
+			t.Errorf("IsValidColor(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestNormalizeColor(t *testing.T) {
+	tests := map[string]string{
+		"#FFF":            "#ffffff",
+		"#a1B":            "#aa11bb",
+		"#AABBCC":         "#aabbcc",
+		" #abc ":          "#aabbcc",
+		"rgba(0,0,0,0.5)": "rgba(0,0,0,0.5)",
+		" Red ":           "Red",
+	}
+
+	for input, want := range tests {
+		if got := NormalizeColor(input); got != want {
+			t.Errorf("NormalizeColor(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ContentType defines a kind of content, such as posts or products, and the
+// custom fields its items carry
+type ContentType struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name        string             `bson:"name" json:"name" binding:"required"` // Slug stored on each item, e.g. "product"
+	Label       string             `bson:"label" json:"label" binding:"required"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Fields      []ContentField     `bson:"fields" json:"fields"`
+	BuiltIn     bool               `bson:"built_in" json:"built_in"` // Shipped with the CMS; cannot be deleted
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// ContentField describes one custom field of a content type
+type ContentField struct {
+	Key       string   `bson:"key" json:"key"`
+	Label     string   `bson:"label" json:"label"`
+	Type      string   `bson:"type" json:"type"` // text, textarea, richtext, number, boolean, date, url, email or select
+	Required  bool     `bson:"required" json:"required"`
+	Options   []string `bson:"options,omitempty" json:"options,omitempty"`       // Allowed values of a select field
+	MaxLength int      `bson:"max_length,omitempty" json:"max_length,omitempty"` // Limit for text fields; 0 for none
+}
+
+// Content is an item of content of some content type
+type Content struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty" json:"id,omitempty"`
+	Type      string                 `bson:"type" json:"type"`
+	Title     string                 `bson:"title" json:"title"`
+	Slug      string                 `bson:"slug" json:"slug"`
+	Status    string                 `bson:"status" json:"status"` // draft or published
+	Body      string                 `bson:"body,omitempty" json:"body,omitempty"`
+	Fields    map[string]interface{} `bson:"fields,omitempty" json:"fields,omitempty"` // Values of the type's custom fields
+	AuthorID  string                 `bson:"author_id,omitempty" json:"author_id,omitempty"`
+	CreatedAt time.Time              `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time              `bson:"updated_at" json:"updated_at"`
+}
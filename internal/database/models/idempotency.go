@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IdempotencyRecord remembers the response to a mutating request sent with an
+// Idempotency-Key header so a retry gets the same response instead of repeating
+// the operation. Keys are scoped to the user and endpoint that sent them, and
+// bound to the request body by its hash.
+type IdempotencyRecord struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Key         string             `bson:"key" json:"key"`
+	UserID      string             `bson:"user_id" json:"user_id"`
+	Endpoint    string             `bson:"endpoint" json:"endpoint"`         // Method and path, e.g. "POST /api/v1/admin/plugins/seo/toggle"
+	RequestHash string             `bson:"request_hash" json:"request_hash"` // Hex SHA-256 of the request body
+	Completed   bool               `bson:"completed" json:"completed"`
+	Status      int                `bson:"status,omitempty" json:"status,omitempty"`
+	ContentType string             `bson:"content_type,omitempty" json:"content_type,omitempty"`
+	Body        []byte             `bson:"body,omitempty" json:"-"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"expires_at"`
+}
@@ -1,37 +1,64 @@
 package models
 
 import (
-    "time"
+	"time"
 
-    "go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type PluginMetadata struct {
-    ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-    Name        string             `bson:"name" json:"name"`
-    Version     string             `bson:"version" json:"version"`
-    Description string             `bson:"description" json:"description"`
-    Author      string             `bson:"author" json:"author"`
-    Website     string             `bson:"website,omitempty" json:"website,omitempty"`
-    Filename    string             `bson:"filename" json:"filename"`
-    IsActive    bool               `bson:"is_active" json:"is_active"`
-    Settings    []PluginSetting    `bson:"settings" json:"settings"`
-    CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-    UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name         string             `bson:"name" json:"name"`
+	Version      string             `bson:"version" json:"version"`
+	Description  string             `bson:"description" json:"description"`
+	Author       string             `bson:"author" json:"author"`
+	Website      string             `bson:"website,omitempty" json:"website,omitempty"`
+	Filename     string             `bson:"filename" json:"filename"`
+	ContentHash  string             `bson:"content_hash,omitempty" json:"content_hash,omitempty"` // SHA-256 of the uploaded zip
+	Capabilities []string           `bson:"capabilities" json:"capabilities"`                     // Granted from plugin.json, kept for auditing
+	IsActive     bool               `bson:"is_active" json:"is_active"`
+	Settings     []PluginSetting    `bson:"settings" json:"settings"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
 type PluginSetting struct {
-    Key         string      `bson:"key" json:"key"`
-    Label       string      `bson:"label" json:"label"`
-    Type        string      `bson:"type" json:"type"` // text, number, boolean, select
-    Value       interface{} `bson:"value" json:"value"`
-    Description string      `bson:"description,omitempty" json:"description,omitempty"`
-    Options     []string    `bson:"options,omitempty" json:"options,omitempty"`
-    Required    bool        `bson:"required" json:"required"`
+	Key         string      `bson:"key" json:"key"`
+	Label       string      `bson:"label" json:"label"`
+	Type        string      `bson:"type" json:"type"` // text, number, boolean, select
+	Value       interface{} `bson:"value" json:"value"`
+	Description string      `bson:"description,omitempty" json:"description,omitempty"`
+	Options     []string    `bson:"options,omitempty" json:"options,omitempty"`
+	Required    bool        `bson:"required" json:"required"`
+	Group       string      `bson:"group,omitempty" json:"group,omitempty"`
+	Order       int         `bson:"order,omitempty" json:"order,omitempty"`
+	Min         *float64    `bson:"min,omitempty" json:"min,omitempty"`
+	Max         *float64    `bson:"max,omitempty" json:"max,omitempty"`
+	Pattern     string      `bson:"pattern,omitempty" json:"pattern,omitempty"`
+	Placeholder string      `bson:"placeholder,omitempty" json:"placeholder,omitempty"`
+}
+
+// PluginSettingsChange records one update of a plugin's settings
+type PluginSettingsChange struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Plugin    string             `bson:"plugin" json:"plugin"`
+	Changes   []SettingChange    `bson:"changes" json:"changes"`
+	UserID    string             `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Username  string             `bson:"username,omitempty" json:"username,omitempty"`
+	Source    string             `bson:"source" json:"source"` // admin_ui or api
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// SettingChange is the before and after value of a single setting. A nil
+// OldValue means the key was added, a nil NewValue that it was removed.
+type SettingChange struct {
+	Key      string      `bson:"key" json:"key"`
+	OldValue interface{} `bson:"old_value" json:"old_value"`
+	NewValue interface{} `bson:"new_value" json:"new_value"`
 }
 
 type PluginUpload struct {
-    Name        string `json:"name" binding:"required"`
-    Description string `json:"description"`
-    Author      string `json:"author"`
-}
\ No newline at end of file
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+}
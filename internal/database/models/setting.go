@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SiteSetting represents a single site-wide setting stored in the database
+type SiteSetting struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Key       string             `bson:"key" json:"key"`
+	Value     interface{}        `bson:"value" json:"value"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	UpdatedBy string             `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+}
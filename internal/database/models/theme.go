@@ -1,8 +1,12 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
+	"html"
+	"net/url"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -137,6 +141,20 @@ type SEOSettings struct {
 	Schema          map[string]interface{} `bson:"schema,omitempty" json:"schema,omitempty"`
 }
 
+// SEOContext holds per-page SEO values that override the site-wide SEOSettings
+type SEOContext struct {
+	Title        string                 `json:"title,omitempty"`
+	Description  string                 `json:"description,omitempty"`
+	Keywords     string                 `json:"keywords,omitempty"`
+	CanonicalURL string                 `json:"canonical_url,omitempty"`
+	Robots       string                 `json:"robots,omitempty"`
+	OGImage      string                 `json:"og_image,omitempty"`
+	OGType       string                 `json:"og_type,omitempty"` // website, article, etc.
+	TwitterCard  string                 `json:"twitter_card,omitempty"`
+	MetaTags     map[string]string      `json:"meta_tags,omitempty"`
+	Schema       map[string]interface{} `json:"schema,omitempty"`
+}
+
 // AnalyticsSettings represents analytics integration
 type AnalyticsSettings struct {
 	GoogleAnalytics    string `bson:"google_analytics,omitempty" json:"google_analytics,omitempty"`
@@ -218,12 +236,6 @@ func (t *ThemeMetadata) IsInstalled() bool {
 	return t.Path != "" && t.InstalledAt.After(time.Time{})
 }
 
-// IsUpdateAvailable checks if theme has updates (placeholder logic)
-func (t *ThemeMetadata) IsUpdateAvailable() bool {
-	// In a real implementation, you would check against a marketplace or repository
-	return false
-}
-
 // GetThemeDir returns the theme directory path
 func (t *ThemeMetadata) GetThemeDir() string {
 	return t.Path
@@ -271,12 +283,17 @@ func (tc *ThemeCustomization) GetColor(key, fallback string) string {
 	return fallback
 }
 
-// SetColor sets a color value
-func (tc *ThemeCustomization) SetColor(key, value string) {
+// SetColor sets a color value, expanding shorthand hex to #RRGGBB. It returns
+// false and leaves the colors unchanged when value is not a valid color.
+func (tc *ThemeCustomization) SetColor(key, value string) bool {
+	if !IsValidColor(value) {
+		return false
+	}
 	if tc.Colors == nil {
 		tc.Colors = make(map[string]string)
 	}
-	tc.Colors[key] = value
+	tc.Colors[key] = NormalizeColor(value)
+	return true
 }
 
 // GetFont returns a font value with fallback
@@ -355,3 +372,248 @@ j=d.createElement(s),dl=l!='dataLayer'?'&l='+l:'';j.async=true;j.src=
 
 	return code.String()
 }
+
+// GetFontLinks returns the <link> tags needed to load the theme's fonts.
+// Google fonts are combined into a single request with their weights merged;
+// fonts with a custom FontURL are linked directly.
+func (tc *ThemeCustomization) GetFontLinks() string {
+	fonts := []FontSettings{
+		tc.Typography.PrimaryFont,
+		tc.Typography.SecondaryFont,
+		tc.Typography.HeadingFont,
+	}
+
+	var families []string
+	weights := make(map[string]map[string]bool)
+	var customURLs []string
+	seenURLs := make(map[string]bool)
+
+	for _, font := range fonts {
+		if font.FontURL != "" {
+			if !seenURLs[font.FontURL] {
+				seenURLs[font.FontURL] = true
+				customURLs = append(customURLs, font.FontURL)
+			}
+			continue
+		}
+
+		if !font.GoogleFont || font.Family == "" {
+			continue
+		}
+
+		if _, exists := weights[font.Family]; !exists {
+			weights[font.Family] = make(map[string]bool)
+			families = append(families, font.Family)
+		}
+		if weight := normalizeFontWeight(font.Weight); weight != "" {
+			weights[font.Family][weight] = true
+		}
+	}
+
+	var code strings.Builder
+
+	if len(families) > 0 {
+		params := make([]string, 0, len(families))
+		for _, family := range families {
+			param := "family=" + strings.ReplaceAll(url.QueryEscape(family), "%20", "+")
+
+			var familyWeights []string
+			for weight := range weights[family] {
+				familyWeights = append(familyWeights, weight)
+			}
+			if len(familyWeights) > 0 {
+				sort.Slice(familyWeights, func(i, j int) bool {
+					return len(familyWeights[i]) < len(familyWeights[j]) ||
+						(len(familyWeights[i]) == len(familyWeights[j]) && familyWeights[i] < familyWeights[j])
+				})
+				param += ":wght@" + strings.Join(familyWeights, ";")
+			}
+
+			params = append(params, param)
+		}
+
+		href := "https://fonts.googleapis.com/css2?" + strings.Join(params, "&") + "&display=swap"
+
+		code.WriteString(`<link rel="preconnect" href="https://fonts.googleapis.com">` + "\n")
+		code.WriteString(`<link rel="preconnect" href="https://fonts.gstatic.com" crossorigin>` + "\n")
+		code.WriteString(fmt.Sprintf(`<link rel="stylesheet" href="%s">`+"\n", html.EscapeString(href)))
+	}
+
+	for _, fontURL := range customURLs {
+		code.WriteString(fmt.Sprintf(`<link rel="stylesheet" href="%s">`+"\n", html.EscapeString(fontURL)))
+	}
+
+	return code.String()
+}
+
+// normalizeFontWeight converts a CSS font weight into the numeric form Google Fonts expects
+func normalizeFontWeight(weight string) string {
+	weight = strings.ToLower(strings.TrimSpace(weight))
+	switch weight {
+	case "":
+		return ""
+	case "normal":
+		return "400"
+	case "bold":
+		return "700"
+	}
+
+	for _, char := range weight {
+		if char < '0' || char > '9' {
+			return ""
+		}
+	}
+	return weight
+}
+
+// GetSEOTags returns the <head> markup for SEO: title, meta description and
+// keywords, canonical link, robots, OpenGraph, Twitter card and JSON-LD.
+// Values set on page take precedence over the site-wide SEO settings.
+func (tc *ThemeCustomization) GetSEOTags(page SEOContext) string {
+	site := tc.SEO
+
+	title := firstNonEmpty(page.Title, site.SiteTitle)
+	description := firstNonEmpty(page.Description, site.SiteDescription)
+	keywords := firstNonEmpty(page.Keywords, site.SiteKeywords)
+	canonical := firstNonEmpty(page.CanonicalURL, site.CanonicalURL)
+	robots := firstNonEmpty(page.Robots, site.RobotsContent)
+	image := firstNonEmpty(page.OGImage, site.OGImage)
+	twitterCard := firstNonEmpty(page.TwitterCard, site.TwitterCard)
+	ogType := firstNonEmpty(page.OGType, "website")
+
+	var code strings.Builder
+
+	writeTag := func(format string, value string) {
+		if value != "" {
+			code.WriteString(fmt.Sprintf(format+"\n", html.EscapeString(value)))
+		}
+	}
+
+	writeTag(`<title>%s</title>`, title)
+	writeTag(`<meta name="description" content="%s">`, description)
+	writeTag(`<meta name="keywords" content="%s">`, keywords)
+	writeTag(`<link rel="canonical" href="%s">`, canonical)
+	writeTag(`<meta name="robots" content="%s">`, robots)
+
+	// OpenGraph
+	if title != "" || description != "" || image != "" {
+		writeTag(`<meta property="og:type" content="%s">`, ogType)
+	}
+	writeTag(`<meta property="og:title" content="%s">`, title)
+	writeTag(`<meta property="og:description" content="%s">`, description)
+	writeTag(`<meta property="og:image" content="%s">`, image)
+	writeTag(`<meta property="og:url" content="%s">`, canonical)
+
+	// Twitter card
+	writeTag(`<meta name="twitter:card" content="%s">`, twitterCard)
+	if twitterCard != "" {
+		writeTag(`<meta name="twitter:title" content="%s">`, title)
+		writeTag(`<meta name="twitter:description" content="%s">`, description)
+		writeTag(`<meta name="twitter:image" content="%s">`, image)
+	}
+
+	// Additional meta tags
+	metaTags := make(map[string]string)
+	for name, content := range site.MetaTags {
+		metaTags[name] = content
+	}
+	for name, content := range page.MetaTags {
+		metaTags[name] = content
+	}
+	names := make([]string, 0, len(metaTags))
+	for name, content := range metaTags {
+		if name != "" && content != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		code.WriteString(fmt.Sprintf(`<meta name="%s" content="%s">`+"\n",
+			html.EscapeString(name), html.EscapeString(metaTags[name])))
+	}
+
+	// JSON-LD structured data
+	schema := make(map[string]interface{})
+	for key, value := range site.Schema {
+		schema[key] = value
+	}
+	for key, value := range page.Schema {
+		schema[key] = value
+	}
+	if len(schema) > 0 {
+		// json.Marshal escapes <, > and & so the payload cannot close the script tag
+		if data, err := json.Marshal(schema); err == nil {
+			code.WriteString(`<script type="application/ld+json">`)
+			code.Write(data)
+			code.WriteString("</script>\n")
+		}
+	}
+
+	return code.String()
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// GetLogoMarkup returns the logo markup. When a dark-mode logo is configured the
+// logo is wrapped in a <picture> that swaps it based on prefers-color-scheme.
+func (tc *ThemeCustomization) GetLogoMarkup() string {
+	logo := tc.Logo
+	src := strings.TrimSpace(logo.URL)
+	if src == "" {
+		return ""
+	}
+
+	var img strings.Builder
+	img.WriteString(fmt.Sprintf(`<img src="%s" alt="%s"`, html.EscapeString(src), html.EscapeString(logo.Alt)))
+	if logo.Width != "" {
+		img.WriteString(fmt.Sprintf(` width="%s"`, html.EscapeString(logo.Width)))
+	}
+	if logo.Height != "" {
+		img.WriteString(fmt.Sprintf(` height="%s"`, html.EscapeString(logo.Height)))
+	}
+	if logo.Position != "" {
+		img.WriteString(fmt.Sprintf(` class="logo logo-%s"`, html.EscapeString(logo.Position)))
+	} else {
+		img.WriteString(` class="logo"`)
+	}
+	img.WriteString(">")
+
+	darkSrc := strings.TrimSpace(logo.DarkMode)
+	if darkSrc == "" {
+		return img.String() + "\n"
+	}
+
+	return fmt.Sprintf("<picture>\n  <source srcset=\"%s\" media=\"(prefers-color-scheme: dark)\">\n  %s\n</picture>\n",
+		html.EscapeString(darkSrc), img.String())
+}
+
+// GetFaviconLink returns the <link rel="icon"> tag for the configured favicon
+func (tc *ThemeCustomization) GetFaviconLink() string {
+	href := strings.TrimSpace(tc.Favicon)
+	if href == "" {
+		return ""
+	}
+
+	iconType := ""
+	switch strings.ToLower(filepath.Ext(strings.SplitN(href, "?", 2)[0])) {
+	case ".svg":
+		iconType = "image/svg+xml"
+	case ".png":
+		iconType = "image/png"
+	case ".ico":
+		iconType = "image/x-icon"
+	}
+
+	if iconType == "" {
+		return fmt.Sprintf(`<link rel="icon" href="%s">`+"\n", html.EscapeString(href))
+	}
+	return fmt.Sprintf(`<link rel="icon" type="%s" href="%s">`+"\n", iconType, html.EscapeString(href))
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetFontLinks(t *testing.T) {
+	tc := &ThemeCustomization{Typography: TypographySettings{
+		PrimaryFont:   FontSettings{Family: "Open Sans", Weight: "normal", GoogleFont: true},
+		SecondaryFont: FontSettings{Family: "Inter", Weight: "300", GoogleFont: true},
+		HeadingFont:   FontSettings{Family: "Open Sans", Weight: "bold", GoogleFont: true},
+	}}
+
+	want := `<link rel="preconnect" href="https://fonts.googleapis.com">
+<link rel="preconnect" href="https://fonts.gstatic.com" crossorigin>
+<link rel="stylesheet" href="https://fonts.googleapis.com/css2?family=Open+Sans:wght@400;700&amp;family=Inter:wght@300&amp;display=swap">
+`
+	if got := tc.GetFontLinks(); got != want {
+		t.Errorf("GetFontLinks =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGetFontLinksCustomURLs(t *testing.T) {
+	tc := &ThemeCustomization{Typography: TypographySettings{
+		PrimaryFont:   FontSettings{Family: "Brand", FontURL: "https://cdn.example.com/brand.css?v=1&x=2", GoogleFont: true},
+		SecondaryFont: FontSettings{Family: "Brand", FontURL: "https://cdn.example.com/brand.css?v=1&x=2"},
+		HeadingFont:   FontSettings{Family: "Georgia"},
+	}}
+
+	// A custom URL wins over GoogleFont, is linked once, and system fonts need nothing
+	want := `<link rel="stylesheet" href="https://cdn.example.com/brand.css?v=1&amp;x=2">` + "\n"
+	if got := tc.GetFontLinks(); got != want {
+		t.Errorf("GetFontLinks =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := (&ThemeCustomization{}).GetFontLinks(); got != "" {
+		t.Errorf("GetFontLinks without fonts = %q, want nothing", got)
+	}
+}
+
+func TestNormalizeFontWeight(t *testing.T) {
+	for weight, want := range map[string]string{
+		"":       "",
+		"normal": "400",
+		"Bold":   "700",
+		"600":    "600",
+		" 300 ":  "300",
+		"light":  "",
+		"40x":    "",
+	} {
+		if got := normalizeFontWeight(weight); got != want {
+			t.Errorf("normalizeFontWeight(%q) = %q, want %q", weight, got, want)
+		}
+	}
+}
+
+func TestGetSEOTagsPageOverridesSite(t *testing.T) {
+	tc := &ThemeCustomization{SEO: SEOSettings{
+		SiteTitle:       "Acme",
+		SiteDescription: "Everything Acme",
+		SiteKeywords:    "acme, widgets",
+		OGImage:         "https://acme.example/og.png",
+		TwitterCard:     "summary",
+		CanonicalURL:    "https://acme.example/",
+		RobotsContent:   "index, follow",
+		MetaTags:        map[string]string{"author": "Acme", "theme-color": "#fff"},
+		Schema:          map[string]interface{}{"@type": "Organization", "name": "Acme"},
+	}}
+
+	got := tc.GetSEOTags(SEOContext{
+		Title:        "Rockets",
+		CanonicalURL: "https://acme.example/rockets",
+		OGType:       "article",
+		MetaTags:     map[string]string{"author": "Wile E."},
+		Schema:       map[string]interface{}{"@type": "Product"},
+	})
+
+	want := `<title>Rockets</title>
+<meta name="description" content="Everything Acme">
+<meta name="keywords" content="acme, widgets">
+<link rel="canonical" href="https://acme.example/rockets">
+<meta name="robots" content="index, follow">
+<meta property="og:type" content="article">
+<meta property="og:title" content="Rockets">
+<meta property="og:description" content="Everything Acme">
+<meta property="og:image" content="https://acme.example/og.png">
+<meta property="og:url" content="https://acme.example/rockets">
+<meta name="twitter:card" content="summary">
+<meta name="twitter:title" content="Rockets">
+<meta name="twitter:description" content="Everything Acme">
+<meta name="twitter:image" content="https://acme.example/og.png">
+<meta name="author" content="Wile E.">
+<meta name="theme-color" content="#fff">
+<script type="application/ld+json">{"@type":"Product","name":"Acme"}</script>
+`
+	if got != want {
+		t.Errorf("GetSEOTags =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGetSEOTagsSkipsEmptyFields(t *testing.T) {
+	if got := (&ThemeCustomization{}).GetSEOTags(SEOContext{}); got != "" {
+		t.Errorf("GetSEOTags without settings = %q, want nothing", got)
+	}
+
+	// Only the title is set: no description, image, Twitter card or JSON-LD
+	tc := &ThemeCustomization{SEO: SEOSettings{SiteTitle: "Acme", MetaTags: map[string]string{"author": ""}}}
+	want := `<title>Acme</title>
+<meta property="og:type" content="website">
+<meta property="og:title" content="Acme">
+`
+	if got := tc.GetSEOTags(SEOContext{}); got != want {
+		t.Errorf("GetSEOTags =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGetSEOTagsEscapes(t *testing.T) {
+	tc := &ThemeCustomization{SEO: SEOSettings{
+		SiteTitle:       `</title><script>alert(1)</script>`,
+		SiteDescription: `say "hi" & leave`,
+		Schema:          map[string]interface{}{"name": "</script><script>alert(1)</script>"},
+	}}
+	got := tc.GetSEOTags(SEOContext{})
+
+	for _, unsafe := range []string{"<script>alert", `content="say "hi"`} {
+		if strings.Contains(got, unsafe) {
+			t.Errorf("output contains %q:\n%s", unsafe, got)
+		}
+	}
+	if !strings.Contains(got, `content="say &#34;hi&#34; &amp; leave"`) {
+		t.Errorf("description was not escaped:\n%s", got)
+	}
+	if strings.Count(got, "</script>") != 1 {
+		t.Errorf("JSON-LD payload closed the script tag:\n%s", got)
+	}
+}
@@ -1,12 +1,20 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// NormalizeEmail returns the form of an email address that is stored and looked
+// up, so that addresses differing only in case or surrounding spaces belong to
+// the same account
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 type User struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Username    string             `bson:"username" json:"username" binding:"required"`
@@ -20,9 +28,10 @@ type User struct {
 }
 
 type UserRegistration struct {
-	Username string `json:"username" binding:"required,min=3,max=20"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Username    string `json:"username" binding:"required,min=3,max=20"`
+	Email       string `json:"email" binding:"required,email"`
+	Password    string `json:"password" binding:"required"` // Checked against the site password policy
+	InviteToken string `json:"invite_token,omitempty"`      // Required while registration is invite-only
 }
 
 type UserLogin struct {
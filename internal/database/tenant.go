@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Tenant maps a site onto its own database
+type Tenant struct {
+	Database string   `json:"database"`
+	Hosts    []string `json:"hosts,omitempty"` // Host names that select this tenant
+}
+
+// TenantRegistry resolves tenants to database handles that share one client
+type TenantRegistry struct {
+	base    *DB
+	tenants map[string]Tenant
+	hosts   map[string]string // Lower-cased host name to tenant ID
+
+	mu      sync.Mutex
+	handles map[string]*DB
+}
+
+// LoadTenantRegistry reads a JSON file of the form
+//
+//	{"tenants": {"site-a": {"database": "site_a", "hosts": ["a.example.com"]}}}
+//
+// and returns a registry whose handles share base's client
+func LoadTenantRegistry(base *DB, path string) (*TenantRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+
+	var file struct {
+		Tenants map[string]Tenant `json:"tenants"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse tenants file: %w", err)
+	}
+	if len(file.Tenants) == 0 {
+		return nil, fmt.Errorf("tenants file %s declares no tenants", path)
+	}
+
+	registry := &TenantRegistry{
+		base:    base,
+		tenants: file.Tenants,
+		hosts:   make(map[string]string),
+		handles: make(map[string]*DB),
+	}
+
+	for id, tenant := range file.Tenants {
+		if tenant.Database == "" {
+			return nil, fmt.Errorf("tenant %s has no database", id)
+		}
+		for _, host := range tenant.Hosts {
+			host = strings.ToLower(host)
+			if other, taken := registry.hosts[host]; taken {
+				return nil, fmt.Errorf("host %s is claimed by tenants %s and %s", host, other, id)
+			}
+			registry.hosts[host] = id
+		}
+	}
+
+	return registry, nil
+}
+
+// IDs returns the configured tenant IDs in sorted order
+func (r *TenantRegistry) IDs() []string {
+	ids := make([]string, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Lookup returns the tenant ID selected by an explicit tenant ID or, failing
+// that, by host name (a port suffix is ignored)
+func (r *TenantRegistry) Lookup(tenantID, host string) (string, bool) {
+	if tenantID != "" {
+		_, exists := r.tenants[tenantID]
+		return tenantID, exists
+	}
+
+	if i := strings.LastIndex(host, ":"); i != -1 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	id, exists := r.hosts[strings.ToLower(host)]
+	return id, exists
+}
+
+// DB returns the database handle for a tenant, creating it on first use
+func (r *TenantRegistry) DB(tenantID string) (*DB, error) {
+	tenant, exists := r.tenants[tenantID]
+	if !exists {
+		return nil, fmt.Errorf("unknown tenant: %s", tenantID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if handle, exists := r.handles[tenantID]; exists {
+		return handle, nil
+	}
+
+	handle := &DB{
+		Client:   r.base.Client,
+		Database: r.base.Client.Database(tenant.Database),
+		tx:       r.base.tx,
+	}
+	r.handles[tenantID] = handle
+	return handle, nil
+}
+
+type contextKey struct{}
+
+// WithDB returns a context carrying a request-scoped database handle
+func WithDB(ctx context.Context, db *DB) context.Context {
+	return context.WithValue(ctx, contextKey{}, db)
+}
+
+// FromContext returns the request-scoped database handle, or fallback when the
+// context has none (single-tenant mode)
+func FromContext(ctx context.Context, fallback *DB) *DB {
+	if db, ok := ctx.Value(contextKey{}).(*DB); ok && db != nil {
+		return db
+	}
+	return fallback
+}
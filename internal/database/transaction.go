@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WithTransaction runs fn inside a multi-document transaction on deployments that
+// support one (replica sets and sharded clusters). On a standalone server fn runs
+// once within a plain session, so its writes are applied sequentially without
+// atomicity. fn should use sc as the context for every operation it performs.
+func (db *DB) WithTransaction(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
+	session, err := db.Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	if !db.supportsTransactions(ctx) {
+		return mongo.WithSession(ctx, session, fn)
+	}
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// txSupport caches whether a deployment supports transactions
+type txSupport struct {
+	mu        sync.Mutex
+	checked   bool
+	supported bool
+}
+
+// supportsTransactions reports whether the server is a replica set member or a
+// mongos router. The result is cached after the first successful check.
+func (db *DB) supportsTransactions(ctx context.Context) bool {
+	tx := db.tx
+	if tx == nil {
+		tx = &txSupport{}
+	}
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.checked {
+		return tx.supported
+	}
+
+	var hello bson.M
+	if err := db.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false
+	}
+
+	_, isReplicaSet := hello["setName"]
+	isMongos := hello["msg"] == "isdbgrid"
+
+	tx.supported = isReplicaSet || isMongos
+	tx.checked = true
+	return tx.supported
+}
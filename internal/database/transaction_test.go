@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testDB connects to MONGO_TEST_URI and returns a scratch database that is
+// dropped when the test ends. Tests using it are skipped without the variable.
+func testDB(t *testing.T) *DB {
+	t.Helper()
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	db, err := Connect(uri, fmt.Sprintf("gocms_test_%d", time.Now().UnixNano()), Consistency{})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		db.Database.Drop(ctx)
+		db.Disconnect(ctx)
+	})
+	return db
+}
+
+func TestWithTransactionFallsBackWhenUnsupported(t *testing.T) {
+	// Nothing listens on port 1, so the deployment check fails and counts as standalone
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+	db := &DB{Client: client, Database: client.Database("cms_test"), tx: &txSupport{}}
+
+	calls := 0
+	err = db.WithTransaction(context.Background(), func(sc mongo.SessionContext) error {
+		calls++
+		if mongo.SessionFromContext(sc) == nil {
+			t.Error("function ran without a session")
+		}
+		return nil
+	})
+	if err != nil || calls != 1 {
+		t.Fatalf("WithTransaction = %v after %d calls, want the function run once", err, calls)
+	}
+
+	// A failed check is not cached, so a later call asks the server again
+	if db.tx.checked {
+		t.Error("failed deployment check was cached")
+	}
+
+	want := errors.New("write failed")
+	if err := db.WithTransaction(context.Background(), func(mongo.SessionContext) error { return want }); !errors.Is(err, want) {
+		t.Errorf("WithTransaction = %v, want the function's error", err)
+	}
+}
+
+func TestWithTransactionStandalone(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	// Force the standalone path whatever the test deployment is
+	db.tx = &txSupport{checked: true}
+
+	err := db.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		if _, err := db.Collection("themes").UpdateMany(sc, bson.M{}, bson.M{"$set": bson.M{"is_active": false}}); err != nil {
+			return err
+		}
+		_, err := db.Collection("themes").InsertOne(sc, bson.M{"name": "default", "is_active": true})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := db.Collection("themes").CountDocuments(ctx, bson.M{"is_active": true}); err != nil || n != 1 {
+		t.Fatalf("active themes = %d (%v), want the write applied", n, err)
+	}
+
+	// Without a transaction, writes made before a failure are kept
+	failed := errors.New("second write failed")
+	err = db.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		if _, err := db.Collection("themes").InsertOne(sc, bson.M{"name": "partial"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("WithTransaction = %v, want the function's error", err)
+	}
+	if n, _ := db.Collection("themes").CountDocuments(ctx, bson.M{"name": "partial"}); n != 1 {
+		t.Errorf("partial writes = %d, want 1 on a standalone server", n)
+	}
+}
+
+func TestWithTransactionRollsBack(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	if !db.supportsTransactions(ctx) {
+		t.Skip("test deployment does not support transactions")
+	}
+
+	// Collections cannot be created inside a transaction on older servers
+	if _, err := db.Collection("themes").InsertOne(ctx, bson.M{"name": "default"}); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("second write failed")
+	err := db.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		if _, err := db.Collection("themes").InsertOne(sc, bson.M{"name": "partial"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("WithTransaction = %v, want the function's error", err)
+	}
+	if n, _ := db.Collection("themes").CountDocuments(ctx, bson.M{"name": "partial"}); n != 0 {
+		t.Errorf("partial writes = %d, want the transaction rolled back", n)
+	}
+}
@@ -0,0 +1,70 @@
+// Package events provides a small in-process publish/subscribe bus used to
+// announce lifecycle changes such as theme activation.
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Wildcard subscribes a handler to every event
+const Wildcard = "*"
+
+// Event is a named notification with an arbitrary payload
+type Event struct {
+	Name string                 `json:"name"`
+	Data map[string]interface{} `json:"data,omitempty"`
+	Time time.Time              `json:"time"`
+}
+
+// Handler receives emitted events
+type Handler func(event Event)
+
+// Bus dispatches events to subscribed handlers
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus creates an empty event bus
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[string][]Handler),
+	}
+}
+
+// Subscribe registers a handler for the named event, or for all events with Wildcard
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Emit delivers an event synchronously to its subscribers, then to wildcard
+// subscribers. A panicking handler is logged and does not affect the others.
+func (b *Bus) Emit(name string, data map[string]interface{}) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[name])+len(b.handlers[Wildcard]))
+	handlers = append(handlers, b.handlers[name]...)
+	handlers = append(handlers, b.handlers[Wildcard]...)
+	b.mu.RUnlock()
+
+	event := Event{Name: name, Data: data, Time: time.Now()}
+	for _, handler := range handlers {
+		dispatch(handler, event)
+	}
+}
+
+func dispatch(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[EVENTS] Handler for %s panicked: %v", event.Name, r)
+		}
+	}()
+	handler(event)
+}
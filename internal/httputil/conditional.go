@@ -0,0 +1,65 @@
+package httputil
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag returns a strong entity tag for body, derived from a hash of its JSON
+// encoding. Map keys are encoded in sorted order, so equal values share a tag.
+func ETag(body interface{}) (string, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf(`"%x"`, sum[:16]), nil
+}
+
+// NotModified sets the ETag and, when modified is non-zero, Last-Modified headers
+// and answers conditional requests. It writes 304 and returns true when the client's
+// copy is current. If-None-Match takes precedence over If-Modified-Since.
+func NotModified(c *gin.Context, etag string, modified time.Time) bool {
+	c.Header("ETag", etag)
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if header := c.GetHeader("If-None-Match"); header != "" {
+		if !etagMatches(header, etag) {
+			return false
+		}
+		c.Status(http.StatusNotModified)
+		return true
+	}
+
+	if header := c.GetHeader("If-Modified-Since"); header != "" && !modified.IsZero() {
+		since, err := http.ParseTime(header)
+		if err != nil || modified.Truncate(time.Second).After(since) {
+			return false
+		}
+		c.Status(http.StatusNotModified)
+		return true
+	}
+
+	return false
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the weak
+// comparison RFC 9110 requires for this header
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
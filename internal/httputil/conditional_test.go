@@ -0,0 +1,71 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestETag(t *testing.T) {
+	a, err := ETag(map[string]interface{}{"x": 1, "nested": map[string]interface{}{"y": "z"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ETag(map[string]interface{}{"nested": map[string]interface{}{"y": "z"}, "x": 1})
+	if a != b {
+		t.Errorf("equal values got different tags %s and %s", a, b)
+	}
+
+	c, _ := ETag(map[string]interface{}{"x": 1, "nested": map[string]interface{}{"y": "changed"}})
+	if a == c {
+		t.Error("a nested change kept the same tag")
+	}
+	if a[0] != '"' || a[len(a)-1] != '"' {
+		t.Errorf("tag %s is not quoted", a)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const etag = `"abc"`
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 500, time.UTC)
+
+	tests := map[string]struct {
+		headers map[string]string
+		want    bool
+	}{
+		"unconditional":            {nil, false},
+		"matching tag":             {map[string]string{"If-None-Match": `"abc"`}, true},
+		"weak matching tag":        {map[string]string{"If-None-Match": `W/"abc"`}, true},
+		"tag in list":              {map[string]string{"If-None-Match": `"old", "abc"`}, true},
+		"wildcard":                 {map[string]string{"If-None-Match": `*`}, true},
+		"other tag":                {map[string]string{"If-None-Match": `"old"`}, false},
+		"not modified since":       {map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+		"modified since":           {map[string]string{"If-Modified-Since": modified.Add(-time.Minute).Format(http.TimeFormat)}, false},
+		"invalid date":             {map[string]string{"If-Modified-Since": "yesterday"}, false},
+		"tag takes precedence":     {map[string]string{"If-None-Match": `"old"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, false},
+		"matching tag, older date": {map[string]string{"If-None-Match": `"abc"`, "If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, true},
+	}
+
+	for name, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		for key, value := range tt.headers {
+			c.Request.Header.Set(key, value)
+		}
+
+		if got := NotModified(c, etag, modified); got != tt.want {
+			t.Errorf("%s: NotModified = %v, want %v", name, got, tt.want)
+		}
+		if w.Header().Get("ETag") != etag || w.Header().Get("Last-Modified") != modified.Format(http.TimeFormat) {
+			t.Errorf("%s: headers = %v", name, w.Header())
+		}
+		if c.Writer.Status() == http.StatusNotModified != tt.want {
+			t.Errorf("%s: status %d", name, c.Writer.Status())
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package httputil holds small helpers shared by HTTP handlers.
+package httputil
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// forwardedTrustedKey marks requests whose forwarding headers were set by a trusted proxy
+const forwardedTrustedKey = "forwarded_trusted"
+
+// TrustProxies returns middleware that lets ResourceURL honor X-Forwarded-Proto
+// and X-Forwarded-Host on requests sent by one of proxies, given as IPs or CIDRs.
+// Entries that are neither are skipped; config.Load rejects them.
+func TrustProxies(proxies []string) gin.HandlerFunc {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		if _, network, err := net.ParseCIDR(proxyCIDR(proxy)); err == nil {
+			networks = append(networks, network)
+		}
+	}
+
+	return func(c *gin.Context) {
+		if peer := net.ParseIP(c.RemoteIP()); peer != nil {
+			for _, network := range networks {
+				if network.Contains(peer) {
+					c.Set(forwardedTrustedKey, true)
+					break
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// proxyCIDR turns a single proxy address into a CIDR covering just that address
+func proxyCIDR(proxy string) string {
+	switch {
+	case strings.Contains(proxy, "/"):
+		return proxy
+	case strings.Contains(proxy, ":"):
+		return proxy + "/128"
+	default:
+		return proxy + "/32"
+	}
+}
+
+// ResourceURL returns the absolute URL of path on the host the request was sent
+// to. X-Forwarded-Proto and X-Forwarded-Host are honored when the request came
+// through a proxy trusted by TrustProxies, so the URL is correct behind it;
+// from anyone else they are ignored.
+func ResourceURL(c *gin.Context, path string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	host := c.Request.Host
+
+	if c.GetBool(forwardedTrustedKey) {
+		if proto := forwardedValue(c.GetHeader("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwardedHost := forwardedValue(c.GetHeader("X-Forwarded-Host")); forwardedHost != "" {
+			host = forwardedHost
+		}
+	}
+
+	return scheme + "://" + host + "/" + strings.TrimLeft(path, "/")
+}
+
+// Created sets the Location header to the new resource's URL and writes a 201 response
+func Created(c *gin.Context, path string, body interface{}) {
+	c.Header("Location", ResourceURL(c, path))
+	c.JSON(http.StatusCreated, body)
+}
+
+// forwardedValue returns the first entry of a comma-separated forwarding header
+func forwardedValue(header string) string {
+	first, _, _ := strings.Cut(header, ",")
+	return strings.ToLower(strings.TrimSpace(first))
+}
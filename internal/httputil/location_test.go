@@ -0,0 +1,52 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResourceURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	forwarded := map[string]string{"X-Forwarded-Proto": "https, http", "X-Forwarded-Host": "cms.example.com"}
+
+	tests := map[string]struct {
+		proxies []string
+		peer    string
+		headers map[string]string
+		want    string
+	}{
+		"direct":                   {nil, "203.0.113.9:4000", nil, "http://backend:8080/api/v1/content/42"},
+		"forwarded, no proxies":    {nil, "203.0.113.9:4000", forwarded, "http://backend:8080/api/v1/content/42"},
+		"forwarded by a stranger":  {[]string{"10.0.0.0/8"}, "203.0.113.9:4000", forwarded, "http://backend:8080/api/v1/content/42"},
+		"forwarded by a proxy":     {[]string{"10.0.0.0/8"}, "10.1.2.3:4000", forwarded, "https://cms.example.com/api/v1/content/42"},
+		"forwarded by a single IP": {[]string{"10.1.2.3"}, "10.1.2.3:4000", forwarded, "https://cms.example.com/api/v1/content/42"},
+		"forwarded over IPv6":      {[]string{"::1"}, "[::1]:4000", forwarded, "https://cms.example.com/api/v1/content/42"},
+		"unknown scheme":           {[]string{"10.1.2.3"}, "10.1.2.3:4000", map[string]string{"X-Forwarded-Proto": "gopher"}, "http://backend:8080/api/v1/content/42"},
+	}
+
+	for name, tt := range tests {
+		r := gin.New()
+		r.Use(TrustProxies(tt.proxies))
+		r.POST("/content", func(c *gin.Context) {
+			Created(c, "/api/v1/content/42", gin.H{})
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "http://backend:8080/content", nil)
+		req.RemoteAddr = tt.peer
+		for key, value := range tt.headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Errorf("%s: status %d, want 201", name, w.Code)
+		}
+		if got := w.Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: Location = %s, want %s", name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLogConfig selects which requests BodyLogger records and how much of each body
+type BodyLogConfig struct {
+	Paths        []string // Path prefixes to log; empty logs every path
+	MaxBytes     int      // Bytes kept from each body; longer bodies are truncated
+	RedactFields []string // Field names whose values are masked, matched case-insensitively by substring
+}
+
+// DefaultRedactFields are masked in every logged body
+var DefaultRedactFields = []string{"password", "token", "secret", "authorization", "api_key", "apikey"}
+
+const redacted = "[REDACTED]"
+
+// BodyLogger logs truncated, redacted request and response bodies for debugging.
+// The request body is teed as the handler reads it, so handlers see it unchanged.
+// Multipart and binary bodies are skipped. It is opt-in and must not be enabled
+// where bodies may contain data that cannot appear in logs.
+func BodyLogger(config BodyLogConfig) gin.HandlerFunc {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = 4096
+	}
+	if len(config.RedactFields) == 0 {
+		config.RedactFields = DefaultRedactFields
+	}
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if !matchesPrefix(path, config.Paths) {
+			c.Next()
+			return
+		}
+
+		request := &cappedBuffer{limit: config.MaxBytes}
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			c.Request.Body = teeReadCloser{io.TeeReader(c.Request.Body, request), c.Request.Body}
+		}
+
+		response := &bodyLogWriter{ResponseWriter: c.Writer, body: cappedBuffer{limit: config.MaxBytes}}
+		c.Writer = response
+
+		c.Next()
+
+		method := c.Request.Method
+		log.Printf("[BODY] %s %s request: %s", method, path,
+			formatBody(c.Request.Header.Get("Content-Type"), request, config.RedactFields))
+		log.Printf("[BODY] %s %s response %d: %s", method, path, c.Writer.Status(),
+			formatBody(response.Header().Get("Content-Type"), &response.body, config.RedactFields))
+	}
+}
+
+func matchesPrefix(path string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// cappedBuffer keeps the first limit bytes written to it and counts the rest
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+	total int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.total += len(p)
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) truncated() bool {
+	return b.total > b.buf.Len()
+}
+
+// bodyLogWriter copies the response body into a capped buffer as it is written
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body cappedBuffer
+}
+
+func (w *bodyLogWriter) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.body.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// formatBody renders a captured body for the log, redacting sensitive fields
+func formatBody(contentType string, body *cappedBuffer, fields []string) string {
+	if body.total == 0 {
+		return "(empty)"
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !isTextMedia(mediaType) {
+		return "(" + mediaTypeOrUnknown(mediaType) + " body omitted, " + strconv.Itoa(body.total) + " bytes)"
+	}
+
+	text := RedactBody(mediaType, body.buf.Bytes(), fields)
+	if body.truncated() {
+		text += "... (truncated, " + strconv.Itoa(body.total) + " bytes)"
+	}
+	return text
+}
+
+func isTextMedia(mediaType string) bool {
+	switch {
+	case mediaType == "application/json",
+		strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/x-www-form-urlencoded",
+		mediaType == "application/xml",
+		strings.HasPrefix(mediaType, "text/"):
+		return true
+	}
+	return false
+}
+
+func mediaTypeOrUnknown(mediaType string) string {
+	if mediaType == "" {
+		return "untyped"
+	}
+	return mediaType
+}
+
+// RedactBody masks the values of sensitive fields in a JSON or form body.
+// JSON that cannot be parsed, such as a truncated body, is redacted textually.
+func RedactBody(mediaType string, body []byte, fields []string) string {
+	if mediaType == "application/x-www-form-urlencoded" {
+		values, err := url.ParseQuery(string(body))
+		if err == nil {
+			for key := range values {
+				if isSensitive(key, fields) {
+					values[key] = []string{redacted}
+				}
+			}
+			return values.Encode()
+		}
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err == nil {
+		if out, err := json.Marshal(redactValue(data, fields)); err == nil {
+			return string(out)
+		}
+	}
+
+	return jsonFieldPattern.ReplaceAllStringFunc(string(body), func(match string) string {
+		parts := jsonFieldPattern.FindStringSubmatch(match)
+		if !isSensitive(parts[1], fields) {
+			return match
+		}
+		return `"` + parts[1] + `":"` + redacted + `"`
+	})
+}
+
+// jsonFieldPattern matches a JSON string field, including one cut off by truncation
+var jsonFieldPattern = regexp.MustCompile(`"([^"\\]+)"\s*:\s*"(?:[^"\\]|\\.)*"?`)
+
+func redactValue(value interface{}, fields []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSensitive(key, fields) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(item, fields)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item, fields)
+		}
+	}
+	return value
+}
+
+func isSensitive(key string, fields []string) bool {
+	key = strings.ToLower(key)
+	for _, field := range fields {
+		if strings.Contains(key, strings.ToLower(field)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		body      string
+		want      string
+	}{
+		{
+			name:      "json",
+			mediaType: "application/json",
+			body:      `{"email":"a@example.com","password":"hunter2","profile":{"apiKey":"k","name":"Ada"},"sessions":[{"refresh_token":"r"}]}`,
+			want:      `{"email":"a@example.com","password":"[REDACTED]","profile":{"apiKey":"[REDACTED]","name":"Ada"},"sessions":[{"refresh_token":"[REDACTED]"}]}`,
+		},
+		{
+			name:      "form",
+			mediaType: "application/x-www-form-urlencoded",
+			body:      "user=ada&Password=hunter2",
+			want:      "Password=%5BREDACTED%5D&user=ada",
+		},
+		{
+			name:      "truncated json",
+			mediaType: "application/json",
+			body:      `{"name":"Ada","client_secret":"abc12`,
+			want:      `{"name":"Ada","client_secret":"[REDACTED]"`,
+		},
+		{
+			name:      "nothing sensitive",
+			mediaType: "application/json",
+			body:      `{"title":"Hello"}`,
+			want:      `{"title":"Hello"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactBody(tt.mediaType, []byte(tt.body), DefaultRedactFields); got != tt.want {
+				t.Errorf("RedactBody = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// captureLog sends the standard logger's output to a buffer for the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(previous) })
+	return &buf
+}
+
+func bodyLogRouter(config BodyLogConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(BodyLogger(config))
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, c.ContentType(), body)
+	}
+	r.POST("/api/v1/plugins/echo", echo)
+	r.POST("/api/v1/auth/login", echo)
+	return r
+}
+
+func TestBodyLoggerRedactsWithoutConsumingTheBody(t *testing.T) {
+	logs := captureLog(t)
+	r := bodyLogRouter(BodyLogConfig{Paths: []string{"/api/v1/plugins"}})
+
+	body := `{"user":"ada","token":"abc"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/plugins/echo", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != body {
+		t.Errorf("handler read %q, want the body unchanged", w.Body.String())
+	}
+	out := logs.String()
+	if strings.Contains(out, "abc") {
+		t.Errorf("token value was logged: %s", out)
+	}
+	if strings.Count(out, `"token":"[REDACTED]"`) != 2 {
+		t.Errorf("want the request and response redacted, got: %s", out)
+	}
+
+	// Paths outside the configured prefixes are not logged
+	logs.Reset()
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if logs.Len() != 0 {
+		t.Errorf("unselected path was logged: %s", logs.String())
+	}
+}
+
+func TestBodyLoggerSkipsBinaryAndTruncates(t *testing.T) {
+	logs := captureLog(t)
+	r := bodyLogRouter(BodyLogConfig{MaxBytes: 16})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/plugins/echo", strings.NewReader("--x\r\nfile contents\r\n--x--"))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if out := logs.String(); strings.Contains(out, "file contents") || !strings.Contains(out, "multipart/form-data body omitted") {
+		t.Errorf("multipart body was not skipped: %s", out)
+	}
+
+	logs.Reset()
+	long := `{"title":"` + strings.Repeat("x", 100) + `"}`
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/plugins/echo", strings.NewReader(long))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != long {
+		t.Error("truncating the log truncated the body the handler read")
+	}
+	if out := logs.String(); strings.Contains(out, strings.Repeat("x", 17)) || !strings.Contains(out, "(truncated, 112 bytes)") {
+		t.Errorf("long body was not truncated to 16 bytes: %s", out)
+	}
+}
@@ -2,27 +2,22 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// CORS middleware handles Cross-Origin Resource Sharing
-func CORS() gin.HandlerFunc {
+// CORS middleware handles Cross-Origin Resource Sharing for the given origins.
+// "*" allows any origin; credentials are always allowed.
+func CORS(allowedOrigins []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
 
-		// List of allowed origins (in production, this should be configurable)
-		allowedOrigins := []string{
-			"http://localhost:3000",
-			"http://localhost:3001",
-			"http://localhost:8080",
-			"https://yourdomain.com",
-		}
-
 		// Check if origin is allowed
 		allowed := false
 		for _, allowedOrigin := range allowedOrigins {
-			if origin == allowedOrigin {
+			if origin == allowedOrigin || allowedOrigin == "*" {
 				allowed = true
 				break
 			}
@@ -90,7 +85,7 @@ func CORSWithConfig(config CORSConfig) gin.HandlerFunc {
 		}
 
 		if config.MaxAge > 0 {
-			c.Header("Access-Control-Max-Age", string(rune(config.MaxAge)))
+			c.Header("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
 		}
 
 		// Handle preflight OPTIONS request
@@ -103,6 +98,62 @@ func CORSWithConfig(config CORSConfig) gin.HandlerFunc {
 	}
 }
 
+// CORSPolicies applies a different CORS policy to each registered path prefix, so
+// that e.g. the admin API can accept fewer origins than public plugin routes. It
+// is installed once on the engine rather than per route group so preflight
+// requests, which match no route, still get the right headers.
+//
+// Precedence: the longest registered prefix that matches the request path at a
+// segment boundary wins; paths matching no prefix use the fallback policy.
+type CORSPolicies struct {
+	fallback gin.HandlerFunc
+	prefixes []string
+	handlers map[string]gin.HandlerFunc
+}
+
+// NewCORSPolicies creates a policy set that uses fallback for unregistered paths
+func NewCORSPolicies(fallback gin.HandlerFunc) *CORSPolicies {
+	return &CORSPolicies{
+		fallback: fallback,
+		handlers: make(map[string]gin.HandlerFunc),
+	}
+}
+
+// Add registers the policy for a path prefix such as "/api/v1/admin", replacing
+// any policy already registered for it
+func (p *CORSPolicies) Add(prefix string, config CORSConfig) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if _, exists := p.handlers[prefix]; !exists {
+		p.prefixes = append(p.prefixes, prefix)
+	}
+	p.handlers[prefix] = CORSWithConfig(config)
+}
+
+// Handler returns the middleware applying the policy chosen for each request
+func (p *CORSPolicies) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p.policyFor(c.Request.URL.Path)(c)
+	}
+}
+
+func (p *CORSPolicies) policyFor(path string) gin.HandlerFunc {
+	best := ""
+	var handler gin.HandlerFunc
+	for _, prefix := range p.prefixes {
+		if len(prefix) <= len(best) && handler != nil {
+			continue
+		}
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			best = prefix
+			handler = p.handlers[prefix]
+		}
+	}
+	if handler == nil {
+		return p.fallback
+	}
+	return handler
+}
+
 type CORSConfig struct {
 	AllowedOrigins   []string
 	AllowedMethods   []string
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"go-cms/internal/database/models"
+	"go-cms/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header carrying a client-chosen idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the keys accepted from clients
+const maxIdempotencyKeyLength = 255
+
+// Idempotency makes mutating requests sent with an Idempotency-Key header safe to
+// retry. The first request with a key runs normally and its response is stored
+// for ttl; a retry with the same key from the same user to the same endpoint gets
+// the stored response, marked with Idempotent-Replayed, without running the
+// handler again. Reusing a key with a different request body is rejected with 422,
+// and a retry that arrives while the first request is still running with 409.
+// Server errors are not stored, so the operation can be retried.
+// It must run after authentication so the user is known.
+func Idempotency(store repository.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !isMutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Idempotency-Key must be at most 255 characters",
+			})
+			return
+		}
+
+		hash, err := hashBody(c.Request)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		now := time.Now()
+		record := &models.IdempotencyRecord{
+			Key:         key,
+			UserID:      c.GetString("user_id"),
+			Endpoint:    c.Request.Method + " " + c.Request.URL.Path,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		existing, err := store.Reserve(ctx, record)
+		if err != nil {
+			log.Printf("[IDEMPOTENCY] Failed to reserve key for %s: %v", record.Endpoint, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to check idempotency key",
+			})
+			return
+		}
+		if existing != nil {
+			if existing.RequestHash != record.RequestHash {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error": "Idempotency-Key was already used with a different request body",
+				})
+				return
+			}
+			if !existing.Completed {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error": "A request with this Idempotency-Key is still being processed",
+				})
+				return
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(existing.Status, existing.ContentType, existing.Body)
+			c.Abort()
+			return
+		}
+
+		// A panicking handler must not leave the key reserved until it expires
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				store.Release(ctx, record.ID)
+				panic(recovered)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(ctx, record.ID); err != nil {
+				log.Printf("[IDEMPOTENCY] Failed to release key for %s: %v", record.Endpoint, err)
+			}
+			return
+		}
+		if err := store.Complete(ctx, record.ID, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("[IDEMPOTENCY] Failed to store response for %s: %v", record.Endpoint, err)
+		}
+	}
+}
+
+// hashBody returns the hex SHA-256 of the request body and puts the body back
+// for the handler
+func hashBody(r *http.Request) (string, error) {
+	if r.Body == nil {
+		return hex.EncodeToString(sha256.New().Sum(nil)), nil
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// responseRecorder keeps a copy of the response body as it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-cms/internal/database/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryIdempotencyStore is an in-memory repository.IdempotencyRepository
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[primitive.ObjectID]*models.IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[primitive.ObjectID]*models.IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, existing := range s.records {
+		if existing.Key != record.Key || existing.UserID != record.UserID || existing.Endpoint != record.Endpoint {
+			continue
+		}
+		if !existing.ExpiresAt.After(time.Now()) {
+			delete(s.records, id)
+			break
+		}
+		copied := *existing
+		return &copied, nil
+	}
+	record.ID = primitive.NewObjectID()
+	copied := *record
+	s.records[record.ID] = &copied
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, id primitive.ObjectID, status int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[id]; ok {
+		record.Completed = true
+		record.Status = status
+		record.ContentType = contentType
+		record.Body = append([]byte(nil), body...)
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+func idempotentEngine(store *memoryIdempotencyStore, ttl time.Duration, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", "u1") })
+	r.Use(Idempotency(store, ttl))
+	r.POST("/things", func(c *gin.Context) {
+		*calls++
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": *calls, "name": body["name"]})
+	})
+	return r
+}
+
+func postWithKey(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	calls := 0
+	r := idempotentEngine(newMemoryIdempotencyStore(), time.Hour, &calls)
+
+	first := postWithKey(r, "k1", `{"name":"a"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first: status %d: %s", first.Code, first.Body)
+	}
+
+	second := postWithKey(r, "k1", `{"name":"a"}`)
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Fatalf("replay: status %d body %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("replay is not marked with Idempotent-Replayed")
+	}
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+}
+
+func TestIdempotencyRejectsDifferentBody(t *testing.T) {
+	calls := 0
+	r := idempotentEngine(newMemoryIdempotencyStore(), time.Hour, &calls)
+
+	postWithKey(r, "k1", `{"name":"a"}`)
+	w := postWithKey(r, "k1", `{"name":"b"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d, want 422", w.Code)
+	}
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+}
+
+func TestIdempotencyHandlerSeesBody(t *testing.T) {
+	calls := 0
+	r := idempotentEngine(newMemoryIdempotencyStore(), time.Hour, &calls)
+
+	w := postWithKey(r, "k1", `{"name":"kept"}`)
+	if !strings.Contains(w.Body.String(), `"name":"kept"`) {
+		t.Fatalf("handler did not receive the body: %s", w.Body)
+	}
+}
+
+func TestIdempotencyExpiredKeyRunsAgain(t *testing.T) {
+	calls := 0
+	r := idempotentEngine(newMemoryIdempotencyStore(), -time.Second, &calls)
+
+	postWithKey(r, "k1", `{"name":"a"}`)
+	postWithKey(r, "k1", `{"name":"a"}`)
+	if calls != 2 {
+		t.Fatalf("handler ran %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit rejects request bodies larger than maxBytes with 413. It bounds
+// JSON and other non-multipart endpoints independently of the multipart memory setting.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limitBody(c, maxBytes, "Request body too large")
+	}
+}
+
+// ContentSizeLimit rejects content bodies larger than the limit returned by maxBytes.
+// The limit is read on every request so changes to the site setting apply immediately.
+func ContentSizeLimit(maxBytes func() int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limitBody(c, maxBytes(), "Content too large")
+	}
+}
+
+// limitBody enforces limit on the request body; a limit of zero or less disables it
+func limitBody(c *gin.Context, limit int64, message string) {
+	if limit <= 0 {
+		c.Next()
+		return
+	}
+
+	// Reject up front when the client declares an oversized body
+	if c.Request.ContentLength > limit {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":     message,
+			"max_bytes": limit,
+		})
+		return
+	}
+
+	// Guard against chunked or understated bodies while they are read
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+	c.Next()
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go-cms/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestContentSizeLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var limit atomic.Int64
+	limit.Store(64)
+
+	r := gin.New()
+	r.POST("/content", ContentSizeLimit(limit.Load), func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			validation.BindError(c, err)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	// A JSON object of exactly n bytes
+	body := func(n int) string {
+		return `{"title":"` + strings.Repeat("x", n-len(`{"title":""}`)) + `"}`
+	}
+	post := func(data string, chunked bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/content", strings.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		if chunked {
+			// Hide the length so only the reader guards the limit
+			req.ContentLength = -1
+			req.Body = io.NopCloser(strings.NewReader(data))
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, chunked := range []bool{false, true} {
+		if w := post(body(64), chunked); w.Code != http.StatusCreated {
+			t.Errorf("body at the limit (chunked=%v): status %d, want 201", chunked, w.Code)
+		}
+		w := post(body(65), chunked)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("body one byte over (chunked=%v): status %d, want 413", chunked, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `"max_bytes":64`) {
+			t.Errorf("413 body %s does not report the limit", w.Body)
+		}
+	}
+
+	// Changes to the setting apply to the next request
+	limit.Store(128)
+	if w := post(body(100), false); w.Code != http.StatusCreated {
+		t.Errorf("after raising the limit: status %d, want 201", w.Code)
+	}
+
+	// Zero disables the limit
+	limit.Store(0)
+	if w := post(body(4096), false); w.Code != http.StatusCreated {
+		t.Errorf("without a limit: status %d, want 201", w.Code)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(BodyLimit(1024))
+	r.POST("/settings", func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			validation.BindError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	post := func(data string, chunked bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/settings", strings.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		if chunked {
+			req.ContentLength = -1
+			req.Body = io.NopCloser(strings.NewReader(data))
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post(`{"custom_css":"body{}"}`, false); w.Code != http.StatusOK {
+		t.Errorf("small body: status %d, want 200", w.Code)
+	}
+
+	oversized := `{"custom_css":"` + strings.Repeat("a", 4096) + `"}`
+	for _, chunked := range []bool{false, true} {
+		w := post(oversized, chunked)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("oversized body (chunked=%v): status %d, want 413", chunked, w.Code)
+		}
+		if want := `{"error":"Request body too large","max_bytes":1024}`; w.Body.String() != want {
+			t.Errorf("oversized body (chunked=%v): body %s, want %s", chunked, w.Body, want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceStatus describes whether the site is in maintenance mode and what
+// visitors are told while it is
+type MaintenanceStatus struct {
+	Enabled    bool   `json:"enabled"`
+	Message    string `json:"message"`
+	RetryAfter int64  `json:"retry_after"` // Seconds; 0 omits the Retry-After header
+}
+
+// Maintenance answers requests with 503 while maintenance mode is enabled. Paths
+// starting with one of the bypass prefixes, and requests for which isAdmin
+// reports true, are let through so admins can finish the upgrade and turn it off.
+// The status is read on every request so toggling takes effect immediately.
+func Maintenance(status func() MaintenanceStatus, isAdmin func(c *gin.Context) bool, bypass ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current := status()
+		if !current.Enabled {
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.Path
+		for _, prefix := range bypass {
+			if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+				c.Next()
+				return
+			}
+		}
+
+		if isAdmin(c) {
+			c.Next()
+			return
+		}
+
+		if current.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.FormatInt(current.RetryAfter, 10))
+		}
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":       current.Message,
+			"maintenance": true,
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-cms/internal/clock"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit allows each user at most limit requests per window and answers the
+// rest with 429 and a Retry-After header. Users are identified by the user_id set
+// by authentication, falling back to the client IP. A limit of zero or less disables it.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	return rateLimit(limit, window, clock.Real())
+}
+
+func rateLimit(limit int, window time.Duration, clk clock.Clock) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	limiter := &fixedWindow{limit: limit, window: window, clock: clk, windows: make(map[string]*rateWindow)}
+	return func(c *gin.Context) {
+		key := c.GetString("user_id")
+		if key == "" {
+			key = c.ClientIP()
+		}
+
+		if wait := limiter.take(key); wait > 0 {
+			seconds := int(math.Ceil(wait.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many requests",
+				"retry_after": seconds,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// fixedWindow counts requests per key in windows starting at each key's first request
+type fixedWindow struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	clock   clock.Clock
+	windows map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// take records a request for key. It returns zero when the request is allowed,
+// otherwise how long until the key's window resets.
+func (f *fixedWindow) take(key string) time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.clock.Now()
+
+	// Forget finished windows so the map only holds recently active keys
+	for k, w := range f.windows {
+		if now.Sub(w.start) >= f.window {
+			delete(f.windows, k)
+		}
+	}
+
+	w, ok := f.windows[key]
+	if !ok {
+		f.windows[key] = &rateWindow{start: now, count: 1}
+		return 0
+	}
+	if w.count >= f.limit {
+		return w.start.Add(f.window).Sub(now)
+	}
+	w.count++
+	return 0
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-cms/internal/clock"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitPerUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", c.GetHeader("X-User")) })
+	r.POST("/upload", rateLimit(2, time.Minute, clk), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	upload := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/upload", nil)
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := upload("alice"); w.Code != http.StatusOK {
+			t.Fatalf("upload %d: status %d", i+1, w.Code)
+		}
+	}
+
+	clk.Advance(20 * time.Second)
+	w := upload("alice")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third upload: status %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "40" {
+		t.Errorf("Retry-After %q, want 40", got)
+	}
+
+	// Other users have their own window
+	if w := upload("bob"); w.Code != http.StatusOK {
+		t.Errorf("other user: status %d", w.Code)
+	}
+
+	clk.Advance(40 * time.Second)
+	if w := upload("alice"); w.Code != http.StatusOK {
+		t.Errorf("after the window: status %d", w.Code)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/upload", rateLimit(0, time.Minute, clock.Real()), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("upload %d: status %d", i+1, w.Code)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StaticWithCache serves files from root with Cache-Control, ETag and Last-Modified
+// headers. Conditional requests (If-None-Match / If-Modified-Since) receive 304.
+// Mount it on a wildcard route named "filepath", e.g. r.GET("/themes/*filepath", ...).
+func StaticWithCache(root string, maxAge time.Duration) gin.HandlerFunc {
+	fs := http.Dir(root)
+	cacheControl := "no-cache"
+	if maxAge > 0 {
+		cacheControl = fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	}
+
+	return func(c *gin.Context) {
+		name := path.Clean("/" + c.Param("filepath"))
+
+		file, err := fs.Open(name)
+		if err != nil {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		// Serve index.html for directories, never a listing
+		if info.IsDir() {
+			file.Close()
+			name = path.Join(name, "index.html")
+			if file, err = fs.Open(name); err != nil {
+				c.AbortWithStatus(http.StatusNotFound)
+				return
+			}
+			defer file.Close()
+
+			if info, err = file.Stat(); err != nil || info.IsDir() {
+				c.AbortWithStatus(http.StatusNotFound)
+				return
+			}
+		}
+
+		c.Header("Cache-Control", cacheControl)
+		c.Header("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+
+		// ServeContent sets Last-Modified and answers conditional requests with 304
+		http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), file)
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-cms/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tenant selects the database for a request from the tenant header or, when the
+// header is absent, the host name. The handle is stored in the request context
+// for database.FromContext. Requests matching no tenant are rejected with 404.
+func Tenant(registry *database.TenantRegistry, header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID, found := registry.Lookup(c.GetHeader(header), c.Request.Host)
+		if !found {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Unknown tenant"})
+			return
+		}
+
+		db, err := registry.DB(tenantID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Unknown tenant"})
+			return
+		}
+
+		c.Set("tenant", tenantID)
+		c.Request = c.Request.WithContext(database.WithDB(c.Request.Context(), db))
+		c.Next()
+	}
+}
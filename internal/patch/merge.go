@@ -0,0 +1,65 @@
+// Package patch implements JSON Merge Patch (RFC 7396) for PATCH endpoints
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Merge applies patch to target following RFC 7396: a null value removes the
+// key, an object is merged recursively and anything else replaces the existing
+// value. Keys absent from patch are left unchanged. target is modified in place
+// and returned; a nil target is treated as an empty object.
+func Merge(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{})
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+
+		patchObject, isObject := value.(map[string]interface{})
+		if !isObject {
+			target[key] = value
+			continue
+		}
+
+		existing, _ := target[key].(map[string]interface{})
+		target[key] = Merge(existing, patchObject)
+	}
+
+	return target
+}
+
+// Apply merges a JSON merge patch document into dst, which must be a pointer to
+// a value that round-trips through encoding/json
+func Apply(dst interface{}, patchDoc []byte) error {
+	var patchObject map[string]interface{}
+	if err := json.Unmarshal(patchDoc, &patchObject); err != nil {
+		return fmt.Errorf("patch must be a JSON object: %w", err)
+	}
+
+	current, err := json.Marshal(dst)
+	if err != nil {
+		return err
+	}
+
+	var target map[string]interface{}
+	if err := json.Unmarshal(current, &target); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(Merge(target, patchObject))
+	if err != nil {
+		return err
+	}
+
+	// Reset dst first, otherwise Unmarshal keeps map entries the patch removed
+	value := reflect.ValueOf(dst).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	return json.Unmarshal(merged, dst)
+}
@@ -0,0 +1,77 @@
+package patch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	target := map[string]interface{}{
+		"title":  "Hello",
+		"author": map[string]interface{}{"name": "Ann", "email": "ann@example.com"},
+		"tags":   []interface{}{"a", "b"},
+	}
+	patchDoc := map[string]interface{}{
+		"title":  "Hi",                                 // Replaced
+		"author": map[string]interface{}{"email": nil}, // Merged; null removes the nested key
+		"tags":   []interface{}{"c"},                   // Arrays are replaced, not merged
+		"draft":  true,                                 // Added
+	}
+
+	want := map[string]interface{}{
+		"title":  "Hi",
+		"author": map[string]interface{}{"name": "Ann"},
+		"tags":   []interface{}{"c"},
+		"draft":  true,
+	}
+	if got := Merge(target, patchDoc); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %v, want %v", got, want)
+	}
+}
+
+func TestMergeAbsentVersusNull(t *testing.T) {
+	target := map[string]interface{}{"keep": 1, "clear": 2}
+
+	got := Merge(target, map[string]interface{}{"clear": nil})
+	if _, ok := got["clear"]; ok {
+		t.Error("null did not remove the key")
+	}
+	if got["keep"] != 1 {
+		t.Error("absent key was changed")
+	}
+}
+
+func TestMergeNilTarget(t *testing.T) {
+	got := Merge(nil, map[string]interface{}{"a": map[string]interface{}{"b": 1}})
+	want := map[string]interface{}{"a": map[string]interface{}{"b": 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %v, want %v", got, want)
+	}
+}
+
+func TestApply(t *testing.T) {
+	type settings struct {
+		Name   string            `json:"name,omitempty"`
+		Colors map[string]string `json:"colors,omitempty"`
+		Count  int               `json:"count"`
+	}
+
+	dst := settings{Name: "site", Colors: map[string]string{"primary": "#000000", "accent": "#ffffff"}, Count: 3}
+	if err := Apply(&dst, []byte(`{"colors": {"accent": null, "text": "#111111"}, "name": null}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := settings{Colors: map[string]string{"primary": "#000000", "text": "#111111"}, Count: 3}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("Apply = %+v, want %+v", dst, want)
+	}
+}
+
+func TestApplyRejectsNonObjects(t *testing.T) {
+	var dst map[string]interface{}
+	for _, doc := range []string{`[]`, `"text"`, `{`} {
+		if err := Apply(&dst, []byte(doc)); err == nil {
+			t.Errorf("Apply(%s) succeeded", doc)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package plugins
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// processExitCalls end the whole host process, not just the plugin
+var processExitCalls = map[string][]string{
+	"os":  {"Exit"},
+	"log": {"Fatal", "Fatalf", "Fatalln"},
+}
+
+// globalStateCalls change process-wide state that the host also relies on
+var globalStateCalls = map[string][]string{
+	"github.com/gin-gonic/gin": {"SetMode", "DisableConsoleColor", "ForceConsoleColor"},
+	"log":                      {"SetOutput", "SetFlags", "SetPrefix"},
+	"log/slog":                 {"SetDefault", "SetLogLoggerLevel"},
+	"net/http":                 {"Handle", "HandleFunc"},
+	"os":                       {"Setenv", "Unsetenv", "Clearenv", "Chdir"},
+	"runtime":                  {"GOMAXPROCS"},
+	"runtime/debug":            {"SetGCPercent", "SetMemoryLimit", "SetMaxThreads"},
+}
+
+// sourceWarnings statically checks a plugin's Go files for constructs that load
+// fine but can destabilize the host once the plugin is opened: init functions,
+// calls that exit the process, and writes to or aliases of other packages'
+// global state. Files that fail to parse are left to the compiler to report.
+func sourceWarnings(sourceDir string) []string {
+	var warnings []string
+	fset := token.NewFileSet()
+
+	filepath.WalkDir(sourceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != sourceDir && skipSourceDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil
+		}
+
+		rel, relErr := filepath.Rel(sourceDir, path)
+		if relErr != nil {
+			rel = filepath.Base(path)
+		}
+		warnings = append(warnings, fileWarnings(fset, rel, file)...)
+		return nil
+	})
+
+	return warnings
+}
+
+// fileWarnings runs the source checks on a single parsed file
+func fileWarnings(fset *token.FileSet, name string, file *ast.File) []string {
+	var warnings []string
+	warn := func(pos token.Pos, format string, args ...interface{}) {
+		line := fset.Position(pos).Line
+		warnings = append(warnings, fmt.Sprintf("%s:%d: %s", name, line, fmt.Sprintf(format, args...)))
+	}
+
+	imports := importNames(file)
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil && decl.Name.Name == "init" {
+				warn(decl.Pos(), "init() runs inside the host process when the plugin is opened; move side effects to Initialize")
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.VAR {
+				continue
+			}
+			// Package-level variables initialized from another package's Default* globals
+			// (http.DefaultClient, gin.DefaultWriter, ...) share them with the host
+			for _, spec := range decl.Specs {
+				for _, value := range spec.(*ast.ValueSpec).Values {
+					if selector, path, ok := packageSelector(value, imports); ok && strings.HasPrefix(selector, "Default") {
+						warn(value.Pos(), "package-level variable aliases %s.%s, which is shared with the host", path, selector)
+					}
+				}
+			}
+		}
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range node.Lhs {
+				if selector, path, ok := assignedPackageVar(lhs, imports); ok {
+					warn(lhs.Pos(), "assigns to %s.%s, which is shared with the host", path, selector)
+				}
+			}
+		case *ast.CallExpr:
+			selector, path, ok := packageSelector(node.Fun, imports)
+			if !ok {
+				return true
+			}
+			if containsString(processExitCalls[path], selector) {
+				warn(node.Pos(), "%s.%s terminates the whole host process; return an error instead", path, selector)
+			} else if containsString(globalStateCalls[path], selector) {
+				warn(node.Pos(), "%s.%s changes process-wide state shared with the host", path, selector)
+			}
+		}
+		return true
+	})
+
+	return warnings
+}
+
+// importNames maps the names a file refers to its imports by to their paths
+func importNames(file *ast.File) map[string]string {
+	names := make(map[string]string, len(file.Imports))
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		names[name] = path
+	}
+	return names
+}
+
+// packageSelector reports whether expr is pkg.Name for an imported package and
+// returns Name and the package's import path
+func packageSelector(expr ast.Expr, imports map[string]string) (string, string, bool) {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return "", "", false
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return "", "", false
+	}
+	path, imported := imports[ident.Name]
+	if !imported {
+		return "", "", false
+	}
+	return selector.Sel.Name, path, true
+}
+
+// assignedPackageVar finds the package variable an assignment target writes
+// into, so http.DefaultClient.Timeout = ... is reported as http.DefaultClient
+func assignedPackageVar(expr ast.Expr, imports map[string]string) (string, string, bool) {
+	for {
+		if selector, path, ok := packageSelector(expr, imports); ok {
+			return selector, path, true
+		}
+		switch inner := expr.(type) {
+		case *ast.SelectorExpr:
+			expr = inner.X
+		case *ast.IndexExpr:
+			expr = inner.X
+		case *ast.StarExpr:
+			expr = inner.X
+		case *ast.ParenExpr:
+			expr = inner.X
+		default:
+			return "", "", false
+		}
+	}
+}
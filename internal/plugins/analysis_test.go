@@ -0,0 +1,55 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceWarnings(t *testing.T) {
+	dir := t.TempDir()
+	source := `package main
+
+import (
+	"log"
+	nethttp "net/http"
+	"os"
+)
+
+var client = nethttp.DefaultClient
+
+func init() {}
+
+func run() {
+	nethttp.DefaultTransport = nil
+	os.Setenv("GIN_MODE", "release")
+	log.Fatal("stop")
+	os.Getenv("HOME")
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Tests and vendored code are not loaded into the host
+	os.WriteFile(filepath.Join(dir, "main_test.go"), []byte("package main\nfunc init() {}\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, "vendor"), 0755)
+	os.WriteFile(filepath.Join(dir, "vendor", "dep.go"), []byte("package dep\nfunc init() {}\n"), 0644)
+
+	warnings := sourceWarnings(dir)
+	want := []string{
+		"main.go:9: package-level variable aliases net/http.DefaultClient",
+		"main.go:11: init()",
+		"main.go:14: assigns to net/http.DefaultTransport",
+		"main.go:15: os.Setenv changes process-wide state",
+		"main.go:16: log.Fatal terminates the whole host process",
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("got %d warnings, want %d:\n%s", len(warnings), len(want), strings.Join(warnings, "\n"))
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(warnings[i], prefix) {
+			t.Errorf("warning %d = %q, want prefix %q", i, warnings[i], prefix)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package plugins
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-cms/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pluginAssetsDir is the directory of a plugin's source tree served as static assets
+const pluginAssetsDir = "assets"
+
+// SetAssetCacheMaxAge sets the Cache-Control max-age of plugin assets
+func (m *Manager) SetAssetCacheMaxAge(maxAge time.Duration) {
+	m.assetMaxAge = maxAge
+}
+
+// assetsDir returns the assets directory of a loaded plugin; m.mu must be held
+func (m *Manager) assetsDir(name string) string {
+	return filepath.Join(m.loader.sourceDir(m.pluginPaths[name]), pluginAssetsDir)
+}
+
+// serveAssets serves files from a plugin's assets directory, which is mounted at
+// /plugins/<name>/assets/ in the plugin's route table. The directory is looked
+// up per request, so it picks up the new files after a reload.
+func (m *Manager) serveAssets(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m.mu.RLock()
+		reason := m.availability(name)
+		dir := m.assetsDir(name)
+		maxAge := m.assetMaxAge
+		m.mu.RUnlock()
+
+		if reason != nil {
+			reason.abort(c, name)
+			return
+		}
+		if !assetPathAllowed(dir, c.Param("filepath")) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid asset path"})
+			return
+		}
+
+		middleware.StaticWithCache(dir, maxAge)(c)
+	}
+}
+
+// assetPathAllowed rejects paths with .. segments and paths that resolve,
+// through symlinks, to somewhere outside dir
+func assetPathAllowed(dir, requested string) bool {
+	for _, segment := range strings.Split(filepath.ToSlash(requested), "/") {
+		if segment == ".." {
+			return false
+		}
+	}
+
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	target, err := filepath.EvalSymlinks(filepath.Join(dir, filepath.FromSlash(requested)))
+	if err != nil {
+		// Missing files are answered with 404 by the file server
+		return os.IsNotExist(err)
+	}
+
+	rel, err := filepath.Rel(root, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
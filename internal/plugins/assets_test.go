@@ -0,0 +1,102 @@
+package plugins
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withAssets creates the assets directory of an installed plugin with files
+func withAssets(t *testing.T, m *Manager, name string, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(m.loader.pluginDir, name, pluginAssetsDir)
+	for file, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestServePluginAssets(t *testing.T) {
+	host := newTestHost(t)
+	host.manager.SetAssetCacheMaxAge(time.Hour)
+	withAssets(t, host.manager, "forms", map[string]string{
+		"widget.js":      "console.log('form')",
+		"css/widget.css": "form{}",
+	})
+	host.add(newFakePlugin("forms"))
+
+	w := host.do(http.MethodGet, "/api/v1/plugins/forms/assets/widget.js")
+	if w.Code != http.StatusOK || w.Body.String() != "console.log('form')" {
+		t.Fatalf("widget.js: status %d, body %q", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "javascript") {
+		t.Errorf("Content-Type = %q, want JavaScript", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=3600" {
+		t.Errorf("Cache-Control = %q, want public, max-age=3600", cc)
+	}
+
+	w = host.do(http.MethodGet, "/api/v1/plugins/forms/assets/css/widget.css")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/css") {
+		t.Errorf("css/widget.css: status %d, Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	if w := host.do(http.MethodGet, "/api/v1/plugins/forms/assets/missing.js"); w.Code != http.StatusNotFound {
+		t.Errorf("missing asset: status %d, want 404", w.Code)
+	}
+
+	// Files beside the assets directory stay private
+	for _, path := range []string{
+		"/api/v1/plugins/forms/assets/../plugin.json",
+		"/api/v1/plugins/forms/assets/css/../../plugin.json",
+		"/api/v1/plugins/forms/assets/%2e%2e/plugin.json",
+	} {
+		if w := host.do(http.MethodGet, path); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", path, w.Code)
+		}
+	}
+
+	// Switching the plugin off takes its assets down with its routes
+	host.manager.SetRoutesEnabled("forms", false)
+	if w := host.do(http.MethodGet, "/api/v1/plugins/forms/assets/widget.js"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("switched off: status %d, want 503", w.Code)
+	}
+}
+
+func TestServePluginAssetsRejectsSymlinkEscape(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	host := newTestHost(t)
+	dir := withAssets(t, host.manager, "forms", map[string]string{"widget.js": "ok"})
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	os.WriteFile(secret, []byte("secret"), 0644)
+	if err := os.Symlink(secret, filepath.Join(dir, "leak.txt")); err != nil {
+		t.Fatal(err)
+	}
+	host.add(newFakePlugin("forms"))
+
+	if w := host.do(http.MethodGet, "/api/v1/plugins/forms/assets/leak.txt"); w.Code != http.StatusBadRequest || strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("symlink out of the assets directory: status %d, body %q", w.Code, w.Body)
+	}
+}
+
+func TestPluginWithoutAssets(t *testing.T) {
+	host := newTestHost(t)
+	host.add(newFakePlugin("forms"))
+
+	w := host.do(http.MethodGet, "/api/v1/plugins/forms/assets/widget.js")
+	if code, _ := gateError(t, w); w.Code != http.StatusNotFound || code != CodeRouteNotFound {
+		t.Errorf("plugin without assets: %d %s, want 404 %s", w.Code, code, CodeRouteNotFound)
+	}
+}
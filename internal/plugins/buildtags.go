@@ -0,0 +1,34 @@
+package plugins
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// HostBuildTag is set on every plugin build by the host. Plugins can keep code
+// for standalone runs, such as a main function, in files guarded by
+// //go:build !cmsplugin, and host-specific code behind //go:build cmsplugin.
+const HostBuildTag = "cmsplugin"
+
+var buildTagPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// manifestBuildTags returns the tags to build a plugin with: HostBuildTag plus
+// the build_tags declared in plugin.json, which must be simple identifiers
+func manifestBuildTags(manifest *PluginManifest) ([]string, error) {
+	tags := []string{HostBuildTag}
+	if manifest == nil {
+		return tags, nil
+	}
+
+	seen := map[string]bool{HostBuildTag: true}
+	for _, tag := range manifest.BuildTags {
+		if !buildTagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid build tag %q in plugin.json: tags must be letters, digits and underscores, not starting with a digit", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
@@ -0,0 +1,83 @@
+package plugins
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManifestBuildTags(t *testing.T) {
+	tags, err := manifestBuildTags(&PluginManifest{BuildTags: []string{"premium", HostBuildTag, "premium", "sqlite_fts5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{HostBuildTag, "premium", "sqlite_fts5"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+
+	for _, tag := range []string{"", "9lives", "a,b", "with space", "-race", "x/y"} {
+		if _, err := manifestBuildTags(&PluginManifest{BuildTags: []string{tag}}); err == nil {
+			t.Errorf("build tag %q was accepted", tag)
+		}
+	}
+}
+
+// copyFixture copies a plugin under testdata to a scratch directory, since
+// compiling writes to the plugin's directory
+func copyFixture(t *testing.T, name string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), name)
+	if err := os.CopyFS(dir, os.DirFS(filepath.Join("testdata", name))); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestCompilePluginWithBuildTags(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles a plugin")
+	}
+	if raceEnabled {
+		t.Skip("the plugin is built without -race and cannot be opened by this binary")
+	}
+	if !NewLoader(t.TempDir()).IsPlatformSupported() {
+		t.Skip("plugins are not supported on this platform")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	dir := copyFixture(t, "buildtags")
+	soPath, err := NewCompiler(t.TempDir()).CompilePlugin(dir, "buildtags")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The host build selects the files for cmsplugin and the manifest's tags
+	p, err := plugin.Open(soPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode, err := p.Lookup("Mode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *mode.(*string); got != "host premium" {
+		t.Errorf("plugin built as %q, want \"host premium\"", got)
+	}
+
+	// Without the host tag the same sources build as a standalone program
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("standalone run: %v: %s", err, output)
+	}
+	if got := strings.TrimSpace(string(output)); got != "standalone" {
+		t.Errorf("standalone run printed %q, want \"standalone\"", got)
+	}
+}
@@ -0,0 +1,211 @@
+package plugins
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+)
+
+// BundleManifestFile marks a zip as a bundle of several plugins when present at its top level
+const BundleManifestFile = "bundle.json"
+
+// BundleManifest lists the member plugins of a bundle zip. Each member is a
+// top-level directory of the zip laid out like a single-plugin zip, and is
+// installed under its directory name.
+type BundleManifest struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	Plugins []string `json:"plugins"`
+}
+
+// BundleMemberResult reports how installing one member of a bundle went
+type BundleMemberResult struct {
+	Plugin  string         `json:"plugin"`
+	Name    string         `json:"name,omitempty"` // Name the plugin reports, which may differ from its directory
+	Version string         `json:"version,omitempty"`
+	Result  *InstallResult `json:"result,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// BundleResult reports the outcome of installing a bundle
+type BundleResult struct {
+	Name    string               `json:"name"`
+	Version string               `json:"version"`
+	Members []BundleMemberResult `json:"members"`
+}
+
+// ReadBundleManifest returns the bundle manifest of a zip, or nil when the zip
+// holds a single plugin
+func ReadBundleManifest(zipPath string) (*BundleManifest, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip file: %w", err)
+	}
+	defer reader.Close()
+
+	var manifestFile *zip.File
+	members := make(map[string]bool)
+	for _, file := range reader.File {
+		if file.Name == BundleManifestFile {
+			manifestFile = file
+		}
+		if dir, _, found := strings.Cut(file.Name, "/"); found {
+			members[dir] = true
+		}
+	}
+	if manifestFile == nil {
+		return nil, nil
+	}
+
+	rc, err := manifestFile.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", BundleManifestFile, err)
+	}
+	defer rc.Close()
+
+	var manifest BundleManifest
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", BundleManifestFile, err)
+	}
+
+	if len(manifest.Plugins) == 0 {
+		return nil, fmt.Errorf("%s lists no plugins", BundleManifestFile)
+	}
+	seen := make(map[string]bool)
+	for _, member := range manifest.Plugins {
+		if member == "" || member != path.Base(member) || member == "." || member == ".." {
+			return nil, fmt.Errorf("%s lists invalid plugin directory %q", BundleManifestFile, member)
+		}
+		if seen[member] {
+			return nil, fmt.Errorf("%s lists plugin %s more than once", BundleManifestFile, member)
+		}
+		seen[member] = true
+		if !members[member] {
+			return nil, fmt.Errorf("bundle has no directory for plugin %s", member)
+		}
+	}
+
+	return &manifest, nil
+}
+
+// InstallBundleFromZip installs every member plugin of a bundle zip. Bundles only
+// install new plugins: a member that is loaded or present on disk, even if
+// deactivated, fails the whole bundle before anything is extracted. Installation
+// is all-or-nothing: if any member fails, the members already installed are
+// unloaded and removed again. The result reports each member either way.
+func (m *Manager) InstallBundleFromZip(zipPath string, manifest *BundleManifest) (*BundleResult, error) {
+	if err := m.beginOperation(); err != nil {
+		return nil, err
+	}
+	defer m.endOperation()
+
+	validationResult, err := m.loader.ValidateZipPlugin(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if !validationResult.IsValid {
+		return nil, fmt.Errorf("invalid bundle: %s", strings.Join(validationResult.Errors, ", "))
+	}
+
+	result := &BundleResult{Name: manifest.Name, Version: manifest.Version}
+	var installed []BundleMemberResult
+
+	m.mu.Lock()
+	for _, member := range manifest.Plugins {
+		if err := CheckReservedName(member); err != nil {
+			m.mu.Unlock()
+			return nil, err
+		}
+		if previous := m.loadedName(member); previous != "" {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("plugin %s is already installed; bundles can only install new plugins", previous)
+		}
+		// Extracting would replace the files of an installed but unloaded plugin
+		if m.loader.isInstalled(member) {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("plugin %s is already installed; bundles can only install new plugins", member)
+		}
+	}
+
+	// From here on every member directory is created by this call, so removing
+	// them on failure never touches a plugin the user already had
+
+	var installErr error
+	for _, member := range manifest.Plugins {
+		memberResult := BundleMemberResult{Plugin: member}
+		if installErr != nil {
+			memberResult.Error = "skipped"
+			result.Members = append(result.Members, memberResult)
+			continue
+		}
+
+		installResult, info, err := m.installPlugin(member, func() (*InstallResult, error) {
+			return m.loader.InstallBundleMember(zipPath, member)
+		})
+		if err != nil {
+			m.loader.UninstallPlugin(member)
+			memberResult.Error = err.Error()
+			installErr = fmt.Errorf("failed to install bundle member %s: %w", member, err)
+		} else {
+			memberResult.Name = info.Name
+			memberResult.Version = info.Version
+			memberResult.Result = installResult
+			installed = append(installed, memberResult)
+		}
+		result.Members = append(result.Members, memberResult)
+	}
+
+	if installErr != nil {
+		m.rollbackBundle(installed)
+		for i := range result.Members {
+			if result.Members[i].Result != nil {
+				result.Members[i].Error = "rolled back"
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if installErr != nil {
+		return result, installErr
+	}
+
+	for _, member := range installed {
+		m.emit(EventPluginInstalled, member.Name, member.Version)
+	}
+	return result, nil
+}
+
+// RollbackBundle unloads and removes the members of a bundle that installed
+// successfully, for when the bundle cannot be recorded after installing
+func (m *Manager) RollbackBundle(result *BundleResult) {
+	var installed []BundleMemberResult
+	for _, member := range result.Members {
+		if member.Result != nil && member.Error == "" {
+			installed = append(installed, member)
+		}
+	}
+
+	m.mu.Lock()
+	m.rollbackBundle(installed)
+	m.mu.Unlock()
+
+	for _, member := range installed {
+		m.emit(EventPluginUninstalled, member.Name, member.Version)
+	}
+}
+
+// rollbackBundle unloads and removes bundle members installed before a later
+// member failed. The caller must hold m.mu.
+func (m *Manager) rollbackBundle(installed []BundleMemberResult) {
+	for _, member := range installed {
+		if _, err := m.unloadPlugin(member.Name); err != nil {
+			log.Printf("Failed to unload bundle member %s during rollback: %v", member.Name, err)
+		}
+		if err := m.loader.UninstallPlugin(member.Plugin); err != nil {
+			log.Printf("Failed to remove bundle member %s during rollback: %v", member.Plugin, err)
+		}
+	}
+}
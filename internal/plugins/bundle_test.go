@@ -0,0 +1,135 @@
+package plugins
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// bundleZip writes a bundle zip whose members are plugins with the given versions
+func bundleZip(t *testing.T, versions map[string]string, order ...string) (string, *BundleManifest) {
+	t.Helper()
+	manifest := &BundleManifest{Name: "suite", Version: "1.0.0", Plugins: order}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{BundleManifestFile: string(data)}
+	for _, member := range order {
+		for name, content := range pluginFiles(t, map[string]interface{}{"name": member, "version": versions[member]}) {
+			files[member+"/"+name] = content
+		}
+	}
+
+	zipPath := writeZip(t, files)
+	read, err := ReadBundleManifest(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zipPath, read
+}
+
+func TestInstallBundleFromZip(t *testing.T) {
+	host := newTestHost(t)
+	installFakeToolchain(host.manager)
+
+	zipPath, manifest := bundleZip(t, map[string]string{"forms": "1.0.0", "mailer": "2.1.0"}, "forms", "mailer")
+	result, err := host.manager.InstallBundleFromZip(zipPath, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Members) != 2 {
+		t.Fatalf("%d member results, want 2", len(result.Members))
+	}
+	for _, member := range result.Members {
+		if member.Error != "" || member.Result == nil {
+			t.Errorf("member %s: %+v", member.Plugin, member)
+		}
+	}
+	if got := runningVersion(t, host.manager, "mailer"); got != "2.1.0" {
+		t.Errorf("mailer running %s, want 2.1.0", got)
+	}
+	if _, ok := host.manager.GetPlugin("forms"); !ok {
+		t.Error("forms is not loaded")
+	}
+
+	// Undoing the bundle, as the admin handler does when it cannot be recorded
+	host.manager.RollbackBundle(result)
+	for _, member := range []string{"forms", "mailer"} {
+		if _, ok := host.manager.GetPlugin(member); ok {
+			t.Errorf("%s still loaded after rollback", member)
+		}
+		if host.manager.loader.isInstalled(member) {
+			t.Errorf("%s still on disk after rollback", member)
+		}
+	}
+}
+
+func TestInstallBundleFromZipRollsBackOnMemberFailure(t *testing.T) {
+	host := newTestHost(t)
+	tc := installFakeToolchain(host.manager)
+	tc.failInit["9.9.9"] = true
+
+	zipPath, manifest := bundleZip(t, map[string]string{"forms": "1.0.0", "broken": "9.9.9", "later": "1.0.0"}, "forms", "broken", "later")
+	result, err := host.manager.InstallBundleFromZip(zipPath, manifest)
+	if err == nil {
+		t.Fatal("bundle with a failing member installed")
+	}
+
+	want := map[string]string{"forms": "rolled back", "later": "skipped"}
+	for _, member := range result.Members {
+		if member.Plugin == "broken" {
+			if member.Error == "" {
+				t.Error("failing member reports no error")
+			}
+			continue
+		}
+		if member.Error != want[member.Plugin] {
+			t.Errorf("member %s error %q, want %q", member.Plugin, member.Error, want[member.Plugin])
+		}
+	}
+	for _, member := range manifest.Plugins {
+		if _, ok := host.manager.GetPlugin(member); ok {
+			t.Errorf("%s is loaded after a failed bundle", member)
+		}
+		if host.manager.loader.isInstalled(member) {
+			t.Errorf("%s is on disk after a failed bundle", member)
+		}
+	}
+}
+
+func TestInstallBundleFromZipKeepsInstalledPlugins(t *testing.T) {
+	host := newTestHost(t)
+	tc := installFakeToolchain(host.manager)
+	tc.install(t, host.manager, "forms", pluginFiles(t, map[string]interface{}{"name": "forms", "version": "1.0.0"}))
+
+	// A deactivated plugin is installed on disk but not loaded
+	deactivated := filepath.Join(host.manager.loader.pluginDir, "mailer")
+	os.MkdirAll(deactivated, 0755)
+	for name, content := range pluginFiles(t, map[string]interface{}{"name": "mailer", "version": "1.0.0"}) {
+		os.WriteFile(filepath.Join(deactivated, name), []byte(content), 0644)
+	}
+
+	for name, members := range map[string][]string{"loaded": {"forms"}, "on disk": {"mailer"}} {
+		t.Run(name, func(t *testing.T) {
+			versions := map[string]string{"fresh": "1.0.0", members[0]: "5.0.0"}
+			zipPath, manifest := bundleZip(t, versions, "fresh", members[0])
+
+			if _, err := host.manager.InstallBundleFromZip(zipPath, manifest); err == nil {
+				t.Fatal("bundle replaced an installed plugin")
+			}
+			if host.manager.loader.isInstalled("fresh") {
+				t.Error("bundle extracted members despite the conflict")
+			}
+			if got := manifestVersion(t, host.manager, members[0]); got != "1.0.0" {
+				t.Errorf("installed %s now has version %s, want 1.0.0", members[0], got)
+			}
+		})
+	}
+	if got := runningVersion(t, host.manager, "forms"); got != "1.0.0" {
+		t.Errorf("forms running %s, want 1.0.0", got)
+	}
+}
@@ -0,0 +1,192 @@
+package plugins
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCacheMaxEntries bounds the shared plugin cache when no size is configured
+const DefaultCacheMaxEntries = 10000
+
+// CacheBackend stores plugin cache entries. Keys arrive already namespaced by
+// plugin. The in-memory MemoryCache is used unless another backend is set on
+// the manager.
+type CacheBackend interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{}, ttl time.Duration)
+	Delete(key string)
+	DeletePrefix(prefix string)
+}
+
+// MemoryCache is an in-memory CacheBackend. Expired entries are dropped on access
+// and, once maxEntries is reached, the least recently used entry is evicted.
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	recent     *list.List // Front is most recently used
+}
+
+type memoryCacheEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time // Zero means no expiry
+}
+
+// NewMemoryCache creates an in-memory cache holding at most maxEntries entries
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	if maxEntries < 1 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		recent:     list.New(),
+	}
+}
+
+// Get returns the value stored under key if it has not expired
+func (c *MemoryCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		return nil, false
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.recent.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set stores value under key for ttl; a ttl of 0 or less never expires
+func (c *MemoryCache) Set(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if element, exists := c.entries[key]; exists {
+		entry := element.Value.(*memoryCacheEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.recent.MoveToFront(element)
+		return
+	}
+
+	for len(c.entries) >= c.maxEntries {
+		c.remove(c.recent.Back())
+	}
+	c.entries[key] = c.recent.PushFront(&memoryCacheEntry{key: key, value: value, expiresAt: expiresAt})
+}
+
+// Delete removes key from the cache
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		c.remove(element)
+	}
+}
+
+// DeletePrefix removes every key starting with prefix
+func (c *MemoryCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(element)
+		}
+	}
+}
+
+// remove drops an entry; c.mu must be held
+func (c *MemoryCache) remove(element *list.Element) {
+	c.recent.Remove(element)
+	delete(c.entries, element.Value.(*memoryCacheEntry).key)
+}
+
+// PluginCache is the cache handed to a plugin in PluginDependencies. Keys are
+// namespaced to the plugin, and the plugin's cache_ttl setting is the default TTL.
+type PluginCache struct {
+	backend    CacheBackend
+	prefix     string
+	defaultTTL *atomic.Int64 // Nanoseconds; updated when cache_ttl changes
+}
+
+func newPluginCache(backend CacheBackend, name string, defaultTTL *atomic.Int64) *PluginCache {
+	return &PluginCache{
+		backend:    backend,
+		prefix:     cachePrefix(name),
+		defaultTTL: defaultTTL,
+	}
+}
+
+// NewPluginCache returns a cache for the named plugin outside of a Manager, such
+// as in plugin tests. defaultTTL is used when Set is given a ttl of 0.
+func NewPluginCache(backend CacheBackend, name string, defaultTTL time.Duration) *PluginCache {
+	ttl := &atomic.Int64{}
+	ttl.Store(int64(defaultTTL))
+	return newPluginCache(backend, name, ttl)
+}
+
+// cachePrefix is the namespace of a plugin's cache keys
+func cachePrefix(name string) string {
+	return "plugin:" + name + ":"
+}
+
+// Get returns the cached value for key
+func (c *PluginCache) Get(key string) (interface{}, bool) {
+	return c.backend.Get(c.prefix + key)
+}
+
+// Set caches value under key. A ttl of 0 uses the plugin's cache_ttl setting.
+func (c *PluginCache) Set(key string, value interface{}, ttl time.Duration) {
+	if ttl == 0 {
+		ttl = time.Duration(c.defaultTTL.Load())
+	}
+	c.backend.Set(c.prefix+key, value, ttl)
+}
+
+// Delete removes key from the cache
+func (c *PluginCache) Delete(key string) {
+	c.backend.Delete(c.prefix + key)
+}
+
+// Clear removes every entry cached by the plugin
+func (c *PluginCache) Clear() {
+	c.backend.DeletePrefix(c.prefix)
+}
+
+// SettingsCacheTTL returns the cache_ttl setting (in seconds) as a duration,
+// or 0 when the plugin does not declare one
+func SettingsCacheTTL(settings []PluginSetting) time.Duration {
+	for _, setting := range settings {
+		if setting.Key != "cache_ttl" {
+			continue
+		}
+		switch seconds := setting.Value.(type) {
+		case int:
+			return time.Duration(seconds) * time.Second
+		case int32:
+			return time.Duration(seconds) * time.Second
+		case int64:
+			return time.Duration(seconds) * time.Second
+		case float64:
+			return time.Duration(seconds * float64(time.Second))
+		}
+	}
+	return 0
+}
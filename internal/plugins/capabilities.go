@@ -0,0 +1,73 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Capabilities a plugin may request in plugin.json. Only database and config
+// gate host services; network and filesystem are recorded for review but cannot
+// be enforced, since plugins run in-process.
+const (
+	CapabilityDatabase   = "database"
+	CapabilityConfig     = "config"
+	CapabilityNetwork    = "network"
+	CapabilityFilesystem = "filesystem"
+)
+
+var knownCapabilities = map[string]bool{
+	CapabilityDatabase:   true,
+	CapabilityConfig:     true,
+	CapabilityNetwork:    true,
+	CapabilityFilesystem: true,
+}
+
+// AllCapabilities is granted to plugins whose manifest predates capabilities
+var AllCapabilities = []string{CapabilityConfig, CapabilityDatabase, CapabilityFilesystem, CapabilityNetwork}
+
+// normalizeCapabilities lower-cases, de-duplicates and sorts the requested
+// capabilities, returning the unknown ones separately
+func normalizeCapabilities(requested []string) ([]string, []string) {
+	seen := make(map[string]bool)
+	capabilities := []string{}
+	var unknown []string
+
+	for _, capability := range requested {
+		capability = strings.ToLower(strings.TrimSpace(capability))
+		if seen[capability] {
+			continue
+		}
+		seen[capability] = true
+
+		if !knownCapabilities[capability] {
+			unknown = append(unknown, capability)
+			continue
+		}
+		capabilities = append(capabilities, capability)
+	}
+
+	sort.Strings(capabilities)
+	return capabilities, unknown
+}
+
+// manifestCapabilities returns the capabilities granted by a manifest along with
+// warnings for the reviewer. A manifest without a capabilities field is granted
+// everything for compatibility; a declared list, even an empty one, is enforced.
+func manifestCapabilities(manifest *PluginManifest) ([]string, []string) {
+	if manifest == nil || manifest.Capabilities == nil {
+		return AllCapabilities, []string{"plugin.json does not declare capabilities; all capabilities are granted for compatibility"}
+	}
+
+	capabilities, unknown := normalizeCapabilities(manifest.Capabilities)
+	var warnings []string
+	for _, capability := range unknown {
+		warnings = append(warnings, fmt.Sprintf("unknown capability %q is ignored", capability))
+	}
+	return capabilities, warnings
+}
+
+// hasCapability reports whether capabilities contains capability
+func hasCapability(capabilities []string, capability string) bool {
+	return containsString(capabilities, capability)
+}
@@ -0,0 +1,106 @@
+package plugins
+
+import (
+	"fmt"
+
+	"go-cms/internal/semver"
+)
+
+// CMSCompatibility reports a plugin's declared CMS version range against the
+// running CMS
+type CMSCompatibility struct {
+	CMSVersion    string `json:"cms_version"`
+	MinCMSVersion string `json:"min_cms_version,omitempty"`
+	MaxCMSVersion string `json:"max_cms_version,omitempty"`
+	Constraint    string `json:"cms_constraint,omitempty"` // The "cms" entry of dependencies, such as ">=1.0.0, <2.0.0"
+	Compatible    bool   `json:"compatible"`
+}
+
+// IncompatibleCMSError is returned when a plugin declares a CMS version range
+// that excludes the running CMS
+type IncompatibleCMSError struct {
+	Plugin        string
+	CMSVersion    string
+	MinCMSVersion string
+	MaxCMSVersion string
+	Constraint    string
+}
+
+func (e *IncompatibleCMSError) Error() string {
+	var required string
+	switch {
+	case e.MinCMSVersion != "" && e.MaxCMSVersion != "":
+		required = fmt.Sprintf("%s to %s", e.MinCMSVersion, e.MaxCMSVersion)
+	case e.MinCMSVersion != "":
+		required = e.MinCMSVersion + " or newer"
+	case e.MaxCMSVersion != "":
+		required = e.MaxCMSVersion + " or older"
+	}
+	if e.Constraint != "" {
+		if required != "" {
+			required += " and "
+		}
+		required += e.Constraint
+	}
+	return fmt.Sprintf("plugin %s requires CMS version %s, but this is %s", e.Plugin, required, e.CMSVersion)
+}
+
+// checkCMSVersion checks the manifest's CMS version range against cmsVersion.
+// The range is min_cms_version and max_cms_version together with the "cms"
+// constraint in dependencies; a manifest may use either form or both. A CMS
+// version that is not a semantic version, such as a development build, is not
+// checked.
+func checkCMSVersion(manifest *PluginManifest, cmsVersion string) (*CMSCompatibility, error) {
+	compat := &CMSCompatibility{CMSVersion: cmsVersion, Compatible: true}
+	if manifest == nil {
+		return compat, nil
+	}
+	compat.MinCMSVersion = manifest.MinCMSVersion
+	compat.MaxCMSVersion = manifest.MaxCMSVersion
+	if constraint := manifest.Dependencies[cmsDependency]; semver.IsConstraint(constraint) {
+		compat.Constraint = constraint
+	}
+
+	if cmsVersion == "" || !semver.Valid(cmsVersion) {
+		return compat, nil
+	}
+
+	if manifest.MinCMSVersion != "" {
+		cmp, err := semver.Compare(cmsVersion, manifest.MinCMSVersion)
+		if err != nil {
+			return compat, fmt.Errorf("invalid min_cms_version %q: %w", manifest.MinCMSVersion, err)
+		}
+		compat.Compatible = cmp >= 0
+	}
+	if manifest.MaxCMSVersion != "" {
+		cmp, err := semver.Compare(cmsVersion, manifest.MaxCMSVersion)
+		if err != nil {
+			return compat, fmt.Errorf("invalid max_cms_version %q: %w", manifest.MaxCMSVersion, err)
+		}
+		compat.Compatible = compat.Compatible && cmp <= 0
+	}
+	if compat.Constraint != "" {
+		ok, err := semver.Satisfies(cmsVersion, compat.Constraint)
+		if err != nil {
+			return compat, fmt.Errorf("invalid cms dependency %q: %w", compat.Constraint, err)
+		}
+		compat.Compatible = compat.Compatible && ok
+	}
+
+	if !compat.Compatible {
+		return compat, &IncompatibleCMSError{
+			Plugin:        manifest.Name,
+			CMSVersion:    cmsVersion,
+			MinCMSVersion: manifest.MinCMSVersion,
+			MaxCMSVersion: manifest.MaxCMSVersion,
+			Constraint:    compat.Constraint,
+		}
+	}
+	return compat, nil
+}
+
+// SetCMSVersion sets the CMS version plugins' min_cms_version and max_cms_version
+// are checked against on install
+func (m *Manager) SetCMSVersion(version string) {
+	m.loader.cmsVersion = version
+}
@@ -0,0 +1,116 @@
+package plugins
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckCMSVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		manifest   PluginManifest
+		cmsVersion string
+		compatible bool
+		message    string
+	}{
+		{"range satisfied", PluginManifest{MinCMSVersion: "1.0.0", MaxCMSVersion: "1.9.0"}, "1.4.0", true, ""},
+		{"below minimum", PluginManifest{MinCMSVersion: "1.5.0"}, "1.4.0", false, "requires CMS version 1.5.0 or newer"},
+		{"constraint satisfied", PluginManifest{Dependencies: map[string]string{"cms": ">=1.0.0, <2.0.0"}}, "1.4.0", true, ""},
+		{"constraint not satisfied", PluginManifest{Dependencies: map[string]string{"cms": ">=1.0.0, <2.0.0"}}, "2.0.0", false, "requires CMS version >=1.0.0, <2.0.0"},
+		{"both forms", PluginManifest{MinCMSVersion: "1.0.0", Dependencies: map[string]string{"cms": "<1.3.0"}}, "1.4.0", false, "requires CMS version 1.0.0 or newer and <1.3.0"},
+		{"development build", PluginManifest{MinCMSVersion: "9.0.0", Dependencies: map[string]string{"cms": ">=9.0.0"}}, "dev", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.manifest.Name = "seo"
+			compat, err := checkCMSVersion(&tt.manifest, tt.cmsVersion)
+			if compat.Compatible != tt.compatible {
+				t.Errorf("Compatible = %v, want %v", compat.Compatible, tt.compatible)
+			}
+			if tt.message == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var compatErr *IncompatibleCMSError
+			if !errors.As(err, &compatErr) || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("err = %v, want an IncompatibleCMSError containing %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func TestCheckDependenciesLeavesCMSToTheVersionRange(t *testing.T) {
+	l := NewLoader(t.TempDir())
+	l.cmsVersion = "2.0.0"
+	manifest := &PluginManifest{Name: "seo", Dependencies: map[string]string{"cms": "<2.0.0"}}
+	if err := l.CheckDependencies(manifest); err != nil {
+		t.Errorf("CheckDependencies reported the cms constraint: %v", err)
+	}
+
+	manifest.Dependencies["unshared/module"] = ">=1.0.0"
+	var dependencyErr *DependencyError
+	if err := l.CheckDependencies(manifest); !errors.As(err, &dependencyErr) || len(dependencyErr.Failures) != 1 {
+		t.Errorf("err = %v, want one failure for the unshared module", err)
+	}
+}
+
+func TestInstallChecksCMSVersion(t *testing.T) {
+	host := newTestHost(t)
+	installFakeToolchain(host.manager)
+	host.manager.SetCMSVersion("1.4.0")
+
+	compatible := writeZip(t, pluginFiles(t, map[string]interface{}{
+		"name": "seo", "version": "1.0.0", "min_cms_version": "1.0.0", "max_cms_version": "1.9.0",
+	}))
+	if _, err := host.manager.InstallPluginFromZip(compatible, "seo"); err != nil {
+		t.Fatalf("compatible plugin: %v", err)
+	}
+	if got := runningVersion(t, host.manager, "seo"); got != "1.0.0" {
+		t.Errorf("running version %s, want 1.0.0", got)
+	}
+
+	incompatible := writeZip(t, pluginFiles(t, map[string]interface{}{
+		"name": "forms", "version": "1.0.0", "min_cms_version": "1.5.0",
+	}))
+	_, err := host.manager.InstallPluginFromZip(incompatible, "forms")
+	var compatErr *IncompatibleCMSError
+	if !errors.As(err, &compatErr) || compatErr.MinCMSVersion != "1.5.0" || compatErr.CMSVersion != "1.4.0" {
+		t.Fatalf("incompatible plugin: err = %v, want an IncompatibleCMSError for 1.5.0", err)
+	}
+	if _, ok := host.manager.GetPlugin("forms"); ok {
+		t.Error("incompatible plugin was loaded")
+	}
+	if builds := host.manager.loader.compiler.pluginBuilds("forms"); len(builds) != 0 {
+		t.Errorf("incompatible plugin was compiled: %v", builds)
+	}
+}
+
+func TestUpdateToIncompatibleVersionKeepsRunningVersion(t *testing.T) {
+	host := newTestHost(t)
+	tc := installFakeToolchain(host.manager)
+	host.manager.SetCMSVersion("1.4.0")
+	old := tc.install(t, host.manager, "seo", pluginFiles(t, map[string]interface{}{"name": "seo", "version": "1.0.0"}))
+
+	zipPath := writeZip(t, pluginFiles(t, map[string]interface{}{
+		"name": "seo", "version": "2.0.0", "dependencies": map[string]string{"cms": ">=2.0.0"},
+	}))
+	_, err := host.manager.UpdatePluginFromZip(zipPath, "seo", false)
+	var compatErr *IncompatibleCMSError
+	if !errors.As(err, &compatErr) {
+		t.Fatalf("err = %v, want an IncompatibleCMSError", err)
+	}
+
+	if old.shutdownCount() != 0 {
+		t.Error("running version was shut down for an incompatible update")
+	}
+	if got := runningVersion(t, host.manager, "seo"); got != "1.0.0" {
+		t.Errorf("running version %s, want 1.0.0", got)
+	}
+	if got := manifestVersion(t, host.manager, "seo"); got != "1.0.0" {
+		t.Errorf("installed manifest version %s, want 1.0.0", got)
+	}
+	assertNoUpdateLeftovers(t, host.manager, "seo")
+}
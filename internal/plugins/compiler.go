@@ -1,38 +1,144 @@
 package plugins
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type Compiler struct {
 	buildDir string
 	goPath   string
+	builds   *buildLimiter // Shared by compilers that should queue behind each other
 }
 
 func NewCompiler(buildDir string) *Compiler {
 	return &Compiler{
 		buildDir: buildDir,
 		goPath:   "go", // Can be overridden if Go is not in PATH
+		builds:   newBuildLimiter(DefaultMaxConcurrentBuilds()),
 	}
 }
 
-// CompilePlugin compiles a plugin directory into a .so file
+// DefaultMaxConcurrentBuilds is min(NumCPU, 2), which keeps small hosts from
+// running out of memory when several plugins build at once
+func DefaultMaxConcurrentBuilds() int {
+	return min(runtime.NumCPU(), 2)
+}
+
+// buildLimiter caps the number of go build invocations running at once
+type buildLimiter struct {
+	slots   chan struct{}
+	queued  atomic.Int32
+	running atomic.Int32
+}
+
+func newBuildLimiter(limit int) *buildLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &buildLimiter{slots: make(chan struct{}, limit)}
+}
+
+// acquire blocks until a build slot is free
+func (b *buildLimiter) acquire() {
+	b.queued.Add(1)
+	b.slots <- struct{}{}
+	b.queued.Add(-1)
+	b.running.Add(1)
+}
+
+// release frees a slot taken by acquire
+func (b *buildLimiter) release() {
+	b.running.Add(-1)
+	<-b.slots
+}
+
+// SetMaxConcurrentBuilds limits how many plugins this compiler builds at once.
+// It must be called before any compilation starts.
+func (c *Compiler) SetMaxConcurrentBuilds(limit int) {
+	c.builds = newBuildLimiter(limit)
+}
+
+// buildSeparator separates the plugin name from the build stamp in build file
+// names, e.g. seo@1718000000000000000.so
+const buildSeparator = "@"
+
+// newBuildPath returns a path no earlier build of pluginName has used.
+// plugin.Open caches plugins by path, so a rebuild written over the previous
+// file would open as the old code.
+func (c *Compiler) newBuildPath(pluginName string) string {
+	return filepath.Join(c.buildDir, fmt.Sprintf("%s%s%d.so", pluginName, buildSeparator, time.Now().UnixNano()))
+}
+
+// pluginBuilds lists the builds of pluginName, newest first
+func (c *Compiler) pluginBuilds(pluginName string) []cachedBuild {
+	builds, err := c.cachedBuilds()
+	if err != nil {
+		return nil
+	}
+
+	var matching []cachedBuild
+	for i := len(builds) - 1; i >= 0; i-- {
+		if buildPluginName(builds[i].path) == pluginName {
+			matching = append(matching, builds[i])
+		}
+	}
+	return matching
+}
+
+// RemoveBuilds deletes the builds of pluginName other than keep, which may be
+// empty to delete them all. Builds of a running plugin stay mapped in memory,
+// so deleting their files is safe.
+func (c *Compiler) RemoveBuilds(pluginName, keep string) {
+	for _, build := range c.pluginBuilds(pluginName) {
+		if build.path != keep {
+			os.Remove(build.path)
+		}
+	}
+}
+
+// buildPluginName returns the plugin a build file belongs to
+func buildPluginName(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".so")
+	if i := strings.LastIndex(name, buildSeparator); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// CompilePlugin compiles a plugin directory into a new .so file with HostBuildTag
+// and the build tags declared in plugin.json. Earlier builds are left in place,
+// since a running plugin or a rollback may still need them. Builds beyond the
+// concurrency limit wait for a running one to finish.
 func (c *Compiler) CompilePlugin(pluginDir, pluginName string) (string, error) {
+	c.builds.acquire()
+	defer c.builds.release()
+
 	// Ensure build directory exists
 	if err := os.MkdirAll(c.buildDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create build directory: %w", err)
 	}
 
-	// Output file path
-	outputFile := filepath.Join(c.buildDir, pluginName+".so")
+	outputFile := c.newBuildPath(pluginName)
 
-	// Remove existing build
-	os.Remove(outputFile)
+	manifest, err := readManifest(pluginDir)
+	if err != nil {
+		return "", err
+	}
+	tags, err := manifestBuildTags(manifest)
+	if err != nil {
+		return "", err
+	}
 
 	// Check if we need to run go mod tidy
 	if err := c.ensureGoMod(pluginDir, pluginName); err != nil {
@@ -40,7 +146,7 @@ func (c *Compiler) CompilePlugin(pluginDir, pluginName string) (string, error) {
 	}
 
 	// Build the plugin
-	cmd := exec.Command(c.goPath, "build", "-buildmode=plugin", "-o", outputFile, ".")
+	cmd := exec.Command(c.goPath, "build", "-buildmode=plugin", "-tags", strings.Join(tags, ","), "-o", outputFile, ".")
 	cmd.Dir = pluginDir
 
 	// Set environment variables
@@ -63,14 +169,35 @@ func (c *Compiler) CompilePlugin(pluginDir, pluginName string) (string, error) {
 	return outputFile, nil
 }
 
-// EnsureGoMod ensures the plugin has a proper go.mod file
+// EnsureGoMod ensures the plugin has a proper go.mod file. Versions declared in
+// plugin.json are honored; a shipped go.mod must agree with both the manifest and
+// the host, since mismatched shared modules produce a .so the host cannot open.
 func (c *Compiler) ensureGoMod(pluginDir, pluginName string) error {
 	goModPath := filepath.Join(pluginDir, "go.mod")
 
+	manifest, err := readManifest(pluginDir)
+	if err != nil {
+		return err
+	}
+	declared, goVersion := manifestModuleVersions(manifest)
+	host := hostModuleVersions()
+
+	for _, warning := range hostVersionWarnings(declared, host) {
+		log.Printf("Warning: plugin %s: %s", pluginName, warning)
+	}
+
 	// Check if go.mod exists
 	if _, err := os.Stat(goModPath); os.IsNotExist(err) {
 		// Create go.mod
-		if err := c.createGoMod(pluginDir, pluginName); err != nil {
+		if err := c.createGoMod(pluginDir, pluginName, goVersion, declared, host); err != nil {
+			return err
+		}
+	} else {
+		requires, err := readGoModRequires(goModPath)
+		if err != nil {
+			return fmt.Errorf("failed to read go.mod: %w", err)
+		}
+		if err := checkDependencyVersions(requires, declared, host); err != nil {
 			return err
 		}
 	}
@@ -86,70 +213,102 @@ func (c *Compiler) ensureGoMod(pluginDir, pluginName string) error {
 	return nil
 }
 
-// CreateGoMod creates a go.mod file for the plugin
-func (c *Compiler) createGoMod(pluginDir, pluginName string) error {
-	goModContent := fmt.Sprintf(`module %s
+// CreateGoMod creates a go.mod file for the plugin. Shared modules default to the
+// host's versions unless the manifest declares otherwise.
+func (c *Compiler) createGoMod(pluginDir, pluginName, goVersion string, declared, host map[string]string) error {
+	if goVersion == "" {
+		goVersion = "1.21"
+	}
 
-go 1.21
+	requires := make(map[string]string, len(host)+len(declared))
+	for module, version := range host {
+		requires[module] = version
+	}
+	for module, version := range declared {
+		requires[module] = version
+	}
 
-require (
-	github.com/gin-gonic/gin v1.9.1
-	go.mongodb.org/mongo-driver v1.12.1
-)
-`, pluginName)
+	modules := make([]string, 0, len(requires))
+	for module := range requires {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "module %s\n\ngo %s\n\nrequire (\n", pluginName, goVersion)
+	for _, module := range modules {
+		fmt.Fprintf(&b, "\t%s %s\n", module, requires[module])
+	}
+	b.WriteString(")\n")
 
 	goModPath := filepath.Join(pluginDir, "go.mod")
-	return os.WriteFile(goModPath, []byte(goModContent), 0644)
+	return os.WriteFile(goModPath, []byte(b.String()), 0644)
 }
 
-// CompileWithCache compiles plugin only if source is newer than built .so file
+// CompileWithCache compiles plugin only if source is newer than its latest build
 func (c *Compiler) CompileWithCache(pluginDir, pluginName string) (string, bool, error) {
-	outputFile := filepath.Join(c.buildDir, pluginName+".so")
-
-	// Check if .so file exists and get its modification time
-	soInfo, err := os.Stat(outputFile)
-	if os.IsNotExist(err) {
-		// File doesn't exist, need to compile
-		compiled, err := c.CompilePlugin(pluginDir, pluginName)
-		return compiled, true, err
+	latest, err := c.upToDateBuild(pluginDir, pluginName)
+	if err != nil || latest != "" {
+		return latest, false, err
 	}
 
-	// Check if any source files are newer than the .so file
-	needsRecompile, err := c.needsRecompilation(pluginDir, soInfo.ModTime())
-	if err != nil {
-		return "", false, err
+	compiled, err := c.CompilePlugin(pluginDir, pluginName)
+	return compiled, true, err
+}
+
+// upToDateBuild returns the plugin's latest build when no source file is newer
+// than it, and an empty path when the plugin needs compiling
+func (c *Compiler) upToDateBuild(pluginDir, pluginName string) (string, error) {
+	builds := c.pluginBuilds(pluginName)
+	if len(builds) == 0 {
+		// No build yet, need to compile
+		return "", nil
 	}
+	latest := builds[0]
 
-	if needsRecompile {
-		compiled, err := c.CompilePlugin(pluginDir, pluginName)
-		return compiled, true, err
+	// Check if any source files are newer than the build
+	needsRecompile, err := c.needsRecompilation(pluginDir, latest.modTime)
+	if err != nil || needsRecompile {
+		return "", err
 	}
 
 	// Return existing file
-	return outputFile, false, nil
+	return latest.path, nil
 }
 
-// NeedsRecompilation checks if any source files are newer than the target
-func (c *Compiler) needsRecompilation(pluginDir string, targetTime time.Time) (bool, error) {
-	needsRecompile := false
+// errNewerSource stops the walk in needsRecompilation once a newer file is found
+var errNewerSource = errors.New("newer source file found")
 
-	err := filepath.Walk(pluginDir, func(path string, info os.FileInfo, err error) error {
+// needsRecompilation reports whether any .go, go.mod, go.sum or plugin.json file
+// anywhere below pluginDir is newer than targetTime. plugin.json counts because
+// it declares the build tags.
+func (c *Compiler) needsRecompilation(pluginDir string, targetTime time.Time) (bool, error) {
+	err := filepath.WalkDir(pluginDir, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		if entry.IsDir() {
+			return nil
+		}
 
-		// Check .go files and go.mod
-		if strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "go.mod") || strings.HasSuffix(path, "go.sum") {
+		// Check .go files, go.mod and the manifest
+		if strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "go.mod") || strings.HasSuffix(path, "go.sum") || entry.Name() == "plugin.json" {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
 			if info.ModTime().After(targetTime) {
-				needsRecompile = true
-				return filepath.SkipDir // Found a newer file, can stop checking
+				return errNewerSource
 			}
 		}
 
 		return nil
 	})
 
-	return needsRecompile, err
+	if errors.Is(err, errNewerSource) {
+		return true, nil
+	}
+	return false, err
 }
 
 // ValidateCompilation validates that the compiled plugin can be loaded
@@ -197,6 +356,107 @@ func (c *Compiler) CleanupOldBuilds(maxAge time.Duration) error {
 	return nil
 }
 
+// CacheStats describes the compiled plugins in the build directory
+type CacheStats struct {
+	Dir       string     `json:"dir"`
+	TotalSize int64      `json:"total_size"`
+	FileCount int        `json:"file_count"`
+	Oldest    *time.Time `json:"oldest,omitempty"`
+	Newest    *time.Time `json:"newest,omitempty"`
+}
+
+// cachedBuild is a .so file in the build directory
+type cachedBuild struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// cachedBuilds lists the .so files in the build directory, oldest first.
+// A missing build directory is an empty cache.
+func (c *Compiler) cachedBuilds() ([]cachedBuild, error) {
+	entries, err := os.ReadDir(c.buildDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var builds []cachedBuild
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".so") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		builds = append(builds, cachedBuild{
+			path:    filepath.Join(c.buildDir, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(builds, func(i, j int) bool {
+		return builds[i].modTime.Before(builds[j].modTime)
+	})
+	return builds, nil
+}
+
+// GetCacheStats returns the size and age of the compiled plugins in the build directory
+func (c *Compiler) GetCacheStats() (CacheStats, error) {
+	stats := CacheStats{Dir: c.buildDir}
+
+	builds, err := c.cachedBuilds()
+	if err != nil {
+		return stats, err
+	}
+	if len(builds) == 0 {
+		return stats, nil
+	}
+
+	for _, build := range builds {
+		stats.TotalSize += build.size
+	}
+	stats.FileCount = len(builds)
+	oldest, newest := builds[0].modTime, builds[len(builds)-1].modTime
+	stats.Oldest, stats.Newest = &oldest, &newest
+
+	return stats, nil
+}
+
+// TrimBuilds removes the oldest .so files until the cache is no larger than maxSize.
+// A maxSize of 0 or less disables the limit.
+func (c *Compiler) TrimBuilds(maxSize int64) error {
+	if maxSize <= 0 {
+		return nil
+	}
+
+	builds, err := c.cachedBuilds()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, build := range builds {
+		total += build.size
+	}
+	for _, build := range builds {
+		if total <= maxSize {
+			break
+		}
+		if err := os.Remove(build.path); err != nil {
+			log.Printf("Failed to remove cached build %s: %v", build.path, err)
+			continue
+		}
+		total -= build.size
+	}
+
+	return nil
+}
+
 // GetCompilerInfo returns information about the Go compiler
 func (c *Compiler) GetCompilerInfo() (*CompilerInfo, error) {
 	// Get Go version
@@ -221,6 +481,10 @@ func (c *Compiler) GetCompilerInfo() (*CompilerInfo, error) {
 		GOARCH:     envLines[1],
 		CGOEnabled: envLines[2] == "1",
 		BuildDir:   c.buildDir,
+
+		MaxConcurrentBuilds: cap(c.builds.slots),
+		ActiveBuilds:        int(c.builds.running.Load()),
+		QueuedBuilds:        int(c.builds.queued.Load()),
 	}, nil
 }
 
@@ -230,4 +494,8 @@ type CompilerInfo struct {
 	GOARCH     string `json:"goarch"`
 	CGOEnabled bool   `json:"cgo_enabled"`
 	BuildDir   string `json:"build_dir"`
+
+	MaxConcurrentBuilds int `json:"max_concurrent_builds"`
+	ActiveBuilds        int `json:"active_builds"`
+	QueuedBuilds        int `json:"queued_builds"` // Builds waiting for a free slot
 }
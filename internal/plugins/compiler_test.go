@@ -0,0 +1,210 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBuildPluginName(t *testing.T) {
+	for path, want := range map[string]string{
+		"/b/seo@1718000000000000000.so":   "seo",
+		"/b/my-plugin@17.so":              "my-plugin",
+		"/b/legacy.so":                    "legacy",
+		"/b/seo@1@1718000000000000000.so": "seo@1",
+	} {
+		if got := buildPluginName(path); got != want {
+			t.Errorf("buildPluginName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRemoveBuildsKeepsCurrent(t *testing.T) {
+	c := NewCompiler(t.TempDir())
+	os.MkdirAll(c.buildDir, 0755)
+
+	var paths []string
+	for i := 0; i < 3; i++ {
+		path := c.newBuildPath("seo")
+		if err := os.WriteFile(path, []byte("so"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, time.Now(), time.Now().Add(time.Duration(i)*time.Second))
+		paths = append(paths, path)
+	}
+	other := filepath.Join(c.buildDir, "seo-extra@1.so")
+	os.WriteFile(other, []byte("so"), 0644)
+
+	if builds := c.pluginBuilds("seo"); len(builds) != 3 || builds[0].path != paths[2] {
+		t.Fatalf("pluginBuilds = %v, want 3 builds newest first", builds)
+	}
+
+	c.RemoveBuilds("seo", paths[1])
+	if builds := c.pluginBuilds("seo"); len(builds) != 1 || builds[0].path != paths[1] {
+		t.Fatalf("after RemoveBuilds: %v, want only %s", builds, paths[1])
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Error("RemoveBuilds removed another plugin's build")
+	}
+}
+
+func TestNeedsRecompilation(t *testing.T) {
+	built := time.Now().Add(-time.Hour)
+	files := []string{
+		"main.go",
+		"go.mod",
+		"plugin.json",
+		"README.md",
+		"api/handlers.go",
+		"api/v2/deep/nested/routes.go",
+		"assets/logo.png",
+		"zzz/last.go",
+	}
+
+	tests := map[string]struct {
+		newer []string
+		want  bool
+	}{
+		"nothing changed":                      {nil, false},
+		"top level file":                       {[]string{"main.go"}, true},
+		"go.mod":                               {[]string{"go.mod"}, true},
+		"manifest":                             {[]string{"plugin.json"}, true},
+		"one level down":                       {[]string{"api/handlers.go"}, true},
+		"four levels down":                     {[]string{"api/v2/deep/nested/routes.go"}, true},
+		"last directory walked":                {[]string{"zzz/last.go"}, true},
+		"only files that are not built":        {[]string{"README.md", "assets/logo.png"}, false},
+		"after a newer file that is not built": {[]string{"assets/logo.png", "zzz/last.go"}, true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range files {
+				path := filepath.Join(dir, file)
+				os.MkdirAll(filepath.Dir(path), 0755)
+				if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				os.Chtimes(path, built.Add(-time.Hour), built.Add(-time.Hour))
+			}
+			for _, file := range tt.newer {
+				os.Chtimes(filepath.Join(dir, file), time.Now(), time.Now())
+			}
+
+			got, err := NewCompiler(t.TempDir()).needsRecompilation(dir, built)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("needsRecompilation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeGo writes a stand-in go command whose builds record how many builds were
+// running when each started, one count per line in the returned file
+func fakeGo(t *testing.T) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake go command is a shell script")
+	}
+	dir := t.TempDir()
+	running := filepath.Join(dir, "running")
+	counts := filepath.Join(dir, "counts")
+	if err := os.Mkdir(running, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	script := fmt.Sprintf(`#!/bin/sh
+[ "$1" = build ] || exit 0
+while [ $# -gt 0 ]; do
+	[ "$1" = -o ] && out=$2
+	shift
+done
+touch %[1]s/$$
+ls %[1]s | wc -l >> %[2]s
+sleep 0.1
+touch "$out"
+rm %[1]s/$$
+`, running, counts)
+	goPath := filepath.Join(dir, "go")
+	if err := os.WriteFile(goPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return goPath, counts
+}
+
+func TestCompilePluginLimitsConcurrentBuilds(t *testing.T) {
+	goPath, counts := fakeGo(t)
+	c := NewCompiler(t.TempDir())
+	c.goPath = goPath
+	c.SetMaxConcurrentBuilds(2)
+
+	const builds = 6
+	dirs := make([]string, builds)
+	for i := range dirs {
+		name := fmt.Sprintf("plugin%d", i)
+		dirs[i] = filepath.Join(t.TempDir(), name)
+		os.MkdirAll(dirs[i], 0755)
+		for file, content := range pluginFiles(t, map[string]interface{}{"name": name, "version": "1.0.0"}) {
+			os.WriteFile(filepath.Join(dirs[i], file), []byte(content), 0644)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var queued int
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.CompilePlugin(dir, fmt.Sprintf("plugin%d", i)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	// Builds beyond the limit wait in the queue
+	deadline := time.Now().Add(5 * time.Second)
+	for queued == 0 && time.Now().Before(deadline) {
+		if c.builds.running.Load() == 2 {
+			queued = int(c.builds.queued.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+	if queued == 0 {
+		t.Error("no build was queued behind the limit")
+	}
+
+	data, err := os.ReadFile(counts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Fields(string(data))
+	if len(lines) != builds {
+		t.Fatalf("%d builds ran, want %d", len(lines), builds)
+	}
+	for _, line := range lines {
+		if n, _ := strconv.Atoi(line); n > 2 {
+			t.Errorf("%d builds ran at once, want at most 2", n)
+		}
+	}
+	if running, waiting := c.builds.running.Load(), c.builds.queued.Load(); running != 0 || waiting != 0 {
+		t.Errorf("after the builds: %d running and %d queued, want none", running, waiting)
+	}
+}
+
+func TestDefaultMaxConcurrentBuilds(t *testing.T) {
+	if got, want := DefaultMaxConcurrentBuilds(), min(runtime.NumCPU(), 2); got != want {
+		t.Errorf("DefaultMaxConcurrentBuilds = %d, want %d", got, want)
+	}
+	if got := cap(newBuildLimiter(0).slots); got != 1 {
+		t.Errorf("limit 0 allows %d builds, want at least 1", got)
+	}
+}
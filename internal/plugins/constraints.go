@@ -0,0 +1,81 @@
+package plugins
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+
+	"go-cms/internal/semver"
+)
+
+// cmsDependency names the CMS itself in plugin.json dependencies
+const cmsDependency = "cms"
+
+// DependencyError is returned when a plugin's dependency constraints are not met
+// by the running host. Each failure names the constraint and the version found.
+type DependencyError struct {
+	Plugin   string
+	Failures []string
+}
+
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("plugin %s has unmet dependencies:\n  - %s", e.Plugin, strings.Join(e.Failures, "\n  - "))
+}
+
+// CheckDependencies checks the version constraints in the manifest's
+// dependencies, such as "gin": ">=1.9.1", against the Go version the host was
+// built with and the shared modules it was built with. Dependencies given as
+// plain versions are pins written to the plugin's go.mod and are not checked
+// here. A "cms" constraint is part of the plugin's CMS version range and is
+// checked with min_cms_version and max_cms_version by checkCMSVersion.
+func (l *Loader) CheckDependencies(manifest *PluginManifest) error {
+	if manifest == nil {
+		return nil
+	}
+
+	host := hostModuleVersions()
+	var failures []string
+
+	for name, constraint := range manifest.Dependencies {
+		if !semver.IsConstraint(constraint) {
+			continue
+		}
+
+		var have string
+		switch name {
+		case cmsDependency:
+			continue
+		case "go":
+			have = strings.TrimPrefix(runtime.Version(), "go")
+			if !semver.Valid(have) {
+				continue
+			}
+		default:
+			module := name
+			if alias, ok := dependencyAliases[name]; ok {
+				module = alias
+			}
+			version, shared := host[module]
+			if !shared {
+				failures = append(failures, fmt.Sprintf("%s %s: constraints are only supported for cms, go and modules shared with the host", name, constraint))
+				continue
+			}
+			have = version
+		}
+
+		ok, err := semver.Satisfies(have, constraint)
+		switch {
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("%s %s: %v", name, constraint, err))
+		case !ok:
+			failures = append(failures, fmt.Sprintf("%s %s is not satisfied by %s", name, constraint, have))
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	sort.Strings(failures)
+	return &DependencyError{Plugin: manifest.Name, Failures: failures}
+}
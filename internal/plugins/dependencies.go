@@ -0,0 +1,171 @@
+package plugins
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"go-cms/internal/semver"
+)
+
+// Modules shared between the host and plugins. A plugin built against a different
+// version of any of these cannot be opened by the host.
+const (
+	ginModule   = "github.com/gin-gonic/gin"
+	mongoModule = "go.mongodb.org/mongo-driver"
+)
+
+// Host versions used when build info is unavailable; keep in sync with go.mod
+var fallbackHostVersions = map[string]string{
+	ginModule:   "v1.10.1",
+	mongoModule: "v1.17.4",
+}
+
+// dependencyAliases maps the short names used in plugin.json to module paths
+var dependencyAliases = map[string]string{
+	"gin":          ginModule,
+	"mongo":        mongoModule,
+	"mongodb":      mongoModule,
+	"mongo-driver": mongoModule,
+}
+
+// hostModuleVersions returns the versions of the shared modules the host was built with
+func hostModuleVersions() map[string]string {
+	versions := make(map[string]string, len(fallbackHostVersions))
+	for module, version := range fallbackHostVersions {
+		versions[module] = version
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if _, shared := versions[dep.Path]; shared {
+				versions[dep.Path] = dep.Version
+			}
+		}
+	}
+
+	return versions
+}
+
+// manifestModuleVersions resolves the manifest's pinned dependencies to module
+// paths. The "go" entry is returned separately as the language version.
+// Constraints such as ">=1.9.1" are left to CheckDependencies.
+func manifestModuleVersions(manifest *PluginManifest) (map[string]string, string) {
+	modules := make(map[string]string)
+	goVersion := ""
+	if manifest == nil {
+		return modules, goVersion
+	}
+
+	for name, version := range manifest.Dependencies {
+		if semver.IsConstraint(version) {
+			continue
+		}
+		if name == "go" {
+			goVersion = version
+			continue
+		}
+		if module, ok := dependencyAliases[name]; ok {
+			name = module
+		}
+		// Only module paths can be written to go.mod
+		if !strings.Contains(name, "/") {
+			continue
+		}
+		modules[name] = version
+	}
+
+	return modules, goVersion
+}
+
+// readManifest loads and validates plugin.json from the plugin directory if present
+func readManifest(pluginDir string) (*PluginManifest, error) {
+	data, err := os.ReadFile(filepath.Join(pluginDir, "plugin.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin.json: %w", err)
+	}
+
+	return parseManifest(data)
+}
+
+// readGoModRequires returns the required module versions declared in a go.mod file
+func readGoModRequires(goModPath string) (map[string]string, error) {
+	file, err := os.Open(goModPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	requires := make(map[string]string)
+	inBlock := false
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !inBlock:
+			continue
+		}
+
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			requires[fields[0]] = fields[1]
+		}
+	}
+
+	return requires, scanner.Err()
+}
+
+// checkDependencyVersions compares the versions a plugin's go.mod requires with
+// its manifest and with the host. Any divergence is returned as a single error
+// listing every mismatch.
+func checkDependencyVersions(requires, declared, host map[string]string) error {
+	var mismatches []string
+
+	for module, required := range requires {
+		if want, ok := declared[module]; ok && want != required {
+			mismatches = append(mismatches, fmt.Sprintf("%s: go.mod requires %s but plugin.json declares %s", module, required, want))
+		}
+		if want, ok := host[module]; ok && want != required {
+			mismatches = append(mismatches, fmt.Sprintf("%s: go.mod requires %s but the host is built with %s", module, required, want))
+		}
+	}
+
+	if len(mismatches) == 0 {
+		return nil
+	}
+
+	sort.Strings(mismatches)
+	return fmt.Errorf("plugin dependency versions are incompatible:\n  - %s", strings.Join(mismatches, "\n  - "))
+}
+
+// hostVersionWarnings lists manifest dependencies that diverge from the host
+func hostVersionWarnings(declared, host map[string]string) []string {
+	var warnings []string
+	for module, version := range declared {
+		if want, ok := host[module]; ok && want != version {
+			warnings = append(warnings, fmt.Sprintf("%s %s declared in plugin.json differs from host version %s; the plugin will fail to load", module, version, want))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
@@ -0,0 +1,122 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hostKeysKey carries the host context's keys, such as the authenticated user,
+// into a plugin's route table
+type hostKeysKey struct{}
+
+// mountDispatcher adds the routes that send every request under /plugins/ to
+// the dispatcher. They are added once, on the public group when there is one so
+// public plugin routes need no authentication; protected routes then run the
+// protected group's middleware inside the plugin's route table. The caller must
+// hold m.mu.
+func (m *Manager) mountDispatcher() {
+	if m.dispatching || m.router == nil {
+		return
+	}
+	m.dispatching = true
+
+	group := m.publicRoute
+	if group == nil {
+		group = m.router
+	}
+	group.Any("/plugins/:plugin", m.dispatch)
+	group.Any("/plugins/:plugin/*path", m.dispatch)
+}
+
+// buildRouteTable records a plugin's routes in a gin engine of its own, at the
+// same paths they would have on the host router. Unlike routes on the host
+// router, the table can be replaced on reload and dropped on unload. The caller
+// must hold m.mu.
+func (m *Manager) buildRouteTable(name string, plugin Plugin) (_ *gin.Engine, err error) {
+	// gin panics on conflicting routes, such as a public and a protected route
+	// with the same method and path, so report them as an error instead
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("plugin %s registers conflicting routes: %v", name, r)
+		}
+	}()
+
+	base := m.router.BasePath() + "/plugins/" + strings.ToLower(name)
+
+	table := gin.New()
+	table.Use(restoreHostKeys)
+	table.NoRoute(func(c *gin.Context) {
+		reason := &unavailable{http.StatusNotFound, CodeRouteNotFound, fmt.Sprintf("Plugin %s has no route %s %s", name, c.Request.Method, c.Request.URL.Path), 0}
+		reason.abort(c, name)
+	})
+
+	// The dispatcher runs behind the public group's middleware, so protected
+	// routes add only what the protected group has on top of it
+	var protected []gin.HandlerFunc
+	if m.publicRoute != nil {
+		protected = m.protectedMW
+	}
+	plugin.RegisterRoutes(table.Group(base, protected...))
+
+	// Public routes are opt-in and mounted outside authentication
+	if provider, ok := plugin.(PublicRoutesProvider); ok && m.publicRoute != nil {
+		provider.RegisterPublicRoutes(table.Group(base))
+	}
+
+	// Assets are public so pages can reference them directly
+	if _, err := os.Stat(m.assetsDir(name)); err == nil {
+		table.GET(base+"/assets/*filepath", m.serveAssets(name))
+	}
+
+	return table, nil
+}
+
+// dispatch serves a request under /plugins/<name>/ from the plugin's current
+// route table. A plugin that is switched off, unloaded or being reloaded gets a
+// structured error instead, so unloading takes effect at once. Admitted
+// requests are counted so unloading can wait for them.
+func (m *Manager) dispatch(c *gin.Context) {
+	// Count the request under the lock so an unload cannot miss it
+	m.mu.RLock()
+	name := m.nameForSegment(c.Param("plugin"))
+	reason := m.availability(name)
+	table := m.routes[name]
+	stats := m.stats[name]
+	active := m.active[name]
+	if reason == nil && table != nil {
+		active.add()
+	}
+	m.mu.RUnlock()
+
+	if reason == nil && table == nil {
+		reason = &unavailable{http.StatusNotFound, CodeRouteNotFound, fmt.Sprintf("Plugin %s has no routes", name), 0}
+	}
+	if reason != nil {
+		reason.abort(c, name)
+		return
+	}
+	defer active.done()
+
+	start := time.Now()
+	ctx := context.WithValue(c.Request.Context(), hostKeysKey{}, c.Keys)
+	table.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+	stats.observe(time.Since(start), c.Writer.Status())
+	c.Abort()
+}
+
+// restoreHostKeys copies the keys set by the host's middleware, such as the
+// authenticated user, into the plugin's request context
+func restoreHostKeys(c *gin.Context) {
+	if keys, ok := c.Request.Context().Value(hostKeysKey{}).(map[string]any); ok {
+		for key, value := range keys {
+			c.Set(key, value)
+		}
+	}
+	c.Next()
+}
@@ -0,0 +1,88 @@
+package plugins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProtectedPluginRoutesRunHostMiddlewareOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var engineRuns, authRuns int
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) { engineRuns++ })
+	authenticate := func(c *gin.Context) {
+		authRuns++
+		if c.GetHeader("Authorization") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set("user_id", "u1")
+	}
+
+	m := NewManager()
+	m.loader = NewLoader(t.TempDir())
+	m.SetPublicRouter(engine.Group("/api/v1"))
+	m.SetProtectedMiddleware(authenticate)
+	m.RegisterRoutes(engine.Group("/api/v1", authenticate))
+	host := &testHost{manager: m, engine: engine}
+
+	plugin := newFakePlugin("guarded")
+	plugin.routes = func(r *gin.RouterGroup) {
+		r.GET("/me", func(c *gin.Context) { c.String(http.StatusOK, c.GetString("user_id")) })
+	}
+	host.add(plugin)
+
+	if w := host.do(http.MethodGet, "/api/v1/plugins/guarded/me"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated request: status %d, want 401", w.Code)
+	}
+	if engineRuns != 1 || authRuns != 1 {
+		t.Fatalf("engine middleware ran %d times and auth %d, want once each", engineRuns, authRuns)
+	}
+
+	engineRuns, authRuns = 0, 0
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/plugins/guarded/me", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	host.engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "u1" {
+		t.Fatalf("authenticated request: status %d body %q", w.Code, w.Body.String())
+	}
+	if engineRuns != 1 || authRuns != 1 {
+		t.Fatalf("engine middleware ran %d times and auth %d, want once each", engineRuns, authRuns)
+	}
+}
+
+func TestConflictingPublicAndProtectedRoutesFailTheLoad(t *testing.T) {
+	host := newTestHost(t)
+
+	plugin := newFakePlugin("comments")
+	plugin.routes = func(r *gin.RouterGroup) {
+		r.POST("/comments", func(c *gin.Context) {})
+	}
+	plugin.public = func(r *gin.RouterGroup) {
+		r.POST("/comments", func(c *gin.Context) {})
+	}
+
+	host.manager.mu.Lock()
+	_, err := host.manager.startPlugin("comments", publicFakePlugin{plugin}, time.Now())
+	host.manager.mu.Unlock()
+	if err == nil || !strings.Contains(err.Error(), "conflicting routes") {
+		t.Fatalf("err = %v, want a conflicting routes error", err)
+	}
+
+	if _, loaded := host.manager.GetPlugin("comments"); loaded {
+		t.Error("plugin with conflicting routes was left loaded")
+	}
+	if plugin.shutdownCount() != 1 {
+		t.Errorf("plugin shut down %d times, want 1", plugin.shutdownCount())
+	}
+	if w := host.do(http.MethodPost, "/api/v1/plugins/comments/comments"); w.Code != http.StatusNotFound {
+		t.Errorf("status %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,97 @@
+package plugins
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultDrainTimeout bounds how long unloading a plugin waits for its in-flight
+// requests to finish
+const DefaultDrainTimeout = 10 * time.Second
+
+// SetDrainTimeout sets how long unloading a plugin waits for requests already
+// running in its handlers before calling Shutdown anyway. Zero or less does not
+// wait. Call it before plugins are loaded.
+func (m *Manager) SetDrainTimeout(timeout time.Duration) {
+	m.drainTimeout = timeout
+}
+
+// requestTracker counts the requests running in a plugin's handlers and lets an
+// unload wait for them without polling
+type requestTracker struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{} // Closed when n drops to zero
+}
+
+func newRequestTracker() *requestTracker {
+	idle := make(chan struct{})
+	close(idle)
+	return &requestTracker{idle: idle}
+}
+
+// add records a request entering the plugin's handlers
+func (t *requestTracker) add() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.n == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.n++
+}
+
+// done records a request leaving the plugin's handlers
+func (t *requestTracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.n--
+	if t.n == 0 {
+		close(t.idle)
+	}
+}
+
+// count returns the number of requests running
+func (t *requestTracker) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.n
+}
+
+// wait returns a channel that is closed once no requests are running
+func (t *requestTracker) wait() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.idle
+}
+
+// activeFor returns the in-flight request tracker of a plugin, creating it if
+// needed. Trackers are kept across reloads so a drain also waits for requests
+// still running in the previous load's handlers.
+// The caller must hold m.mu.
+func (m *Manager) activeFor(name string) *requestTracker {
+	active, exists := m.active[name]
+	if !exists {
+		active = newRequestTracker()
+		m.active[name] = active
+	}
+	return active
+}
+
+// drain waits, up to the drain timeout, for a plugin's in-flight requests to
+// finish. The caller must not hold m.mu, so the requests can complete and other
+// plugins keep serving meanwhile; the plugin must already be marked unloading so
+// dispatch admits no new requests.
+func (m *Manager) drain(name string, active *requestTracker) {
+	if active == nil || m.drainTimeout <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(m.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-active.wait():
+	case <-timer.C:
+		log.Printf("Plugin %s still has %d requests in flight after %s; shutting it down anyway", name, active.count(), m.drainTimeout)
+	}
+}
@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUnloadWaitsForSlowRequest(t *testing.T) {
+	host := newTestHost(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+
+	plugin := newFakePlugin("slow")
+	plugin.routes = func(r *gin.RouterGroup) {
+		r.GET("/work", func(c *gin.Context) {
+			close(started)
+			<-release
+			mu.Lock()
+			order = append(order, "request")
+			mu.Unlock()
+			c.String(http.StatusOK, "done")
+		})
+	}
+	plugin.onShutdown = func() {
+		mu.Lock()
+		order = append(order, "shutdown")
+		mu.Unlock()
+	}
+	host.add(plugin)
+	host.add(newFakePlugin("other"))
+
+	result := make(chan int)
+	go func() { result <- host.do(http.MethodGet, "/api/v1/plugins/slow/work").Code }()
+	<-started
+
+	unloaded := make(chan error)
+	go func() { unloaded <- host.manager.UnloadPlugin("slow") }()
+
+	// While draining, the manager lock is free and the plugin turns new requests away
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		host.manager.mu.RLock()
+		draining := host.manager.unloading["slow"]
+		host.manager.mu.RUnlock()
+		if draining {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("unload did not start draining")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if len(host.manager.GetAllPlugins()) != 2 {
+		t.Fatal("loaded plugins changed before the drain finished")
+	}
+	if w := host.do(http.MethodGet, "/api/v1/plugins/slow/work"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("request during drain: status %d, want 503", w.Code)
+	}
+	if plugin.shutdownCount() != 0 {
+		t.Fatal("Shutdown ran before the in-flight request finished")
+	}
+
+	close(release)
+	if code := <-result; code != http.StatusOK {
+		t.Fatalf("in-flight request: status %d, want 200", code)
+	}
+	if err := <-unloaded; err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 2 || order[0] != "request" || order[1] != "shutdown" {
+		t.Fatalf("order = %v, want [request shutdown]", order)
+	}
+}
+
+func TestUnloadGivesUpAfterDrainTimeout(t *testing.T) {
+	host := newTestHost(t)
+	host.manager.SetDrainTimeout(20 * time.Millisecond)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	plugin := newFakePlugin("stuck")
+	plugin.routes = func(r *gin.RouterGroup) {
+		r.GET("/work", func(c *gin.Context) {
+			close(started)
+			<-release
+		})
+	}
+	host.add(plugin)
+
+	go host.do(http.MethodGet, "/api/v1/plugins/stuck/work")
+	<-started
+
+	if err := host.manager.UnloadPlugin("stuck"); err != nil {
+		t.Fatal(err)
+	}
+	if plugin.shutdownCount() != 1 {
+		t.Fatal("Shutdown did not run after the drain timeout")
+	}
+}
+
+func TestRequestTracker(t *testing.T) {
+	tracker := newRequestTracker()
+	select {
+	case <-tracker.wait():
+	default:
+		t.Fatal("new tracker is not idle")
+	}
+
+	tracker.add()
+	tracker.add()
+	idle := tracker.wait()
+	tracker.done()
+	select {
+	case <-idle:
+		t.Fatal("idle with a request still running")
+	default:
+	}
+	tracker.done()
+	select {
+	case <-idle:
+	default:
+		t.Fatal("not idle after every request finished")
+	}
+}
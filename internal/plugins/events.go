@@ -0,0 +1,21 @@
+package plugins
+
+// Plugin lifecycle events, emitted on the manager's event bus after the
+// transition has succeeded. Event data carries "plugin" and "version".
+const (
+	EventPluginInstalled   = "plugin.installed"
+	EventPluginUpdated     = "plugin.updated"
+	EventPluginActivated   = "plugin.activated"
+	EventPluginDeactivated = "plugin.deactivated"
+	EventPluginReloaded    = "plugin.reloaded"
+	EventPluginUninstalled = "plugin.uninstalled"
+)
+
+// emit publishes a lifecycle event. It must be called without m.mu held so
+// subscribers can call back into the manager.
+func (m *Manager) emit(event, name, version string) {
+	m.events.Emit(event, map[string]interface{}{
+		"plugin":  name,
+		"version": version,
+	})
+}
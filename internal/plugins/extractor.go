@@ -4,24 +4,51 @@ import (
 	"archive/zip"
 	"encoding/json"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
+// Hard limits on what a plugin zip may extract, guarding against zip bombs
+const (
+	maxExtractFiles     = 10000
+	maxExtractFileSize  = 100 << 20 // Uncompressed bytes per file
+	maxExtractTotalSize = 500 << 20 // Uncompressed bytes per plugin
+)
+
 type Extractor struct {
 	basePluginDir string
+	maxTotalSize  int64 // Uncompressed bytes one extraction may write
 }
 
 func NewExtractor(basePluginDir string) *Extractor {
 	return &Extractor{
 		basePluginDir: basePluginDir,
+		maxTotalSize:  maxExtractTotalSize,
 	}
 }
 
 // ExtractZipPlugin extracts a zip file to the plugins directory
 func (e *Extractor) ExtractZipPlugin(zipPath, pluginName string) (string, error) {
+	return e.extractTo(zipPath, pluginName, "")
+}
+
+// ExtractBundleMember extracts one member plugin of a bundle zip, the entries under
+// its top-level directory, to the plugins directory
+func (e *Extractor) ExtractBundleMember(zipPath, member string) (string, error) {
+	return e.extractTo(zipPath, member, member+"/")
+}
+
+// extractTo extracts the zip entries under prefix into the plugin's directory,
+// stripping the prefix from their paths. On any failure the plugin directory is
+// removed, so a later scan never finds a partially extracted plugin.
+func (e *Extractor) extractTo(zipPath, pluginName, prefix string) (_ string, err error) {
 	// Create plugin directory
 	pluginDir := filepath.Join(e.basePluginDir, pluginName)
 	if err := os.RemoveAll(pluginDir); err != nil {
@@ -31,6 +58,13 @@ func (e *Extractor) ExtractZipPlugin(zipPath, pluginName string) (string, error)
 	if err := os.MkdirAll(pluginDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create plugin directory: %w", err)
 	}
+	defer func() {
+		if err != nil {
+			if removeErr := os.RemoveAll(pluginDir); removeErr != nil {
+				err = fmt.Errorf("%w (and failed to remove partial plugin directory: %v)", err, removeErr)
+			}
+		}
+	}()
 
 	// Open zip file
 	reader, err := zip.OpenReader(zipPath)
@@ -39,90 +73,300 @@ func (e *Extractor) ExtractZipPlugin(zipPath, pluginName string) (string, error)
 	}
 	defer reader.Close()
 
-	// Extract files
+	// Extract files. Many small files can add up to a bomb too, so the bytes
+	// written are capped across the whole plugin as well as per file.
+	extracted := 0
+	remaining := e.maxTotalSize
 	for _, file := range reader.File {
-		if err := e.extractFile(file, pluginDir); err != nil {
+		if !strings.HasPrefix(file.Name, prefix) || file.Name == prefix {
+			continue
+		}
+		if extracted++; extracted > maxExtractFiles {
+			return "", fmt.Errorf("plugin has more than %d files", maxExtractFiles)
+		}
+		written, err := e.extractFile(file, strings.TrimPrefix(file.Name, prefix), pluginDir, remaining)
+		if err != nil {
 			return "", fmt.Errorf("failed to extract file %s: %w", file.Name, err)
 		}
+		remaining -= written
+	}
+
+	// Zipping a folder rather than its contents leaves the plugin one level down
+	if err := flattenWrappingDir(pluginDir); err != nil {
+		return "", fmt.Errorf("failed to normalize plugin layout: %w", err)
 	}
 
 	return pluginDir, nil
 }
 
-func (e *Extractor) extractFile(file *zip.File, destDir string) error {
+// flattenWrappingDir moves the contents of wrapping directories up into pluginDir.
+// A directory only counts as a wrapper when it is the sole entry and pluginDir
+// itself holds no plugin files, so multi-directory plugins are left untouched.
+func flattenWrappingDir(pluginDir string) error {
+	for !isPluginRoot(pluginDir) {
+		entries, err := os.ReadDir(pluginDir)
+		if err != nil {
+			return err
+		}
+
+		var wrapper os.DirEntry
+		var junk []string
+		for _, entry := range entries {
+			if isArchiveJunk(entry.Name()) {
+				junk = append(junk, entry.Name())
+				continue
+			}
+			if wrapper != nil || !entry.IsDir() {
+				return nil
+			}
+			wrapper = entry
+		}
+		if wrapper == nil {
+			return nil
+		}
+
+		// Drop archive metadata so it cannot collide with the unwrapped files
+		for _, name := range junk {
+			if err := os.RemoveAll(filepath.Join(pluginDir, name)); err != nil {
+				return err
+			}
+		}
+
+		// Move the wrapper aside first in case it contains an entry with its own name
+		staging := filepath.Join(pluginDir, ".unwrap-"+wrapper.Name())
+		if err := os.Rename(filepath.Join(pluginDir, wrapper.Name()), staging); err != nil {
+			return err
+		}
+
+		inner, err := os.ReadDir(staging)
+		if err != nil {
+			return err
+		}
+		for _, entry := range inner {
+			if err := os.Rename(filepath.Join(staging, entry.Name()), filepath.Join(pluginDir, entry.Name())); err != nil {
+				return err
+			}
+		}
+
+		if err := os.Remove(staging); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isPluginRoot reports whether dir directly holds the plugin's main.go, plugin.json
+// or a file declaring NewPlugin
+func isPluginRoot(dir string) bool {
+	for _, marker := range []string{"main.go", "plugin.json"} {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+
+	mainFile, _, err := findNewPlugin(dir)
+	return err == nil && mainFile != ""
+}
+
+// isArchiveJunk reports whether an entry is metadata added by archiving tools
+func isArchiveJunk(name string) bool {
+	return name == "__MACOSX" || strings.HasPrefix(name, ".")
+}
+
+// extractFile writes one zip entry below destDir and returns the bytes written.
+// remaining is what is left of the extraction's total size budget.
+func (e *Extractor) extractFile(file *zip.File, name, destDir string, remaining int64) (int64, error) {
 	// Clean file path to prevent directory traversal
-	cleanPath := filepath.Clean(file.Name)
+	cleanPath := filepath.Clean(name)
 	if strings.Contains(cleanPath, "..") {
-		return fmt.Errorf("invalid file path: %s", file.Name)
+		return 0, fmt.Errorf("invalid file path: %s", file.Name)
 	}
 
 	destPath := filepath.Join(destDir, cleanPath)
 
 	// Create directory if it's a directory entry
 	if file.FileInfo().IsDir() {
-		return os.MkdirAll(destPath, file.FileInfo().Mode())
+		return 0, os.MkdirAll(destPath, file.FileInfo().Mode())
+	}
+
+	if file.UncompressedSize64 > maxExtractFileSize {
+		return 0, fmt.Errorf("file is larger than %d bytes", maxExtractFileSize)
+	}
+	if file.UncompressedSize64 > uint64(remaining) {
+		return 0, fmt.Errorf("plugin is larger than %d bytes uncompressed", e.maxTotalSize)
 	}
 
 	// Create parent directory
 	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return err
+		return 0, err
 	}
 
 	// Open file from zip
 	rc, err := file.Open()
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer rc.Close()
 
 	// Create destination file
 	outFile, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.FileInfo().Mode())
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer outFile.Close()
 
-	// Copy content
-	_, err = io.Copy(outFile, rc)
-	return err
+	// Copy content, without trusting the size recorded in the zip header
+	limit := min(maxExtractFileSize, remaining)
+	written, err := io.Copy(outFile, io.LimitReader(rc, limit+1))
+	if err != nil {
+		return written, err
+	}
+	if written > maxExtractFileSize {
+		return written, fmt.Errorf("file is larger than %d bytes", maxExtractFileSize)
+	}
+	if written > remaining {
+		return written, fmt.Errorf("plugin is larger than %d bytes uncompressed", e.maxTotalSize)
+	}
+	return written, outFile.Close()
 }
 
-// ValidatePluginStructure validates that the extracted plugin has the required structure
-func (e *Extractor) ValidatePluginStructure(pluginDir string) error {
-	// Check for main.go
-	mainFile := filepath.Join(pluginDir, "main.go")
-	if _, err := os.Stat(mainFile); os.IsNotExist(err) {
-		return fmt.Errorf("plugin must contain main.go file")
+// ValidatePluginStructure validates that the extracted plugin has the required
+// structure and returns the package root to compile. The root is the directory
+// holding the main package that declares NewPlugin; it may be nested, e.g. when
+// a folder was zipped instead of its contents.
+func (e *Extractor) ValidatePluginStructure(pluginDir string) (string, error) {
+	root, mainFile, err := findPluginRoot(pluginDir)
+	if err != nil {
+		return "", err
 	}
 
 	// Check for plugin.json (WordPress-like manifest)
-	manifestFile := filepath.Join(pluginDir, "plugin.json")
+	manifestFile := filepath.Join(root, "plugin.json")
 	if _, err := os.Stat(manifestFile); os.IsNotExist(err) {
-		// Try to auto-generate from main.go if plugin.json doesn't exist
-		return e.generateManifest(pluginDir)
+		// Try to auto-generate if plugin.json doesn't exist
+		if err := e.generateManifest(root, filepath.Base(pluginDir), mainFile); err != nil {
+			return "", err
+		}
 	}
 
-	return nil
+	return root, nil
+}
+
+// findPluginRoot locates the shallowest directory whose Go files form a main
+// package declaring func NewPlugin, along with the file that declares it
+func findPluginRoot(pluginDir string) (string, string, error) {
+	var candidates []string
+	err := filepath.WalkDir(pluginDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != pluginDir && skipSourceDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		candidates = append(candidates, path)
+		return nil
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to scan plugin directory: %w", err)
+	}
+
+	// WalkDir is lexical, so order by depth to prefer the shallowest root
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return strings.Count(candidates[i], string(filepath.Separator)) < strings.Count(candidates[j], string(filepath.Separator))
+	})
+
+	foundGoFiles := false
+	for _, dir := range candidates {
+		mainFile, hasGoFiles, err := findNewPlugin(dir)
+		if err != nil {
+			return "", "", err
+		}
+		foundGoFiles = foundGoFiles || hasGoFiles
+		if mainFile != "" {
+			return dir, mainFile, nil
+		}
+	}
+
+	if !foundGoFiles {
+		return "", "", fmt.Errorf("plugin must contain Go source files")
+	}
+	return "", "", fmt.Errorf("plugin must contain a main package with a NewPlugin function")
+}
+
+// findNewPlugin parses the Go files in dir and returns the file declaring
+// NewPlugin. Every non-test file in that directory must belong to package main.
+func findNewPlugin(dir string) (string, bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false, err
+	}
+
+	fset := token.NewFileSet()
+	mainFile := ""
+	hasGoFiles := false
+	var otherPackages []string
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		hasGoFiles = true
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return "", true, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+
+		if file.Name.Name != "main" {
+			otherPackages = append(otherPackages, fmt.Sprintf("%s (package %s)", name, file.Name.Name))
+			continue
+		}
+
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "NewPlugin" {
+				mainFile = name
+			}
+		}
+	}
+
+	if mainFile != "" && len(otherPackages) > 0 {
+		return "", true, fmt.Errorf("all Go files in the plugin root must be package main: %s", strings.Join(otherPackages, ", "))
+	}
+
+	return mainFile, hasGoFiles, nil
 }
 
-// GenerateManifest creates a plugin.json file by analyzing main.go
-func (e *Extractor) generateManifest(pluginDir string) error {
-	manifest := `{
-  "name": "` + filepath.Base(pluginDir) + `",
-  "version": "1.0.0",
-  "description": "Auto-generated plugin",
-  "author": "Unknown",
-  "main": "main.go",
-  "dependencies": {
-    "go": "1.21"
-  }
-}`
+// skipSourceDir reports whether a directory can never hold the plugin root
+func skipSourceDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata"
+}
+
+// GenerateManifest creates a plugin.json file for a plugin that does not ship one
+func (e *Extractor) generateManifest(pluginDir, pluginName, mainFile string) error {
+	manifest := PluginManifest{
+		Name:         pluginName,
+		Version:      "1.0.0",
+		Description:  "Auto-generated plugin",
+		Author:       "Unknown",
+		Main:         mainFile,
+		Dependencies: map[string]string{"go": "1.21"},
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
 
 	manifestPath := filepath.Join(pluginDir, "plugin.json")
-	return os.WriteFile(manifestPath, []byte(manifest), 0644)
+	return os.WriteFile(manifestPath, data, 0644)
 }
 
-// GetPluginInfo reads plugin information from plugin.json
+// GetPluginInfo reads plugin information from plugin.json, which must match the
+// manifest schema; see ManifestError
 func (e *Extractor) GetPluginInfo(pluginDir string) (*PluginManifest, error) {
 	manifestPath := filepath.Join(pluginDir, "plugin.json")
 	data, err := os.ReadFile(manifestPath)
@@ -130,12 +374,7 @@ func (e *Extractor) GetPluginInfo(pluginDir string) (*PluginManifest, error) {
 		return nil, fmt.Errorf("failed to read plugin.json: %w", err)
 	}
 
-	var manifest PluginManifest
-	if err := json.Unmarshal(data, &manifest); err != nil {
-		return nil, fmt.Errorf("failed to parse plugin.json: %w", err)
-	}
-
-	return &manifest, nil
+	return parseManifest(data)
 }
 
 type PluginManifest struct {
@@ -147,4 +386,11 @@ type PluginManifest struct {
 	Main         string            `json:"main"`
 	Dependencies map[string]string `json:"dependencies"`
 	Scripts      map[string]string `json:"scripts,omitempty"`
+	Capabilities []string          `json:"capabilities,omitempty"` // Omitted means all, for older plugins
+	Priority     *int              `json:"priority,omitempty"`     // Lower loads earlier; omitted means DefaultPriority
+	BuildTags    []string          `json:"build_tags,omitempty"`   // Passed to go build in addition to HostBuildTag
+
+	// Range of CMS versions the plugin supports; either end may be omitted
+	MinCMSVersion string `json:"min_cms_version,omitempty"`
+	MaxCMSVersion string `json:"max_cms_version,omitempty"`
 }
@@ -0,0 +1,126 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestExtractTotalSizeCap(t *testing.T) {
+	extractor := NewExtractor(t.TempDir())
+	extractor.maxTotalSize = 1000
+
+	// Each file is well under the per-file cap; together they are over the total
+	files := map[string]string{"main.go": pluginMain}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		files[name] = strings.Repeat("x", 400)
+	}
+	_, err := extractor.ExtractZipPlugin(writeZip(t, files), "bomb")
+	if err == nil || !strings.Contains(err.Error(), "larger than 1000 bytes uncompressed") {
+		t.Fatalf("extract error = %v, want the total size cap", err)
+	}
+
+	// The files written before the cap was hit are removed with the directory
+	if _, err := os.Stat(filepath.Join(extractor.basePluginDir, "bomb")); !os.IsNotExist(err) {
+		t.Errorf("partially extracted plugin left behind: %v", err)
+	}
+}
+
+func TestExtractWithinTotalSizeCap(t *testing.T) {
+	extractor := NewExtractor(t.TempDir())
+	extractor.maxTotalSize = 1000
+
+	files := map[string]string{"main.go": pluginMain, "a.txt": strings.Repeat("x", 500)}
+	dir, err := extractor.ExtractZipPlugin(writeZip(t, files), "forms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "a.txt")); err != nil || len(data) != 500 {
+		t.Errorf("a.txt = %d bytes, %v; want all 500", len(data), err)
+	}
+}
+
+func TestExtractFailureRemovesPluginDir(t *testing.T) {
+	extractor := NewExtractor(t.TempDir())
+
+	files := map[string]string{"main.go": pluginMain, "../escape.go": pluginMain}
+	if _, err := extractor.ExtractZipPlugin(writeZip(t, files), "forms"); err == nil {
+		t.Fatal("zip with a path outside the plugin was extracted")
+	}
+	if _, err := os.Stat(filepath.Join(extractor.basePluginDir, "forms")); !os.IsNotExist(err) {
+		t.Errorf("plugin directory left behind after a failed extraction: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(extractor.basePluginDir, "escape.go")); !os.IsNotExist(err) {
+		t.Error("an entry escaped the plugin directory")
+	}
+}
+
+// extractedFiles lists the files below dir, relative to it and with forward slashes
+func extractedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestExtractFlattensWrappingDir(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+		want  []string
+	}{
+		"flat": {
+			files: map[string]string{"main.go": pluginMain, "handlers/api.go": "package handlers"},
+			want:  []string{"handlers/api.go", "main.go"},
+		},
+		"wrapped": {
+			files: map[string]string{"seo-1.0/main.go": pluginMain, "seo-1.0/handlers/api.go": "package handlers"},
+			want:  []string{"handlers/api.go", "main.go"},
+		},
+		"wrapped twice": {
+			files: map[string]string{"dist/seo/plugin.json": `{"name":"seo"}`, "dist/seo/main.go": pluginMain},
+			want:  []string{"main.go", "plugin.json"},
+		},
+		"wrapped with archive metadata": {
+			files: map[string]string{"seo/main.go": pluginMain, "__MACOSX/seo/._main.go": "x", ".DS_Store": "x"},
+			want:  []string{"main.go"},
+		},
+		"wrapper holding a directory of its own name": {
+			files: map[string]string{"seo/main.go": pluginMain, "seo/seo/util.go": "package seo"},
+			want:  []string{"main.go", "seo/util.go"},
+		},
+		"root with a single subdirectory": {
+			files: map[string]string{"plugin.json": `{"name":"seo"}`, "src/main.go": pluginMain},
+			want:  []string{"plugin.json", "src/main.go"},
+		},
+		"several top-level directories": {
+			files: map[string]string{"api/main.go": pluginMain, "web/index.html": "<p>"},
+			want:  []string{"api/main.go", "web/index.html"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			extractor := NewExtractor(t.TempDir())
+			dir, err := extractor.ExtractZipPlugin(writeZip(t, tt.files), "seo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := extractedFiles(t, dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extracted %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Codes in the error body of requests to plugins that cannot serve them
+const (
+	CodePluginNotFound  = "PLUGIN_NOT_FOUND"
+	CodePluginDisabled  = "PLUGIN_DISABLED"
+	CodePluginReloading = "PLUGIN_RELOADING"
+	CodePluginUnloading = "PLUGIN_UNLOADING"
+	CodeRouteNotFound   = "ROUTE_NOT_FOUND"
+)
+
+// reloadRetryAfter is the Retry-After, in seconds, sent while a plugin reloads
+const reloadRetryAfter = 5
+
+// unavailable describes why a plugin cannot serve a request
+type unavailable struct {
+	status     int
+	code       string
+	message    string
+	retryAfter int // Seconds; 0 when retrying will not help
+}
+
+// availability reports why the named plugin cannot serve requests, or nil when it
+// can. The caller must hold m.mu.
+func (m *Manager) availability(name string) *unavailable {
+	_, loaded := m.plugins[name]
+	switch {
+	case loaded && m.unloading[name]:
+		return &unavailable{http.StatusServiceUnavailable, CodePluginUnloading, fmt.Sprintf("Plugin %s is shutting down", name), 0}
+	case loaded && !m.routesOff[name]:
+		return nil
+	case loaded:
+		return &unavailable{http.StatusServiceUnavailable, CodePluginDisabled, fmt.Sprintf("Plugin %s is disabled", name), 0}
+	case m.reloading[name]:
+		return &unavailable{http.StatusServiceUnavailable, CodePluginReloading, fmt.Sprintf("Plugin %s is reloading", name), reloadRetryAfter}
+	case m.disabled[name]:
+		return &unavailable{http.StatusServiceUnavailable, CodePluginDisabled, fmt.Sprintf("Plugin %s is disabled", name), 0}
+	default:
+		return &unavailable{http.StatusNotFound, CodePluginNotFound, fmt.Sprintf("Plugin %s is not installed", name), 0}
+	}
+}
+
+// abort answers the request with the structured error body:
+// {"error": {"code": ..., "message": ..., "plugin": ...}}
+func (u *unavailable) abort(c *gin.Context, name string) {
+	body := gin.H{
+		"code":    u.code,
+		"message": u.message,
+		"plugin":  name,
+	}
+	if u.retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(u.retryAfter))
+		body["retry_after"] = u.retryAfter
+	}
+	c.AbortWithStatusJSON(u.status, gin.H{"error": body})
+}
+
+// nameForSegment maps the lowercased plugin name used in route paths back to the
+// plugin's name, returning segment itself when no plugin matches. The caller
+// must hold m.mu.
+func (m *Manager) nameForSegment(segment string) string {
+	for name := range m.plugins {
+		if strings.ToLower(name) == segment {
+			return name
+		}
+	}
+	for _, names := range []map[string]bool{m.reloading, m.disabled} {
+		for name := range names {
+			if strings.ToLower(name) == segment {
+				return name
+			}
+		}
+	}
+	return segment
+}
@@ -0,0 +1,164 @@
+package plugins
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gateError decodes the structured error body of a gated plugin request
+func gateError(t *testing.T, w *httptest.ResponseRecorder) (code, plugin string) {
+	t.Helper()
+	var body struct {
+		Error struct {
+			Code   string `json:"code"`
+			Plugin string `json:"plugin"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error body %q: %v", w.Body.String(), err)
+	}
+	return body.Error.Code, body.Error.Plugin
+}
+
+func TestGatedRoutes(t *testing.T) {
+	host := newTestHost(t)
+	plugin := newFakePlugin("forms")
+	plugin.routes = func(r *gin.RouterGroup) {
+		r.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	}
+	host.add(plugin)
+
+	w := host.do(http.MethodGet, "/api/v1/plugins/missing/ping")
+	if code, name := gateError(t, w); w.Code != http.StatusNotFound || code != CodePluginNotFound || name != "missing" {
+		t.Errorf("missing plugin: %d %s for %q, want 404 %s", w.Code, code, name, CodePluginNotFound)
+	}
+
+	host.manager.SetRoutesEnabled("forms", false)
+	w = host.do(http.MethodGet, "/api/v1/plugins/forms/ping")
+	if code, _ := gateError(t, w); w.Code != http.StatusServiceUnavailable || code != CodePluginDisabled {
+		t.Errorf("switched off plugin: %d %s, want 503 %s", w.Code, code, CodePluginDisabled)
+	}
+	if w.Header().Get("Retry-After") != "" {
+		t.Error("a switched off plugin sent Retry-After; retrying will not help")
+	}
+
+	host.manager.SetRoutesEnabled("forms", true)
+	if w := host.do(http.MethodGet, "/api/v1/plugins/forms/ping"); w.Code != http.StatusOK {
+		t.Errorf("switched back on: status %d, want 200", w.Code)
+	}
+}
+
+func TestGatedRoutesOfDeactivatedPlugin(t *testing.T) {
+	host := newTestHost(t)
+	host.add(newFakePlugin("forms"))
+
+	if err := host.manager.UnloadPlugin("forms"); err != nil {
+		t.Fatal(err)
+	}
+	host.manager.SetDisabled("forms", true)
+	w := host.do(http.MethodGet, "/api/v1/plugins/forms/ping")
+	if code, _ := gateError(t, w); w.Code != http.StatusServiceUnavailable || code != CodePluginDisabled {
+		t.Errorf("deactivated plugin: %d %s, want 503 %s", w.Code, code, CodePluginDisabled)
+	}
+}
+
+func TestGatedRoutesWhileReloading(t *testing.T) {
+	host := newTestHost(t)
+	host.manager.SetReloadCooldown(0)
+	tc := installFakeToolchain(host.manager)
+	tc.install(t, host.manager, "forms", pluginFiles(t, map[string]interface{}{"name": "forms", "version": "1.0.0"}))
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	compile := host.manager.loader.compile
+	host.manager.loader.compile = func(sourceDir, pluginName string) (string, error) {
+		close(entered)
+		<-release
+		return compile(sourceDir, pluginName)
+	}
+
+	reloaded := make(chan error)
+	go func() {
+		_, err := host.manager.ReloadPlugin("forms", false)
+		reloaded <- err
+	}()
+	<-entered
+
+	w := host.do(http.MethodGet, "/api/v1/plugins/forms/ping")
+	if code, _ := gateError(t, w); w.Code != http.StatusServiceUnavailable || code != CodePluginReloading {
+		t.Errorf("reloading plugin: %d %s, want 503 %s", w.Code, code, CodePluginReloading)
+	}
+	if got := w.Header().Get("Retry-After"); got != "5" {
+		t.Errorf("Retry-After = %q while reloading, want 5", got)
+	}
+
+	close(release)
+	if err := <-reloaded; err != nil {
+		t.Fatal(err)
+	}
+
+	// Back and loaded, but the fake registers no routes
+	w = host.do(http.MethodGet, "/api/v1/plugins/forms/ping")
+	if code, _ := gateError(t, w); w.Code != http.StatusNotFound || code != CodeRouteNotFound {
+		t.Errorf("reloaded plugin without routes: %d %s, want 404 %s", w.Code, code, CodeRouteNotFound)
+	}
+}
+
+func TestSettingsEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings []PluginSetting
+		want     bool
+	}{
+		{"no settings", nil, true},
+		{"no enabled setting", []PluginSetting{{Key: "greeting", Value: "hi"}}, true},
+		{"enabled", []PluginSetting{{Key: "enabled", Value: true}}, true},
+		{"disabled", []PluginSetting{{Key: "greeting", Value: "hi"}, {Key: "enabled", Value: false}}, false},
+		{"not a boolean", []PluginSetting{{Key: "enabled", Value: "no"}}, true},
+	}
+	for _, tt := range tests {
+		if got := SettingsEnabled(tt.settings); got != tt.want {
+			t.Errorf("%s: SettingsEnabled = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEnabledSettingGatesPublicAndProtectedRoutes(t *testing.T) {
+	host := newTestHost(t)
+	plugin := newFakePlugin("comments")
+	plugin.routes = func(r *gin.RouterGroup) {
+		r.GET("/moderation", func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	plugin.public = func(r *gin.RouterGroup) {
+		r.GET("/feed", func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	host.add(publicFakePlugin{plugin})
+
+	paths := []string{"/api/v1/plugins/comments/moderation", "/api/v1/plugins/comments/feed"}
+	expect := func(state string, want int) {
+		t.Helper()
+		for _, path := range paths {
+			if w := host.do(http.MethodGet, path); w.Code != want {
+				t.Errorf("%s: %s answered %d, want %d", state, path, w.Code, want)
+			}
+		}
+	}
+
+	expect("enabled", http.StatusOK)
+
+	// Restoring persisted settings at startup switches the routes off without unloading
+	host.manager.RestoreActivation(nil, []string{"comments"})
+	expect("enabled=false at startup", http.StatusServiceUnavailable)
+	if _, loaded := host.manager.GetPlugin("comments"); !loaded {
+		t.Error("switching the routes off unloaded the plugin")
+	}
+
+	if _, err := host.manager.RefreshPluginSettings("comments", []PluginSetting{{Key: "enabled", Type: "boolean", Value: true}}); err != nil {
+		t.Fatal(err)
+	}
+	expect("enabled=true saved", http.StatusOK)
+}
@@ -0,0 +1,243 @@
+package plugins
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakePlugin is a Plugin whose routes and settings are set by the test
+type fakePlugin struct {
+	info     PluginInfo
+	settings []PluginSetting
+	routes   func(*gin.RouterGroup)
+	public   func(*gin.RouterGroup)
+	initErr  error
+	onInit   func(deps *PluginDependencies) // Runs in Initialize, as a plugin reading its configuration would
+
+	mu          sync.Mutex
+	initialized int
+	shutdowns   int
+	onShutdown  func()
+}
+
+func newFakePlugin(name string) *fakePlugin {
+	return &fakePlugin{info: PluginInfo{Name: name, Version: "1.0.0"}}
+}
+
+func (p *fakePlugin) GetInfo() PluginInfo { return p.info }
+
+func (p *fakePlugin) Initialize(deps *PluginDependencies) error {
+	if p.onInit != nil {
+		p.onInit(deps)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.initialized++
+	return p.initErr
+}
+
+func (p *fakePlugin) RegisterRoutes(router *gin.RouterGroup) {
+	if p.routes != nil {
+		p.routes(router)
+	}
+}
+
+func (p *fakePlugin) GetAdminMenuItems() []AdminMenuItem { return nil }
+
+func (p *fakePlugin) GetSettings() []PluginSetting { return p.settings }
+
+func (p *fakePlugin) Shutdown() error {
+	p.mu.Lock()
+	p.shutdowns++
+	hook := p.onShutdown
+	p.mu.Unlock()
+	if hook != nil {
+		hook()
+	}
+	return nil
+}
+
+func (p *fakePlugin) shutdownCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.shutdowns
+}
+
+// publicFakePlugin is a fakePlugin that also serves public routes
+type publicFakePlugin struct {
+	*fakePlugin
+}
+
+func (p publicFakePlugin) RegisterPublicRoutes(router *gin.RouterGroup) {
+	if p.public != nil {
+		p.public(router)
+	}
+}
+
+// testHost is a manager mounted on a gin engine the way the router mounts it:
+// plugins are dispatched from the public group and protected routes run the
+// protected group's middleware
+type testHost struct {
+	manager *Manager
+	engine  *gin.Engine
+}
+
+// newTestHost creates a manager whose plugin directory is a scratch directory.
+// auth stands in for the protected group's middleware.
+func newTestHost(t *testing.T, auth ...gin.HandlerFunc) *testHost {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	m := NewManager()
+	m.loader = NewLoader(t.TempDir())
+	m.SetDrainTimeout(DefaultDrainTimeout)
+
+	engine := gin.New()
+	m.SetPublicRouter(engine.Group("/api/v1"))
+	m.SetProtectedMiddleware(auth...)
+	m.RegisterRoutes(engine.Group("/api/v1", auth...))
+	return &testHost{manager: m, engine: engine}
+}
+
+// add loads plugin into the manager as if it had been compiled and initialized
+func (h *testHost) add(plugin Plugin) {
+	name := plugin.GetInfo().Name
+	h.manager.mu.Lock()
+	defer h.manager.mu.Unlock()
+	h.manager.plugins[name] = plugin
+	h.manager.pluginPaths[name] = name
+	if err := h.manager.registerPluginRoutes(name, plugin); err != nil {
+		panic(err)
+	}
+}
+
+func (h *testHost) do(method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.engine.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+// pluginMain is a minimal main package the extractor recognizes as a plugin
+const pluginMain = "package main\n\nfunc NewPlugin() interface{} { return nil }\n"
+
+// pluginFiles returns the files of a plugin with the given manifest fields
+func pluginFiles(t *testing.T, manifest map[string]interface{}) map[string]string {
+	t.Helper()
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{"main.go": pluginMain, "plugin.json": string(data)}
+}
+
+// writeZip writes files into a new zip archive and returns its path
+func writeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plugin.zip")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(out)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+	return path
+}
+
+// fakeToolchain stands in for the compiler and plugin.Open. A build records the
+// manifest version it was compiled from; opening it returns a fakePlugin with
+// that version, failing Initialize when failInit names the version.
+type fakeToolchain struct {
+	mu       sync.Mutex
+	failInit map[string]bool
+	opened   []*fakePlugin
+}
+
+func installFakeToolchain(m *Manager) *fakeToolchain {
+	tc := &fakeToolchain{failInit: make(map[string]bool)}
+	l := m.loader
+	l.compile = func(sourceDir, pluginName string) (string, error) {
+		manifest, err := readManifest(sourceDir)
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(l.buildDir, 0755); err != nil {
+			return "", err
+		}
+		path := l.compiler.newBuildPath(pluginName)
+		// Keep build stamps distinct on coarse clocks
+		time.Sleep(time.Millisecond)
+		return path, os.WriteFile(path, []byte(manifest.Name+"\n"+manifest.Version), 0644)
+	}
+	l.open = func(soPath string) (Plugin, error) {
+		data, err := os.ReadFile(soPath)
+		if err != nil {
+			return nil, err
+		}
+		fields := strings.SplitN(string(data), "\n", 2)
+		plugin := newFakePlugin(fields[0])
+		plugin.info.Version = fields[1]
+		tc.mu.Lock()
+		defer tc.mu.Unlock()
+		if tc.failInit[fields[1]] {
+			plugin.initErr = errors.New("initialization failed")
+		}
+		tc.opened = append(tc.opened, plugin)
+		return plugin, nil
+	}
+	m.SetDependencies(&PluginDependencies{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	return tc
+}
+
+// install puts a plugin's files in the plugin directory and starts it through
+// the fake toolchain, as LoadPlugins would
+func (tc *fakeToolchain) install(t *testing.T, m *Manager, name string, files map[string]string) *fakePlugin {
+	t.Helper()
+	dir := filepath.Join(m.loader.pluginDir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	build, err := m.loader.compile(dir, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := m.loader.open(build)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.startPlugin(name, instance, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	m.loader.recordBuild(name, build)
+	return instance.(*fakePlugin)
+}
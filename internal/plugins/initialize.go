@@ -0,0 +1,95 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// DefaultInitTimeout bounds how long a plugin's Initialize may run
+const DefaultInitTimeout = 30 * time.Second
+
+// SetInitTimeout sets how long each plugin's Initialize may run before the plugin
+// is treated as failed. Call it before plugins are loaded.
+func (m *Manager) SetInitTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		m.initTimeout = timeout
+	}
+}
+
+// InitFailures returns the plugins whose last initialization failed, with the reason
+func (m *Manager) InitFailures() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	failures := make(map[string]string, len(m.initFailed))
+	for name, reason := range m.initFailed {
+		failures[name] = reason
+	}
+	return failures
+}
+
+// GetLoadTimes returns how long each loaded plugin took to load: compiling or
+// opening it and running Initialize. Plugins loaded at startup are compiled
+// together beforehand, so only their Initialize is timed.
+func (m *Manager) GetLoadTimes() map[string]time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	times := make(map[string]time.Duration, len(m.loadTimes))
+	for name, elapsed := range m.loadTimes {
+		times[name] = elapsed
+	}
+	return times
+}
+
+// recordLoadTime records the time since start as the plugin's load time. The
+// caller must hold m.mu.
+func (m *Manager) recordLoadTime(name string, start time.Time) {
+	elapsed := time.Since(start)
+	m.loadTimes[name] = elapsed
+	if elapsed >= time.Second {
+		log.Printf("Plugin %s took %s to load", name, elapsed.Round(time.Millisecond))
+	}
+}
+
+// initialize runs the plugin's Initialize with the configured timeout and records
+// the outcome. A plugin that panics or does not return in time is reported as
+// failed; its goroutine is abandoned rather than waited on. The caller must hold
+// m.mu. It is released while Initialize runs, so a slow plugin holds up neither
+// other manager operations nor requests to the plugins already loaded.
+func (m *Manager) initialize(name string, plugin Plugin, deps *PluginDependencies) error {
+	m.starting[name] = true
+	m.mu.Unlock()
+	err := m.runInitialize(plugin, deps)
+	m.mu.Lock()
+	delete(m.starting, name)
+
+	if err != nil {
+		log.Printf("Plugin %s init_failed: %v", name, err)
+		m.initFailed[name] = err.Error()
+		return err
+	}
+	delete(m.initFailed, name)
+	return nil
+}
+
+// runInitialize calls the plugin's Initialize, giving up after the init timeout
+func (m *Manager) runInitialize(plugin Plugin, deps *PluginDependencies) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("plugin panicked during initialization: %v", r)
+			}
+		}()
+		done <- plugin.Initialize(deps)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(m.initTimeout):
+		return fmt.Errorf("initialization timed out after %s", m.initTimeout)
+	}
+}
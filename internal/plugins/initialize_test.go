@@ -0,0 +1,105 @@
+package plugins
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newInitHost is a test host whose plugins are initialized, which needs dependencies
+func newInitHost(t *testing.T) *testHost {
+	host := newTestHost(t)
+	host.manager.SetDependencies(&PluginDependencies{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	return host
+}
+
+func TestInitializeTimeout(t *testing.T) {
+	host := newInitHost(t)
+	host.manager.SetInitTimeout(20 * time.Millisecond)
+
+	release := make(chan struct{})
+	defer close(release)
+	plugin := newFakePlugin("slow")
+	plugin.onInit = func(*PluginDependencies) { <-release }
+
+	host.manager.mu.Lock()
+	_, err := host.manager.startPlugin("slow", plugin, time.Now())
+	host.manager.mu.Unlock()
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("start error = %v, want a timeout", err)
+	}
+
+	if _, ok := host.manager.GetPlugin("slow"); ok {
+		t.Error("plugin that timed out was loaded")
+	}
+	if reason := host.manager.InitFailures()["slow"]; !strings.Contains(reason, "timed out") {
+		t.Errorf("init failure = %q, want the timeout", reason)
+	}
+}
+
+func TestInitializePanicIsAFailure(t *testing.T) {
+	host := newInitHost(t)
+	plugin := newFakePlugin("broken")
+	plugin.onInit = func(*PluginDependencies) { panic("boom") }
+
+	host.manager.mu.Lock()
+	_, err := host.manager.startPlugin("broken", plugin, time.Now())
+	host.manager.mu.Unlock()
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("start error = %v, want the panic", err)
+	}
+	if _, failed := host.manager.InitFailures()["broken"]; !failed {
+		t.Error("panic was not recorded as an init failure")
+	}
+}
+
+func TestInitializeRunsOutsideManagerLock(t *testing.T) {
+	host := newInitHost(t)
+	other := newFakePlugin("other")
+	other.routes = func(r *gin.RouterGroup) {
+		r.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	}
+	host.add(other)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	plugin := newFakePlugin("slow")
+	plugin.onInit = func(*PluginDependencies) {
+		close(entered)
+		<-release
+	}
+
+	started := make(chan error)
+	go func() {
+		host.manager.mu.Lock()
+		_, err := host.manager.startPlugin("slow", plugin, time.Now())
+		host.manager.mu.Unlock()
+		started <- err
+	}()
+	<-entered
+
+	// Other plugins keep serving and the manager keeps answering
+	if w := host.do(http.MethodGet, "/api/v1/plugins/other/ping"); w.Code != http.StatusOK {
+		t.Errorf("other plugin: status %d while slow initializes", w.Code)
+	}
+	if n := len(host.manager.GetAllPlugins()); n != 1 {
+		t.Errorf("%d plugins listed while slow initializes, want 1", n)
+	}
+	// The plugin cannot be removed from under its own start
+	if err := host.manager.UninstallPlugin("slow"); err == nil {
+		t.Error("uninstall ran while the plugin was initializing")
+	}
+
+	close(release)
+	if err := <-started; err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := host.manager.GetPlugin("slow"); !ok {
+		t.Error("slow is not loaded once initialized")
+	}
+}
@@ -1,9 +1,15 @@
 package plugins
 
 import (
+	"log/slog"
+
 	"github.com/gin-gonic/gin"
 )
 
+// APIVersion is the version of the plugin interface the host implements. It changes
+// when Plugin, PublicRoutesProvider or PluginDependencies change incompatibly.
+const APIVersion = "1.0"
+
 // Plugin represents the interface that all plugins must implement
 type Plugin interface {
 	// GetInfo returns basic plugin information
@@ -25,6 +31,22 @@ type Plugin interface {
 	Shutdown() error
 }
 
+// PublicRoutesProvider is implemented by plugins that serve unauthenticated routes,
+// such as comment submission or public widgets. RegisterPublicRoutes receives a
+// group at the same /plugins/<name> prefix as RegisterRoutes but mounted on the
+// public API group; RegisterRoutes stays behind authentication. The two sets of
+// routes must not register the same method and path.
+type PublicRoutesProvider interface {
+	RegisterPublicRoutes(router *gin.RouterGroup)
+}
+
+// SettingsApplier is implemented by plugins that can take new setting values while
+// running. ApplySettings receives every setting's effective value keyed by setting
+// key. Plugins without it only see new values after a reload.
+type SettingsApplier interface {
+	ApplySettings(values map[string]interface{}) error
+}
+
 type PluginInfo struct {
 	Name        string `json:"name"`
 	Version     string `json:"version"`
@@ -33,11 +55,33 @@ type PluginInfo struct {
 	Website     string `json:"website,omitempty"`
 }
 
+// PluginDependencies are the host services handed to a plugin. With multi-tenancy
+// enabled, request handlers should use database.FromContext(c.Request.Context(), Database)
+// to reach the current tenant's database.
 type PluginDependencies struct {
-	Database interface{} // Will be *database.DB
-	Config   interface{} // Will be *config.Config
+	Database interface{}  // Will be *database.DB
+	Config   interface{}  // Will be *config.Config
+	Logger   *slog.Logger // Scoped to the plugin: every record carries plugin=<name>; never shared between plugins
+	Cache    *PluginCache // Per-plugin cache; the cache_ttl setting is the default TTL
+
+	// Capabilities granted from plugin.json. Database and Config are nil unless
+	// the database and config capabilities are granted.
+	Capabilities []string
 }
 
+// Logger is the part of PluginDependencies.Logger most plugins need. Helpers
+// that only log can accept a Logger so they can be handed the scoped logger in
+// production and any stand-in elsewhere. Arguments after the message are
+// alternating keys and values.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+var _ Logger = (*slog.Logger)(nil)
+
 type AdminMenuItem struct {
 	ID       string          `json:"id"`
 	Title    string          `json:"title"`
@@ -56,4 +100,10 @@ type PluginSetting struct {
 	Description string      `json:"description,omitempty"`
 	Options     []string    `json:"options,omitempty"` // For select type
 	Required    bool        `json:"required"`
+	Group       string      `json:"group,omitempty"`   // Section/tab in the admin UI; defaults to "General"
+	Order       int         `json:"order,omitempty"`   // Position within the group
+	Min         *float64    `json:"min,omitempty"`     // Minimum value for numbers, minimum length for text
+	Max         *float64    `json:"max,omitempty"`     // Maximum value for numbers, maximum length for text
+	Pattern     string      `json:"pattern,omitempty"` // Regular expression text values must match
+	Placeholder string      `json:"placeholder,omitempty"`
 }
@@ -2,82 +2,194 @@ package plugins
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"plugin"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Loader struct {
+	loadedMu      sync.RWMutex // Guards loadedPlugins and builds; installs and reloads may run concurrently
 	loadedPlugins map[string]*plugin.Plugin
+	builds        map[string]string // Build each installed plugin was last loaded from
 	pluginDir     string
 	buildDir      string
 	extractor     *Extractor
 	compiler      *Compiler
+	cmsVersion    string // Checked against each plugin's min_cms_version and max_cms_version
+
+	// compile builds a plugin's sources into a new, validated .so and open creates
+	// an instance from a build. Installs, loads and updates go through them so
+	// tests can stand in for the toolchain.
+	compile func(sourceDir, pluginName string) (string, error)
+	open    func(soPath string) (Plugin, error)
 }
 
 func NewLoader(pluginDir string) *Loader {
 	buildDir := filepath.Join(pluginDir, ".build")
 
-	return &Loader{
+	l := &Loader{
 		loadedPlugins: make(map[string]*plugin.Plugin),
+		builds:        make(map[string]string),
 		pluginDir:     pluginDir,
 		buildDir:      buildDir,
 		extractor:     NewExtractor(pluginDir),
 		compiler:      NewCompiler(buildDir),
 	}
+	l.compile = func(sourceDir, pluginName string) (string, error) {
+		soPath, err := l.compiler.CompilePlugin(sourceDir, pluginName)
+		if err != nil {
+			return "", err
+		}
+		if err := l.compiler.ValidateCompilation(soPath); err != nil {
+			os.Remove(soPath)
+			return "", fmt.Errorf("plugin compilation validation failed: %w", err)
+		}
+		return soPath, nil
+	}
+	l.open = l.loadCompiledPlugin
+	return l
+}
+
+// buildCached returns the plugin's latest build when its sources have not changed
+// since, compiling a new one otherwise, and reports whether it compiled
+func (l *Loader) buildCached(sourceDir, pluginName string) (string, bool, error) {
+	latest, err := l.compiler.upToDateBuild(sourceDir, pluginName)
+	if err != nil || latest != "" {
+		return latest, false, err
+	}
+
+	soPath, err := l.compile(sourceDir, pluginName)
+	return soPath, err == nil, err
 }
 
 // InstallFromZip installs a plugin from a zip file
-func (l *Loader) InstallFromZip(zipPath, pluginName string) error {
+func (l *Loader) InstallFromZip(zipPath, pluginName string) (*InstallResult, error) {
 	// Extract the zip file
 	pluginDir, err := l.extractor.ExtractZipPlugin(zipPath, pluginName)
 	if err != nil {
-		return fmt.Errorf("failed to extract plugin: %w", err)
+		return nil, fmt.Errorf("failed to extract plugin: %w", err)
+	}
+
+	result, err := l.installExtracted(pluginDir, pluginName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Clean up the original zip file
+	os.Remove(zipPath)
+
+	return result, nil
+}
+
+// InstallBundleMember installs one member plugin of a bundle zip. The zip is left
+// in place for the remaining members.
+func (l *Loader) InstallBundleMember(zipPath, member string) (*InstallResult, error) {
+	pluginDir, err := l.extractor.ExtractBundleMember(zipPath, member)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract plugin: %w", err)
+	}
+
+	return l.installExtracted(pluginDir, member)
+}
+
+// prepareExtracted checks a plugin extracted to pluginDir before it is compiled
+// and returns its package root along with the install warnings. When installed
+// is set, a plugin older than that version is refused with a DowngradeError.
+func (l *Loader) prepareExtracted(pluginDir, installed string) (string, *InstallResult, error) {
+	// Note whether the author shipped a manifest before one is generated
+	shippedManifest := false
+	if root, _, err := findPluginRoot(pluginDir); err == nil {
+		_, statErr := os.Stat(filepath.Join(root, "plugin.json"))
+		shippedManifest = statErr == nil
 	}
 
 	// Validate plugin structure
-	if err := l.extractor.ValidatePluginStructure(pluginDir); err != nil {
-		// Clean up on failure
-		os.RemoveAll(pluginDir)
-		return fmt.Errorf("invalid plugin structure: %w", err)
+	sourceDir, err := l.extractor.ValidatePluginStructure(pluginDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid plugin structure: %w", err)
 	}
 
-	// Compile the plugin
-	soPath, recompiled, err := l.compiler.CompileWithCache(pluginDir, pluginName)
+	// Refuse plugins built for another CMS version before compiling them
+	manifest, err := readManifest(sourceDir)
+	if err == nil {
+		err = checkDowngrade(manifest, installed)
+	}
+	if err == nil {
+		_, err = checkCMSVersion(manifest, l.cmsVersion)
+	}
+	if err == nil {
+		err = l.CheckDependencies(manifest)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	result := &InstallResult{Warnings: manifestWarnings(sourceDir, shippedManifest)}
+	result.Warnings = append(result.Warnings, sourceWarnings(sourceDir)...)
+	return sourceDir, result, nil
+}
+
+// installExtracted validates, compiles and loads a plugin extracted to pluginDir
+func (l *Loader) installExtracted(pluginDir, pluginName string) (*InstallResult, error) {
+	sourceDir, result, err := l.prepareExtracted(pluginDir, "")
 	if err != nil {
 		// Clean up on failure
 		os.RemoveAll(pluginDir)
-		return fmt.Errorf("failed to compile plugin: %w", err)
+		return nil, err
 	}
 
-	// Validate compilation
-	if err := l.compiler.ValidateCompilation(soPath); err != nil {
+	// Compile the plugin
+	soPath, recompiled, err := l.buildCached(sourceDir, pluginName)
+	if err != nil {
+		// Clean up on failure
 		os.RemoveAll(pluginDir)
-		os.Remove(soPath)
-		return fmt.Errorf("plugin compilation validation failed: %w", err)
+		return nil, fmt.Errorf("failed to compile plugin: %w", err)
 	}
+	result.Recompiled = recompiled
 
-	// Load the compiled plugin
-	if err := l.LoadPluginFromFile(soPath); err != nil {
+	// Load the compiled plugin to check it is usable
+	if _, err := l.open(soPath); err != nil {
 		os.RemoveAll(pluginDir)
 		os.Remove(soPath)
-		return fmt.Errorf("failed to load compiled plugin: %w", err)
+		return nil, fmt.Errorf("failed to load compiled plugin: %w", err)
 	}
 
-	// Clean up the original zip file
-	os.Remove(zipPath)
-
 	if recompiled {
 		fmt.Printf("Plugin %s installed and compiled successfully\n", pluginName)
 	} else {
 		fmt.Printf("Plugin %s installed (using cached build)\n", pluginName)
 	}
 
-	return nil
+	return result, nil
+}
+
+// manifestWarnings lists non-fatal issues with an installed plugin's manifest
+func manifestWarnings(sourceDir string, shippedManifest bool) []string {
+	warnings := []string{}
+	if !shippedManifest {
+		warnings = append(warnings, "plugin.json was missing and has been auto-generated")
+	}
+
+	manifest, err := readManifest(sourceDir)
+	if err != nil || manifest == nil {
+		return warnings
+	}
+
+	if manifest.Website == "" {
+		warnings = append(warnings, "plugin.json does not declare a website")
+	}
+	warnings = append(warnings, unknownFieldWarnings(sourceDir)...)
+
+	declared, _ := manifestModuleVersions(manifest)
+	warnings = append(warnings, hostVersionWarnings(declared, hostModuleVersions())...)
+
+	return warnings
 }
 
 // LoadPluginFromFile loads a plugin from a .so file (maintains backward compatibility)
@@ -100,7 +212,7 @@ func (l *Loader) LoadPluginFromFile(path string) error {
 
 	// Store loaded plugin for potential cleanup
 	pluginName := l.getPluginNameFromPath(path)
-	l.loadedPlugins[pluginName] = p
+	l.trackLoaded(pluginName, p)
 
 	// Look for the NewPlugin symbol
 	symbol, err := p.Lookup("NewPlugin")
@@ -120,6 +232,10 @@ func (l *Loader) LoadPluginFromFile(path string) error {
 		return fmt.Errorf("NewPlugin function in %s returned nil", path)
 	}
 
+	if err := validatePluginInstance(pluginInstance, l.expectedNames(pluginName)); err != nil {
+		return fmt.Errorf("plugin %s is not usable: %w", path, err)
+	}
+
 	return nil
 }
 
@@ -133,13 +249,18 @@ func (l *Loader) LoadPluginFromDirectory(pluginName string) (Plugin, error) {
 	}
 
 	// Compile the plugin
-	soPath, _, err := l.compiler.CompileWithCache(pluginDir, pluginName)
+	soPath, _, err := l.buildCached(l.sourceDir(pluginName), pluginName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compile plugin: %w", err)
 	}
 
 	// Load the compiled plugin
-	return l.loadCompiledPlugin(soPath)
+	instance, err := l.open(soPath)
+	if err != nil {
+		return nil, err
+	}
+	l.recordBuild(pluginName, soPath)
+	return instance, nil
 }
 
 // LoadCompiledPlugin loads a plugin from a compiled .so file and returns the instance
@@ -152,7 +273,7 @@ func (l *Loader) loadCompiledPlugin(soPath string) (Plugin, error) {
 
 	// Store loaded plugin
 	pluginName := l.getPluginNameFromPath(soPath)
-	l.loadedPlugins[pluginName] = p
+	l.trackLoaded(pluginName, p)
 
 	// Look for the NewPlugin symbol
 	symbol, err := p.Lookup("NewPlugin")
@@ -172,9 +293,45 @@ func (l *Loader) loadCompiledPlugin(soPath string) (Plugin, error) {
 		return nil, fmt.Errorf("NewPlugin function in %s returned nil", soPath)
 	}
 
+	if err := validatePluginInstance(pluginInstance, l.expectedNames(pluginName)); err != nil {
+		return nil, fmt.Errorf("plugin %s is not usable: %w", soPath, err)
+	}
+
 	return pluginInstance, nil
 }
 
+// expectedNames returns the names a plugin installed as pluginName may report:
+// its directory name and, when present, the name in its plugin.json
+func (l *Loader) expectedNames(pluginName string) []string {
+	names := []string{pluginName}
+	if manifest, err := readManifest(l.sourceDir(pluginName)); err == nil && manifest != nil && manifest.Name != "" && manifest.Name != pluginName {
+		names = append(names, manifest.Name)
+	}
+	return names
+}
+
+// validatePluginInstance checks that a freshly created plugin can describe itself.
+// GetInfo must report one of the expected names, and neither GetInfo nor
+// GetSettings may panic.
+func validatePluginInstance(p Plugin, expected []string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("plugin panicked while being inspected: %v", r)
+		}
+	}()
+
+	info := p.GetInfo()
+	if strings.TrimSpace(info.Name) == "" {
+		return fmt.Errorf("GetInfo returned an empty name")
+	}
+	if !containsString(expected, info.Name) {
+		return fmt.Errorf("GetInfo returned name %q, expected %s", info.Name, strings.Join(expected, " or "))
+	}
+
+	p.GetSettings()
+	return nil
+}
+
 // LoadAllPlugins discovers and loads all plugins
 func (l *Loader) LoadAllPlugins() (map[string]Plugin, error) {
 	plugins := make(map[string]Plugin)
@@ -218,7 +375,7 @@ func (l *Loader) LoadAllPlugins() (map[string]Plugin, error) {
 				continue
 			}
 
-			pluginInstance, err := l.loadCompiledPlugin(soPath)
+			pluginInstance, err := l.open(soPath)
 			if err != nil {
 				loadErrors = append(loadErrors, fmt.Sprintf("%s: %v", pluginName, err))
 				continue
@@ -277,6 +434,112 @@ func (l *Loader) ValidateZipPlugin(zipPath string) (*PluginValidationResult, err
 	return result, nil
 }
 
+// DryRunZipPlugin runs the install pipeline against a scratch directory without
+// persisting or loading anything. The trial compile is only run when compile is set.
+func (l *Loader) DryRunZipPlugin(zipPath, pluginName string, compile bool) (*PluginDryRunResult, error) {
+	validation, err := l.ValidateZipPlugin(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PluginDryRunResult{Validation: validation}
+	if !validation.IsValid {
+		return result, nil
+	}
+
+	scratchDir, err := os.MkdirTemp("", "plugin-dryrun-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratchDir)
+
+	// Extract and check structure
+	extractor := NewExtractor(scratchDir)
+	pluginDir, err := extractor.ExtractZipPlugin(zipPath, pluginName)
+	if err != nil {
+		validation.IsValid = false
+		validation.Errors = append(validation.Errors, fmt.Sprintf("Failed to extract plugin: %v", err))
+		return result, nil
+	}
+
+	sourceDir, err := extractor.ValidatePluginStructure(pluginDir)
+	if err != nil {
+		validation.IsValid = false
+		validation.Errors = append(validation.Errors, fmt.Sprintf("Invalid plugin structure: %v", err))
+		return result, nil
+	}
+
+	// Parse the manifest
+	manifest, err := extractor.GetPluginInfo(sourceDir)
+	if err != nil {
+		validation.IsValid = false
+		var manifestErr *ManifestError
+		if errors.As(err, &manifestErr) {
+			for _, problem := range manifestErr.Problems {
+				validation.Errors = append(validation.Errors, "plugin.json: "+problem)
+			}
+		} else {
+			validation.Errors = append(validation.Errors, err.Error())
+		}
+		return result, nil
+	}
+	validation.Warnings = append(validation.Warnings, unknownFieldWarnings(sourceDir)...)
+
+	result.Info = &PluginInfo{
+		Name:        manifest.Name,
+		Version:     manifest.Version,
+		Description: manifest.Description,
+		Author:      manifest.Author,
+		Website:     manifest.Website,
+	}
+
+	compat, err := checkCMSVersion(manifest, l.cmsVersion)
+	result.Compatibility = compat
+	if err != nil {
+		validation.IsValid = false
+		validation.Errors = append(validation.Errors, err.Error())
+		return result, nil
+	}
+
+	var dependencyErr *DependencyError
+	if err := l.CheckDependencies(manifest); errors.As(err, &dependencyErr) {
+		validation.IsValid = false
+		for _, failure := range dependencyErr.Failures {
+			validation.Errors = append(validation.Errors, "dependency "+failure)
+		}
+		return result, nil
+	}
+
+	if _, err := manifestBuildTags(manifest); err != nil {
+		validation.IsValid = false
+		validation.Errors = append(validation.Errors, err.Error())
+		return result, nil
+	}
+
+	capabilities, capabilityWarnings := manifestCapabilities(manifest)
+	result.Capabilities = capabilities
+	validation.Warnings = append(validation.Warnings, capabilityWarnings...)
+	validation.Warnings = append(validation.Warnings, sourceWarnings(sourceDir)...)
+
+	if !compile {
+		return result, nil
+	}
+
+	// Trial compile into the scratch directory
+	compiler := NewCompiler(filepath.Join(scratchDir, ".build"))
+	compiler.goPath = l.compiler.goPath
+	compiler.builds = l.compiler.builds
+	if _, err := compiler.CompilePlugin(sourceDir, pluginName); err != nil {
+		validation.IsValid = false
+		validation.Errors = append(validation.Errors, "Trial compilation failed")
+		result.CompileOutput = err.Error()
+		return result, nil
+	}
+
+	result.Compiled = true
+	return result, nil
+}
+
 // GetPluginInfo gets plugin information without loading it
 func (l *Loader) GetPluginInfo(pluginName string) (*PluginInfo, error) {
 	pluginDir := filepath.Join(l.pluginDir, pluginName)
@@ -287,7 +550,7 @@ func (l *Loader) GetPluginInfo(pluginName string) (*PluginInfo, error) {
 	}
 
 	// Try to read plugin.json first
-	manifestPath := filepath.Join(pluginDir, "plugin.json")
+	manifestPath := filepath.Join(l.sourceDir(pluginName), "plugin.json")
 	if data, err := os.ReadFile(manifestPath); err == nil {
 		var manifest PluginManifest
 		if err := json.Unmarshal(data, &manifest); err == nil {
@@ -311,40 +574,88 @@ func (l *Loader) GetPluginInfo(pluginName string) (*PluginInfo, error) {
 	return &info, nil
 }
 
+// trackLoaded records an opened plugin so it can be looked up and cleaned up later
+func (l *Loader) trackLoaded(pluginName string, p *plugin.Plugin) {
+	l.loadedMu.Lock()
+	defer l.loadedMu.Unlock()
+
+	l.loadedPlugins[pluginName] = p
+}
+
 // UninstallPlugin removes a plugin completely
 func (l *Loader) UninstallPlugin(pluginName string) error {
 	pluginDir := filepath.Join(l.pluginDir, pluginName)
-	soPath := filepath.Join(l.buildDir, pluginName+".so")
 
 	// Remove plugin directory
 	if err := os.RemoveAll(pluginDir); err != nil {
 		return fmt.Errorf("failed to remove plugin directory: %w", err)
 	}
 
-	// Remove compiled .so file
-	os.Remove(soPath)
+	// Remove compiled .so files
+	l.compiler.RemoveBuilds(pluginName, "")
 
 	// Remove from loaded plugins
+	l.loadedMu.Lock()
 	delete(l.loadedPlugins, pluginName)
+	delete(l.builds, pluginName)
+	l.loadedMu.Unlock()
 
 	return nil
 }
 
-// RecompilePlugin forces recompilation of a plugin
+// RecompilePlugin forces recompilation of a plugin. The new build gets a path of
+// its own, so the next load opens it rather than the cached previous build.
 func (l *Loader) RecompilePlugin(pluginName string) error {
-	pluginDir := filepath.Join(l.pluginDir, pluginName)
-	soPath := filepath.Join(l.buildDir, pluginName+".so")
+	_, err := l.compile(l.sourceDir(pluginName), pluginName)
+	return err
+}
 
-	// Remove existing compiled file to force recompilation
-	os.Remove(soPath)
+// recordBuild notes the build a plugin was loaded from and removes its other builds
+func (l *Loader) recordBuild(pluginName, soPath string) {
+	l.loadedMu.Lock()
+	l.builds[pluginName] = soPath
+	l.loadedMu.Unlock()
 
-	// Recompile
-	_, err := l.compiler.CompilePlugin(pluginDir, pluginName)
-	return err
+	l.compiler.RemoveBuilds(pluginName, soPath)
+}
+
+// currentBuild returns the build a plugin was last loaded from
+func (l *Loader) currentBuild(pluginName string) string {
+	l.loadedMu.RLock()
+	defer l.loadedMu.RUnlock()
+	return l.builds[pluginName]
+}
+
+// RebuildPlugin compiles a plugin unconditionally, or with cached only if its
+// sources are newer than the cached build, and reports whether it compiled
+func (l *Loader) RebuildPlugin(pluginName string, cached bool) (bool, error) {
+	if !cached {
+		return true, l.RecompilePlugin(pluginName)
+	}
+
+	_, recompiled, err := l.buildCached(l.sourceDir(pluginName), pluginName)
+	return recompiled, err
 }
 
 // Utility functions
 
+// isInstalled reports whether a plugin directory exists, whether or not the
+// plugin is loaded
+func (l *Loader) isInstalled(pluginName string) bool {
+	_, err := os.Stat(filepath.Join(l.pluginDir, pluginName))
+	return err == nil
+}
+
+// sourceDir returns the package root of an installed plugin, which may be nested
+// below its install directory
+func (l *Loader) sourceDir(pluginName string) string {
+	pluginDir := filepath.Join(l.pluginDir, pluginName)
+	if root, _, err := findPluginRoot(pluginDir); err == nil {
+		return root
+	}
+	return pluginDir
+}
+
 func (l *Loader) isPluginSupported() bool {
 	supportedOS := map[string]bool{
 		"linux":   true,
@@ -355,8 +666,7 @@ func (l *Loader) isPluginSupported() bool {
 }
 
 func (l *Loader) getPluginNameFromPath(path string) string {
-	filename := filepath.Base(path)
-	return strings.TrimSuffix(filename, ".so")
+	return buildPluginName(path)
 }
 
 // GetSupportedPlatforms returns list of supported platforms
@@ -374,9 +684,33 @@ func (l *Loader) IsPlatformSupported() bool {
 	return l.isPluginSupported()
 }
 
-// CleanupBuildCache removes old compiled files
-func (l *Loader) CleanupBuildCache(maxAge time.Duration) error {
-	return l.compiler.CleanupOldBuilds(maxAge)
+// CleanupBuildCache removes compiled files older than maxAge, then the oldest
+// remaining ones until the cache fits in maxSize (0 for no size limit)
+func (l *Loader) CleanupBuildCache(maxAge time.Duration, maxSize int64) error {
+	if err := l.compiler.CleanupOldBuilds(maxAge); err != nil {
+		return err
+	}
+	return l.compiler.TrimBuilds(maxSize)
+}
+
+// GetCacheStats returns statistics about the build cache
+func (l *Loader) GetCacheStats() (CacheStats, error) {
+	return l.compiler.GetCacheStats()
+}
+
+// Capabilities returns the capabilities granted to an installed plugin and any
+// warnings about its declaration
+func (l *Loader) Capabilities(pluginName string) ([]string, []string) {
+	manifest, err := readManifest(l.sourceDir(pluginName))
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	return manifestCapabilities(manifest)
+}
+
+// SetMaxConcurrentBuilds limits how many plugins are compiled at once
+func (l *Loader) SetMaxConcurrentBuilds(limit int) {
+	l.compiler.SetMaxConcurrentBuilds(limit)
 }
 
 // GetCompilerInfo returns information about the Go compiler
@@ -386,12 +720,29 @@ func (l *Loader) GetCompilerInfo() (*CompilerInfo, error) {
 
 // Supporting types
 
+// InstallResult reports non-fatal details of a successful install
+type InstallResult struct {
+	Warnings     []string `json:"warnings"`
+	Recompiled   bool     `json:"recompiled"`
+	Capabilities []string `json:"capabilities"`
+	Updated      bool     `json:"updated"` // An already loaded plugin was replaced
+}
+
 type PluginValidationResult struct {
 	IsValid  bool     `json:"is_valid"`
 	Errors   []string `json:"errors"`
 	Warnings []string `json:"warnings"`
 }
 
+type PluginDryRunResult struct {
+	Validation    *PluginValidationResult `json:"validation"`
+	Info          *PluginInfo             `json:"info,omitempty"`
+	Capabilities  []string                `json:"capabilities,omitempty"`
+	Compatibility *CMSCompatibility       `json:"compatibility,omitempty"` // Declared CMS version range
+	Compiled      bool                    `json:"compiled"`
+	CompileOutput string                  `json:"compile_output,omitempty"`
+}
+
 type CompatibilityInfo struct {
 	Path            string      `json:"path"`
 	CurrentPlatform string      `json:"current_platform"`
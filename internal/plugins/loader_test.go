@@ -0,0 +1,131 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestLoaderConcurrentLoadAndUninstall is meant for the race detector: loads and
+// uninstalls of different plugins share the loader's maps.
+func TestLoaderConcurrentLoadAndUninstall(t *testing.T) {
+	m := NewManager()
+	m.loader = NewLoader(t.TempDir())
+	installFakeToolchain(m)
+	l := m.loader
+
+	// Record opened plugins as plugin.Open does
+	open := l.open
+	l.open = func(soPath string) (Plugin, error) {
+		instance, err := open(soPath)
+		if err == nil {
+			l.trackLoaded(l.getPluginNameFromPath(soPath), nil)
+		}
+		return instance, err
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("plugin%d", i)
+		files := pluginFiles(t, map[string]interface{}{"name": name, "version": "1.0.0"})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dir := filepath.Join(l.pluginDir, name)
+			for j := 0; j < 20; j++ {
+				os.MkdirAll(dir, 0755)
+				for file, content := range files {
+					os.WriteFile(filepath.Join(dir, file), []byte(content), 0644)
+				}
+				if _, err := l.LoadPluginFromDirectory(name); err != nil {
+					t.Error(err)
+					return
+				}
+				if l.currentBuild(name) == "" {
+					t.Errorf("%s: no build recorded after loading", name)
+				}
+				if err := l.UninstallPlugin(name); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	l.loadedMu.RLock()
+	defer l.loadedMu.RUnlock()
+	if len(l.loadedPlugins) != 0 || len(l.builds) != 0 {
+		t.Errorf("after uninstalling everything: loaded %v, builds %v", l.loadedPlugins, l.builds)
+	}
+}
+
+// panickingPlugin panics when inspected
+type panickingPlugin struct {
+	*fakePlugin
+	inInfo bool
+}
+
+func (p *panickingPlugin) GetInfo() PluginInfo {
+	if p.inInfo {
+		panic("nil config")
+	}
+	return p.fakePlugin.GetInfo()
+}
+
+func (p *panickingPlugin) GetSettings() []PluginSetting {
+	panic("settings not loaded")
+}
+
+func TestValidatePluginInstance(t *testing.T) {
+	expected := []string{"seo", "seo-tools"}
+	tests := []struct {
+		name    string
+		plugin  Plugin
+		wantErr string
+	}{
+		{"directory name", newFakePlugin("seo"), ""},
+		{"manifest name", newFakePlugin("seo-tools"), ""},
+		{"empty name", newFakePlugin(""), "empty name"},
+		{"blank name", newFakePlugin("  "), "empty name"},
+		{"unexpected name", newFakePlugin("analytics"), `name "analytics", expected seo or seo-tools`},
+		{"GetInfo panics", &panickingPlugin{fakePlugin: newFakePlugin("seo"), inInfo: true}, "panicked while being inspected: nil config"},
+		{"GetSettings panics", &panickingPlugin{fakePlugin: newFakePlugin("seo")}, "panicked while being inspected: settings not loaded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePluginInstance(tt.plugin, expected)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validatePluginInstance = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validatePluginInstance = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpectedNames(t *testing.T) {
+	l := NewLoader(t.TempDir())
+	dir := filepath.Join(l.pluginDir, "seo")
+	os.MkdirAll(dir, 0755)
+
+	// Without a manifest only the directory name is expected
+	if got := l.expectedNames("seo"); !reflect.DeepEqual(got, []string{"seo"}) {
+		t.Errorf("expectedNames without a manifest = %v", got)
+	}
+
+	for file, content := range pluginFiles(t, map[string]interface{}{"name": "seo-tools", "version": "1.0.0"}) {
+		os.WriteFile(filepath.Join(dir, file), []byte(content), 0644)
+	}
+	if got, want := l.expectedNames("seo"), []string{"seo", "seo-tools"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expectedNames = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package plugins
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultLogBufferSize is the number of log entries kept per plugin
+const DefaultLogBufferSize = 500
+
+// LogEntry is a captured plugin log record
+type LogEntry struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+
+	level slog.Level
+}
+
+// logBuffer is a fixed-size ring of a plugin's most recent log entries. Once
+// full, each new entry overwrites the oldest, so writers never wait for space.
+type logBuffer struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int
+	full    bool
+}
+
+func newLogBuffer(size int) *logBuffer {
+	if size <= 0 {
+		size = DefaultLogBufferSize
+	}
+	return &logBuffer{entries: make([]LogEntry, size)}
+}
+
+// add records a log record, dropping the oldest entry when the buffer is full
+func (b *logBuffer) add(record slog.Record) {
+	entry := LogEntry{
+		Time:    record.Time,
+		Level:   record.Level.String(),
+		Message: record.Message,
+		level:   record.Level,
+	}
+	if record.NumAttrs() > 0 {
+		entry.Attrs = make(map[string]string, record.NumAttrs())
+		record.Attrs(func(attr slog.Attr) bool {
+			entry.Attrs[attr.Key] = attr.Value.Resolve().String()
+			return true
+		})
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// recent returns up to lines of the newest entries at or above minLevel, oldest
+// first. lines <= 0 returns every matching entry.
+func (b *logBuffer) recent(lines int, minLevel slog.Level) []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	count, start := b.next, 0
+	if b.full {
+		count, start = len(b.entries), b.next
+	}
+
+	// Walk backwards from the newest entry so the line limit keeps the latest ones
+	var matched []LogEntry
+	for i := count - 1; i >= 0; i-- {
+		entry := b.entries[(start+i)%len(b.entries)]
+		if entry.level < minLevel {
+			continue
+		}
+		matched = append(matched, entry)
+		if lines > 0 && len(matched) == lines {
+			break
+		}
+	}
+
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	return matched
+}
@@ -0,0 +1,59 @@
+package plugins
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// pluginLogHandler wraps the host handler so a single plugin can be switched
+// to debug logging without changing the level of the rest of the system. Every
+// record it handles is also captured in the plugin's log buffer.
+type pluginLogHandler struct {
+	slog.Handler
+	debug  *atomic.Bool
+	buffer *logBuffer
+}
+
+func (h *pluginLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.debug.Load() {
+		return level >= slog.LevelDebug
+	}
+	return h.Handler.Enabled(ctx, level)
+}
+
+func (h *pluginLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.buffer != nil {
+		h.buffer.add(record)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *pluginLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &pluginLogHandler{Handler: h.Handler.WithAttrs(attrs), debug: h.debug, buffer: h.buffer}
+}
+
+func (h *pluginLogHandler) WithGroup(name string) slog.Handler {
+	return &pluginLogHandler{Handler: h.Handler.WithGroup(name), debug: h.debug, buffer: h.buffer}
+}
+
+// newPluginLogger returns a logger namespaced to the given plugin that also
+// records into buffer
+func newPluginLogger(base *slog.Logger, name string, debug *atomic.Bool, buffer *logBuffer) *slog.Logger {
+	if base == nil {
+		base = slog.Default()
+	}
+	handler := &pluginLogHandler{Handler: base.Handler(), debug: debug, buffer: buffer}
+	return slog.New(handler).With(slog.String("plugin", name))
+}
+
+// debugModeEnabled reports whether the plugin's debug_mode setting is switched on
+func debugModeEnabled(settings []PluginSetting) bool {
+	for _, setting := range settings {
+		if setting.Key == "debug_mode" {
+			enabled, _ := setting.Value.(bool)
+			return enabled
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// configuredPlugin reports debug_mode off until Initialize has read its
+// configuration, which switches it to enabled
+func configuredPlugin(enabled bool) (*fakePlugin, *PluginDependencies) {
+	plugin := newFakePlugin("forms")
+	plugin.settings = []PluginSetting{{Key: "debug_mode", Type: "boolean", Value: false}}
+	initialized := &PluginDependencies{}
+	plugin.onInit = func(deps *PluginDependencies) {
+		plugin.settings = []PluginSetting{{Key: "debug_mode", Type: "boolean", Value: enabled}}
+		*initialized = *deps
+	}
+	return plugin, initialized
+}
+
+func startFake(t *testing.T, m *Manager, plugin *fakePlugin) {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.startPlugin(plugin.info.Name, plugin, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// greet logs through the Logger interface, as a plugin's helpers would
+func greet(logger Logger) {
+	logger.Info("initialized")
+}
+
+func TestInitializeReceivesScopedLogger(t *testing.T) {
+	host := newTestHost(t)
+	var out bytes.Buffer
+	shared := slog.New(slog.NewJSONHandler(&out, nil))
+	host.manager.SetDependencies(&PluginDependencies{Logger: shared})
+
+	loggers := make(map[string]*slog.Logger)
+	for _, name := range []string{"forms", "seo"} {
+		plugin := newFakePlugin(name)
+		plugin.onInit = func(deps *PluginDependencies) {
+			loggers[name] = deps.Logger
+			greet(deps.Logger)
+		}
+		startFake(t, host.manager, plugin)
+	}
+
+	if loggers["forms"] == shared || loggers["forms"] == loggers["seo"] {
+		t.Error("Initialize received a logger shared with the host or another plugin")
+	}
+	var tagged []string
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		var record struct {
+			Msg    string `json:"msg"`
+			Plugin string `json:"plugin"`
+		}
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Msg == "initialized" {
+			tagged = append(tagged, record.Plugin)
+		}
+	}
+	if len(tagged) != 2 || tagged[0] != "forms" || tagged[1] != "seo" {
+		t.Errorf("initialize logs tagged plugin=%q, want forms then seo", tagged)
+	}
+}
+
+func TestPluginDebugFollowsInitializedSettings(t *testing.T) {
+	host := newTestHost(t)
+	host.manager.SetDependencies(&PluginDependencies{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+
+	plugin, deps := configuredPlugin(true)
+	startFake(t, host.manager, plugin)
+
+	if !deps.Logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("debug logging is off although the initialized plugin enables debug_mode")
+	}
+}
+
+func TestLoadedPluginDebugFollowsInitializedSettings(t *testing.T) {
+	host := newTestHost(t)
+	host.manager.SetDependencies(&PluginDependencies{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	installFakeToolchain(host.manager)
+	l := host.manager.loader
+
+	// The opened plugin enables debug_mode only once it is initialized
+	deps := &PluginDependencies{}
+	open := l.open
+	l.open = func(soPath string) (Plugin, error) {
+		instance, err := open(soPath)
+		if plugin, ok := instance.(*fakePlugin); ok {
+			plugin.settings = []PluginSetting{{Key: "debug_mode", Type: "boolean", Value: false}}
+			plugin.onInit = func(initialized *PluginDependencies) {
+				plugin.settings = []PluginSetting{{Key: "debug_mode", Type: "boolean", Value: true}}
+				*deps = *initialized
+			}
+		}
+		return instance, err
+	}
+	dir := filepath.Join(l.pluginDir, "forms")
+	os.MkdirAll(dir, 0755)
+	for file, content := range pluginFiles(t, map[string]interface{}{"name": "forms", "version": "1.0.0"}) {
+		os.WriteFile(filepath.Join(dir, file), []byte(content), 0644)
+	}
+
+	if err := host.manager.LoadPlugins(l.pluginDir); err != nil {
+		t.Fatal(err)
+	}
+	if deps.Logger == nil || !deps.Logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("debug logging is off although the plugin enabled debug_mode when loaded with the others")
+	}
+}
+
+func TestSavedPluginDebugWins(t *testing.T) {
+	host := newTestHost(t)
+	host.manager.SetDependencies(&PluginDependencies{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+
+	// Restored before the plugin loads, as at startup
+	host.manager.SetPluginDebug("forms", false)
+	plugin, deps := configuredPlugin(true)
+	startFake(t, host.manager, plugin)
+
+	if deps.Logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("the plugin's own debug_mode overrode the saved one")
+	}
+
+	host.manager.SetPluginDebug("forms", true)
+	if !deps.Logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("changing the setting did not switch debug logging on")
+	}
+}
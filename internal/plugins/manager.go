@@ -1,29 +1,90 @@
 package plugins
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"os"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go-cms/internal/events"
+
 	"github.com/gin-gonic/gin"
 )
 
+// ErrShuttingDown is returned when a plugin operation is requested after shutdown has begun
+var ErrShuttingDown = errors.New("plugin manager is shutting down")
+
 type Manager struct {
-	plugins     map[string]Plugin
-	pluginPaths map[string]string
-	loader      *Loader
-	deps        *PluginDependencies
-	mu          sync.RWMutex
-	router      *gin.RouterGroup // Store router for dynamic route registration
+	plugins      map[string]Plugin
+	pluginPaths  map[string]string
+	loader       *Loader
+	deps         *PluginDependencies
+	mu           sync.RWMutex
+	router       *gin.RouterGroup       // Store router for dynamic route registration
+	publicRoute  *gin.RouterGroup       // Unauthenticated group for PublicRoutesProvider plugins
+	protectedMW  []gin.HandlerFunc      // Middleware the protected group adds to the public group's
+	routes       map[string]*gin.Engine // Route table of each loaded plugin, consulted by the dispatcher
+	dispatching  bool                   // Whether the dispatcher's routes have been added
+	assetMaxAge  time.Duration          // Cache-Control max-age of plugin assets
+	debugFlags   map[string]*atomic.Bool
+	debugSaved   map[string]bool // debug_mode values an admin set; they win over the plugin's own
+	disabled     map[string]bool // Plugins deactivated by an admin; skipped by LoadPlugins
+	routesOff    map[string]bool // Plugins whose persisted "enabled" setting is false; routes answer 503
+	reloading    map[string]bool // Plugins unloaded by ReloadPlugin and not yet loaded again
+	starting     map[string]bool // Plugins whose Initialize is running; m.mu is released meanwhile
+	events       *events.Bus
+	cache        CacheBackend               // Shared by all plugins; keys are namespaced per plugin
+	cacheTTLs    map[string]*atomic.Int64   // Default TTL per plugin, from its cache_ttl setting
+	logBuffers   map[string]*logBuffer      // Recent log entries per plugin
+	logSize      int                        // Entries kept in each plugin's log buffer
+	initTimeout  time.Duration              // Longest a plugin's Initialize may run
+	initFailed   map[string]string          // Plugins whose last Initialize failed, with the reason
+	loadTimes    map[string]time.Duration   // Time each loaded plugin took to compile and initialize
+	stats        map[string]*routeStats     // Request accounting per plugin, kept across reloads until uninstall
+	reloads      *reloadThrottle            // Coalesces and rate-limits ReloadPlugin and HotReload
+	active       map[string]*requestTracker // Requests running in each plugin's handlers
+	unloading    map[string]bool            // Plugins draining before Shutdown; dispatch admits no new requests
+	drainTimeout time.Duration              // Longest an unload waits for those requests
+
+	platformWarning string // Set by CheckPlatform when installed plugins cannot load here
+
+	// In-flight operation tracking used to drain compiles during shutdown
+	opMu     sync.Mutex
+	inflight sync.WaitGroup
+	draining bool
 }
 
 func NewManager() *Manager {
 	return &Manager{
-		plugins:     make(map[string]Plugin),
-		pluginPaths: make(map[string]string),
-		loader:      NewLoader("./plugins"),
+		plugins:      make(map[string]Plugin),
+		pluginPaths:  make(map[string]string),
+		debugFlags:   make(map[string]*atomic.Bool),
+		debugSaved:   make(map[string]bool),
+		disabled:     make(map[string]bool),
+		routesOff:    make(map[string]bool),
+		reloading:    make(map[string]bool),
+		starting:     make(map[string]bool),
+		routes:       make(map[string]*gin.Engine),
+		cache:        NewMemoryCache(DefaultCacheMaxEntries),
+		cacheTTLs:    make(map[string]*atomic.Int64),
+		logBuffers:   make(map[string]*logBuffer),
+		logSize:      DefaultLogBufferSize,
+		initTimeout:  DefaultInitTimeout,
+		initFailed:   make(map[string]string),
+		loadTimes:    make(map[string]time.Duration),
+		stats:        make(map[string]*routeStats),
+		reloads:      newReloadThrottle(DefaultReloadCooldown),
+		active:       make(map[string]*requestTracker),
+		unloading:    make(map[string]bool),
+		drainTimeout: DefaultDrainTimeout,
+		loader:       NewLoader("./plugins"),
 	}
 }
 
@@ -32,63 +93,352 @@ func (m *Manager) SetDependencies(deps *PluginDependencies) {
 	m.deps = deps
 }
 
+// dependenciesFor returns a copy of the shared dependencies with a logger namespaced
+// to the plugin and only the services its capabilities allow. dirName is the
+// plugin's install directory, where its plugin.json lives.
+func (m *Manager) dependenciesFor(name, dirName string, plugin Plugin) *PluginDependencies {
+	if m.deps == nil {
+		return nil
+	}
+
+	debug := m.evaluateDebug(name, plugin)
+	settings := SafeSettings(name, plugin)
+
+	ttl, exists := m.cacheTTLs[name]
+	if !exists {
+		ttl = &atomic.Int64{}
+		m.cacheTTLs[name] = ttl
+	}
+	ttl.Store(int64(SettingsCacheTTL(settings)))
+
+	buffer, exists := m.logBuffers[name]
+	if !exists {
+		buffer = newLogBuffer(m.logSize)
+		m.logBuffers[name] = buffer
+	}
+
+	deps := *m.deps
+	deps.Logger = newPluginLogger(m.deps.Logger, name, debug, buffer)
+	deps.Cache = newPluginCache(m.cache, name, ttl)
+
+	capabilities, warnings := m.loader.Capabilities(dirName)
+	for _, warning := range warnings {
+		log.Printf("Plugin %s: %s", name, warning)
+	}
+	deps.Capabilities = capabilities
+	if !hasCapability(capabilities, CapabilityDatabase) {
+		deps.Database = nil
+	}
+	if !hasCapability(capabilities, CapabilityConfig) {
+		deps.Config = nil
+	}
+
+	return &deps
+}
+
+// evaluateDebug sets the plugin's debug flag from the debug_mode an admin saved,
+// or else from the plugin's own settings, and returns it. Plugins may only know
+// their configured settings once initialized, so it runs again after Initialize.
+// The caller must hold m.mu.
+func (m *Manager) evaluateDebug(name string, plugin Plugin) *atomic.Bool {
+	debug, exists := m.debugFlags[name]
+	if !exists {
+		debug = &atomic.Bool{}
+		m.debugFlags[name] = debug
+	}
+	if enabled, saved := m.debugSaved[name]; saved {
+		debug.Store(enabled)
+	} else {
+		debug.Store(debugModeEnabled(SafeSettings(name, plugin)))
+	}
+	return debug
+}
+
+// SetPluginDebug switches debug-level logging on or off for a single plugin. The
+// choice outlasts reloads of the plugin. It may be called before the plugin is
+// loaded, to restore a saved debug_mode.
+func (m *Manager) SetPluginDebug(name string, enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	debug, exists := m.debugFlags[name]
+	if !exists {
+		debug = &atomic.Bool{}
+		m.debugFlags[name] = debug
+	}
+	m.debugSaved[name] = enabled
+	debug.Store(enabled)
+}
+
+// SetPluginCacheTTL changes the default TTL of a plugin's cache entries
+func (m *Manager) SetPluginCacheTTL(name string, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cacheTTL, exists := m.cacheTTLs[name]
+	if !exists {
+		cacheTTL = &atomic.Int64{}
+		m.cacheTTLs[name] = cacheTTL
+	}
+	cacheTTL.Store(int64(ttl))
+}
+
+// SetLogBufferSize sets how many log entries are kept per plugin. Call it before
+// plugins are loaded.
+func (m *Manager) SetLogBufferSize(size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if size > 0 {
+		m.logSize = size
+	}
+}
+
+// PluginLogs returns up to lines of a plugin's most recent log entries at or above
+// minLevel, oldest first. lines <= 0 returns everything buffered.
+func (m *Manager) PluginLogs(name string, lines int, minLevel slog.Level) ([]LogEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	buffer, exists := m.logBuffers[name]
+	if !exists {
+		if _, loaded := m.plugins[name]; !loaded {
+			return nil, fmt.Errorf("plugin %s not found", name)
+		}
+		return []LogEntry{}, nil
+	}
+	return buffer.recent(lines, minLevel), nil
+}
+
+// SetCacheBackend replaces the in-memory plugin cache. Call it before plugins are loaded.
+func (m *Manager) SetCacheBackend(backend CacheBackend) {
+	m.cache = backend
+}
+
+// SetDisabled records whether a plugin has been deactivated so that bulk loads skip it
+func (m *Manager) SetDisabled(name string, disabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if disabled {
+		m.disabled[name] = true
+	} else {
+		delete(m.disabled, name)
+	}
+}
+
+// IsDisabled reports whether a plugin has been deactivated
+func (m *Manager) IsDisabled(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.disabled[name]
+}
+
+// SetRoutesEnabled records a plugin's persisted "enabled" setting. While it is
+// false every route of the plugin answers 503, but the plugin stays loaded.
+func (m *Manager) SetRoutesEnabled(name string, enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if enabled {
+		delete(m.routesOff, name)
+	} else {
+		m.routesOff[name] = true
+	}
+}
+
+// RoutesEnabled reports whether a plugin's routes are being served
+func (m *Manager) RoutesEnabled(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return !m.routesOff[name]
+}
+
+// RestoreActivation replaces the recorded activation state with the persisted
+// one: the plugins an admin deactivated and those whose "enabled" setting is
+// false. Loads that follow, including HotReload, skip the deactivated plugins.
+func (m *Manager) RestoreActivation(inactive, switchedOff []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.disabled = make(map[string]bool, len(inactive))
+	for _, name := range inactive {
+		m.disabled[name] = true
+	}
+	m.routesOff = make(map[string]bool, len(switchedOff))
+	for _, name := range switchedOff {
+		m.routesOff[name] = true
+	}
+}
+
+// SetEventBus sets the bus that plugin lifecycle events are emitted on
+func (m *Manager) SetEventBus(bus *events.Bus) {
+	m.events = bus
+}
+
+// SetMaxConcurrentBuilds limits how many plugins are compiled at once. Call it
+// before plugins are loaded.
+func (m *Manager) SetMaxConcurrentBuilds(limit int) {
+	m.loader.SetMaxConcurrentBuilds(limit)
+}
+
 // SetRouter stores the router for dynamic route registration
 func (m *Manager) SetRouter(router *gin.RouterGroup) {
 	m.router = router
 }
 
+// SetPublicRouter stores the unauthenticated group that plugins implementing
+// PublicRoutesProvider mount their public routes on. Without it public routes
+// are not registered.
+func (m *Manager) SetPublicRouter(router *gin.RouterGroup) {
+	m.publicRoute = router
+}
+
+// SetProtectedMiddleware stores the middleware the protected group adds on top
+// of the public group's, such as authentication. Protected plugin routes are
+// dispatched from the public group, so their route tables run these handlers
+// themselves. Call it before routes are registered.
+func (m *Manager) SetProtectedMiddleware(handlers ...gin.HandlerFunc) {
+	m.protectedMW = handlers
+}
+
+// beginOperation registers an in-flight plugin operation, refusing new ones once draining has started
+func (m *Manager) beginOperation() error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	if m.draining {
+		return ErrShuttingDown
+	}
+	m.inflight.Add(1)
+	return nil
+}
+
+// endOperation marks an in-flight plugin operation as finished
+func (m *Manager) endOperation() {
+	m.inflight.Done()
+}
+
+// StopAccepting prevents new install, load, reload and uninstall operations from starting
+func (m *Manager) StopAccepting() {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.draining = true
+}
+
+// WaitForOperations blocks until all in-flight plugin operations finish or ctx expires
+func (m *Manager) WaitForOperations(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // InstallPluginFromZip installs a plugin from a zip file
-func (m *Manager) InstallPluginFromZip(zipPath, pluginName string) error {
+func (m *Manager) InstallPluginFromZip(zipPath, pluginName string) (*InstallResult, error) {
+	if err := m.beginOperation(); err != nil {
+		return nil, err
+	}
+	defer m.endOperation()
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	result, info, err := m.installPluginFromZip(zipPath, pluginName)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	m.emit(EventPluginInstalled, info.Name, info.Version)
+	return result, nil
+}
 
+// loadedName returns the name of the loaded plugin installed in dirName, or "".
+// The caller must hold m.mu.
+func (m *Manager) loadedName(dirName string) string {
+	if _, exists := m.plugins[dirName]; exists {
+		return dirName
+	}
+	for name, path := range m.pluginPaths {
+		if path == dirName {
+			return name
+		}
+	}
+	return ""
+}
+
+// installPluginFromZip installs, initializes and registers a plugin from a zip
+// file. The caller must hold m.mu.
+func (m *Manager) installPluginFromZip(zipPath, pluginName string) (*InstallResult, *PluginInfo, error) {
 	// Validate zip file first
 	validationResult, err := m.loader.ValidateZipPlugin(zipPath)
 	if err != nil {
-		return fmt.Errorf("validation error: %w", err)
+		return nil, nil, fmt.Errorf("validation error: %w", err)
 	}
 
 	if !validationResult.IsValid {
-		return fmt.Errorf("invalid plugin: %s", strings.Join(validationResult.Errors, ", "))
+		return nil, nil, fmt.Errorf("invalid plugin: %s", strings.Join(validationResult.Errors, ", "))
+	}
+
+	result, info, err := m.installPlugin(pluginName, func() (*InstallResult, error) {
+		return m.loader.InstallFromZip(zipPath, pluginName)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	result.Warnings = append(append([]string(nil), validationResult.Warnings...), result.Warnings...)
+	return result, info, nil
+}
+
+// installPlugin runs install to put a plugin's files in place and compile it, then
+// loads, initializes and registers it. The caller must hold m.mu.
+func (m *Manager) installPlugin(pluginName string, install func() (*InstallResult, error)) (*InstallResult, *PluginInfo, error) {
+	if err := CheckReservedName(pluginName); err != nil {
+		return nil, nil, err
 	}
 
 	// Check if plugin is already loaded
-	if _, exists := m.plugins[pluginName]; exists {
-		return fmt.Errorf("plugin %s is already installed", pluginName)
+	if _, exists := m.plugins[pluginName]; exists || m.starting[pluginName] {
+		return nil, nil, fmt.Errorf("plugin %s is already installed", pluginName)
 	}
 
+	start := time.Now()
+
 	// Install the plugin
-	if err := m.loader.InstallFromZip(zipPath, pluginName); err != nil {
-		return fmt.Errorf("installation failed: %w", err)
+	result, err := install()
+	if err != nil {
+		return nil, nil, fmt.Errorf("installation failed: %w", err)
 	}
 
+	capabilities, capabilityWarnings := m.loader.Capabilities(pluginName)
+	result.Capabilities = capabilities
+	result.Warnings = append(result.Warnings, capabilityWarnings...)
+
 	// Load the plugin
 	pluginInstance, err := m.loader.LoadPluginFromDirectory(pluginName)
 	if err != nil {
-		return fmt.Errorf("failed to load installed plugin: %w", err)
+		return nil, nil, fmt.Errorf("failed to load installed plugin: %w", err)
 	}
 
-	// Initialize the plugin
-	if m.deps != nil {
-		if err := pluginInstance.Initialize(m.deps); err != nil {
-			// Cleanup on initialization failure
-			m.loader.UninstallPlugin(pluginName)
-			return fmt.Errorf("failed to initialize plugin %s: %w", pluginName, err)
-		}
-	}
-
-	// Store the plugin
-	info := pluginInstance.GetInfo()
-	m.plugins[info.Name] = pluginInstance
-	m.pluginPaths[info.Name] = pluginName
-
-	// Register routes dynamically
-	if m.router != nil {
-		m.registerPluginRoutes(info.Name, pluginInstance)
+	info, err := m.startPlugin(pluginName, pluginInstance, start)
+	if err != nil {
+		// Cleanup on initialization failure
+		m.loader.UninstallPlugin(pluginName)
+		return nil, nil, err
 	}
 
 	log.Printf("Plugin installed and loaded: %s v%s", info.Name, info.Version)
-	return nil
+	return result, info, nil
 }
 
 // LoadPlugins loads all existing plugins
@@ -96,26 +446,68 @@ func (m *Manager) LoadPlugins(pluginDir string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	return m.loadAllPlugins()
+}
+
+// loadAllPlugins loads and initializes every installed plugin. The caller must
+// hold m.mu; it is released while each plugin initializes.
+func (m *Manager) loadAllPlugins() error {
 	plugins, err := m.loader.LoadAllPlugins()
 	if err != nil {
 		log.Printf("Warning: %v", err)
 	}
 
-	// Initialize all loaded plugins
-	for name, plugin := range plugins {
+	// Initialize in priority order so plugins can rely on earlier ones
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+
+	for _, entry := range m.loadOrder(names) {
+		name, plugin := entry.Name, plugins[entry.Name]
+
+		// Respect the persisted activation state
+		if m.disabled[name] {
+			log.Printf("Skipping deactivated plugin: %s", name)
+			continue
+		}
+
+		if err := CheckReservedName(name); err != nil {
+			log.Printf("Skipping plugin: %v", err)
+			continue
+		}
+
+		// Another operation may have loaded it while an earlier plugin initialized
+		if _, loaded := m.plugins[name]; loaded || m.starting[name] {
+			continue
+		}
+
 		// Initialize the plugin
-		if m.deps != nil {
-			if err := plugin.Initialize(m.deps); err != nil {
+		start := time.Now()
+		if deps := m.dependenciesFor(name, name, plugin); deps != nil {
+			if err := m.initialize(name, plugin, deps); err != nil {
 				log.Printf("Failed to initialize plugin %s: %v", name, err)
 				continue
 			}
+			m.evaluateDebug(name, plugin)
+		}
+		// Register routes before storing the plugin, as startPlugin does. At startup
+		// there is no router yet and RegisterRoutes registers them later; a hot
+		// reload has one and must rebuild the tables its unloads removed.
+		if err := m.registerPluginRoutes(name, plugin); err != nil {
+			log.Printf("Failed to register routes of plugin %s: %v", name, err)
+			if shutdownErr := plugin.Shutdown(); shutdownErr != nil {
+				log.Printf("Error shutting down plugin %s: %v", name, shutdownErr)
+			}
+			continue
 		}
+		m.recordLoadTime(name, start)
 
 		// Store the plugin
 		m.plugins[name] = plugin
 		m.pluginPaths[name] = name
 
-		log.Printf("Loaded plugin: %s v%s", plugin.GetInfo().Name, plugin.GetInfo().Version)
+		log.Printf("Loaded plugin: %s v%s (priority %d)", plugin.GetInfo().Name, plugin.GetInfo().Version, entry.Priority)
 	}
 
 	return nil
@@ -123,77 +515,158 @@ func (m *Manager) LoadPlugins(pluginDir string) error {
 
 // LoadPlugin loads a single plugin by name
 func (m *Manager) LoadPlugin(pluginName string) error {
+	if err := m.beginOperation(); err != nil {
+		return err
+	}
+	defer m.endOperation()
+
+	info, err := m.loadPlugin(pluginName)
+	if err != nil {
+		return err
+	}
+
+	m.emit(EventPluginActivated, info.Name, info.Version)
+	return nil
+}
+
+// loadPlugin compiles, initializes and registers a single plugin
+func (m *Manager) loadPlugin(pluginName string) (*PluginInfo, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Check if plugin is already loaded
-	if _, exists := m.plugins[pluginName]; exists {
-		return fmt.Errorf("plugin %s is already loaded", pluginName)
+	if _, exists := m.plugins[pluginName]; exists || m.starting[pluginName] {
+		return nil, fmt.Errorf("plugin %s is already loaded", pluginName)
 	}
 
 	// Load the plugin
+	start := time.Now()
 	pluginInstance, err := m.loader.LoadPluginFromDirectory(pluginName)
 	if err != nil {
-		return fmt.Errorf("failed to load plugin %s: %w", pluginName, err)
+		return nil, fmt.Errorf("failed to load plugin %s: %w", pluginName, err)
+	}
+
+	info, err := m.startPlugin(pluginName, pluginInstance, start)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Loaded plugin: %s v%s", info.Name, info.Version)
+	return info, nil
+}
+
+// startPlugin initializes an instance of the plugin installed in dirName, then
+// stores it and registers its routes. start is when loading it began. The caller
+// must hold m.mu; it is released while the plugin initializes.
+func (m *Manager) startPlugin(dirName string, instance Plugin, start time.Time) (*PluginInfo, error) {
+	// The name the plugin reports is what routes and settings are keyed by
+	info := instance.GetInfo()
+	if err := CheckReservedName(info.Name); err != nil {
+		return nil, err
 	}
 
 	// Initialize the plugin
-	if m.deps != nil {
-		if err := pluginInstance.Initialize(m.deps); err != nil {
-			return fmt.Errorf("failed to initialize plugin %s: %w", pluginName, err)
+	if deps := m.dependenciesFor(info.Name, dirName, instance); deps != nil {
+		if err := m.initialize(info.Name, instance, deps); err != nil {
+			return nil, fmt.Errorf("failed to initialize plugin %s: %w", dirName, err)
 		}
+		m.evaluateDebug(info.Name, instance)
 	}
 
-	// Store the plugin
-	info := pluginInstance.GetInfo()
-	m.plugins[info.Name] = pluginInstance
-	m.pluginPaths[info.Name] = pluginName
-
-	// Register routes dynamically
-	if m.router != nil {
-		m.registerPluginRoutes(info.Name, pluginInstance)
+	// Register routes dynamically, before the plugin is stored so one whose
+	// routes cannot be registered is refused rather than left half loaded
+	if err := m.registerPluginRoutes(info.Name, instance); err != nil {
+		if shutdownErr := instance.Shutdown(); shutdownErr != nil {
+			log.Printf("Error shutting down plugin %s: %v", info.Name, shutdownErr)
+		}
+		return nil, err
 	}
+	m.recordLoadTime(info.Name, start)
 
-	log.Printf("Loaded plugin: %s v%s", info.Name, info.Version)
-	return nil
+	// Store the plugin
+	m.plugins[info.Name] = instance
+	m.pluginPaths[info.Name] = dirName
+	return &info, nil
 }
 
 // UnloadPlugin unloads a plugin
 func (m *Manager) UnloadPlugin(name string) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	info, err := m.unloadPlugin(name)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	m.emit(EventPluginDeactivated, name, info.Version)
+	return nil
+}
 
+// unloadPlugin shuts a plugin down and removes it from the manager, after waiting
+// for its in-flight requests to drain. The caller must hold m.mu; it is released
+// while the requests drain, so other plugins keep serving, and held again before
+// Shutdown.
+func (m *Manager) unloadPlugin(name string) (*PluginInfo, error) {
 	plugin, exists := m.plugins[name]
 	if !exists {
-		return fmt.Errorf("plugin %s not found", name)
+		return nil, fmt.Errorf("plugin %s not found", name)
+	}
+	if m.unloading[name] {
+		return nil, fmt.Errorf("plugin %s is already being unloaded", name)
 	}
+	info := plugin.GetInfo()
+
+	// Stop admitting requests, then let those already in the plugin's handlers
+	// finish without holding the lock they need to be dispatched
+	m.unloading[name] = true
+	active := m.active[name]
+	m.mu.Unlock()
+	m.drain(name, active)
+	m.mu.Lock()
+	delete(m.unloading, name)
 
 	// Shutdown the plugin
 	if err := plugin.Shutdown(); err != nil {
 		log.Printf("Error shutting down plugin %s: %v", name, err)
 	}
 
-	// Remove from manager
+	// Remove from manager; dropping the route table stops its routes at once
 	delete(m.plugins, name)
 	delete(m.pluginPaths, name)
-
-	// Note: We can't dynamically remove routes from Gin router
-	// This is a limitation of Gin. In a production system, you might
-	// need to track routes and rebuild the router or use a more
-	// dynamic routing solution.
+	delete(m.routes, name)
+	delete(m.loadTimes, name)
+	m.cache.DeletePrefix(cachePrefix(name))
 
 	log.Printf("Unloaded plugin: %s", name)
-	return nil
+	return &info, nil
 }
 
 // UninstallPlugin completely removes a plugin
 func (m *Manager) UninstallPlugin(name string) error {
+	if err := m.beginOperation(); err != nil {
+		return err
+	}
+	defer m.endOperation()
+
 	// First unload if loaded
+	var version string
+	m.mu.Lock()
+	if m.starting[name] {
+		m.mu.Unlock()
+		return fmt.Errorf("plugin %s is being started; try again once it has loaded", name)
+	}
 	if _, exists := m.plugins[name]; exists {
-		if err := m.UnloadPlugin(name); err != nil {
+		info, err := m.unloadPlugin(name)
+		if err != nil {
+			m.mu.Unlock()
 			return fmt.Errorf("failed to unload plugin: %w", err)
 		}
+		version = info.Version
 	}
+	delete(m.initFailed, name)
+	delete(m.debugSaved, name)
+	delete(m.stats, name)
+	m.mu.Unlock()
 
 	// Then uninstall from filesystem
 	if err := m.loader.UninstallPlugin(name); err != nil {
@@ -201,32 +674,68 @@ func (m *Manager) UninstallPlugin(name string) error {
 	}
 
 	log.Printf("Uninstalled plugin: %s", name)
+	m.emit(EventPluginUninstalled, name, version)
 	return nil
 }
 
-// ReloadPlugin reloads a plugin
-func (m *Manager) ReloadPlugin(name string) error {
+// ReloadPlugin unloads a plugin, recompiles it and loads it again. With cached
+// the existing build is reused unless the plugin's sources are newer than it. It
+// reports whether a compile happened. It is needed after the plugin's code
+// changes; RefreshPluginSettings is much cheaper when only its settings changed.
+// Concurrent reloads of a plugin share one run, except that a reload that must
+// recompile runs again after one that reused the cached build. A plugin reloaded
+// within the cooldown, including by a hot reload, yields a ReloadThrottledError.
+func (m *Manager) ReloadPlugin(name string, cached bool) (bool, error) {
+	return m.reloads.do(name, !cached, func() (bool, error) {
+		return m.reloadPlugin(name, cached)
+	})
+}
+
+func (m *Manager) reloadPlugin(name string, cached bool) (bool, error) {
+	if err := m.beginOperation(); err != nil {
+		return false, err
+	}
+	defer m.endOperation()
+
 	// Get the plugin path before unloading
 	m.mu.RLock()
 	pluginPath, exists := m.pluginPaths[name]
 	m.mu.RUnlock()
 
 	if !exists {
-		return fmt.Errorf("plugin %s not found", name)
+		return false, fmt.Errorf("plugin %s not found", name)
 	}
 
-	// Unload the plugin
-	if err := m.UnloadPlugin(name); err != nil {
-		return fmt.Errorf("failed to unload plugin: %w", err)
+	// Unload the plugin. Its routes answer 503 with Retry-After until it is back.
+	m.mu.Lock()
+	_, err := m.unloadPlugin(name)
+	if err == nil {
+		m.reloading[name] = true
 	}
+	m.mu.Unlock()
+	if err != nil {
+		return false, fmt.Errorf("failed to unload plugin: %w", err)
+	}
+	defer func() {
+		m.mu.Lock()
+		delete(m.reloading, name)
+		m.mu.Unlock()
+	}()
 
-	// Recompile the plugin
-	if err := m.loader.RecompilePlugin(pluginPath); err != nil {
-		return fmt.Errorf("failed to recompile plugin: %w", err)
+	// Rebuild the plugin
+	recompiled, err := m.loader.RebuildPlugin(pluginPath, cached)
+	if err != nil {
+		return false, fmt.Errorf("failed to recompile plugin: %w", err)
 	}
 
 	// Load it again
-	return m.LoadPlugin(pluginPath)
+	info, err := m.loadPlugin(pluginPath)
+	if err != nil {
+		return recompiled, err
+	}
+
+	m.emit(EventPluginReloaded, info.Name, info.Version)
+	return recompiled, nil
 }
 
 // GetPlugin returns a specific plugin
@@ -253,32 +762,39 @@ func (m *Manager) GetAllPlugins() map[string]Plugin {
 
 // RegisterRoutes registers all plugin routes
 func (m *Manager) RegisterRoutes(router *gin.RouterGroup) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
 	// Store router for dynamic registration
 	m.router = router
-
-	// Create a plugin group
-	router.Group("/plugins")
+	m.mountDispatcher()
 
 	for name, plugin := range m.plugins {
-		m.registerPluginRoutes(name, plugin)
+		if err := m.registerPluginRoutes(name, plugin); err != nil {
+			log.Printf("Plugin %s has no routes: %v", name, err)
+		}
 	}
 }
 
-// RegisterPluginRoutes registers routes for a specific plugin
-func (m *Manager) registerPluginRoutes(name string, plugin Plugin) {
+// registerPluginRoutes records a plugin's routes in a fresh route table, replacing
+// the table of any previous load. The caller must hold m.mu.
+func (m *Manager) registerPluginRoutes(name string, plugin Plugin) error {
 	if m.router == nil {
-		return
+		return nil
 	}
+	m.mountDispatcher()
 
-	// Create a plugin group
-	pluginGroup := m.router.Group("/plugins")
+	table, err := m.buildRouteTable(name, plugin)
+	if err != nil {
+		return err
+	}
+
+	// Created here, under the write lock, so the dispatcher only reads them
+	m.statsFor(name)
+	m.activeFor(name)
 
-	// Create a sub-group for each plugin
-	pluginRouter := pluginGroup.Group("/" + strings.ToLower(name))
-	plugin.RegisterRoutes(pluginRouter)
+	m.routes[name] = table
+	return nil
 }
 
 // GetAdminMenuItems returns all admin menu items from plugins
@@ -288,14 +804,27 @@ func (m *Manager) GetAdminMenuItems() []AdminMenuItem {
 
 	var allItems []AdminMenuItem
 
-	for _, plugin := range m.plugins {
-		items := plugin.GetAdminMenuItems()
+	for name, plugin := range m.plugins {
+		items := SafeAdminMenuItems(name, plugin)
 		allItems = append(allItems, items...)
 	}
 
 	return allItems
 }
 
+// GetAdminMenuItemsByPlugin returns the admin menu items of each loaded plugin keyed by plugin name
+func (m *Manager) GetAdminMenuItemsByPlugin() map[string][]AdminMenuItem {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	items := make(map[string][]AdminMenuItem, len(m.plugins))
+	for name, plugin := range m.plugins {
+		items[name] = SafeAdminMenuItems(name, plugin)
+	}
+
+	return items
+}
+
 // GetPluginSettings returns settings for a specific plugin
 func (m *Manager) GetPluginSettings(pluginName string) ([]PluginSetting, error) {
 	m.mu.RLock()
@@ -306,7 +835,7 @@ func (m *Manager) GetPluginSettings(pluginName string) ([]PluginSetting, error)
 		return nil, fmt.Errorf("plugin %s not found", pluginName)
 	}
 
-	return plugin.GetSettings(), nil
+	return SortSettings(SafeSettings(pluginName, plugin)), nil
 }
 
 // GetPluginInfo returns information about an installed plugin (without loading it)
@@ -314,19 +843,37 @@ func (m *Manager) GetPluginInfo(pluginName string) (*PluginInfo, error) {
 	return m.loader.GetPluginInfo(pluginName)
 }
 
-// ListInstalledPlugins returns list of all installed plugins (loaded and unloaded)
+// ListInstalledPlugins returns every installed plugin, loaded or not, sorted by
+// name. Loaded plugins are listed under the name they report; plugin directories
+// that failed to load are listed under their directory name.
 func (m *Manager) ListInstalledPlugins() ([]string, error) {
-	// This would scan the plugins directory for installed plugins
-	// For now, return loaded plugins
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	var plugins []string
+	installed := make(map[string]bool, len(m.plugins))
 	for name := range m.plugins {
-		plugins = append(plugins, name)
+		installed[name] = true
+	}
+
+	entries, err := os.ReadDir(m.loader.pluginDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read plugin directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if m.loadedName(entry.Name()) == "" {
+			installed[entry.Name()] = true
+		}
 	}
 
-	return plugins, nil
+	names := make([]string, 0, len(installed))
+	for name := range installed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
 }
 
 // ValidatePlugin validates a plugin zip before installation
@@ -334,6 +881,16 @@ func (m *Manager) ValidatePlugin(zipPath string) (*PluginValidationResult, error
 	return m.loader.ValidateZipPlugin(zipPath)
 }
 
+// DryRunPlugin validates a plugin zip end to end without installing it
+func (m *Manager) DryRunPlugin(zipPath, pluginName string, compile bool) (*PluginDryRunResult, error) {
+	if err := m.beginOperation(); err != nil {
+		return nil, err
+	}
+	defer m.endOperation()
+
+	return m.loader.DryRunZipPlugin(zipPath, pluginName, compile)
+}
+
 // GetSystemInfo returns system information for plugin development
 func (m *Manager) GetSystemInfo() (*SystemInfo, error) {
 	compilerInfo, err := m.loader.GetCompilerInfo()
@@ -341,17 +898,38 @@ func (m *Manager) GetSystemInfo() (*SystemInfo, error) {
 		return nil, err
 	}
 
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.plugins))
+	for name := range m.plugins {
+		names = append(names, name)
+	}
+
+	loadTimes := make(map[string]float64, len(m.loadTimes))
+	for name, elapsed := range m.loadTimes {
+		loadTimes[name] = milliseconds(elapsed)
+	}
+
 	return &SystemInfo{
 		Platform:    m.loader.GetCurrentPlatform(),
 		Supported:   m.loader.IsPlatformSupported(),
 		Compiler:    *compilerInfo,
 		LoadedCount: len(m.plugins),
+		LoadOrder:   m.loadOrder(names),
+		LoadTimesMs: loadTimes,
 	}, nil
 }
 
-// CleanupCache removes old compiled plugin files
-func (m *Manager) CleanupCache(maxAge time.Duration) error {
-	return m.loader.CleanupBuildCache(maxAge)
+// CleanupCache removes compiled plugin files older than maxAge and trims the
+// cache to maxSize bytes (0 for no size limit)
+func (m *Manager) CleanupCache(maxAge time.Duration, maxSize int64) error {
+	return m.loader.CleanupBuildCache(maxAge, maxSize)
+}
+
+// GetCacheStats returns statistics about the compiled plugin cache
+func (m *Manager) GetCacheStats() (CacheStats, error) {
+	return m.loader.GetCacheStats()
 }
 
 // ShutdownAll shuts down all plugins
@@ -366,10 +944,23 @@ func (m *Manager) ShutdownAll() {
 	}
 }
 
-// HotReload reloads all plugins without restarting the server
+// HotReload reloads all plugins without restarting the server. Concurrent calls
+// share one run, and a call within the cooldown of the last hot reload or reload
+// of a single plugin yields a ReloadThrottledError.
 func (m *Manager) HotReload() error {
+	_, err := m.reloads.do(hotReloadKey, false, func() (bool, error) {
+		return false, m.hotReload()
+	})
+	return err
+}
+
+func (m *Manager) hotReload() error {
+	if err := m.beginOperation(); err != nil {
+		return err
+	}
+	defer m.endOperation()
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
 	log.Println("Starting hot reload of all plugins...")
 
@@ -381,18 +972,28 @@ func (m *Manager) HotReload() error {
 
 	// Unload all plugins
 	for _, name := range pluginNames {
-		if err := m.UnloadPlugin(name); err != nil {
+		if _, err := m.unloadPlugin(name); err != nil {
 			log.Printf("Error unloading plugin %s during hot reload: %v", name, err)
 		}
 	}
 
 	// Reload all plugins
-	if err := m.LoadPlugins("./plugins"); err != nil {
+	if err := m.loadAllPlugins(); err != nil {
+		m.mu.Unlock()
 		log.Printf("Error during hot reload: %v", err)
 		return err
 	}
 
-	log.Printf("Hot reload completed. Loaded %d plugins.", len(m.plugins))
+	reloaded := make([]PluginInfo, 0, len(m.plugins))
+	for _, plugin := range m.plugins {
+		reloaded = append(reloaded, plugin.GetInfo())
+	}
+	m.mu.Unlock()
+
+	log.Printf("Hot reload completed. Loaded %d plugins.", len(reloaded))
+	for _, info := range reloaded {
+		m.emit(EventPluginReloaded, info.Name, info.Version)
+	}
 	return nil
 }
 
@@ -403,4 +1004,10 @@ type SystemInfo struct {
 	Supported   bool         `json:"supported"`
 	Compiler    CompilerInfo `json:"compiler"`
 	LoadedCount int          `json:"loaded_count"`
+
+	// LoadOrder is the order loaded plugins are initialized in at startup
+	LoadOrder []PluginLoadOrder `json:"load_order"`
+
+	// LoadTimesMs is how long each loaded plugin took to load; see GetLoadTimes
+	LoadTimesMs map[string]float64 `json:"load_times_ms"`
 }
@@ -0,0 +1,167 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ManifestError lists everything wrong with a plugin.json
+type ManifestError struct {
+	Problems []string
+}
+
+func (e *ManifestError) Error() string {
+	return "invalid plugin.json: " + strings.Join(e.Problems, "; ")
+}
+
+// manifestFieldKind is the JSON type a plugin.json field must have
+type manifestFieldKind int
+
+const (
+	kindString manifestFieldKind = iota
+	kindInteger
+	kindStringList
+	kindStringMap
+)
+
+// manifestFields is the schema of plugin.json, derived from the JSON tags of
+// PluginManifest so the two cannot drift apart
+var manifestFields = manifestSchema(reflect.TypeOf(PluginManifest{}))
+
+// manifestSchema maps the JSON name of each field of a manifest struct to the
+// kind its values must have
+func manifestSchema(t reflect.Type) map[string]manifestFieldKind {
+	fields := make(map[string]manifestFieldKind, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		typ := field.Type
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		switch {
+		case typ.Kind() == reflect.String:
+			fields[name] = kindString
+		case typ.Kind() == reflect.Int:
+			fields[name] = kindInteger
+		case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.String:
+			fields[name] = kindStringList
+		case typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String && typ.Elem().Kind() == reflect.String:
+			fields[name] = kindStringMap
+		default:
+			panic(fmt.Sprintf("plugin.json field %s has unsupported type %s", name, field.Type))
+		}
+	}
+	return fields
+}
+
+// requiredManifestFields must be present and non-empty
+var requiredManifestFields = []string{"name", "version"}
+
+// parseManifest validates plugin.json against manifestFields and decodes it. All
+// problems are reported together in a ManifestError.
+func parseManifest(data []byte) (*PluginManifest, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, &ManifestError{Problems: []string{fmt.Sprintf("not a JSON object: %v", err)}}
+	}
+
+	var problems []string
+	for _, name := range requiredManifestFields {
+		var value string
+		if raw, ok := fields[name]; !ok || isJSONNull(raw) {
+			problems = append(problems, name+" is required")
+		} else if json.Unmarshal(raw, &value) == nil && strings.TrimSpace(value) == "" {
+			problems = append(problems, name+" must not be empty")
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		kind, known := manifestFields[name]
+		if !known || isJSONNull(fields[name]) {
+			continue
+		}
+		if problem := checkManifestField(name, kind, fields[name]); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ManifestError{Problems: problems}
+	}
+
+	var manifest PluginManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, &ManifestError{Problems: []string{err.Error()}}
+	}
+	return &manifest, nil
+}
+
+// checkManifestField describes how a field's value does not match its kind, or
+// returns "" when it does
+func checkManifestField(name string, kind manifestFieldKind, raw json.RawMessage) string {
+	switch kind {
+	case kindString:
+		var value string
+		if json.Unmarshal(raw, &value) != nil {
+			return name + " must be a string"
+		}
+	case kindInteger:
+		var value int
+		if json.Unmarshal(raw, &value) != nil {
+			return name + " must be an integer"
+		}
+	case kindStringList:
+		var value []string
+		if json.Unmarshal(raw, &value) != nil {
+			return name + " must be an array of strings"
+		}
+	case kindStringMap:
+		var value map[string]string
+		if json.Unmarshal(raw, &value) != nil {
+			return name + " must be an object with string values"
+		}
+	}
+	return ""
+}
+
+// unknownFieldWarnings warns about top-level fields of a plugin's plugin.json
+// that the schema does not define, which are usually typos
+func unknownFieldWarnings(pluginDir string) []string {
+	data, err := os.ReadFile(filepath.Join(pluginDir, "plugin.json"))
+	if err != nil {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return nil
+	}
+
+	var warnings []string
+	for name := range fields {
+		if _, known := manifestFields[name]; !known {
+			warnings = append(warnings, fmt.Sprintf("plugin.json has unknown field %q, which is ignored", name))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseManifestRejectsMalformedManifests(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		problems []string
+	}{
+		{"not an object", `["seo"]`, []string{"not a JSON object"}},
+		{"not JSON", `{name: seo}`, []string{"not a JSON object"}},
+		{"missing name and version", `{"description": "SEO"}`, []string{"name is required", "version is required"}},
+		{"empty name", `{"name": " ", "version": "1.0.0"}`, []string{"name must not be empty"}},
+		{"null version", `{"name": "seo", "version": null}`, []string{"version is required"}},
+		{"wrong types", `{"name": "seo", "version": 1, "priority": "high", "capabilities": "database", "dependencies": {"gin": 1}}`, []string{
+			"capabilities must be an array of strings",
+			"dependencies must be an object with string values",
+			"priority must be an integer",
+			"version must be a string",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseManifest([]byte(tt.data))
+			var manifestErr *ManifestError
+			if !errors.As(err, &manifestErr) {
+				t.Fatalf("err = %v, want a ManifestError", err)
+			}
+			if len(manifestErr.Problems) != len(tt.problems) {
+				t.Fatalf("problems %q, want %q", manifestErr.Problems, tt.problems)
+			}
+			for i, want := range tt.problems {
+				if !strings.HasPrefix(manifestErr.Problems[i], want) {
+					t.Errorf("problem %d = %q, want %q", i, manifestErr.Problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseManifestIgnoresUnknownFields(t *testing.T) {
+	data := `{"name": "seo", "version": "1.0.0", "priority": 5, "autor": "Jane"}`
+	manifest, err := parseManifest([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Name != "seo" || manifest.Priority == nil || *manifest.Priority != 5 {
+		t.Errorf("decoded %+v", manifest)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "plugin.json"), []byte(data), 0644)
+	warnings := unknownFieldWarnings(dir)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"autor"`) {
+		t.Errorf("warnings = %q, want one for autor", warnings)
+	}
+}
+
+func TestManifestFieldsCoverPluginManifest(t *testing.T) {
+	want := map[string]manifestFieldKind{
+		"name":            kindString,
+		"version":         kindString,
+		"description":     kindString,
+		"author":          kindString,
+		"website":         kindString,
+		"main":            kindString,
+		"dependencies":    kindStringMap,
+		"scripts":         kindStringMap,
+		"capabilities":    kindStringList,
+		"priority":        kindInteger,
+		"build_tags":      kindStringList,
+		"min_cms_version": kindString,
+		"max_cms_version": kindString,
+	}
+	if !reflect.DeepEqual(manifestFields, want) {
+		t.Errorf("manifestFields = %v, want %v", manifestFields, want)
+	}
+}
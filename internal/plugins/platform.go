@@ -0,0 +1,63 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// UnsupportedPlatformError reports plugins installed on a platform where Go
+// plugins cannot be loaded
+type UnsupportedPlatformError struct {
+	Platform  string
+	Supported []string
+	Plugins   []string // Plugin directories that will not load
+}
+
+func (e *UnsupportedPlatformError) Error() string {
+	return fmt.Sprintf("plugins are not supported on %s, so %d installed plugin(s) will not load (%s); "+
+		"plugins are built with Go's plugin package, which only works on %s, so run the CMS there, e.g. in a Linux container",
+		e.Platform, len(e.Plugins), strings.Join(e.Plugins, ", "), strings.Join(e.Supported, ", "))
+}
+
+// CheckPlatform returns an UnsupportedPlatformError when pluginDir holds plugins
+// that cannot load on this platform. The result is remembered and reported by
+// PlatformWarning.
+func (m *Manager) CheckPlatform(pluginDir string) error {
+	if m.loader.IsPlatformSupported() {
+		return nil
+	}
+
+	entries, err := os.ReadDir(pluginDir)
+	if err != nil {
+		return nil
+	}
+	var installed []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			installed = append(installed, entry.Name())
+		}
+	}
+	if len(installed) == 0 {
+		return nil
+	}
+
+	platformErr := &UnsupportedPlatformError{
+		Platform:  m.loader.GetCurrentPlatform(),
+		Supported: m.loader.GetSupportedPlatforms(),
+		Plugins:   installed,
+	}
+	m.mu.Lock()
+	m.platformWarning = platformErr.Error()
+	m.mu.Unlock()
+	return platformErr
+}
+
+// PlatformWarning describes why installed plugins cannot load on this platform,
+// or is empty when they can
+func (m *Manager) PlatformWarning() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.platformWarning
+}
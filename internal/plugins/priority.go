@@ -0,0 +1,43 @@
+package plugins
+
+import "sort"
+
+// DefaultPriority is the load priority of plugins that do not declare one.
+// Plugins with a lower priority are initialized first.
+const DefaultPriority = 10
+
+// PluginLoadOrder is one entry of the order plugins are initialized in
+type PluginLoadOrder struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+}
+
+// Priority returns the load priority declared in an installed plugin's plugin.json
+func (l *Loader) Priority(pluginName string) int {
+	manifest, err := readManifest(l.sourceDir(pluginName))
+	if err != nil || manifest == nil || manifest.Priority == nil {
+		return DefaultPriority
+	}
+	return *manifest.Priority
+}
+
+// loadOrder sorts plugin names by priority, then name, so startup initializes
+// plugins in the same order every time
+func (m *Manager) loadOrder(names []string) []PluginLoadOrder {
+	order := make([]PluginLoadOrder, 0, len(names))
+	for _, name := range names {
+		dirName := name
+		if path, exists := m.pluginPaths[name]; exists {
+			dirName = path
+		}
+		order = append(order, PluginLoadOrder{Name: name, Priority: m.loader.Priority(dirName)})
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].Priority != order[j].Priority {
+			return order[i].Priority < order[j].Priority
+		}
+		return order[i].Name < order[j].Name
+	})
+	return order
+}
@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestLoadPluginsInPriorityOrder(t *testing.T) {
+	host := newTestHost(t)
+	installFakeToolchain(host.manager)
+	l := host.manager.loader
+
+	// Record the order plugins are initialized in
+	var mu sync.Mutex
+	var initialized []string
+	open := l.open
+	l.open = func(soPath string) (Plugin, error) {
+		instance, err := open(soPath)
+		if plugin, ok := instance.(*fakePlugin); ok {
+			plugin.onInit = func(*PluginDependencies) {
+				mu.Lock()
+				defer mu.Unlock()
+				initialized = append(initialized, plugin.info.Name)
+			}
+		}
+		return instance, err
+	}
+
+	manifests := map[string]map[string]interface{}{
+		"search":    {"name": "search", "version": "1.0.0", "priority": 20},
+		"auditing":  {"name": "auditing", "version": "1.0.0"},
+		"analytics": {"name": "analytics", "version": "1.0.0"},
+		"cache":     {"name": "cache", "version": "1.0.0", "priority": 1},
+		"zeta":      {"name": "zeta", "version": "1.0.0", "priority": -5},
+	}
+	for name, manifest := range manifests {
+		dir := filepath.Join(l.pluginDir, name)
+		os.MkdirAll(dir, 0755)
+		for file, content := range pluginFiles(t, manifest) {
+			os.WriteFile(filepath.Join(dir, file), []byte(content), 0644)
+		}
+	}
+
+	if err := host.manager.LoadPlugins(l.pluginDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lower priorities first, plugins without one at DefaultPriority, ties by name
+	want := []string{"zeta", "cache", "analytics", "auditing", "search"}
+	if !reflect.DeepEqual(initialized, want) {
+		t.Errorf("initialized %v, want %v", initialized, want)
+	}
+
+	// The same order is what system info reports
+	host.manager.mu.RLock()
+	order := host.manager.loadOrder([]string{"search", "auditing", "analytics", "cache", "zeta"})
+	host.manager.mu.RUnlock()
+	wantOrder := []PluginLoadOrder{
+		{Name: "zeta", Priority: -5},
+		{Name: "cache", Priority: 1},
+		{Name: "analytics", Priority: DefaultPriority},
+		{Name: "auditing", Priority: DefaultPriority},
+		{Name: "search", Priority: 20},
+	}
+	if !reflect.DeepEqual(order, wantOrder) {
+		t.Errorf("load order %+v, want %+v", order, wantOrder)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package plugins
+
+// raceEnabled reports whether the test binary was built with -race. Plugins
+// built without it cannot be opened by such a binary.
+const raceEnabled = true
@@ -0,0 +1,44 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+)
+
+// RefreshPluginSettings applies persisted setting values to a loaded plugin
+// without recompiling or reopening it. The host-managed settings (enabled,
+// cache_ttl and debug_mode) always take effect; the plugin's own settings only
+// do when it implements SettingsApplier, and otherwise need ReloadPlugin.
+//
+// Use this after settings change in the database; use ReloadPlugin after the
+// plugin's code changes.
+func (m *Manager) RefreshPluginSettings(name string, settings []PluginSetting) (applied bool, err error) {
+	m.mu.RLock()
+	plugin, exists := m.plugins[name]
+	m.mu.RUnlock()
+	if !exists {
+		return false, fmt.Errorf("plugin %s not found", name)
+	}
+
+	m.SetRoutesEnabled(name, SettingsEnabled(settings))
+	m.SetPluginCacheTTL(name, SettingsCacheTTL(settings))
+
+	values := make(map[string]interface{}, len(settings))
+	for _, setting := range settings {
+		values[setting.Key] = setting.Value
+	}
+	if debugMode, ok := values["debug_mode"].(bool); ok {
+		m.SetPluginDebug(name, debugMode)
+	}
+
+	applier, ok := plugin.(SettingsApplier)
+	if !ok {
+		return false, nil
+	}
+	if err := SafeApplySettings(name, applier, values); err != nil {
+		return false, fmt.Errorf("plugin %s rejected its settings: %w", name, err)
+	}
+
+	log.Printf("Refreshed settings of plugin %s", name)
+	return true, nil
+}
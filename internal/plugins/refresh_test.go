@@ -0,0 +1,64 @@
+package plugins
+
+import (
+	"errors"
+	"testing"
+)
+
+// applierPlugin is a fakePlugin that takes new setting values while running
+type applierPlugin struct {
+	*fakePlugin
+	applied map[string]interface{}
+	err     error
+}
+
+func (p *applierPlugin) ApplySettings(values map[string]interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.applied = values
+	return nil
+}
+
+func TestRefreshPluginSettingsDoesNotCompile(t *testing.T) {
+	host := newTestHost(t)
+	host.manager.loader.compile = func(sourceDir, pluginName string) (string, error) {
+		t.Errorf("compiler invoked for %s", pluginName)
+		return "", errors.New("unexpected compile")
+	}
+	plugin := &applierPlugin{fakePlugin: newFakePlugin("forms")}
+	host.add(plugin)
+
+	applied, err := host.manager.RefreshPluginSettings("forms", []PluginSetting{
+		{Key: "enabled", Type: "boolean", Value: false},
+		{Key: "greeting", Type: "string", Value: "hello"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !applied || plugin.applied["greeting"] != "hello" {
+		t.Errorf("applied = %v with %v, want the plugin to get greeting", applied, plugin.applied)
+	}
+	if running, _ := host.manager.GetPlugin("forms"); running != Plugin(plugin) {
+		t.Error("refresh replaced the running plugin")
+	}
+	if host.manager.RoutesEnabled("forms") {
+		t.Error("enabled=false did not switch the routes off")
+	}
+}
+
+func TestRefreshPluginSettingsRejected(t *testing.T) {
+	host := newTestHost(t)
+	host.add(&applierPlugin{fakePlugin: newFakePlugin("forms"), err: errors.New("bad greeting")})
+
+	applied, err := host.manager.RefreshPluginSettings("forms", []PluginSetting{{Key: "greeting", Value: ""}})
+	if err == nil || applied {
+		t.Errorf("applied = %v, err = %v; want the rejection reported", applied, err)
+	}
+
+	// Plugins without ApplySettings only get the host-managed settings
+	host.add(newFakePlugin("mailer"))
+	if applied, err := host.manager.RefreshPluginSettings("mailer", nil); err != nil || applied {
+		t.Errorf("applied = %v, err = %v for a plugin without ApplySettings", applied, err)
+	}
+}
@@ -0,0 +1,151 @@
+package plugins
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withPingRoutes gives every plugin the fake toolchain opens a GET /ping route
+func withPingRoutes(m *Manager) {
+	open := m.loader.open
+	m.loader.open = func(soPath string) (Plugin, error) {
+		instance, err := open(soPath)
+		if plugin, ok := instance.(*fakePlugin); ok {
+			plugin.routes = func(r *gin.RouterGroup) {
+				r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+			}
+		}
+		return instance, err
+	}
+}
+
+func TestReloadPluginRecompiles(t *testing.T) {
+	host := newTestHost(t)
+	host.manager.SetReloadCooldown(0)
+	tc := installFakeToolchain(host.manager)
+	tc.install(t, host.manager, "forms", pluginFiles(t, map[string]interface{}{"name": "forms", "version": "1.0.0"}))
+	before := host.manager.loader.currentBuild("forms")
+
+	// The sources are unchanged, yet a plain reload still compiles
+	recompiled, err := host.manager.ReloadPlugin("forms", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recompiled {
+		t.Error("reload reported no compile")
+	}
+	if after := host.manager.loader.currentBuild("forms"); after == before {
+		t.Errorf("reload kept the cached build %s", before)
+	}
+	if _, ok := host.manager.GetPlugin("forms"); !ok {
+		t.Error("forms is not loaded after the reload")
+	}
+}
+
+func TestReloadPluginCached(t *testing.T) {
+	host := newTestHost(t)
+	host.manager.SetReloadCooldown(0)
+	tc := installFakeToolchain(host.manager)
+	tc.install(t, host.manager, "forms", pluginFiles(t, map[string]interface{}{"name": "forms", "version": "1.0.0"}))
+	before := host.manager.loader.currentBuild("forms")
+
+	recompiled, err := host.manager.ReloadPlugin("forms", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recompiled || host.manager.loader.currentBuild("forms") != before {
+		t.Error("cached reload rebuilt unchanged sources")
+	}
+
+	// Changed sources are rebuilt even when the cache is allowed
+	files := pluginFiles(t, map[string]interface{}{"name": "forms", "version": "1.1.0"})
+	manifest := filepath.Join(host.manager.loader.pluginDir, "forms", "plugin.json")
+	if err := os.WriteFile(manifest, []byte(files["plugin.json"]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(manifest, later, later)
+
+	recompiled, err = host.manager.ReloadPlugin("forms", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recompiled {
+		t.Error("cached reload did not rebuild changed sources")
+	}
+	if got := runningVersion(t, host.manager, "forms"); got != "1.1.0" {
+		t.Errorf("forms running %s, want 1.1.0", got)
+	}
+}
+
+func TestHotReloadKeepsPluginRoutes(t *testing.T) {
+	host := newTestHost(t)
+	host.manager.SetReloadCooldown(0)
+	tc := installFakeToolchain(host.manager)
+	withPingRoutes(host.manager)
+	tc.install(t, host.manager, "seo", pluginFiles(t, map[string]interface{}{"name": "seo", "version": "1.0.0"}))
+
+	if w := host.do(http.MethodGet, "/api/v1/plugins/seo/ping"); w.Code != http.StatusOK {
+		t.Fatalf("before hot reload: status %d: %s", w.Code, w.Body)
+	}
+	for i := 1; i <= 2; i++ {
+		if err := host.manager.HotReload(); err != nil {
+			t.Fatal(err)
+		}
+		if w := host.do(http.MethodGet, "/api/v1/plugins/seo/ping"); w.Code != http.StatusOK {
+			t.Errorf("after hot reload %d: status %d: %s", i, w.Code, w.Body)
+		}
+	}
+}
+
+func TestHotReloadKeepsDeactivatedPluginsUnloaded(t *testing.T) {
+	host := newTestHost(t)
+	host.manager.SetReloadCooldown(0)
+	tc := installFakeToolchain(host.manager)
+	withPingRoutes(host.manager)
+	tc.install(t, host.manager, "forms", pluginFiles(t, map[string]interface{}{"name": "forms", "version": "1.0.0"}))
+	seo := tc.install(t, host.manager, "seo", pluginFiles(t, map[string]interface{}{"name": "seo", "version": "1.0.0"}))
+
+	// Deactivate forms as the admin handler does
+	host.manager.SetDisabled("forms", true)
+	if err := host.manager.UnloadPlugin("forms"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := host.manager.HotReload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := host.manager.GetPlugin("forms"); ok {
+		t.Error("hot reload reactivated a deactivated plugin")
+	}
+	if plugin, ok := host.manager.GetPlugin("seo"); !ok || plugin == Plugin(seo) {
+		t.Error("seo was not reloaded")
+	}
+	if w := host.do(http.MethodGet, "/api/v1/plugins/seo/ping"); w.Code != http.StatusOK {
+		t.Errorf("seo after hot reload: status %d: %s", w.Code, w.Body)
+	}
+	w := host.do(http.MethodGet, "/api/v1/plugins/forms/ping")
+	if code, _ := gateError(t, w); w.Code != http.StatusServiceUnavailable || code != CodePluginDisabled {
+		t.Errorf("deactivated plugin after hot reload: %d %s, want 503 %s", w.Code, code, CodePluginDisabled)
+	}
+
+	// The persisted state replaces the recorded one before the admin handler's
+	// hot reload, so a plugin reactivated elsewhere comes back
+	host.manager.RestoreActivation(nil, nil)
+	if err := host.manager.HotReload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := host.manager.GetPlugin("forms"); !ok {
+		t.Error("reactivated plugin was not loaded by the hot reload")
+	}
+	for _, name := range []string{"forms", "seo"} {
+		if w := host.do(http.MethodGet, "/api/v1/plugins/"+name+"/ping"); w.Code != http.StatusOK {
+			t.Errorf("%s after the second hot reload: status %d: %s", name, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reservedNames are core route prefixes and admin menu IDs that a plugin name may
+// not take, so a plugin cannot shadow core routing or navigation
+var reservedNames = map[string]bool{
+	// Core route prefixes
+	"admin":    true,
+	"api":      true,
+	"auth":     true,
+	"health":   true,
+	"login":    true,
+	"logout":   true,
+	"profile":  true,
+	"refresh":  true,
+	"register": true,
+	"system":   true,
+	"uploads":  true,
+
+	// Core admin menu sections
+	"content":   true,
+	"dashboard": true,
+	"media":     true,
+	"plugins":   true,
+	"settings":  true,
+	"themes":    true,
+	"tools":     true,
+	"users":     true,
+}
+
+// ReservedNameError reports a plugin whose name collides with a core name
+type ReservedNameError struct {
+	Name string
+}
+
+func (e *ReservedNameError) Error() string {
+	return fmt.Sprintf("plugin name %q is reserved by the core", e.Name)
+}
+
+// CheckReservedName returns a *ReservedNameError when name is reserved
+func CheckReservedName(name string) error {
+	if reservedNames[strings.ToLower(name)] {
+		return &ReservedNameError{Name: name}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+)
+
+// SafeAdminMenuItems returns the plugin's admin menu items. A panic inside the
+// plugin is logged and yields no items, so one faulty plugin cannot break menu assembly.
+func SafeAdminMenuItems(name string, plugin Plugin) (items []AdminMenuItem) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Plugin %s panicked in GetAdminMenuItems: %v", name, r)
+			items = nil
+		}
+	}()
+	return plugin.GetAdminMenuItems()
+}
+
+// SafeSettings returns the plugin's settings. A panic inside the plugin is
+// logged and yields no settings.
+func SafeSettings(name string, plugin Plugin) (settings []PluginSetting) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Plugin %s panicked in GetSettings: %v", name, r)
+			settings = nil
+		}
+	}()
+	return plugin.GetSettings()
+}
+
+// SafeApplySettings passes setting values to a plugin implementing SettingsApplier.
+// A panic inside the plugin is logged and returned as an error.
+func SafeApplySettings(name string, applier SettingsApplier, values map[string]interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Plugin %s panicked in ApplySettings: %v", name, r)
+			err = fmt.Errorf("plugin %s panicked in ApplySettings: %v", name, r)
+		}
+	}()
+	return applier.ApplySettings(values)
+}
@@ -0,0 +1,64 @@
+package plugins
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// menuPlugin is a fakePlugin with admin menu items
+type menuPlugin struct {
+	*fakePlugin
+	items []AdminMenuItem
+}
+
+func (p *menuPlugin) GetAdminMenuItems() []AdminMenuItem { return p.items }
+
+// brokenPlugin panics whenever the host asks it for menu items or settings
+type brokenPlugin struct {
+	*fakePlugin
+}
+
+func (p *brokenPlugin) GetAdminMenuItems() []AdminMenuItem { panic("menu not built") }
+
+func (p *brokenPlugin) GetSettings() []PluginSetting { panic("settings not loaded") }
+
+func (p *brokenPlugin) ApplySettings(map[string]interface{}) error { panic("cannot apply") }
+
+func TestMenuItemsSurviveAPanickingPlugin(t *testing.T) {
+	host := newTestHost(t)
+	items := []AdminMenuItem{{ID: "forms", Title: "Forms", URL: "/admin/forms"}}
+	host.add(&menuPlugin{fakePlugin: newFakePlugin("forms"), items: items})
+	host.add(&brokenPlugin{newFakePlugin("broken")})
+
+	if got := host.manager.GetAdminMenuItems(); !reflect.DeepEqual(got, items) {
+		t.Errorf("GetAdminMenuItems = %+v, want the healthy plugin's items", got)
+	}
+
+	byPlugin := host.manager.GetAdminMenuItemsByPlugin()
+	if !reflect.DeepEqual(byPlugin["forms"], items) || byPlugin["broken"] != nil {
+		t.Errorf("GetAdminMenuItemsByPlugin = %+v, want items for forms and none for broken", byPlugin)
+	}
+}
+
+func TestSettingsSurviveAPanickingPlugin(t *testing.T) {
+	host := newTestHost(t)
+	host.add(&brokenPlugin{newFakePlugin("broken")})
+
+	settings, err := host.manager.GetPluginSettings("broken")
+	if err != nil || len(settings) != 0 {
+		t.Errorf("GetPluginSettings = %+v, %v; want no settings and no error", settings, err)
+	}
+
+	err = SafeApplySettings("broken", &brokenPlugin{newFakePlugin("broken")}, nil)
+	if err == nil || err.Error() != "plugin broken panicked in ApplySettings: cannot apply" {
+		t.Errorf("SafeApplySettings = %v, want the panic as an error", err)
+	}
+
+	// Errors returned normally are passed through
+	want := errors.New("bad greeting")
+	applier := &applierPlugin{fakePlugin: newFakePlugin("forms"), err: want}
+	if err := SafeApplySettings("forms", applier, nil); !errors.Is(err, want) {
+		t.Errorf("SafeApplySettings = %v, want %v", err, want)
+	}
+}
@@ -0,0 +1,231 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// DefaultSettingsGroup is the group assigned to settings that do not declare one
+const DefaultSettingsGroup = "General"
+
+// SortSettings returns a copy of settings with every setting assigned a group,
+// ordered by group (in order of first appearance) and then by Order within it
+func SortSettings(settings []PluginSetting) []PluginSetting {
+	sorted := make([]PluginSetting, len(settings))
+	copy(sorted, settings)
+
+	rank := make(map[string]int)
+	for i := range sorted {
+		if sorted[i].Group == "" {
+			sorted[i].Group = DefaultSettingsGroup
+		}
+		if _, seen := rank[sorted[i].Group]; !seen {
+			rank[sorted[i].Group] = len(rank)
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if rank[sorted[i].Group] != rank[sorted[j].Group] {
+			return rank[sorted[i].Group] < rank[sorted[j].Group]
+		}
+		return sorted[i].Order < sorted[j].Order
+	})
+
+	return sorted
+}
+
+// SettingGroups returns the distinct groups of sorted settings in display order
+func SettingGroups(settings []PluginSetting) []string {
+	groups := []string{}
+	seen := make(map[string]bool)
+	for _, setting := range settings {
+		group := setting.Group
+		if group == "" {
+			group = DefaultSettingsGroup
+		}
+		if !seen[group] {
+			seen[group] = true
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// SettingSchema describes a setting for form rendering, without its value
+type SettingSchema struct {
+	Key         string   `json:"key"`
+	Label       string   `json:"label"`
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Options     []string `json:"options,omitempty"`
+	Required    bool     `json:"required"`
+	Group       string   `json:"group"`
+	Order       int      `json:"order"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	Placeholder string   `json:"placeholder,omitempty"`
+}
+
+// BuildSettingsSchema splits sorted settings into their schema and their current values
+func BuildSettingsSchema(settings []PluginSetting) ([]SettingSchema, map[string]interface{}) {
+	sorted := SortSettings(settings)
+	schema := make([]SettingSchema, len(sorted))
+	values := make(map[string]interface{}, len(sorted))
+
+	for i, setting := range sorted {
+		schema[i] = SettingSchema{
+			Key:         setting.Key,
+			Label:       setting.Label,
+			Type:        setting.Type,
+			Description: setting.Description,
+			Options:     setting.Options,
+			Required:    setting.Required,
+			Group:       setting.Group,
+			Order:       setting.Order,
+			Min:         setting.Min,
+			Max:         setting.Max,
+			Pattern:     setting.Pattern,
+			Placeholder: setting.Placeholder,
+		}
+		values[setting.Key] = setting.Value
+	}
+
+	return schema, values
+}
+
+// ValidateSettingValue checks a new value against the setting's type and constraints
+func ValidateSettingValue(setting PluginSetting, value interface{}) error {
+	if value == nil || value == "" {
+		if setting.Required {
+			return fmt.Errorf("%s is required", setting.Key)
+		}
+		return nil
+	}
+
+	switch setting.Type {
+	case "number":
+		n, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("%s must be a number", setting.Key)
+		}
+		if setting.Min != nil && n < *setting.Min {
+			return fmt.Errorf("%s must be at least %v", setting.Key, *setting.Min)
+		}
+		if setting.Max != nil && n > *setting.Max {
+			return fmt.Errorf("%s must be at most %v", setting.Key, *setting.Max)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be true or false", setting.Key)
+		}
+
+	case "select":
+		s, ok := value.(string)
+		if !ok || !containsString(setting.Options, s) {
+			return fmt.Errorf("%s must be one of %v", setting.Key, setting.Options)
+		}
+
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be text", setting.Key)
+		}
+		length := float64(utf8.RuneCountInString(s))
+		if setting.Min != nil && length < *setting.Min {
+			return fmt.Errorf("%s must be at least %v characters", setting.Key, *setting.Min)
+		}
+		if setting.Max != nil && length > *setting.Max {
+			return fmt.Errorf("%s must be at most %v characters", setting.Key, *setting.Max)
+		}
+		if setting.Pattern != "" {
+			re, err := regexp.Compile(setting.Pattern)
+			if err != nil {
+				return fmt.Errorf("%s has an invalid pattern: %w", setting.Key, err)
+			}
+			if !re.MatchString(s) {
+				return fmt.Errorf("%s does not match the required format", setting.Key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// settingTypes are the setting types the admin UI can render
+var settingTypes = map[string]bool{
+	"text":    true,
+	"number":  true,
+	"boolean": true,
+	"select":  true,
+}
+
+// CheckSettings reports problems with the settings a plugin declares: empty or
+// repeated keys, unknown types, selects without options and default values that
+// ValidateSettingValue would reject. A required setting may leave its default
+// empty for the admin to fill in.
+func CheckSettings(settings []PluginSetting) []error {
+	var problems []error
+	seen := make(map[string]bool, len(settings))
+	for _, setting := range settings {
+		if setting.Key == "" {
+			problems = append(problems, fmt.Errorf("setting %q has an empty key", setting.Label))
+			continue
+		}
+		if seen[setting.Key] {
+			problems = append(problems, fmt.Errorf("setting %s is declared more than once", setting.Key))
+		}
+		seen[setting.Key] = true
+
+		if !settingTypes[setting.Type] {
+			problems = append(problems, fmt.Errorf("setting %s has unknown type %q", setting.Key, setting.Type))
+			continue
+		}
+		if setting.Type == "select" && len(setting.Options) == 0 {
+			problems = append(problems, fmt.Errorf("select setting %s has no options", setting.Key))
+		}
+
+		if setting.Value == nil || setting.Value == "" {
+			continue
+		}
+		// Values are validated as they arrive in JSON, so compare the default in that form
+		data, err := json.Marshal(setting.Value)
+		var value interface{}
+		if err == nil {
+			err = json.Unmarshal(data, &value)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("setting %s has a default that cannot be encoded: %w", setting.Key, err))
+			continue
+		}
+		if err := ValidateSettingValue(setting, value); err != nil {
+			problems = append(problems, fmt.Errorf("setting %s has an invalid default: %w", setting.Key, err))
+		}
+	}
+	return problems
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SettingsEnabled reports whether the "enabled" setting is switched on. Plugins
+// without an enabled setting are always enabled.
+func SettingsEnabled(settings []PluginSetting) bool {
+	for _, setting := range settings {
+		if setting.Key == "enabled" {
+			enabled, ok := setting.Value.(bool)
+			return !ok || enabled
+		}
+	}
+	return true
+}
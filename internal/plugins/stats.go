@@ -0,0 +1,145 @@
+package plugins
+
+import (
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the request latency histogram kept per
+// plugin; slower requests fall in a final overflow bucket
+var latencyBuckets = [...]time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// routeStats accumulates the requests served by one plugin's routes. Every field
+// is updated atomically so recording a request never takes a lock.
+type routeStats struct {
+	requests  atomic.Int64
+	errors    atomic.Int64 // Responses with a 5xx status
+	totalTime atomic.Int64 // Nanoseconds spent in the plugin's handlers
+	slowest   atomic.Int64 // Nanoseconds of the slowest request, which bounds the overflow bucket
+	buckets   [len(latencyBuckets) + 1]atomic.Int64
+}
+
+func (s *routeStats) observe(elapsed time.Duration, status int) {
+	s.requests.Add(1)
+	s.totalTime.Add(int64(elapsed))
+	if status >= http.StatusInternalServerError {
+		s.errors.Add(1)
+	}
+
+	bucket := len(latencyBuckets)
+	for i, bound := range latencyBuckets {
+		if elapsed <= bound {
+			bucket = i
+			break
+		}
+	}
+	s.buckets[bucket].Add(1)
+
+	for slowest := s.slowest.Load(); int64(elapsed) > slowest; slowest = s.slowest.Load() {
+		if s.slowest.CompareAndSwap(slowest, int64(elapsed)) {
+			break
+		}
+	}
+}
+
+// percentile returns the upper bound of the histogram bucket holding the given
+// quantile, so it overstates the true value by at most one bucket width. The
+// overflow bucket has no bound, so the slowest request seen stands in for it.
+func (s *routeStats) percentile(q float64) time.Duration {
+	var counts [len(s.buckets)]int64
+	var total int64
+	for i := range s.buckets {
+		counts[i] = s.buckets[i].Load()
+		total += counts[i]
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := int64(q*float64(total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, count := range counts[:len(latencyBuckets)] {
+		seen += count
+		if seen >= rank {
+			return latencyBuckets[i]
+		}
+	}
+	return time.Duration(s.slowest.Load())
+}
+
+// PluginStats summarizes the requests served by a plugin's routes since startup
+type PluginStats struct {
+	Plugin      string  `json:"plugin"`
+	Requests    int64   `json:"requests"`
+	Errors      int64   `json:"errors"`
+	TotalTimeMs float64 `json:"total_time_ms"`
+	AvgMs       float64 `json:"avg_ms"`
+	P95Ms       float64 `json:"p95_ms"`
+}
+
+// statsFor returns the stats entry of a plugin, creating it if needed. The
+// caller must hold m.mu.
+func (m *Manager) statsFor(name string) *routeStats {
+	stats, exists := m.stats[name]
+	if !exists {
+		stats = &routeStats{}
+		m.stats[name] = stats
+	}
+	return stats
+}
+
+// PluginStats returns request accounting for every plugin whose routes have been
+// registered, busiest first by total handler time
+func (m *Manager) PluginStats() []PluginStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]PluginStats, 0, len(m.stats))
+	for name, stats := range m.stats {
+		requests := stats.requests.Load()
+		total := time.Duration(stats.totalTime.Load())
+
+		entry := PluginStats{
+			Plugin:      name,
+			Requests:    requests,
+			Errors:      stats.errors.Load(),
+			TotalTimeMs: milliseconds(total),
+			P95Ms:       milliseconds(stats.percentile(0.95)),
+		}
+		if requests > 0 {
+			entry.AvgMs = milliseconds(total / time.Duration(requests))
+		}
+		result = append(result, entry)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalTimeMs != result[j].TotalTimeMs {
+			return result[i].TotalTimeMs > result[j].TotalTimeMs
+		}
+		return result[i].Plugin < result[j].Plugin
+	})
+	return result
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,66 @@
+package plugins
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPluginStatsAccounting(t *testing.T) {
+	host := newTestHost(t)
+	plugin := newFakePlugin("forms")
+	plugin.routes = func(r *gin.RouterGroup) {
+		r.GET("/ok", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+		r.GET("/fail", func(c *gin.Context) { c.String(http.StatusInternalServerError, "fail") })
+	}
+	host.add(plugin)
+
+	for i := 0; i < 10; i++ {
+		host.do(http.MethodGet, "/api/v1/plugins/forms/ok")
+	}
+	host.do(http.MethodGet, "/api/v1/plugins/forms/fail")
+
+	stats := host.manager.PluginStats()
+	if len(stats) != 1 || stats[0].Plugin != "forms" {
+		t.Fatalf("stats = %+v, want one entry for forms", stats)
+	}
+	if stats[0].Requests != 11 || stats[0].Errors != 1 {
+		t.Errorf("%d requests and %d errors, want 11 and 1", stats[0].Requests, stats[0].Errors)
+	}
+	if stats[0].TotalTimeMs <= 0 || stats[0].AvgMs > stats[0].TotalTimeMs || stats[0].P95Ms <= 0 {
+		t.Errorf("timings = %+v, want positive totals and an average within them", stats[0])
+	}
+
+	// Uninstalling drops the plugin's accounting
+	if err := host.manager.UninstallPlugin("forms"); err != nil {
+		t.Fatal(err)
+	}
+	if stats := host.manager.PluginStats(); len(stats) != 0 {
+		t.Errorf("stats after uninstall = %+v, want none", stats)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var stats routeStats
+	if got := stats.percentile(0.95); got != 0 {
+		t.Errorf("p95 without requests = %s, want 0", got)
+	}
+
+	for i := 0; i < 19; i++ {
+		stats.observe(3*time.Millisecond, http.StatusOK)
+	}
+	stats.observe(40*time.Millisecond, http.StatusOK)
+	if got := stats.percentile(0.95); got != 5*time.Millisecond {
+		t.Errorf("p95 = %s, want the 5ms bucket bound", got)
+	}
+
+	// Requests past the last bound report the slowest one, not the bound
+	var slow routeStats
+	slow.observe(30*time.Second, http.StatusOK)
+	slow.observe(12*time.Second, http.StatusOK)
+	if got := slow.percentile(0.95); got != 30*time.Second {
+		t.Errorf("p95 in the overflow bucket = %s, want the slowest request", got)
+	}
+}
@@ -0,0 +1,5 @@
+//go:build cmsplugin && !premium
+
+package main
+
+const mode = "host"
@@ -0,0 +1,7 @@
+package main
+
+// Mode reports which build of the plugin this is
+var Mode = mode
+
+// NewPlugin is looked up by the host; the fixture only needs the symbol
+func NewPlugin() interface{} { return nil }
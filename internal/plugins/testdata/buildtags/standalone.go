@@ -0,0 +1,11 @@
+//go:build !cmsplugin
+
+package main
+
+import "fmt"
+
+const mode = "standalone"
+
+func main() {
+	fmt.Println(Mode)
+}
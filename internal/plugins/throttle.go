@@ -0,0 +1,125 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultReloadCooldown is the default time that must pass after a reload before
+// the same reload may run again
+const DefaultReloadCooldown = 30 * time.Second
+
+// hotReloadKey is the key of HotReload. A hot reload reloads every plugin, so it
+// shares its cooldown with the reloads of single plugins.
+const hotReloadKey = "*"
+
+// ReloadThrottledError is returned when a reload is requested again before its
+// cooldown has passed
+type ReloadThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ReloadThrottledError) Error() string {
+	return fmt.Sprintf("reload was run too recently; retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// reloadThrottle coalesces concurrent reloads with the same key into one run and
+// refuses new runs until the cooldown since the last one has passed
+type reloadThrottle struct {
+	mu       sync.Mutex
+	cooldown time.Duration
+	inflight map[string]*reloadCall
+	finished map[string]time.Time
+}
+
+// reloadCall is a reload in progress; waiters read the result once done is closed
+type reloadCall struct {
+	recompile  bool // Whether the run recompiles rather than reusing cached builds
+	done       chan struct{}
+	recompiled bool
+	err        error
+}
+
+func newReloadThrottle(cooldown time.Duration) *reloadThrottle {
+	return &reloadThrottle{
+		cooldown: cooldown,
+		inflight: make(map[string]*reloadCall),
+		finished: make(map[string]time.Time),
+	}
+}
+
+// SetReloadCooldown sets how long reloads of the same plugin must be apart. A hot
+// reload counts as a reload of every plugin. Zero or less disables the cooldown;
+// concurrent requests are still coalesced.
+func (m *Manager) SetReloadCooldown(cooldown time.Duration) {
+	m.reloads.mu.Lock()
+	defer m.reloads.mu.Unlock()
+	m.reloads.cooldown = cooldown
+}
+
+// do runs reload unless one with the same key is already running, in which case
+// it waits for that one and shares its result. A caller that needs a recompile
+// does not settle for a running reload that reuses cached builds: it waits for
+// it to finish and then runs its own. Otherwise a ReloadThrottledError is returned
+// instead of running while the cooldown of an earlier successful run lasts; see wait.
+func (t *reloadThrottle) do(key string, recompile bool, reload func() (bool, error)) (bool, error) {
+	t.mu.Lock()
+	superseded := false
+	for {
+		call, running := t.inflight[key]
+		if !running {
+			break
+		}
+		t.mu.Unlock()
+		<-call.done
+		if call.recompile || !recompile {
+			return call.recompiled, call.err
+		}
+		t.mu.Lock()
+		superseded = true
+	}
+	if wait := t.wait(key); wait > 0 && !superseded {
+		t.mu.Unlock()
+		return false, &ReloadThrottledError{RetryAfter: wait}
+	}
+	call := &reloadCall{recompile: recompile, done: make(chan struct{})}
+	t.inflight[key] = call
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		delete(t.inflight, key)
+		// Failed reloads can be retried straight away
+		if call.err == nil {
+			t.finished[key] = time.Now()
+		}
+		t.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.recompiled, call.err = reload()
+	return call.recompiled, call.err
+}
+
+// wait returns how much of the cooldown is left before key may be reloaded. A
+// plugin waits for its own last reload and the last hot reload; a hot reload
+// waits for the last reload of any kind. The caller must hold t.mu.
+func (t *reloadThrottle) wait(key string) time.Duration {
+	if t.cooldown <= 0 {
+		return 0
+	}
+
+	var last time.Time
+	for finishedKey, finished := range t.finished {
+		if finishedKey == key || key == hotReloadKey || finishedKey == hotReloadKey {
+			if finished.After(last) {
+				last = finished
+			}
+		}
+	}
+	if last.IsZero() {
+		return 0
+	}
+	return t.cooldown - time.Since(last)
+}
@@ -0,0 +1,172 @@
+package plugins
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockedReload is a reload that counts its runs and blocks until released
+type blockedReload struct {
+	runs    atomic.Int32
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newBlockedReload() *blockedReload {
+	return &blockedReload{entered: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (b *blockedReload) run() (bool, error) {
+	b.runs.Add(1)
+	b.entered <- struct{}{}
+	<-b.release
+	return true, nil
+}
+
+// settle gives callers started alongside the running reload of key time to reach
+// it. They wait on it without holding the throttle's lock, so cannot be observed.
+func settle(t *reloadThrottle, key string) {
+	t.mu.Lock()
+	_, running := t.inflight[key]
+	t.mu.Unlock()
+	if running {
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestReloadThrottleCoalesces(t *testing.T) {
+	throttle := newReloadThrottle(time.Minute)
+	reload := newBlockedReload()
+
+	var wg sync.WaitGroup
+	results := make(chan bool, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recompiled, err := throttle.do("forms", false, reload.run)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- recompiled
+		}()
+	}
+	<-reload.entered
+	settle(throttle, "forms")
+	close(reload.release)
+	wg.Wait()
+	close(results)
+
+	if n := reload.runs.Load(); n != 1 {
+		t.Errorf("%d runs for concurrent reloads, want 1", n)
+	}
+	for recompiled := range results {
+		if !recompiled {
+			t.Error("a coalesced caller did not get the shared result")
+		}
+	}
+}
+
+func TestReloadThrottleRecompileDoesNotShareCachedRun(t *testing.T) {
+	throttle := newReloadThrottle(time.Minute)
+	reload := newBlockedReload()
+
+	cached := make(chan error)
+	go func() {
+		_, err := throttle.do("forms", false, reload.run)
+		cached <- err
+	}()
+	<-reload.entered
+
+	// A recompile asked for during a cached run gets a run of its own afterwards
+	recompiled := make(chan error)
+	go func() {
+		_, err := throttle.do("forms", true, reload.run)
+		recompiled <- err
+	}()
+	settle(throttle, "forms")
+	reload.release <- struct{}{}
+	if err := <-cached; err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-reload.entered:
+	case <-time.After(time.Second):
+		t.Fatal("the recompile settled for the cached reload")
+	}
+
+	// A cached reload asked for during a recompile shares it
+	shared := make(chan error)
+	go func() {
+		_, err := throttle.do("forms", false, reload.run)
+		shared <- err
+	}()
+	settle(throttle, "forms")
+	close(reload.release)
+	if err := <-recompiled; err != nil {
+		t.Errorf("recompile: %v", err)
+	}
+	if err := <-shared; err != nil {
+		t.Errorf("cached reload during the recompile: %v", err)
+	}
+
+	if n := reload.runs.Load(); n != 2 {
+		t.Errorf("%d runs, want the cached one and the recompile", n)
+	}
+}
+
+func TestReloadThrottleCooldown(t *testing.T) {
+	throttle := newReloadThrottle(time.Minute)
+	succeed := func() (bool, error) { return true, nil }
+
+	if _, err := throttle.do("forms", true, succeed); err != nil {
+		t.Fatal(err)
+	}
+	_, err := throttle.do("forms", true, succeed)
+	var throttled *ReloadThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("second reload error = %v, want a ReloadThrottledError", err)
+	}
+	if throttled.RetryAfter <= 0 || throttled.RetryAfter > time.Minute {
+		t.Errorf("retry after %s, want within the minute", throttled.RetryAfter)
+	}
+
+	// Other plugins have cooldowns of their own
+	if _, err := throttle.do("mailer", true, succeed); err != nil {
+		t.Errorf("reload of another plugin: %v", err)
+	}
+
+	// Failed reloads can be retried straight away
+	fail := func() (bool, error) { return false, errors.New("compile failed") }
+	throttle.do("search", true, fail)
+	if _, err := throttle.do("search", true, succeed); err != nil {
+		t.Errorf("retry after a failed reload: %v", err)
+	}
+}
+
+func TestReloadThrottleSharesCooldownWithHotReload(t *testing.T) {
+	succeed := func() (bool, error) { return false, nil }
+	var throttled *ReloadThrottledError
+
+	throttle := newReloadThrottle(time.Minute)
+	throttle.do("forms", true, succeed)
+	if _, err := throttle.do(hotReloadKey, false, succeed); !errors.As(err, &throttled) {
+		t.Errorf("hot reload right after a plugin reload: %v, want throttled", err)
+	}
+
+	throttle = newReloadThrottle(time.Minute)
+	throttle.do(hotReloadKey, false, succeed)
+	if _, err := throttle.do("forms", true, succeed); !errors.As(err, &throttled) {
+		t.Errorf("plugin reload right after a hot reload: %v, want throttled", err)
+	}
+
+	throttle = newReloadThrottle(0)
+	throttle.do(hotReloadKey, false, succeed)
+	if _, err := throttle.do("forms", true, succeed); err != nil {
+		t.Errorf("reload without a cooldown: %v", err)
+	}
+}
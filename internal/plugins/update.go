@@ -0,0 +1,277 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-cms/internal/semver"
+)
+
+// Hidden directories under the plugin directory used while updating a plugin.
+// LoadAllPlugins skips them.
+const (
+	stagingDirName = ".staging"
+	backupDirName  = ".backup"
+)
+
+// stagedUpdate is a new version of an installed plugin that has been extracted,
+// compiled and opened next to the running version, which is untouched until the
+// swap
+type stagedUpdate struct {
+	pluginName string
+	dir        string // Extracted new version, outside the scanned plugin directory
+	backup     string // Where the running version's files wait during the swap
+	build      string // New build
+	instance   Plugin // Instance of the new build, not yet initialized
+	result     *InstallResult
+}
+
+// stageUpdate extracts, checks, compiles and opens the plugin in zipPath as the
+// next version of pluginName without touching the installed version. When
+// installed is set, a plugin older than that version is refused.
+func (l *Loader) stageUpdate(zipPath, pluginName, installed string) (_ *stagedUpdate, err error) {
+	extractor := NewExtractor(filepath.Join(l.pluginDir, stagingDirName))
+	dir, err := extractor.ExtractZipPlugin(zipPath, pluginName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract plugin: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	sourceDir, result, err := l.prepareExtracted(dir, installed)
+	if err != nil {
+		return nil, err
+	}
+
+	build, err := l.compile(sourceDir, pluginName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile plugin: %w", err)
+	}
+	instance, err := l.open(build)
+	if err != nil {
+		os.Remove(build)
+		return nil, fmt.Errorf("failed to load compiled plugin: %w", err)
+	}
+	result.Recompiled = true
+
+	return &stagedUpdate{
+		pluginName: pluginName,
+		dir:        dir,
+		backup:     filepath.Join(l.pluginDir, backupDirName, pluginName),
+		build:      build,
+		instance:   instance,
+		result:     result,
+	}, nil
+}
+
+// swapIn moves the running version's files aside and the staged version into
+// their place
+func (l *Loader) swapIn(staged *stagedUpdate) error {
+	live := filepath.Join(l.pluginDir, staged.pluginName)
+	if err := os.MkdirAll(filepath.Dir(staged.backup), 0755); err != nil {
+		return err
+	}
+	os.RemoveAll(staged.backup)
+	if err := os.Rename(live, staged.backup); err != nil {
+		return fmt.Errorf("failed to move the running version aside: %w", err)
+	}
+	if err := os.Rename(staged.dir, live); err != nil {
+		os.Rename(staged.backup, live)
+		return fmt.Errorf("failed to move the new version into place: %w", err)
+	}
+	return nil
+}
+
+// swapOut puts the previous version's files back after a failed swap and
+// discards the new version
+func (l *Loader) swapOut(staged *stagedUpdate) error {
+	live := filepath.Join(l.pluginDir, staged.pluginName)
+	if _, err := os.Stat(staged.backup); err == nil {
+		if err := os.RemoveAll(live); err != nil {
+			return err
+		}
+		if err := os.Rename(staged.backup, live); err != nil {
+			return err
+		}
+	}
+	l.discard(staged)
+	return nil
+}
+
+// finish removes the previous version's files once the new version is running
+func (l *Loader) finish(staged *stagedUpdate) {
+	os.RemoveAll(staged.backup)
+	l.recordBuild(staged.pluginName, staged.build)
+}
+
+// discard removes a staged version that will not be used
+func (l *Loader) discard(staged *stagedUpdate) {
+	os.RemoveAll(staged.dir)
+	os.Remove(staged.build)
+}
+
+// DowngradeError is returned when an upload would replace an installed plugin
+// with an older version
+type DowngradeError struct {
+	Plugin    string
+	Installed string
+	Uploaded  string
+}
+
+func (e *DowngradeError) Error() string {
+	return fmt.Sprintf("uploaded version %s of plugin %s is older than the installed version %s", e.Uploaded, e.Plugin, e.Installed)
+}
+
+// checkDowngrade refuses a manifest whose version is older than installed.
+// Versions that are not semantic versions are never treated as downgrades.
+func checkDowngrade(manifest *PluginManifest, installed string) error {
+	if manifest == nil || installed == "" {
+		return nil
+	}
+	cmp, err := semver.Compare(manifest.Version, installed)
+	if err != nil {
+		log.Printf("Cannot compare versions of plugin %s: %v", manifest.Name, err)
+		return nil
+	}
+	if cmp < 0 {
+		return &DowngradeError{Plugin: manifest.Name, Installed: installed, Uploaded: manifest.Version}
+	}
+	return nil
+}
+
+// UpdatePluginFromZip replaces an installed plugin with the one in a zip file.
+// The new version is extracted, compiled and opened while the running version
+// keeps serving; a version that fails any of those steps is rejected and the
+// running one is left alone. Only then is the running version shut down and the
+// new one swapped in and initialized. If that fails, the previous version's
+// files and build are reinstated and it is started again. A plugin whose files
+// are on disk but which is not loaded goes through the same steps, and its
+// files are put back on failure. A plugin that is not installed at all is simply
+// installed. The result's Updated field reports which of the two happened.
+//
+// Unless allowDowngrade is set, a plugin older than the installed version is
+// refused with a DowngradeError before it is compiled. The installed version is
+// read from the plugin.json of the plugin's files on disk.
+func (m *Manager) UpdatePluginFromZip(zipPath, pluginName string, allowDowngrade bool) (*InstallResult, error) {
+	if err := m.beginOperation(); err != nil {
+		return nil, err
+	}
+	defer m.endOperation()
+
+	m.mu.RLock()
+	previous := m.loadedName(pluginName)
+	m.mu.RUnlock()
+
+	installed := ""
+	if manifest, err := readManifest(m.loader.sourceDir(pluginName)); err == nil && manifest != nil {
+		installed = manifest.Version
+	}
+
+	if previous == "" && installed == "" {
+		m.mu.Lock()
+		result, info, err := m.installPluginFromZip(zipPath, pluginName)
+		m.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		m.emit(EventPluginInstalled, info.Name, info.Version)
+		return result, nil
+	}
+
+	validation, err := m.loader.ValidateZipPlugin(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if !validation.IsValid {
+		return nil, fmt.Errorf("invalid plugin: %v", validation.Errors)
+	}
+
+	floor := installed
+	if allowDowngrade {
+		floor = ""
+	}
+	staged, err := m.loader.stageUpdate(zipPath, pluginName, floor)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	info, err := m.replacePlugin(previous, staged)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(zipPath)
+
+	result := staged.result
+	result.Warnings = append(append([]string(nil), validation.Warnings...), result.Warnings...)
+	capabilities, capabilityWarnings := m.loader.Capabilities(pluginName)
+	result.Capabilities = capabilities
+	result.Warnings = append(result.Warnings, capabilityWarnings...)
+	result.Updated = true
+	m.emit(EventPluginUpdated, info.Name, info.Version)
+	return result, nil
+}
+
+// replacePlugin shuts down the running version of a plugin and starts the staged
+// one in its place, reinstating the running version if the new one fails to
+// start. previous is empty when the installed version is not loaded; its files
+// are still put back on failure. The caller must hold m.mu.
+func (m *Manager) replacePlugin(previous string, staged *stagedUpdate) (*PluginInfo, error) {
+	if previous != "" {
+		if _, err := m.unloadPlugin(previous); err != nil {
+			m.loader.discard(staged)
+			return nil, fmt.Errorf("failed to unload plugin: %w", err)
+		}
+	}
+
+	if err := m.loader.swapIn(staged); err != nil {
+		m.loader.discard(staged)
+		if previous == "" {
+			return nil, fmt.Errorf("update failed: %w", err)
+		}
+		return nil, m.reinstate(staged.pluginName, err)
+	}
+
+	info, err := m.startPlugin(staged.pluginName, staged.instance, time.Now())
+	if err != nil {
+		if swapErr := m.loader.swapOut(staged); swapErr != nil {
+			return nil, fmt.Errorf("%w; restoring the previous version's files also failed: %v", err, swapErr)
+		}
+		if previous == "" {
+			return nil, fmt.Errorf("update failed; the previous version's files were restored: %w", err)
+		}
+		return nil, m.reinstate(staged.pluginName, err)
+	}
+
+	m.loader.finish(staged)
+	return info, nil
+}
+
+// reinstate starts the previous version of a plugin again from its build after a
+// failed update and returns updateErr, noting whether the restart worked. The
+// caller must hold m.mu.
+func (m *Manager) reinstate(pluginName string, updateErr error) error {
+	build := m.loader.currentBuild(pluginName)
+	if build == "" {
+		return fmt.Errorf("update failed and the previous version has no build to restore: %w", updateErr)
+	}
+
+	instance, err := m.loader.open(build)
+	if err == nil {
+		_, err = m.startPlugin(pluginName, instance, time.Now())
+	}
+	if err != nil {
+		log.Printf("Failed to restore plugin %s after a failed update: %v", pluginName, err)
+		return fmt.Errorf("update failed and the previous version could not be restarted (%v): %w", err, updateErr)
+	}
+
+	log.Printf("Update of plugin %s failed; the previous version was restored", pluginName)
+	return fmt.Errorf("update failed; the previous version was restored: %w", updateErr)
+}
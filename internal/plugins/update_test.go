@@ -0,0 +1,196 @@
+package plugins
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func manifestVersion(t *testing.T, m *Manager, name string) string {
+	t.Helper()
+	manifest, err := readManifest(filepath.Join(m.loader.pluginDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manifest.Version
+}
+
+func runningVersion(t *testing.T, m *Manager, name string) string {
+	t.Helper()
+	plugin, ok := m.GetPlugin(name)
+	if !ok {
+		t.Fatalf("plugin %s is not loaded", name)
+	}
+	return plugin.GetInfo().Version
+}
+
+func assertNoUpdateLeftovers(t *testing.T, m *Manager, name string) {
+	t.Helper()
+	for _, dir := range []string{stagingDirName, backupDirName} {
+		if _, err := os.Stat(filepath.Join(m.loader.pluginDir, dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s/%s was left behind", dir, name)
+		}
+	}
+}
+
+func TestUpdatePluginFromZipSwapsVersions(t *testing.T) {
+	host := newTestHost(t)
+	tc := installFakeToolchain(host.manager)
+	old := tc.install(t, host.manager, "seo", pluginFiles(t, map[string]interface{}{"name": "seo", "version": "1.0.0"}))
+	oldBuild := host.manager.loader.currentBuild("seo")
+
+	zipPath := writeZip(t, pluginFiles(t, map[string]interface{}{"name": "seo", "version": "2.0.0"}))
+	result, err := host.manager.UpdatePluginFromZip(zipPath, "seo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Updated {
+		t.Error("result is not marked as an update")
+	}
+
+	if got := runningVersion(t, host.manager, "seo"); got != "2.0.0" {
+		t.Errorf("running version %s, want 2.0.0", got)
+	}
+	if got := manifestVersion(t, host.manager, "seo"); got != "2.0.0" {
+		t.Errorf("installed manifest version %s, want 2.0.0", got)
+	}
+	if old.shutdownCount() != 1 {
+		t.Errorf("old version shut down %d times, want 1", old.shutdownCount())
+	}
+
+	newBuild := host.manager.loader.currentBuild("seo")
+	if newBuild == oldBuild {
+		t.Error("new version reuses the old build path, which plugin.Open would serve from its cache")
+	}
+	if _, err := os.Stat(oldBuild); !os.IsNotExist(err) {
+		t.Error("old build was not removed after the update")
+	}
+	assertNoUpdateLeftovers(t, host.manager, "seo")
+}
+
+func TestUpdatePluginFromZipRestoresPreviousVersion(t *testing.T) {
+	host := newTestHost(t)
+	tc := installFakeToolchain(host.manager)
+	tc.install(t, host.manager, "seo", pluginFiles(t, map[string]interface{}{"name": "seo", "version": "1.0.0"}))
+	oldBuild := host.manager.loader.currentBuild("seo")
+	tc.failInit["2.0.0"] = true
+
+	zipPath := writeZip(t, pluginFiles(t, map[string]interface{}{"name": "seo", "version": "2.0.0"}))
+	if _, err := host.manager.UpdatePluginFromZip(zipPath, "seo", false); err == nil {
+		t.Fatal("update with a failing Initialize succeeded")
+	}
+
+	if got := runningVersion(t, host.manager, "seo"); got != "1.0.0" {
+		t.Errorf("running version %s, want the restored 1.0.0", got)
+	}
+	if got := manifestVersion(t, host.manager, "seo"); got != "1.0.0" {
+		t.Errorf("installed manifest version %s, want the restored 1.0.0", got)
+	}
+	if got := host.manager.loader.currentBuild("seo"); got != oldBuild {
+		t.Errorf("current build %s, want the previous %s", got, oldBuild)
+	}
+	if builds := host.manager.loader.compiler.pluginBuilds("seo"); len(builds) != 1 {
+		t.Errorf("%d builds left, want only the previous one", len(builds))
+	}
+	assertNoUpdateLeftovers(t, host.manager, "seo")
+}
+
+func TestUpdatePluginFromZipRejectsBrokenUploadWithoutUnloading(t *testing.T) {
+	host := newTestHost(t)
+	tc := installFakeToolchain(host.manager)
+	old := tc.install(t, host.manager, "seo", pluginFiles(t, map[string]interface{}{"name": "seo", "version": "1.0.0"}))
+
+	// No Go sources, so staging fails before anything is swapped
+	zipPath := writeZip(t, map[string]string{"README.md": "nothing here"})
+	if _, err := host.manager.UpdatePluginFromZip(zipPath, "seo", false); err == nil {
+		t.Fatal("update without a plugin succeeded")
+	}
+
+	if old.shutdownCount() != 0 {
+		t.Error("running version was shut down for an upload that never staged")
+	}
+	if got := runningVersion(t, host.manager, "seo"); got != "1.0.0" {
+		t.Errorf("running version %s, want 1.0.0", got)
+	}
+	assertNoUpdateLeftovers(t, host.manager, "seo")
+}
+
+func TestUpdatePluginFromZipVersions(t *testing.T) {
+	tests := []struct {
+		name           string
+		uploaded       string
+		allowDowngrade bool
+		refused        bool
+	}{
+		{"upgrade", "1.1.0", false, false},
+		{"same version", "1.0.0", false, false},
+		{"downgrade", "0.9.0", false, true},
+		{"allowed downgrade", "0.9.0", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := newTestHost(t)
+			tc := installFakeToolchain(host.manager)
+			old := tc.install(t, host.manager, "seo", pluginFiles(t, map[string]interface{}{"name": "seo", "version": "1.0.0"}))
+
+			zipPath := writeZip(t, pluginFiles(t, map[string]interface{}{"name": "seo", "version": tt.uploaded}))
+			_, err := host.manager.UpdatePluginFromZip(zipPath, "seo", tt.allowDowngrade)
+
+			want := tt.uploaded
+			if tt.refused {
+				want = "1.0.0"
+				var downgradeErr *DowngradeError
+				if !errors.As(err, &downgradeErr) || downgradeErr.Installed != "1.0.0" || downgradeErr.Uploaded != tt.uploaded {
+					t.Fatalf("err = %v, want a DowngradeError from 1.0.0 to %s", err, tt.uploaded)
+				}
+				if old.shutdownCount() != 0 {
+					t.Error("running version was shut down for a refused downgrade")
+				}
+				if builds := host.manager.loader.compiler.pluginBuilds("seo"); len(builds) != 1 {
+					t.Errorf("%d builds, want the refused version left uncompiled", len(builds))
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := runningVersion(t, host.manager, "seo"); got != want {
+				t.Errorf("running version %s, want %s", got, want)
+			}
+			assertNoUpdateLeftovers(t, host.manager, "seo")
+		})
+	}
+}
+
+func TestUpdatePluginFromZipRefusesDowngradeOfUnloadedPlugin(t *testing.T) {
+	host := newTestHost(t)
+	tc := installFakeToolchain(host.manager)
+	tc.install(t, host.manager, "seo", pluginFiles(t, map[string]interface{}{"name": "seo", "version": "1.0.0"}))
+	if err := host.manager.UnloadPlugin("seo"); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath := writeZip(t, pluginFiles(t, map[string]interface{}{"name": "seo", "version": "0.9.0"}))
+	var downgradeErr *DowngradeError
+	if _, err := host.manager.UpdatePluginFromZip(zipPath, "seo", false); !errors.As(err, &downgradeErr) {
+		t.Fatalf("err = %v, want a DowngradeError", err)
+	}
+	if got := manifestVersion(t, host.manager, "seo"); got != "1.0.0" {
+		t.Errorf("installed files are version %q after a refused downgrade, want 1.0.0", got)
+	}
+	assertNoUpdateLeftovers(t, host.manager, "seo")
+
+	// An upgrade of the unloaded plugin replaces its files and starts it
+	zipPath = writeZip(t, pluginFiles(t, map[string]interface{}{"name": "seo", "version": "1.1.0"}))
+	result, err := host.manager.UpdatePluginFromZip(zipPath, "seo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Updated {
+		t.Error("upgrade of an installed but unloaded plugin was reported as a fresh install")
+	}
+	if got := runningVersion(t, host.manager, "seo"); got != "1.1.0" {
+		t.Errorf("running version = %q, want 1.1.0", got)
+	}
+	assertNoUpdateLeftovers(t, host.manager, "seo")
+}
@@ -0,0 +1,151 @@
+// Package registry looks up the latest published versions of themes and plugins
+// so the admin can show which installed ones have updates.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go-cms/internal/semver"
+)
+
+// Kinds of package the registry serves
+const (
+	KindTheme  = "themes"
+	KindPlugin = "plugins"
+)
+
+// Update statuses
+const (
+	StatusAvailable = "available"
+	StatusUpToDate  = "up_to_date"
+	StatusUnknown   = "unknown" // The registry is not configured, unreachable, or the version cannot be compared
+)
+
+// failureTTL is how long a failed lookup is remembered, so an unreachable
+// registry is not retried on every request
+const failureTTL = time.Minute
+
+// requestTimeout bounds a single registry request
+const requestTimeout = 5 * time.Second
+
+// Update describes how an installed package compares to the registry
+type Update struct {
+	Name             string `json:"name"`
+	InstalledVersion string `json:"installed_version"`
+	LatestVersion    string `json:"latest_version,omitempty"`
+	Status           string `json:"status"`
+}
+
+type cacheEntry struct {
+	version string
+	err     error
+	expires time.Time
+}
+
+// Client queries a registry serving GET <base>/<kind>/<name> as
+// {"name": "...", "version": "1.2.3"}. Results are cached for the client's TTL.
+type Client struct {
+	baseURL    string
+	ttl        time.Duration
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewClient creates a registry client. An empty baseURL disables lookups and
+// every check reports StatusUnknown.
+func NewClient(baseURL string, ttl time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		ttl:        ttl,
+		httpClient: &http.Client{Timeout: requestTimeout},
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// LatestVersion returns the newest published version of a package
+func (c *Client) LatestVersion(ctx context.Context, kind, name string) (string, error) {
+	if c == nil || c.baseURL == "" {
+		return "", fmt.Errorf("registry is not configured")
+	}
+
+	key := kind + "/" + name
+	c.mu.Lock()
+	entry, cached := c.cache[key]
+	c.mu.Unlock()
+	if cached && time.Now().Before(entry.expires) {
+		return entry.version, entry.err
+	}
+
+	version, err := c.fetch(ctx, kind, name)
+	entry = cacheEntry{version: version, err: err, expires: time.Now().Add(c.ttl)}
+	if err != nil {
+		entry.expires = time.Now().Add(failureTTL)
+	}
+
+	c.mu.Lock()
+	c.cache[key] = entry
+	c.mu.Unlock()
+
+	return version, err
+}
+
+// Check compares an installed version with the registry. Lookup and comparison
+// failures yield StatusUnknown rather than an error.
+func (c *Client) Check(ctx context.Context, kind, name, installed string) Update {
+	update := Update{Name: name, InstalledVersion: installed, Status: StatusUnknown}
+
+	latest, err := c.LatestVersion(ctx, kind, name)
+	if err != nil {
+		return update
+	}
+	update.LatestVersion = latest
+
+	cmp, err := semver.Compare(latest, installed)
+	if err != nil {
+		return update
+	}
+	update.Status = StatusUpToDate
+	if cmp > 0 {
+		update.Status = StatusAvailable
+	}
+	return update
+}
+
+func (c *Client) fetch(ctx context.Context, kind, name string) (string, error) {
+	endpoint := c.baseURL + "/" + kind + "/" + url.PathEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("registry request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned %s for %s", resp.Status, endpoint)
+	}
+
+	var body struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid registry response: %w", err)
+	}
+	if body.Version == "" {
+		return "", fmt.Errorf("registry response for %s has no version", endpoint)
+	}
+	return body.Version, nil
+}
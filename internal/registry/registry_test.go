@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/plugins/seo":
+			w.Write([]byte(`{"name": "seo", "version": "1.2.0"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", time.Minute)
+	tests := []struct {
+		name, installed, want string
+	}{
+		{"seo", "1.1.0", StatusAvailable},
+		{"seo", "1.2.0", StatusUpToDate},
+		{"seo", "2.0.0", StatusUpToDate},
+		{"seo", "not-a-version", StatusUnknown},
+		{"missing", "1.0.0", StatusUnknown},
+	}
+	for _, tt := range tests {
+		update := client.Check(context.Background(), KindPlugin, tt.name, tt.installed)
+		if update.Status != tt.want {
+			t.Errorf("Check(%s %s) = %s, want %s", tt.name, tt.installed, update.Status, tt.want)
+		}
+	}
+
+	// One request per package; the rest are answered from the cache
+	if n := requests.Load(); n != 2 {
+		t.Errorf("registry received %d requests, want 2", n)
+	}
+}
+
+func TestCheckWithoutRegistry(t *testing.T) {
+	update := NewClient("", time.Minute).Check(context.Background(), KindTheme, "default", "1.0.0")
+	if update.Status != StatusUnknown {
+		t.Errorf("Status = %s, want %s", update.Status, StatusUnknown)
+	}
+}
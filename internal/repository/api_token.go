@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-cms/internal/database"
+	"go-cms/internal/database/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// APITokenRepository provides access to personal access tokens
+type APITokenRepository interface {
+	// Create inserts a new token and sets its ID
+	Create(ctx context.Context, token *models.APIToken) error
+
+	// ListByUser returns a user's tokens, newest first
+	ListByUser(ctx context.Context, userID string) ([]models.APIToken, error)
+
+	// FindByHash returns the unexpired token with the given hash. It returns
+	// ErrNotFound when no such token exists.
+	FindByHash(ctx context.Context, tokenHash string, at time.Time) (*models.APIToken, error)
+
+	// Delete revokes one of a user's tokens. It returns ErrNotFound when the user
+	// has no token with that ID.
+	Delete(ctx context.Context, userID, id string) error
+
+	// Touch records that a token was used
+	Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error
+}
+
+// MongoAPITokenRepository is the MongoDB implementation of APITokenRepository
+type MongoAPITokenRepository struct {
+	db *database.DB
+}
+
+// NewMongoAPITokenRepository creates an APITokenRepository backed by the "api_tokens" collection.
+// Operations use the request-scoped database from ctx when there is one.
+func NewMongoAPITokenRepository(db *database.DB) *MongoAPITokenRepository {
+	return &MongoAPITokenRepository{
+		db: db,
+	}
+}
+
+// collection returns the api_tokens collection of the database selected by ctx.
+// Tokens are read from the primary so a revoked token stops working at once.
+func (r *MongoAPITokenRepository) collection(ctx context.Context) *mongo.Collection {
+	return database.FromContext(ctx, r.db).Critical().Collection("api_tokens")
+}
+
+func (r *MongoAPITokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	result, err := r.collection(ctx).InsertOne(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	token.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoAPITokenRepository) ListByUser(ctx context.Context, userID string) ([]models.APIToken, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection(ctx).Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := []models.APIToken{}
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *MongoAPITokenRepository) FindByHash(ctx context.Context, tokenHash string, at time.Time) (*models.APIToken, error) {
+	filter := bson.M{
+		"token_hash": tokenHash,
+		"$or": []bson.M{
+			{"expires_at": bson.M{"$exists": false}},
+			{"expires_at": bson.M{"$gt": at}},
+		},
+	}
+
+	var token models.APIToken
+	if err := r.collection(ctx).FindOne(ctx, filter).Decode(&token); err != nil {
+		return nil, translateError(err)
+	}
+	return &token, nil
+}
+
+func (r *MongoAPITokenRepository) Delete(ctx context.Context, userID, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	result, err := r.collection(ctx).DeleteOne(ctx, bson.M{"_id": objectID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoAPITokenRepository) Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := r.collection(ctx).UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"last_used_at": at},
+	})
+	return err
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"go-cms/internal/database"
+	"go-cms/internal/database/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDuplicate is returned when an insert or update collides with a unique index
+var ErrDuplicate = errors.New("duplicate")
+
+// ContentTypeRepository provides access to content type definitions
+type ContentTypeRepository interface {
+	// List returns every content type ordered by name
+	List(ctx context.Context) ([]models.ContentType, error)
+
+	// FindByName returns the content type with the given name
+	FindByName(ctx context.Context, name string) (*models.ContentType, error)
+
+	// Create inserts a new content type and sets its ID. It returns ErrDuplicate
+	// when the name is taken.
+	Create(ctx context.Context, contentType *models.ContentType) error
+
+	// Update replaces the label, description and fields of the named content type
+	Update(ctx context.Context, contentType *models.ContentType) error
+
+	// Delete removes the named content type
+	Delete(ctx context.Context, name string) error
+}
+
+// ContentFilter narrows a content listing
+type ContentFilter struct {
+	Type   string // Only content of this type; empty for all types
+	Status string // Only content with this status; empty for any
+	Limit  int64
+	Offset int64
+}
+
+// ContentRepository provides access to content items
+type ContentRepository interface {
+	// List returns the content matching filter, newest first, and the total number of matches
+	List(ctx context.Context, filter ContentFilter) ([]models.Content, int64, error)
+
+	// FindByID returns the content with the given hex ID
+	FindByID(ctx context.Context, id string) (*models.Content, error)
+
+	// CountByType returns how many items of the given type exist
+	CountByType(ctx context.Context, contentType string) (int64, error)
+
+	// Create inserts new content and sets its ID. It returns ErrDuplicate when
+	// the slug is taken within the type.
+	Create(ctx context.Context, content *models.Content) error
+
+	// Update replaces the editable fields of existing content
+	Update(ctx context.Context, content *models.Content) error
+
+	// Delete removes the content with the given hex ID
+	Delete(ctx context.Context, id string) error
+}
+
+// MongoContentTypeRepository is the MongoDB implementation of ContentTypeRepository
+type MongoContentTypeRepository struct {
+	db *database.DB
+}
+
+// NewMongoContentTypeRepository creates a ContentTypeRepository backed by the
+// "content_types" collection. Operations use the request-scoped database from
+// ctx when there is one.
+func NewMongoContentTypeRepository(db *database.DB) *MongoContentTypeRepository {
+	return &MongoContentTypeRepository{
+		db: db,
+	}
+}
+
+// collection returns the content_types collection of the database selected by ctx
+func (r *MongoContentTypeRepository) collection(ctx context.Context) *mongo.Collection {
+	return database.FromContext(ctx, r.db).Collection("content_types")
+}
+
+func (r *MongoContentTypeRepository) List(ctx context.Context) ([]models.ContentType, error) {
+	cursor, err := r.collection(ctx).Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	contentTypes := []models.ContentType{}
+	if err := cursor.All(ctx, &contentTypes); err != nil {
+		return nil, err
+	}
+	return contentTypes, nil
+}
+
+func (r *MongoContentTypeRepository) FindByName(ctx context.Context, name string) (*models.ContentType, error) {
+	var contentType models.ContentType
+	if err := r.collection(ctx).FindOne(ctx, bson.M{"name": name}).Decode(&contentType); err != nil {
+		return nil, translateError(err)
+	}
+	return &contentType, nil
+}
+
+func (r *MongoContentTypeRepository) Create(ctx context.Context, contentType *models.ContentType) error {
+	result, err := r.collection(ctx).InsertOne(ctx, contentType)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+
+	contentType.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoContentTypeRepository) Update(ctx context.Context, contentType *models.ContentType) error {
+	result, err := r.collection(ctx).UpdateOne(ctx, bson.M{"name": contentType.Name}, bson.M{
+		"$set": bson.M{
+			"label":       contentType.Label,
+			"description": contentType.Description,
+			"fields":      contentType.Fields,
+			"updated_at":  contentType.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoContentTypeRepository) Delete(ctx context.Context, name string) error {
+	result, err := r.collection(ctx).DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MongoContentRepository is the MongoDB implementation of ContentRepository
+type MongoContentRepository struct {
+	db *database.DB
+}
+
+// NewMongoContentRepository creates a ContentRepository backed by the "content"
+// collection. Operations use the request-scoped database from ctx when there is one.
+func NewMongoContentRepository(db *database.DB) *MongoContentRepository {
+	return &MongoContentRepository{
+		db: db,
+	}
+}
+
+// collection returns the content collection of the database selected by ctx
+func (r *MongoContentRepository) collection(ctx context.Context) *mongo.Collection {
+	return database.FromContext(ctx, r.db).Collection("content")
+}
+
+func (r *MongoContentRepository) List(ctx context.Context, filter ContentFilter) ([]models.Content, int64, error) {
+	query := bson.M{}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	total, err := r.collection(ctx).CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(filter.Offset).
+		SetLimit(filter.Limit)
+	cursor, err := r.collection(ctx).Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := []models.Content{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *MongoContentRepository) FindByID(ctx context.Context, id string) (*models.Content, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var content models.Content
+	if err := r.collection(ctx).FindOne(ctx, bson.M{"_id": objectID}).Decode(&content); err != nil {
+		return nil, translateError(err)
+	}
+	return &content, nil
+}
+
+func (r *MongoContentRepository) CountByType(ctx context.Context, contentType string) (int64, error) {
+	return r.collection(ctx).CountDocuments(ctx, bson.M{"type": contentType})
+}
+
+func (r *MongoContentRepository) Create(ctx context.Context, content *models.Content) error {
+	result, err := r.collection(ctx).InsertOne(ctx, content)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+
+	content.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoContentRepository) Update(ctx context.Context, content *models.Content) error {
+	result, err := r.collection(ctx).UpdateOne(ctx, bson.M{"_id": content.ID}, bson.M{
+		"$set": bson.M{
+			"title":      content.Title,
+			"slug":       content.Slug,
+			"status":     content.Status,
+			"body":       content.Body,
+			"fields":     content.Fields,
+			"updated_at": content.UpdatedAt,
+		},
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoContentRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	result, err := r.collection(ctx).DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
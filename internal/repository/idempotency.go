@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-cms/internal/database"
+	"go-cms/internal/database/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// IdempotencyRepository stores the outcome of requests sent with an idempotency key
+type IdempotencyRepository interface {
+	// Reserve inserts record as in progress and returns nil. If an unexpired record
+	// with the same key, user and endpoint exists, nothing is inserted and that
+	// record is returned instead.
+	Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+
+	// Complete stores the response of a reserved request
+	Complete(ctx context.Context, id primitive.ObjectID, status int, contentType string, body []byte) error
+
+	// Release deletes a reservation so the key can be used again
+	Release(ctx context.Context, id primitive.ObjectID) error
+}
+
+// MongoIdempotencyRepository is the MongoDB implementation of IdempotencyRepository
+type MongoIdempotencyRepository struct {
+	db *database.DB
+}
+
+// NewMongoIdempotencyRepository creates an IdempotencyRepository backed by the
+// "idempotency_keys" collection, which expires records through a TTL index.
+// Operations use the request-scoped database from ctx when there is one.
+func NewMongoIdempotencyRepository(db *database.DB) *MongoIdempotencyRepository {
+	return &MongoIdempotencyRepository{
+		db: db,
+	}
+}
+
+// collection returns the idempotency_keys collection of the database selected by ctx
+func (r *MongoIdempotencyRepository) collection(ctx context.Context) *mongo.Collection {
+	return database.FromContext(ctx, r.db).Collection("idempotency_keys")
+}
+
+func (r *MongoIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	scope := bson.M{"key": record.Key, "user_id": record.UserID, "endpoint": record.Endpoint}
+
+	// The TTL monitor only runs once a minute, so clear an expired record first
+	expired := bson.M{"expires_at": bson.M{"$lte": time.Now()}}
+	for k, v := range scope {
+		expired[k] = v
+	}
+	if _, err := r.collection(ctx).DeleteOne(ctx, expired); err != nil {
+		return nil, err
+	}
+
+	result, err := r.collection(ctx).InsertOne(ctx, record)
+	if err == nil {
+		record.ID = result.InsertedID.(primitive.ObjectID)
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	var existing models.IdempotencyRecord
+	if err := r.collection(ctx).FindOne(ctx, scope).Decode(&existing); err != nil {
+		return nil, translateError(err)
+	}
+	return &existing, nil
+}
+
+func (r *MongoIdempotencyRepository) Complete(ctx context.Context, id primitive.ObjectID, status int, contentType string, body []byte) error {
+	_, err := r.collection(ctx).UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"completed":    true,
+			"status":       status,
+			"content_type": contentType,
+			"body":         body,
+		},
+	})
+	return err
+}
+
+func (r *MongoIdempotencyRepository) Release(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection(ctx).DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-cms/internal/database"
+	"go-cms/internal/database/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InviteRepository provides access to registration invites
+type InviteRepository interface {
+	// Create inserts a new invite and sets its ID
+	Create(ctx context.Context, invite *models.Invite) error
+
+	// Consume atomically marks the unused, unexpired invite with the given token
+	// hash as used by email. Invites restricted to another address do not match.
+	// It returns ErrNotFound when no such invite exists.
+	Consume(ctx context.Context, tokenHash, email string, at time.Time) (*models.Invite, error)
+
+	// Release makes a consumed invite usable again, e.g. when registration fails afterwards
+	Release(ctx context.Context, id primitive.ObjectID) error
+}
+
+// MongoInviteRepository is the MongoDB implementation of InviteRepository
+type MongoInviteRepository struct {
+	db *database.DB
+}
+
+// NewMongoInviteRepository creates an InviteRepository backed by the "invites" collection.
+// Operations use the request-scoped database from ctx when there is one.
+func NewMongoInviteRepository(db *database.DB) *MongoInviteRepository {
+	return &MongoInviteRepository{
+		db: db,
+	}
+}
+
+// collection returns the invites collection of the database selected by ctx
+func (r *MongoInviteRepository) collection(ctx context.Context) *mongo.Collection {
+	return database.FromContext(ctx, r.db).Collection("invites")
+}
+
+func (r *MongoInviteRepository) Create(ctx context.Context, invite *models.Invite) error {
+	result, err := r.collection(ctx).InsertOne(ctx, invite)
+	if err != nil {
+		return err
+	}
+
+	invite.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoInviteRepository) Consume(ctx context.Context, tokenHash, email string, at time.Time) (*models.Invite, error) {
+	filter := bson.M{
+		"token_hash": tokenHash,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": at},
+		"$or": []bson.M{
+			{"email": bson.M{"$exists": false}},
+			{"email": email},
+		},
+	}
+	update := bson.M{"$set": bson.M{"used_at": at, "used_by": email}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var invite models.Invite
+	if err := r.collection(ctx).FindOneAndUpdate(ctx, filter, update, opts).Decode(&invite); err != nil {
+		return nil, translateError(err)
+	}
+	return &invite, nil
+}
+
+func (r *MongoInviteRepository) Release(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection(ctx).UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$unset": bson.M{"used_at": "", "used_by": ""},
+	})
+	return err
+}
@@ -0,0 +1,20 @@
+// Package repository isolates data access behind interfaces so handlers do not
+// depend on the MongoDB driver directly.
+package repository
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNotFound is returned when a lookup matches no documents
+var ErrNotFound = errors.New("not found")
+
+// translateError maps driver errors onto repository errors
+func translateError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-cms/internal/database/models"
+	"go-cms/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APITokens is an in-memory repository.APITokenRepository
+type APITokens struct {
+	mu     sync.Mutex
+	tokens []*models.APIToken
+}
+
+var _ repository.APITokenRepository = (*APITokens)(nil)
+
+// NewAPITokens returns an empty repository
+func NewAPITokens() *APITokens {
+	return &APITokens{}
+}
+
+// All returns copies of every stored token
+func (r *APITokens) All() []models.APIToken {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	all := make([]models.APIToken, len(r.tokens))
+	for i, token := range r.tokens {
+		all[i] = *token
+	}
+	return all
+}
+
+func (r *APITokens) Create(ctx context.Context, token *models.APIToken) error {
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	copied := *token
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens = append(r.tokens, &copied)
+	return nil
+}
+
+func (r *APITokens) ListByUser(ctx context.Context, userID string) ([]models.APIToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tokens := []models.APIToken{}
+	for i := len(r.tokens) - 1; i >= 0; i-- {
+		if r.tokens[i].UserID == userID {
+			tokens = append(tokens, *r.tokens[i])
+		}
+	}
+	return tokens, nil
+}
+
+func (r *APITokens) FindByHash(ctx context.Context, tokenHash string, at time.Time) (*models.APIToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && (token.ExpiresAt == nil || token.ExpiresAt.After(at)) {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *APITokens) Delete(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, token := range r.tokens {
+		if token.ID.Hex() == id && token.UserID == userID {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *APITokens) Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.ID == id {
+			token.LastUsedAt = &at
+		}
+	}
+	return nil
+}
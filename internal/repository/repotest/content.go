@@ -0,0 +1,193 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go-cms/internal/database/models"
+	"go-cms/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ContentTypes is an in-memory repository.ContentTypeRepository
+type ContentTypes struct {
+	mu    sync.Mutex
+	types map[string]models.ContentType
+}
+
+var _ repository.ContentTypeRepository = (*ContentTypes)(nil)
+
+// NewContentTypes returns a repository holding copies of types
+func NewContentTypes(types ...models.ContentType) *ContentTypes {
+	r := &ContentTypes{types: make(map[string]models.ContentType)}
+	for _, contentType := range types {
+		r.Create(context.Background(), &contentType)
+	}
+	return r
+}
+
+func (r *ContentTypes) List(ctx context.Context) ([]models.ContentType, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	all := make([]models.ContentType, 0, len(r.types))
+	for _, contentType := range r.types {
+		all = append(all, contentType)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all, nil
+}
+
+func (r *ContentTypes) FindByName(ctx context.Context, name string) (*models.ContentType, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	contentType, exists := r.types[name]
+	if !exists {
+		return nil, repository.ErrNotFound
+	}
+	return &contentType, nil
+}
+
+func (r *ContentTypes) Create(ctx context.Context, contentType *models.ContentType) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.types[contentType.Name]; exists {
+		return repository.ErrDuplicate
+	}
+	if contentType.ID.IsZero() {
+		contentType.ID = primitive.NewObjectID()
+	}
+	r.types[contentType.Name] = *contentType
+	return nil
+}
+
+func (r *ContentTypes) Update(ctx context.Context, contentType *models.ContentType) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, exists := r.types[contentType.Name]
+	if !exists {
+		return repository.ErrNotFound
+	}
+	existing.Label = contentType.Label
+	existing.Description = contentType.Description
+	existing.Fields = contentType.Fields
+	existing.UpdatedAt = contentType.UpdatedAt
+	r.types[contentType.Name] = existing
+	return nil
+}
+
+func (r *ContentTypes) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.types[name]; !exists {
+		return repository.ErrNotFound
+	}
+	delete(r.types, name)
+	return nil
+}
+
+// Content is an in-memory repository.ContentRepository. Slugs are unique within
+// a type, as the index on the content collection makes them.
+type Content struct {
+	mu    sync.Mutex
+	items []*models.Content
+}
+
+var _ repository.ContentRepository = (*Content)(nil)
+
+// NewContent returns a repository holding copies of items, with IDs assigned to
+// those that have none
+func NewContent(items ...models.Content) *Content {
+	r := &Content{}
+	for _, item := range items {
+		r.Create(context.Background(), &item)
+	}
+	return r
+}
+
+func (r *Content) List(ctx context.Context, filter repository.ContentFilter) ([]models.Content, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matching []models.Content
+	for i := len(r.items) - 1; i >= 0; i-- {
+		item := r.items[i]
+		if (filter.Type == "" || item.Type == filter.Type) && (filter.Status == "" || item.Status == filter.Status) {
+			matching = append(matching, *item)
+		}
+	}
+
+	total := int64(len(matching))
+	start := min(filter.Offset, total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+	return matching[start:end], total, nil
+}
+
+func (r *Content) FindByID(ctx context.Context, id string) (*models.Content, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, item := range r.items {
+		if item.ID.Hex() == id {
+			copied := *item
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *Content) CountByType(ctx context.Context, contentType string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, item := range r.items {
+		if item.Type == contentType {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *Content) Create(ctx context.Context, content *models.Content) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, item := range r.items {
+		if item.Type == content.Type && item.Slug == content.Slug {
+			return repository.ErrDuplicate
+		}
+	}
+	if content.ID.IsZero() {
+		content.ID = primitive.NewObjectID()
+	}
+	copied := *content
+	r.items = append(r.items, &copied)
+	return nil
+}
+
+func (r *Content) Update(ctx context.Context, content *models.Content) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, item := range r.items {
+		if item.ID == content.ID {
+			copied := *content
+			r.items[i] = &copied
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *Content) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, item := range r.items {
+		if item.ID.Hex() == id {
+			r.items = append(r.items[:i], r.items[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
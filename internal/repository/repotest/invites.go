@@ -0,0 +1,77 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-cms/internal/database/models"
+	"go-cms/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invites is an in-memory repository.InviteRepository
+type Invites struct {
+	mu      sync.Mutex
+	invites []*models.Invite
+}
+
+var _ repository.InviteRepository = (*Invites)(nil)
+
+// NewInvites returns an empty repository
+func NewInvites() *Invites {
+	return &Invites{}
+}
+
+// All returns copies of every stored invite
+func (r *Invites) All() []models.Invite {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	all := make([]models.Invite, len(r.invites))
+	for i, invite := range r.invites {
+		all[i] = *invite
+	}
+	return all
+}
+
+func (r *Invites) Create(ctx context.Context, invite *models.Invite) error {
+	if invite.ID.IsZero() {
+		invite.ID = primitive.NewObjectID()
+	}
+	copied := *invite
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invites = append(r.invites, &copied)
+	return nil
+}
+
+func (r *Invites) Consume(ctx context.Context, tokenHash, email string, at time.Time) (*models.Invite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, invite := range r.invites {
+		if invite.TokenHash != tokenHash || invite.UsedAt != nil || !invite.ExpiresAt.After(at) {
+			continue
+		}
+		if invite.Email != "" && invite.Email != email {
+			continue
+		}
+		invite.UsedAt = &at
+		invite.UsedBy = email
+		copied := *invite
+		return &copied, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *Invites) Release(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, invite := range r.invites {
+		if invite.ID == id {
+			invite.UsedAt = nil
+			invite.UsedBy = ""
+		}
+	}
+	return nil
+}
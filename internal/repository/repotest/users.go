@@ -0,0 +1,160 @@
+// Package repotest provides in-memory implementations of the repository
+// interfaces for tests that should not need MongoDB.
+package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go-cms/internal/database/models"
+	"go-cms/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Users is an in-memory repository.UserRepository. Lookups match fields
+// exactly, as the unique indexes on the users collection do.
+type Users struct {
+	mu    sync.Mutex
+	users []*models.User
+}
+
+var _ repository.UserRepository = (*Users)(nil)
+
+// NewUsers returns a repository holding copies of users, with IDs assigned to
+// those that have none
+func NewUsers(users ...*models.User) *Users {
+	r := &Users{}
+	for _, user := range users {
+		r.Create(context.Background(), user)
+	}
+	return r
+}
+
+// All returns copies of every stored user
+func (r *Users) All() []models.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	all := make([]models.User, len(r.users))
+	for i, user := range r.users {
+		all[i] = *user
+	}
+	return all
+}
+
+func (r *Users) find(match func(*models.User) bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if match(user) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *Users) FindByID(ctx context.Context, id string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.ID.Hex() == id })
+}
+
+func (r *Users) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Email == email })
+}
+
+func (r *Users) FindByEmailOrUsername(ctx context.Context, email, username string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Email == email || u.Username == username })
+}
+
+func (r *Users) FindOldestByRole(ctx context.Context, role string) (*models.User, error) {
+	r.mu.Lock()
+	matching := make([]*models.User, 0)
+	for _, user := range r.users {
+		if user.Role == role {
+			matching = append(matching, user)
+		}
+	}
+	r.mu.Unlock()
+	if len(matching) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].CreatedAt.Before(matching[j].CreatedAt) })
+	copied := *matching[0]
+	return &copied, nil
+}
+
+func (r *Users) ExistsOtherWithUsername(ctx context.Context, username, excludeID string) (bool, error) {
+	_, err := r.find(func(u *models.User) bool { return u.Username == username && u.ID.Hex() != excludeID })
+	return err == nil, nil
+}
+
+func (r *Users) ExistsOtherWithEmail(ctx context.Context, email, excludeID string) (bool, error) {
+	_, err := r.find(func(u *models.User) bool { return u.Email == email && u.ID.Hex() != excludeID })
+	return err == nil, nil
+}
+
+func (r *Users) Create(ctx context.Context, user *models.User) error {
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	copied := *user
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users = append(r.users, &copied)
+	return nil
+}
+
+func (r *Users) UpdateFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.ID.Hex() != id {
+			continue
+		}
+		for field, value := range fields {
+			switch field {
+			case "username":
+				user.Username = value.(string)
+			case "email":
+				user.Email = value.(string)
+			case "password":
+				user.Password = value.(string)
+			case "role":
+				user.Role = value.(string)
+			case "is_active":
+				user.IsActive = value.(bool)
+			case "updated_at":
+				user.UpdatedAt = value.(time.Time)
+			case "last_login_at":
+				at := value.(time.Time)
+				user.LastLoginAt = &at
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func (r *Users) UpdateLastLogin(ctx context.Context, id string, at time.Time) error {
+	return r.UpdateFields(ctx, id, map[string]interface{}{"last_login_at": at, "updated_at": at})
+}
+
+func (r *Users) Count(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.users)), nil
+}
+
+func (r *Users) CountActiveSince(ctx context.Context, since time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, user := range r.users {
+		if user.LastLoginAt != nil && !user.LastLoginAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
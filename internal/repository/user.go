@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-cms/internal/database"
+	"go-cms/internal/database/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UserRepository provides access to user accounts
+type UserRepository interface {
+	// FindByID returns the user with the given hex ID
+	FindByID(ctx context.Context, id string) (*models.User, error)
+
+	// FindByEmail returns the user with the given email
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+
+	// FindByEmailOrUsername returns a user matching either the email or the username
+	FindByEmailOrUsername(ctx context.Context, email, username string) (*models.User, error)
+
+	// FindOldestByRole returns the earliest created user with the given role
+	FindOldestByRole(ctx context.Context, role string) (*models.User, error)
+
+	// ExistsOtherWithUsername reports whether a user other than excludeID has the username
+	ExistsOtherWithUsername(ctx context.Context, username, excludeID string) (bool, error)
+
+	// ExistsOtherWithEmail reports whether a user other than excludeID has the email
+	ExistsOtherWithEmail(ctx context.Context, email, excludeID string) (bool, error)
+
+	// Create inserts a new user and sets its ID
+	Create(ctx context.Context, user *models.User) error
+
+	// UpdateFields sets the given fields on a user
+	UpdateFields(ctx context.Context, id string, fields map[string]interface{}) error
+
+	// UpdateLastLogin records a successful login
+	UpdateLastLogin(ctx context.Context, id string, at time.Time) error
+
+	// Count returns the total number of users
+	Count(ctx context.Context) (int64, error)
+
+	// CountActiveSince returns the number of users who logged in since the given time
+	CountActiveSince(ctx context.Context, since time.Time) (int64, error)
+}
+
+// MongoUserRepository is the MongoDB implementation of UserRepository
+type MongoUserRepository struct {
+	db *database.DB
+}
+
+// NewMongoUserRepository creates a UserRepository backed by the "users" collection.
+// Operations use the request-scoped database from ctx when there is one.
+func NewMongoUserRepository(db *database.DB) *MongoUserRepository {
+	return &MongoUserRepository{
+		db: db,
+	}
+}
+
+// collection returns the users collection of the database selected by ctx. Users
+// are read from the primary so logins never see a password from before a change.
+func (r *MongoUserRepository) collection(ctx context.Context) *mongo.Collection {
+	return database.FromContext(ctx, r.db).Critical().Collection("users")
+}
+
+func (r *MongoUserRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	return r.findOne(ctx, bson.M{"_id": objectID})
+}
+
+func (r *MongoUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *MongoUserRepository) FindByEmailOrUsername(ctx context.Context, email, username string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{
+		"$or": []bson.M{
+			{"email": email},
+			{"username": username},
+		},
+	})
+}
+
+func (r *MongoUserRepository) FindOldestByRole(ctx context.Context, role string) (*models.User, error) {
+	var user models.User
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	if err := r.collection(ctx).FindOne(ctx, bson.M{"role": role}, opts).Decode(&user); err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *MongoUserRepository) ExistsOtherWithUsername(ctx context.Context, username, excludeID string) (bool, error) {
+	return r.existsOther(ctx, "username", username, excludeID)
+}
+
+func (r *MongoUserRepository) ExistsOtherWithEmail(ctx context.Context, email, excludeID string) (bool, error) {
+	return r.existsOther(ctx, "email", email, excludeID)
+}
+
+func (r *MongoUserRepository) Create(ctx context.Context, user *models.User) error {
+	result, err := r.collection(ctx).InsertOne(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	user.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoUserRepository) UpdateFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	_, err := r.collection(ctx).UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M(fields)})
+	return err
+}
+
+func (r *MongoUserRepository) UpdateLastLogin(ctx context.Context, id string, at time.Time) error {
+	return r.UpdateFields(ctx, id, map[string]interface{}{
+		"last_login_at": at,
+		"updated_at":    at,
+	})
+}
+
+func (r *MongoUserRepository) Count(ctx context.Context) (int64, error) {
+	return r.collection(ctx).CountDocuments(ctx, bson.M{})
+}
+
+func (r *MongoUserRepository) CountActiveSince(ctx context.Context, since time.Time) (int64, error) {
+	return r.collection(ctx).CountDocuments(ctx, bson.M{
+		"last_login_at": bson.M{"$gte": since},
+	})
+}
+
+func (r *MongoUserRepository) findOne(ctx context.Context, filter bson.M) (*models.User, error) {
+	var user models.User
+	if err := r.collection(ctx).FindOne(ctx, filter).Decode(&user); err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *MongoUserRepository) existsOther(ctx context.Context, field, value, excludeID string) (bool, error) {
+	objectID, _ := primitive.ObjectIDFromHex(excludeID)
+	_, err := r.findOne(ctx, bson.M{
+		field: value,
+		"_id": bson.M{"$ne": objectID},
+	})
+	if err == ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -1,22 +1,66 @@
 package router
 
 import (
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
+
 	"go-cms/internal/admin"
 	"go-cms/internal/auth"
+	"go-cms/internal/clock"
 	"go-cms/internal/config"
+	"go-cms/internal/content"
 	"go-cms/internal/database"
+	"go-cms/internal/events"
+	"go-cms/internal/httputil"
 	"go-cms/internal/middleware"
 	"go-cms/internal/plugins"
+	"go-cms/internal/repository"
+	"go-cms/internal/settings"
 	"go-cms/internal/themes"
 
 	"github.com/gin-gonic/gin"
 )
 
+// multipartOverhead allows for form boundaries and headers around an uploaded file
+const multipartOverhead = 1 << 20
+
+// maintenanceBypass lists paths that stay reachable in maintenance mode so admins
+// can sign in and switch it off. Admin API routes enforce their own authentication.
+var maintenanceBypass = []string{
+	"/health",
+	"/api/v1/version",
+	"/admin",
+	"/api/v1/login",
+	"/api/v1/refresh",
+	"/api/v1/admin",
+}
+
 type Dependencies struct {
-	Config        *config.Config
-	Database      *database.DB
-	PluginManager *plugins.Manager
-	ThemeManager  *themes.Manager
+	Config          *config.Config
+	Database        *database.DB
+	PluginManager   *plugins.Manager
+	ThemeManager    *themes.Manager
+	SettingsManager *settings.Manager
+	Events          *events.Bus
+	Tenants         *database.TenantRegistry // nil in single-tenant mode
+
+	// Ready is set once migrations have run and plugins and themes are loaded.
+	// /health/ready answers 503 until then; nil means always ready.
+	Ready *atomic.Bool
+
+	// Build identifies the running binary on /api/v1/version
+	Build BuildInfo
+}
+
+// BuildInfo is the build metadata injected into the server binary at link time
+type BuildInfo struct {
+	Commit string
+	Time   string
 }
 
 func Setup(deps *Dependencies) *gin.Engine {
@@ -29,45 +73,130 @@ func Setup(deps *Dependencies) *gin.Engine {
 	pluginDeps := &plugins.PluginDependencies{
 		Database: deps.Database,
 		Config:   deps.Config,
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+			Level: deps.Config.SlogLevel(),
+		})),
 	}
 	deps.PluginManager.SetDependencies(pluginDeps)
+	deps.PluginManager.SetEventBus(deps.Events)
+
+	if deps.ThemeManager != nil {
+		deps.ThemeManager.SetEventBus(deps.Events)
+	}
 
-	// Middleware
-	r.Use(middleware.CORS())
+	// Repositories
+	users := repository.NewMongoUserRepository(deps.Database)
+	invites := repository.NewMongoInviteRepository(deps.Database)
+	idempotency := repository.NewMongoIdempotencyRepository(deps.Database)
+	contentTypes := repository.NewMongoContentTypeRepository(deps.Database)
+	contentItems := repository.NewMongoContentRepository(deps.Database)
+	apiTokens := repository.NewMongoAPITokenRepository(deps.Database)
+
+	// Middleware. Forwarding headers, which decide the client IP and the URLs in
+	// Location headers, are only believed from the configured proxies.
+	if err := r.SetTrustedProxies(deps.Config.TrustedProxies); err != nil {
+		log.Printf("[ROUTER] Failed to set trusted proxies: %v", err)
+	}
+	r.Use(httputil.TrustProxies(deps.Config.TrustedProxies))
+	r.Use(corsPolicies(deps.Config).Handler())
 	r.Use(middleware.RequestLogger())
+	if deps.Config.DebugBodyLogging {
+		log.Printf("[ROUTER] Logging request and response bodies for %v", deps.Config.DebugBodyLogPaths)
+		r.Use(middleware.BodyLogger(middleware.BodyLogConfig{
+			Paths:    deps.Config.DebugBodyLogPaths,
+			MaxBytes: deps.Config.DebugBodyLogMaxBytes,
+		}))
+	}
+
+	// API middleware shared by every /api/v1 group. With multi-tenancy on, users
+	// and data read through the request context are per tenant; plugins, themes
+	// and site settings stay instance-wide.
+	var apiMiddleware []gin.HandlerFunc
+	if deps.Tenants != nil {
+		apiMiddleware = append(apiMiddleware, middleware.Tenant(deps.Tenants, deps.Config.TenantHeader))
+	}
+	r.Use(middleware.Maintenance(
+		deps.SettingsManager.Maintenance,
+		func(c *gin.Context) bool { return auth.IsAdminRequest(c, deps.Config.JWTSecret) },
+		maintenanceBypass...,
+	))
 
 	// Public routes
-	public := r.Group("/api/v1")
+	public := r.Group("/api/v1", apiMiddleware...)
+	public.Use(middleware.BodyLimit(deps.Config.MaxRequestBodySize))
 	{
 		// Auth routes
-		authHandler := auth.NewHandler(deps.Database, deps.Config.JWTSecret)
+		authHandler := auth.NewHandler(users, deps.Config.JWTSecret)
+		authHandler.SetExplicitLoginErrors(deps.Config.LoginErrorDetail)
+		authHandler.SetRegistration(deps.SettingsManager.RegistrationMode, invites)
+		authHandler.SetPasswordPolicy(deps.SettingsManager.PasswordPolicy)
 		public.POST("/register", authHandler.Register)
 		public.POST("/login", authHandler.Login)
 		public.POST("/refresh", authHandler.RefreshToken)
 	}
 
-	// Protected routes
-	protected := r.Group("/api/v1")
-	protected.Use(auth.JWTMiddleware(deps.Config.JWTSecret))
+	// Protected routes. Their middleware is kept in a slice so the plugin
+	// dispatcher, mounted on the public group, runs it for protected plugin routes.
+	authentication := []gin.HandlerFunc{
+		auth.Authenticate(deps.Config.JWTSecret, apiTokens, users, clock.Real()),
+		middleware.BodyLimit(deps.Config.MaxRequestBodySize),
+	}
+	protected := r.Group("/api/v1", apiMiddleware...)
+	protected.Use(authentication...)
 	{
 		// User routes
-		authHandler := auth.NewHandler(deps.Database, deps.Config.JWTSecret)
+		authHandler := auth.NewHandler(users, deps.Config.JWTSecret)
+		authHandler.SetPasswordPolicy(deps.SettingsManager.PasswordPolicy)
 		protected.GET("/profile", authHandler.GetProfile)
 		protected.PUT("/profile", authHandler.UpdateProfile)
+		protected.PATCH("/profile", authHandler.PatchProfile)
+
+		// Personal access tokens
+		authHandler.SetAPITokens(apiTokens)
+		protected.POST("/tokens", authHandler.CreateAPIToken)
+		protected.GET("/tokens", authHandler.ListAPITokens)
+		protected.DELETE("/tokens/:id", authHandler.RevokeAPIToken)
 
 		// Theme routes
-		themeHandler := themes.NewHandler(deps.ThemeManager)
+		themeHandler := themes.NewHandler(deps.ThemeManager, deps.Config)
 		protected.GET("/themes", themeHandler.GetAll)
 		protected.GET("/themes/:name", themeHandler.GetTheme)
 		protected.POST("/themes/:name/activate", auth.AdminRequired(), themeHandler.ActivateTheme)
+		protected.POST("/themes/:name/deactivate", auth.AdminRequired(), themeHandler.DeactivateTheme)
+		protected.GET("/themes/:name/customization", themeHandler.GetCustomization)
+		protected.PUT("/themes/:name/customization", auth.AdminRequired(), themeHandler.UpdateCustomization)
+		protected.PATCH("/themes/:name/customization", auth.AdminRequired(), themeHandler.PatchCustomization)
+
+		// Content routes
+		contentHandler := content.NewHandler(contentTypes, contentItems)
+		contentSize := middleware.ContentSizeLimit(func() int64 {
+			return deps.SettingsManager.GetInt64(settings.KeyMaxContentSize)
+		})
+		protected.GET("/content-types", contentHandler.ListTypes)
+		protected.GET("/content-types/:name", contentHandler.GetType)
+		protected.GET("/content", contentHandler.List)
+		protected.GET("/content/:id", contentHandler.Get)
+		protected.POST("/content", auth.AdminRequired(), contentSize, contentHandler.Create)
+		protected.PUT("/content/:id", auth.AdminRequired(), contentSize, contentHandler.Update)
+		protected.DELETE("/content/:id", auth.AdminRequired(), contentHandler.Delete)
 	}
 
 	// Admin routes
-	adminGroup := r.Group("/api/v1/admin")
-	adminGroup.Use(auth.JWTMiddleware(deps.Config.JWTSecret))
+	adminGroup := r.Group("/api/v1/admin", apiMiddleware...)
+	adminGroup.Use(auth.Authenticate(deps.Config.JWTSecret, apiTokens, users, clock.Real()))
+	adminGroup.Use(auth.ScopeRequired(auth.ScopeAdmin))
 	adminGroup.Use(auth.AdminRequired())
+	adminGroup.Use(middleware.Idempotency(idempotency, deps.Config.IdempotencyKeyTTL))
+
+	// Upload routes get a larger body limit. The group is created before the default
+	// limit is added below, so it does not inherit it.
+	uploadGroup := adminGroup.Group("",
+		middleware.BodyLimit(deps.Config.MaxUploadSize+multipartOverhead),
+		middleware.RateLimit(deps.Config.UploadRateLimit, time.Minute),
+	)
+	adminGroup.Use(middleware.BodyLimit(deps.Config.MaxRequestBodySize))
 	{
-		adminHandler := admin.NewHandler(deps.Database, deps.PluginManager, deps.ThemeManager)
+		adminHandler := admin.NewHandler(deps.Config, deps.Database, users, deps.PluginManager, deps.ThemeManager)
 
 		// Dashboard
 		adminGroup.GET("/dashboard", adminHandler.GetDashboard)
@@ -75,29 +204,71 @@ func Setup(deps *Dependencies) *gin.Engine {
 
 		// Plugin management
 		adminGroup.GET("/plugins", adminHandler.GetPlugins)
-		adminGroup.POST("/plugins/upload", adminHandler.UploadPlugin)
+		adminGroup.GET("/plugins/updates", adminHandler.GetPluginUpdates)
+		adminGroup.GET("/plugins/stats", adminHandler.GetPluginStats)
+		adminGroup.GET("/plugins/settings", adminHandler.GetAllPluginSettings)
+		adminGroup.GET("/themes/updates", adminHandler.GetThemeUpdates)
+		uploadGroup.POST("/plugins/upload", adminHandler.UploadPlugin)
+		uploadGroup.POST("/plugins/validate", adminHandler.ValidatePluginUpload)
 		adminGroup.POST("/plugins/:name/toggle", adminHandler.TogglePlugin)
 		adminGroup.POST("/plugins/:name/reload", adminHandler.ReloadPlugin)
+		adminGroup.POST("/plugins/:name/refresh-settings", adminHandler.RefreshPluginSettings)
+		adminGroup.GET("/plugins/:name/logs", adminHandler.GetPluginLogs)
 		adminGroup.DELETE("/plugins/:name", adminHandler.DeletePlugin)
 
 		// Plugin settings
 		adminGroup.GET("/plugins/:name/settings", adminHandler.GetPluginSettings)
+		adminGroup.GET("/plugins/:name/settings/schema", adminHandler.GetPluginSettingsSchema)
+		adminGroup.GET("/plugins/:name/settings/history", adminHandler.GetPluginSettingsHistory)
 		adminGroup.PUT("/plugins/:name/settings", adminHandler.UpdatePluginSettings)
+		adminGroup.PATCH("/plugins/:name/settings", adminHandler.PatchPluginSettings)
+		adminGroup.DELETE("/plugins/:name/settings/:key", adminHandler.ResetPluginSetting)
+
+		// Theme installation and removal
+		themeAdminHandler := themes.NewHandler(deps.ThemeManager, deps.Config)
+		uploadGroup.POST("/themes/upload", themeAdminHandler.InstallTheme)
+		adminGroup.GET("/themes/:name/uninstall-preview", themeAdminHandler.PreviewUninstall)
+		adminGroup.DELETE("/themes/:name", themeAdminHandler.UninstallTheme)
+
+		// Site settings
+		settingsHandler := settings.NewHandler(deps.SettingsManager)
+		adminGroup.GET("/settings", settingsHandler.GetAll)
+		adminGroup.PUT("/settings", settingsHandler.Update)
+		adminGroup.POST("/system/maintenance", settingsHandler.SetMaintenance)
+
+		// Registration invites
+		inviteHandler := auth.NewHandler(users, deps.Config.JWTSecret)
+		inviteHandler.SetRegistration(deps.SettingsManager.RegistrationMode, invites)
+		adminGroup.POST("/invites", inviteHandler.CreateInvite)
+
+		// Content types
+		contentTypeHandler := content.NewHandler(contentTypes, contentItems)
+		adminGroup.POST("/content-types", contentTypeHandler.CreateType)
+		adminGroup.PUT("/content-types/:name", contentTypeHandler.UpdateType)
+		adminGroup.DELETE("/content-types/:name", contentTypeHandler.DeleteType)
 
 		// System management
 		adminGroup.GET("/system/info", adminHandler.GetSystemInfo)
+		adminGroup.GET("/system/migrations", auth.SuperAdminRequired(), adminHandler.GetMigrations)
+		adminGroup.POST("/system/migrations/:version/apply", auth.SuperAdminRequired(), adminHandler.ApplyMigration)
+		adminGroup.POST("/system/migrations/:version/rollback", auth.SuperAdminRequired(), adminHandler.RollbackMigration)
+		adminGroup.GET("/system/cache", adminHandler.GetCacheStats)
 		adminGroup.POST("/system/cleanup-cache", adminHandler.CleanupCache)
 		adminGroup.POST("/system/hot-reload", adminHandler.HotReloadAll)
 	}
 
-	// Plugin routes - Store router reference for dynamic registration
+	// Plugin routes - Store router reference for dynamic registration.
+	// RegisterRoutes is mounted behind auth; RegisterPublicRoutes on the public group.
 	deps.PluginManager.SetRouter(protected)
+	deps.PluginManager.SetPublicRouter(public)
+	deps.PluginManager.SetProtectedMiddleware(authentication...)
+	deps.PluginManager.SetAssetCacheMaxAge(deps.Config.PluginAssetsMaxAge)
 	deps.PluginManager.RegisterRoutes(protected)
 
-	// Static file serving for admin dashboard
-	r.Static("/admin", deps.Config.AdminPath)
-	r.Static("/themes", deps.Config.ThemePath)
-	r.Static("/uploads", "./uploads") // For plugin assets
+	// Static file serving with cache headers
+	serveStatic(r, "/admin", deps.Config.AdminPath, deps.Config.AdminCacheMaxAge)
+	serveStatic(r, "/themes", deps.Config.ThemePath, deps.Config.ThemeCacheMaxAge)
+	serveStatic(r, "/uploads", "./uploads", deps.Config.UploadsCacheMaxAge) // For plugin assets
 
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
@@ -107,5 +278,72 @@ func Setup(deps *Dependencies) *gin.Engine {
 		})
 	})
 
+	// Version identifies the running build for deploy checks and plugin authors
+	r.GET("/api/v1/version", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"version":            deps.Config.Version,
+			"go_version":         runtime.Version(),
+			"commit":             deps.Build.Commit,
+			"build_time":         deps.Build.Time,
+			"plugin_api_version": plugins.APIVersion,
+			"environment":        deps.Config.Environment,
+		})
+	})
+
+	// Readiness lets an orchestrator hold traffic until startup has finished
+	r.GET("/health/ready", func(c *gin.Context) {
+		if deps.Ready != nil && !deps.Ready.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "starting",
+				"service": "go-cms",
+			})
+			return
+		}
+		// Serving works without plugins, so a degraded instance stays ready
+		if warning := deps.PluginManager.PlatformWarning(); warning != "" {
+			c.JSON(http.StatusOK, gin.H{
+				"status":   "degraded",
+				"service":  "go-cms",
+				"warnings": []string{warning},
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "ready",
+			"service": "go-cms",
+		})
+	})
+
 	return r
 }
+
+// serveStatic mounts a cached static file handler for GET and HEAD
+func serveStatic(r *gin.Engine, prefix, root string, maxAge time.Duration) {
+	handler := middleware.StaticWithCache(root, maxAge)
+	r.GET(prefix+"/*filepath", handler)
+	r.HEAD(prefix+"/*filepath", handler)
+}
+
+// corsPolicies builds the CORS policy for each route group. The admin API and
+// plugin routes can be given their own origins; everything else, and any group
+// left unconfigured, uses CORSAllowedOrigins.
+func corsPolicies(cfg *config.Config) *middleware.CORSPolicies {
+	policies := middleware.NewCORSPolicies(middleware.CORS(cfg.CORSAllowedOrigins))
+
+	if len(cfg.CORSAdminOrigins) > 0 {
+		admin := middleware.DefaultCORSConfig()
+		admin.AllowedOrigins = cfg.CORSAdminOrigins
+		admin.ExposedHeaders = []string{"Content-Length", "Authorization"}
+		policies.Add("/api/v1/admin", admin)
+	}
+
+	// Authenticated plugin routes share this prefix, so credentials stay off
+	if len(cfg.CORSPluginOrigins) > 0 {
+		plugin := middleware.DefaultCORSConfig()
+		plugin.AllowedOrigins = cfg.CORSPluginOrigins
+		plugin.AllowCredentials = false
+		policies.Add("/api/v1/plugins", plugin)
+	}
+
+	return policies
+}
@@ -0,0 +1,165 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go-cms/internal/config"
+	"go-cms/internal/events"
+	"go-cms/internal/plugins"
+	"go-cms/internal/settings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testDependencies returns router dependencies that need no database. Requests
+// that reach a repository fail, so tests stick to routes answered before that.
+func testDependencies(t *testing.T) *Dependencies {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		JWTSecret:          "router-test-secret-0123456789abcdef",
+		MaxRequestBodySize: 1 << 20,
+		MaxUploadSize:      1 << 20,
+		PluginsDir:         t.TempDir(),
+	}
+	return &Dependencies{
+		Config:          cfg,
+		PluginManager:   plugins.NewManager(),
+		SettingsManager: settings.NewManager(nil, nil),
+		Events:          events.NewBus(),
+	}
+}
+
+func serve(r http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestReadinessFollowsStartup(t *testing.T) {
+	deps := testDependencies(t)
+	deps.Ready = &atomic.Bool{}
+	r := Setup(deps)
+
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("before startup: status %d, want 503", w.Code)
+	}
+
+	deps.Ready.Store(true)
+	w = serve(r, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("after startup: status %d, want 200", w.Code)
+	}
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["status"] != "ready" {
+		t.Fatalf("status %v, want ready", body["status"])
+	}
+
+	// Shutdown flips readiness back so load balancers stop sending traffic
+	deps.Ready.Store(false)
+	if w := serve(r, httptest.NewRequest(http.MethodGet, "/health/ready", nil)); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("during shutdown: status %d, want 503", w.Code)
+	}
+}
+
+func TestVersionReportsBuild(t *testing.T) {
+	deps := testDependencies(t)
+	deps.Config.Version = "1.4.0"
+	deps.Config.Environment = "staging"
+	deps.Build = BuildInfo{Commit: "abc1234", Time: "2024-05-01T10:00:00Z"}
+	r := Setup(deps)
+
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/api/v1/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"version":            "1.4.0",
+		"go_version":         runtime.Version(),
+		"commit":             "abc1234",
+		"build_time":         "2024-05-01T10:00:00Z",
+		"plugin_api_version": plugins.APIVersion,
+		"environment":        "staging",
+	}
+	for key, value := range want {
+		if body[key] != value {
+			t.Errorf("%s = %v, want %v", key, body[key], value)
+		}
+	}
+}
+
+func TestCORSPolicyPerRouteGroup(t *testing.T) {
+	deps := testDependencies(t)
+	deps.Config.CORSAllowedOrigins = []string{"https://site.example"}
+	deps.Config.CORSAdminOrigins = []string{"https://admin.example"}
+	deps.Config.CORSPluginOrigins = []string{"https://widgets.example", "https://partner.example"}
+	r := Setup(deps)
+
+	preflight := func(path, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		return serve(r, req)
+	}
+
+	tests := []struct {
+		path, origin string
+		allowed      bool
+	}{
+		{"/api/v1/admin/plugins", "https://admin.example", true},
+		{"/api/v1/admin/plugins", "https://widgets.example", false},
+		{"/api/v1/admin/plugins", "https://site.example", false},
+		{"/api/v1/plugins/forms/submit", "https://widgets.example", true},
+		{"/api/v1/plugins/forms/submit", "https://partner.example", true},
+		{"/api/v1/plugins/forms/submit", "https://admin.example", false},
+		{"/api/v1/auth/login", "https://site.example", true},
+		{"/api/v1/auth/login", "https://widgets.example", false},
+		// Prefixes match at a segment boundary only
+		{"/api/v1/administrators", "https://site.example", true},
+	}
+	for _, tt := range tests {
+		w := preflight(tt.path, tt.origin)
+		got := w.Header().Get("Access-Control-Allow-Origin")
+		if allowed := got == tt.origin; allowed != tt.allowed {
+			t.Errorf("%s from %s: Access-Control-Allow-Origin %q, want allowed=%v", tt.path, tt.origin, got, tt.allowed)
+		}
+	}
+
+	// Plugin routes may be embedded anywhere, so they never allow credentials
+	if w := preflight("/api/v1/plugins/forms/submit", "https://widgets.example"); w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Error("plugin policy allows credentials")
+	}
+	if w := preflight("/api/v1/admin/plugins", "https://admin.example"); w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("admin policy does not allow credentials")
+	}
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	deps := testDependencies(t)
+	deps.Config.MaxRequestBodySize = 1024
+	r := Setup(deps)
+
+	// The limit applies before the handler, so no database is needed
+	body := `{"username":"` + strings.Repeat("a", 4096) + `","password":"x"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := serve(r, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized login: status %d, want 413", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"max_bytes":1024`) {
+		t.Errorf("413 body %s does not report the configured limit", w.Body)
+	}
+}
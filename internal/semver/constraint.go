@@ -0,0 +1,73 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// constraintOperators are the comparisons a constraint may use, longest first
+// so ">=" is not read as ">"
+var constraintOperators = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+// IsConstraint reports whether s is a version constraint such as ">=1.9.1"
+// rather than a plain version
+func IsConstraint(s string) bool {
+	s = strings.TrimSpace(s)
+	for _, op := range constraintOperators {
+		if strings.HasPrefix(s, op) {
+			return true
+		}
+	}
+	return false
+}
+
+// Satisfies reports whether version meets constraint. A constraint is one or
+// more comparisons separated by commas, all of which must hold, for example
+// ">=1.9.1, <2.0.0". A comparison without an operator requires that exact
+// version.
+func Satisfies(version, constraint string) (bool, error) {
+	if _, err := parseVersion(version); err != nil {
+		return false, err
+	}
+
+	for _, term := range strings.Split(constraint, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return false, fmt.Errorf("invalid constraint %q", constraint)
+		}
+
+		op := "="
+		for _, candidate := range constraintOperators {
+			if strings.HasPrefix(term, candidate) {
+				op = candidate
+				term = strings.TrimSpace(term[len(candidate):])
+				break
+			}
+		}
+
+		cmp, err := Compare(version, term)
+		if err != nil {
+			return false, fmt.Errorf("invalid constraint %q: %w", constraint, err)
+		}
+
+		var ok bool
+		switch op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		case "!=":
+			ok = cmp != 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,68 @@
+package semver
+
+import "testing"
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version, constraint string
+		want                bool
+	}{
+		{"1.10.1", ">=1.9.1", true},
+		{"1.9.1", ">=1.9.1", true},
+		{"1.9.0", ">=1.9.1", false},
+		{"v1.10.1", ">=v1.9.1", true},
+		{"1.5.0", ">=1.0.0, <2.0.0", true},
+		{"2.0.0", ">=1.0.0, <2.0.0", false},
+		{"2.0.0-rc.1", "<2.0.0", true},
+		{"1.2.3", "1.2.3", true},
+		{"1.2.4", "=1.2.3", false},
+		{"1.2.3", "==1.2.3", true},
+		{"1.2.3", "!=1.2.3", false},
+		{"1.2.3", ">1.2.3", false},
+		{"1.2.3", "<=1.2.3", true},
+		{"1.2", ">=1.2.0", true},
+		{"1.2.3+build.5", "1.2.3", true},
+	}
+	for _, tt := range tests {
+		got, err := Satisfies(tt.version, tt.constraint)
+		if err != nil {
+			t.Errorf("Satisfies(%q, %q): %v", tt.version, tt.constraint, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Satisfies(%q, %q) = %v, want %v", tt.version, tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestSatisfiesErrors(t *testing.T) {
+	tests := []struct {
+		version, constraint string
+	}{
+		{"dev", ">=1.0.0"},
+		{"1.0.0", ">=one"},
+		{"1.0.0", ">=1.0.0,"},
+		{"1.0.0", ""},
+	}
+	for _, tt := range tests {
+		if _, err := Satisfies(tt.version, tt.constraint); err == nil {
+			t.Errorf("Satisfies(%q, %q) succeeded, want an error", tt.version, tt.constraint)
+		}
+	}
+}
+
+func TestIsConstraint(t *testing.T) {
+	for s, want := range map[string]bool{
+		">=1.9.1": true,
+		" <2.0.0": true,
+		"!=1.0.0": true,
+		"v1.9.1":  false,
+		"1.23":    false,
+		"":        false,
+		"latest":  false,
+	} {
+		if got := IsConstraint(s); got != want {
+			t.Errorf("IsConstraint(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
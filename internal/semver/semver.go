@@ -0,0 +1,111 @@
+// Package semver compares semantic version strings.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Compare compares two semantic versions such as "1.4.2" or "v2.0.0-beta.1",
+// returning -1, 0 or 1. Missing minor and patch numbers count as zero, a
+// pre-release sorts before its release, and build metadata is ignored.
+func Compare(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range va.core {
+		if va.core[i] != vb.core[i] {
+			return compareInts(va.core[i], vb.core[i]), nil
+		}
+	}
+	return comparePrerelease(va.prerelease, vb.prerelease), nil
+}
+
+// Valid reports whether s is a version Compare accepts
+func Valid(s string) bool {
+	_, err := parseVersion(s)
+	return err == nil
+}
+
+type version struct {
+	core       [3]int
+	prerelease []string
+}
+
+func parseVersion(s string) (version, error) {
+	var v version
+	text := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	text, _, _ = strings.Cut(text, "+")
+	text, prerelease, hasPrerelease := strings.Cut(text, "-")
+	if hasPrerelease {
+		if prerelease == "" {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v.prerelease = strings.Split(prerelease, ".")
+	}
+
+	parts := strings.Split(text, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v.core[i] = n
+	}
+	return v, nil
+}
+
+// comparePrerelease orders pre-release identifiers as semver does: a release is
+// newer than any pre-release, numeric identifiers compare numerically and sort
+// before alphanumeric ones, and a longer list wins when all shared fields match
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, errA := strconv.Atoi(a[i])
+		nb, errB := strconv.Atoi(b[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return compareInts(na, nb)
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(a), len(b))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
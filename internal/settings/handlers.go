@@ -0,0 +1,90 @@
+package settings
+
+import (
+	"net/http"
+
+	"go-cms/internal/auth"
+	"go-cms/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	manager *Manager
+}
+
+func NewHandler(manager *Manager) *Handler {
+	return &Handler{
+		manager: manager,
+	}
+}
+
+// GetAll returns all site settings with their effective values
+func (h *Handler) GetAll(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"settings": h.manager.All(),
+	})
+}
+
+// Update updates one or more site settings
+func (h *Handler) Update(c *gin.Context) {
+	var updates map[string]interface{}
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		validation.BindError(c, err)
+		return
+	}
+
+	updatedBy := ""
+	if userContext, exists := auth.GetUserFromContext(c); exists {
+		updatedBy = userContext.Username
+	}
+
+	if err := h.manager.SetMany(updates, updatedBy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Settings updated successfully",
+		"settings": h.manager.All(),
+	})
+}
+
+// SetMaintenance turns maintenance mode on or off, optionally updating the
+// message and Retry-After shown to visitors
+func (h *Handler) SetMaintenance(c *gin.Context) {
+	var req struct {
+		Enabled    *bool   `json:"enabled" binding:"required"`
+		Message    *string `json:"message"`
+		RetryAfter *int64  `json:"retry_after"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validation.BindError(c, err)
+		return
+	}
+
+	updates := map[string]interface{}{
+		KeyMaintenanceMode: *req.Enabled,
+	}
+	if req.Message != nil {
+		updates[KeyMaintenanceMessage] = *req.Message
+	}
+	if req.RetryAfter != nil {
+		updates[KeyMaintenanceRetryAfter] = *req.RetryAfter
+	}
+
+	updatedBy := ""
+	if userContext, exists := auth.GetUserFromContext(c); exists {
+		updatedBy = userContext.Username
+	}
+
+	if err := h.manager.SetMany(updates, updatedBy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Maintenance mode updated",
+		"maintenance": h.manager.Maintenance(),
+	})
+}
@@ -0,0 +1,335 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-cms/internal/auth"
+	"go-cms/internal/database"
+	"go-cms/internal/database/models"
+	"go-cms/internal/middleware"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Setting keys
+const (
+	KeyMaxContentSize        = "max_content_size"
+	KeyMaintenanceMode       = "maintenance_mode"
+	KeyMaintenanceMessage    = "maintenance_message"
+	KeyMaintenanceRetryAfter = "maintenance_retry_after" // Seconds
+	KeyRegistrationEnabled   = "registration_enabled"
+	KeyRegistrationInvite    = "registration_invite_only" // Registration requires an invite token
+	KeyPasswordMinLength     = "password_min_length"
+	KeyPasswordRequireUpper  = "password_require_upper"
+	KeyPasswordRequireLower  = "password_require_lower"
+	KeyPasswordRequireDigit  = "password_require_digit"
+	KeyPasswordRequireSymbol = "password_require_symbol"
+	KeyPasswordBlockCommon   = "password_block_common" // Reject passwords on the common-password denylist
+)
+
+// PasswordDefaults returns the setting defaults for the password policy keys
+func PasswordDefaults(policy auth.PasswordPolicy) map[string]interface{} {
+	return map[string]interface{}{
+		KeyPasswordMinLength:     int64(policy.MinLength),
+		KeyPasswordRequireUpper:  policy.RequireUpper,
+		KeyPasswordRequireLower:  policy.RequireLower,
+		KeyPasswordRequireDigit:  policy.RequireDigit,
+		KeyPasswordRequireSymbol: policy.RequireSymbol,
+		KeyPasswordBlockCommon:   policy.BlockCommon,
+	}
+}
+
+// Maintenance defaults
+const (
+	DefaultMaintenanceMessage    = "The site is undergoing maintenance. Please try again shortly."
+	DefaultMaintenanceRetryAfter = int64(300)
+)
+
+// maxMaintenanceMessageLength keeps the 503 body small
+const maxMaintenanceMessageLength = 500
+
+// maxPasswordMinLength caps the configurable minimum; bcrypt ignores bytes past 72
+const maxPasswordMinLength = 72
+
+// mongoDocumentLimit is the hard BSON document size limit enforced by MongoDB
+const mongoDocumentLimit = 16 << 20
+
+// Manager holds site-wide settings, cached in memory and persisted in the
+// "settings" collection. Keys without a stored value fall back to their default.
+type Manager struct {
+	db         *database.DB
+	mu         sync.RWMutex
+	defaults   map[string]interface{}
+	values     map[string]interface{}
+	validators map[string]func(value interface{}) (interface{}, error)
+}
+
+// NewManager creates a settings manager with the given defaults
+func NewManager(db *database.DB, defaults map[string]interface{}) *Manager {
+	m := &Manager{
+		db:       db,
+		defaults: defaults,
+		values:   make(map[string]interface{}),
+	}
+
+	m.validators = map[string]func(value interface{}) (interface{}, error){
+		KeyMaxContentSize:        validateMaxContentSize,
+		KeyMaintenanceMode:       validateBool,
+		KeyMaintenanceMessage:    validateMaintenanceMessage,
+		KeyMaintenanceRetryAfter: validateRetryAfter,
+		KeyRegistrationEnabled:   validateBool,
+		KeyRegistrationInvite:    validateBool,
+		KeyPasswordMinLength:     validatePasswordMinLength,
+		KeyPasswordRequireUpper:  validateBool,
+		KeyPasswordRequireLower:  validateBool,
+		KeyPasswordRequireDigit:  validateBool,
+		KeyPasswordRequireSymbol: validateBool,
+		KeyPasswordBlockCommon:   validateBool,
+	}
+
+	return m
+}
+
+// Load reads all stored settings from the database into memory
+func (m *Manager) Load() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := m.db.Collection("settings").Find(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("failed to read settings: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var stored []models.SiteSetting
+	if err := cursor.All(ctx, &stored); err != nil {
+		return fmt.Errorf("failed to decode settings: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, setting := range stored {
+		m.values[setting.Key] = setting.Value
+	}
+
+	return nil
+}
+
+// Get returns the current value of a setting, falling back to its default
+func (m *Manager) Get(key string) (interface{}, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if value, exists := m.values[key]; exists {
+		return value, true
+	}
+	value, exists := m.defaults[key]
+	return value, exists
+}
+
+// GetInt64 returns a numeric setting as an int64
+func (m *Manager) GetInt64(key string) int64 {
+	value, _ := m.Get(key)
+	n, _ := toInt64(value)
+	return n
+}
+
+// GetBool returns a boolean setting
+func (m *Manager) GetBool(key string) bool {
+	value, _ := m.Get(key)
+	b, _ := value.(bool)
+	return b
+}
+
+// GetString returns a string setting
+func (m *Manager) GetString(key string) string {
+	value, _ := m.Get(key)
+	str, _ := value.(string)
+	return str
+}
+
+// All returns every known setting with its effective value
+func (m *Manager) All() map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	all := make(map[string]interface{}, len(m.defaults)+len(m.values))
+	for key, value := range m.defaults {
+		all[key] = value
+	}
+	for key, value := range m.values {
+		all[key] = value
+	}
+	return all
+}
+
+// Set validates and persists a single setting
+func (m *Manager) Set(key string, value interface{}, updatedBy string) error {
+	return m.SetMany(map[string]interface{}{key: value}, updatedBy)
+}
+
+// SetMany validates every update before persisting any of them
+func (m *Manager) SetMany(updates map[string]interface{}, updatedBy string) error {
+	normalized := make(map[string]interface{}, len(updates))
+	for key, value := range updates {
+		value, err := m.normalize(key, value)
+		if err != nil {
+			return err
+		}
+		normalized[key] = value
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := m.db.Collection("settings")
+	opts := options.Replace().SetUpsert(true)
+
+	for key, value := range normalized {
+		setting := models.SiteSetting{
+			Key:       key,
+			Value:     value,
+			UpdatedAt: time.Now(),
+			UpdatedBy: updatedBy,
+		}
+
+		if _, err := collection.ReplaceOne(ctx, bson.M{"key": key}, setting, opts); err != nil {
+			return fmt.Errorf("failed to save setting %s: %w", key, err)
+		}
+
+		m.mu.Lock()
+		m.values[key] = value
+		m.mu.Unlock()
+	}
+
+	return nil
+}
+
+// normalize checks that key is known and runs its validator
+func (m *Manager) normalize(key string, value interface{}) (interface{}, error) {
+	if _, known := m.defaults[key]; !known {
+		return nil, fmt.Errorf("unknown setting: %s", key)
+	}
+
+	validate, exists := m.validators[key]
+	if !exists {
+		return value, nil
+	}
+
+	normalized, err := validate(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return normalized, nil
+}
+
+// Validators
+
+func validateMaxContentSize(value interface{}) (interface{}, error) {
+	size, ok := toInt64(value)
+	if !ok {
+		return nil, fmt.Errorf("must be a number of bytes")
+	}
+	if size <= 0 || size > mongoDocumentLimit {
+		return nil, fmt.Errorf("must be between 1 and %d bytes", mongoDocumentLimit)
+	}
+	return size, nil
+}
+
+func validateBool(value interface{}) (interface{}, error) {
+	b, ok := value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("must be true or false")
+	}
+	return b, nil
+}
+
+func validateMaintenanceMessage(value interface{}) (interface{}, error) {
+	message, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("must be a string")
+	}
+	if len(message) > maxMaintenanceMessageLength {
+		return nil, fmt.Errorf("must be at most %d characters", maxMaintenanceMessageLength)
+	}
+	return message, nil
+}
+
+func validateRetryAfter(value interface{}) (interface{}, error) {
+	seconds, ok := toInt64(value)
+	if !ok {
+		return nil, fmt.Errorf("must be a number of seconds")
+	}
+	if seconds < 0 || seconds > 24*60*60 {
+		return nil, fmt.Errorf("must be between 0 and 86400 seconds")
+	}
+	return seconds, nil
+}
+
+func validatePasswordMinLength(value interface{}) (interface{}, error) {
+	length, ok := toInt64(value)
+	if !ok {
+		return nil, fmt.Errorf("must be a number of characters")
+	}
+	if length < 1 || length > maxPasswordMinLength {
+		return nil, fmt.Errorf("must be between 1 and %d characters", maxPasswordMinLength)
+	}
+	return length, nil
+}
+
+// toInt64 converts the numeric types produced by JSON and BSON decoding
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, false
+		}
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// Maintenance returns the current maintenance mode state
+func (m *Manager) Maintenance() middleware.MaintenanceStatus {
+	return middleware.MaintenanceStatus{
+		Enabled:    m.GetBool(KeyMaintenanceMode),
+		Message:    m.GetString(KeyMaintenanceMessage),
+		RetryAfter: m.GetInt64(KeyMaintenanceRetryAfter),
+	}
+}
+
+// RegistrationMode reports whether /register is open, closed or invite-only
+func (m *Manager) RegistrationMode() auth.RegistrationMode {
+	switch {
+	case !m.GetBool(KeyRegistrationEnabled):
+		return auth.RegistrationClosed
+	case m.GetBool(KeyRegistrationInvite):
+		return auth.RegistrationInviteOnly
+	default:
+		return auth.RegistrationOpen
+	}
+}
+
+// PasswordPolicy returns the password policy new passwords are checked against
+func (m *Manager) PasswordPolicy() auth.PasswordPolicy {
+	return auth.PasswordPolicy{
+		MinLength:     int(m.GetInt64(KeyPasswordMinLength)),
+		RequireUpper:  m.GetBool(KeyPasswordRequireUpper),
+		RequireLower:  m.GetBool(KeyPasswordRequireLower),
+		RequireDigit:  m.GetBool(KeyPasswordRequireDigit),
+		RequireSymbol: m.GetBool(KeyPasswordRequireSymbol),
+		BlockCommon:   m.GetBool(KeyPasswordBlockCommon),
+	}
+}
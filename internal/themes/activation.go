@@ -0,0 +1,102 @@
+package themes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventThemeActivated is emitted after a theme becomes the active theme
+const EventThemeActivated = "theme.activated"
+
+// ActivationHook runs after a theme is switched to. Returning an error rolls the
+// activation back to the previously active theme.
+type ActivationHook func(theme *Theme) error
+
+// AssetInfo describes a scanned theme asset
+type AssetInfo struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// MissingAssetsError reports theme files that are referenced but not present
+type MissingAssetsError struct {
+	Theme   string
+	Missing []string
+}
+
+func (e *MissingAssetsError) Error() string {
+	return fmt.Sprintf("theme %s is missing required files: %s", e.Theme, strings.Join(e.Missing, ", "))
+}
+
+// OnActivate registers a hook run after each theme activation
+func (m *Manager) OnActivate(hook ActivationHook) {
+	m.activationHooks = append(m.activationHooks, hook)
+}
+
+func (m *Manager) runActivationHooks(theme *Theme) error {
+	for _, hook := range m.activationHooks {
+		if err := hook(theme); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateThemeStructure checks that every asset and template file the theme
+// declares exists on disk
+func validateThemeStructure(theme *Theme) error {
+	var missing []string
+
+	for assetType, assetPath := range theme.Assets {
+		if !fileExists(filepath.Join(theme.Path, assetPath)) {
+			missing = append(missing, fmt.Sprintf("%s (%s)", assetPath, assetType))
+		}
+	}
+
+	for _, template := range theme.Templates {
+		if !fileExists(filepath.Join(theme.Path, template.File)) {
+			missing = append(missing, fmt.Sprintf("%s (template %s)", template.File, template.Name))
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &MissingAssetsError{Theme: theme.Name, Missing: missing}
+	}
+	return nil
+}
+
+// scanAssets warms the theme's asset index so the first request after activation
+// does not have to stat every file
+func scanAssets(theme *Theme) error {
+	index := make(map[string]AssetInfo, len(theme.Assets))
+	var missing []string
+
+	for assetType, assetPath := range theme.Assets {
+		fullPath := filepath.Join(theme.Path, assetPath)
+		info, err := os.Stat(fullPath)
+		if err != nil || info.IsDir() {
+			missing = append(missing, fmt.Sprintf("%s (%s)", assetPath, assetType))
+			continue
+		}
+		index[assetType] = AssetInfo{Path: fullPath, Size: info.Size(), ModTime: info.ModTime()}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &MissingAssetsError{Theme: theme.Name, Missing: missing}
+	}
+
+	theme.assetIndex = index
+	return nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,114 @@
+package themes
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go-cms/internal/events"
+)
+
+// activationManager loads a default theme and a "dark" theme declaring one asset
+// and one template, with the asset file written to disk
+func activationManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	root := t.TempDir()
+	writeTheme(t, root, map[string]interface{}{"name": "default", "version": "1.0.0"})
+	dir := writeTheme(t, root, map[string]interface{}{
+		"name":      "dark",
+		"version":   "2.0.0",
+		"assets":    map[string]string{"css": "assets/style.css"},
+		"templates": []map[string]string{{"name": "home", "file": "templates/home.html"}},
+	})
+	os.MkdirAll(filepath.Join(dir, "assets"), 0755)
+	if err := os.WriteFile(filepath.Join(dir, "assets", "style.css"), []byte("body{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(root, nil)
+	if err := m.LoadThemes(); err != nil {
+		t.Fatal(err)
+	}
+	return m, dir
+}
+
+// recordEvents subscribes to theme.activated and returns the received events
+func recordEvents(m *Manager) *[]events.Event {
+	bus := events.NewBus()
+	var received []events.Event
+	bus.Subscribe(EventThemeActivated, func(event events.Event) { received = append(received, event) })
+	m.SetEventBus(bus)
+	return &received
+}
+
+func TestSetActiveThemeWarmsAssetsAndEmits(t *testing.T) {
+	m, dir := activationManager(t)
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "home.html"), []byte("<h1>home</h1>"), 0644)
+	received := recordEvents(m)
+
+	if err := m.SetActiveTheme("dark"); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.GetActiveTheme() != "dark" {
+		t.Errorf("active theme %q, want dark", m.GetActiveTheme())
+	}
+	dark, _ := m.GetTheme("dark")
+	if info, ok := dark.assetIndex["css"]; !ok || info.Size != int64(len("body{}")) {
+		t.Errorf("asset index = %+v", dark.assetIndex)
+	}
+	if len(*received) != 1 {
+		t.Fatalf("received %d events, want 1", len(*received))
+	}
+	if data := (*received)[0].Data; data["theme"] != "dark" || data["previous"] != DefaultTheme || data["version"] != "2.0.0" {
+		t.Errorf("event data = %v", data)
+	}
+}
+
+func TestSetActiveThemeRefusesMissingFiles(t *testing.T) {
+	m, _ := activationManager(t)
+	received := recordEvents(m)
+
+	err := m.SetActiveTheme("dark")
+	var missingErr *MissingAssetsError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("error = %v, want MissingAssetsError", err)
+	}
+	if want := []string{"templates/home.html (template home)"}; !reflect.DeepEqual(missingErr.Missing, want) {
+		t.Errorf("missing = %v, want %v", missingErr.Missing, want)
+	}
+	if m.GetActiveTheme() != DefaultTheme {
+		t.Errorf("active theme %q after refused activation", m.GetActiveTheme())
+	}
+	if len(*received) != 0 {
+		t.Errorf("received %d events for a refused activation", len(*received))
+	}
+}
+
+func TestFailingActivationHookRollsBack(t *testing.T) {
+	m, dir := activationManager(t)
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "home.html"), []byte("<h1>home</h1>"), 0644)
+	received := recordEvents(m)
+
+	hookErr := errors.New("bundle failed")
+	m.OnActivate(func(theme *Theme) error { return hookErr })
+
+	if err := m.SetActiveTheme("dark"); !errors.Is(err, hookErr) {
+		t.Fatalf("error = %v, want the hook's error", err)
+	}
+	if m.GetActiveTheme() != DefaultTheme {
+		t.Errorf("active theme %q, want rollback to %q", m.GetActiveTheme(), DefaultTheme)
+	}
+	dark, _ := m.GetTheme("dark")
+	defaultTheme, _ := m.GetTheme(DefaultTheme)
+	if dark.IsActive || !defaultTheme.IsActive {
+		t.Errorf("IsActive not rolled back: dark=%v default=%v", dark.IsActive, defaultTheme.IsActive)
+	}
+	if len(*received) != 0 {
+		t.Errorf("received %d events for a rolled back activation", len(*received))
+	}
+}
@@ -0,0 +1,48 @@
+package themes
+
+import (
+	"reflect"
+	"sort"
+)
+
+// EventThemeCustomizationUpdated is emitted after a theme's customization has been
+// saved, so subscribers such as CDN purgers can invalidate cached pages. It is
+// delivered asynchronously and never delays the update. Event data:
+//
+//	theme          string   name of the customized theme
+//	active         bool     whether the theme is the active theme
+//	changed_fields []string sorted paths of the values that changed, e.g.
+//	                        "colors.primary", "layout.sidebar" or "custom_css"
+const EventThemeCustomizationUpdated = "theme.customization.updated"
+
+// changedFields lists the paths of the values that differ between two customizations
+func changedFields(before, after Customization) []string {
+	var changed []string
+	changed = append(changed, changedKeys("colors", before.Colors, after.Colors)...)
+	changed = append(changed, changedKeys("fonts", before.Fonts, after.Fonts)...)
+	changed = append(changed, changedKeys("layout", before.Layout, after.Layout)...)
+	if before.CustomCSS != after.CustomCSS {
+		changed = append(changed, "custom_css")
+	}
+	if before.CustomJS != after.CustomJS {
+		changed = append(changed, "custom_js")
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// changedKeys returns prefix.key for every key added, removed or modified between two maps
+func changedKeys[V any](prefix string, before, after map[string]V) []string {
+	var changed []string
+	for key, value := range after {
+		if previous, exists := before[key]; !exists || !reflect.DeepEqual(previous, value) {
+			changed = append(changed, prefix+"."+key)
+		}
+	}
+	for key := range before {
+		if _, exists := after[key]; !exists {
+			changed = append(changed, prefix+"."+key)
+		}
+	}
+	return changed
+}
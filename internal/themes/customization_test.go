@@ -0,0 +1,69 @@
+package themes
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go-cms/internal/events"
+)
+
+func TestChangedFields(t *testing.T) {
+	before := Customization{
+		Colors:    map[string]string{"primary": "#000000", "accent": "#111111"},
+		Layout:    map[string]interface{}{"sidebar": map[string]interface{}{"width": 200}},
+		CustomCSS: "body{}",
+	}
+	after := Customization{
+		Colors:    map[string]string{"primary": "#000000", "text": "#222222"},
+		Fonts:     map[string]string{"body": "Inter"},
+		Layout:    map[string]interface{}{"sidebar": map[string]interface{}{"width": 240}},
+		CustomCSS: "body{}",
+		CustomJS:  "init()",
+	}
+
+	want := []string{"colors.accent", "colors.text", "custom_js", "fonts.body", "layout.sidebar"}
+	if got := changedFields(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("changedFields = %v, want %v", got, want)
+	}
+	if got := changedFields(after, after); len(got) != 0 {
+		t.Errorf("unchanged customization reported %v", got)
+	}
+}
+
+func TestCustomizationUpdateEmitsEvent(t *testing.T) {
+	m := loadedManager(t, "default", "dark")
+	bus := events.NewBus()
+	received := make(chan events.Event, 1)
+	bus.Subscribe(EventThemeCustomizationUpdated, func(event events.Event) { received <- event })
+	m.SetEventBus(bus)
+
+	err := m.UpdateThemeCustomization("dark", Customization{Colors: map[string]string{"primary": "#abc"}, CustomCSS: "h1{}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-received:
+		want := map[string]interface{}{
+			"theme":          "dark",
+			"active":         false,
+			"changed_fields": []string{"colors.primary", "custom_css"},
+		}
+		if !reflect.DeepEqual(event.Data, want) {
+			t.Errorf("event data = %v, want %v", event.Data, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no theme.customization.updated event")
+	}
+
+	// Rejected updates change nothing and emit nothing
+	if err := m.UpdateThemeCustomization("dark", Customization{Colors: map[string]string{"primary": "reddish"}}); err == nil {
+		t.Fatal("invalid color accepted")
+	}
+	select {
+	case event := <-received:
+		t.Errorf("rejected update emitted %v", event.Data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
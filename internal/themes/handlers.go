@@ -1,21 +1,41 @@
 package themes
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"go-cms/internal/auth"
+	"go-cms/internal/config"
+	"go-cms/internal/httputil"
+	"go-cms/internal/patch"
+	"go-cms/internal/validation"
 
 	"github.com/gin-gonic/gin"
 )
 
+// zipMagic is the signature at the start of every zip local file header
+var zipMagic = []byte("PK\x03\x04")
+
 type Handler struct {
-	manager *Manager
+	manager       *Manager
+	tempDir       string
+	maxUploadSize int64
 }
 
-func NewHandler(manager *Manager) *Handler {
+func NewHandler(manager *Manager, cfg *config.Config) *Handler {
 	return &Handler{
-		manager: manager,
+		manager:       manager,
+		tempDir:       cfg.TempDir,
+		maxUploadSize: cfg.MaxUploadSize,
 	}
 }
 
@@ -23,24 +43,15 @@ func NewHandler(manager *Manager) *Handler {
 func (h *Handler) GetAll(c *gin.Context) {
 	themes := h.manager.GetAllThemes()
 
-	var themeList []gin.H
-	for _, theme := range themes {
-		themeData := gin.H{
-			"name":             theme.Name,
-			"version":          theme.Version,
-			"description":      theme.Description,
-			"author":           theme.Author,
-			"author_uri":       theme.AuthorURI,
-			"screenshot":       theme.Screenshot,
-			"tags":             theme.Tags,
-			"min_version":      theme.MinVersion,
-			"required_plugins": theme.RequiredPlugins,
-			"is_active":        theme.IsActive,
-			"installed_at":     theme.InstalledAt,
-			"updated_at":       theme.UpdatedAt,
-		}
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-		themeList = append(themeList, themeData)
+	themeList := make([]ThemeResponse, 0, len(names))
+	for _, name := range names {
+		themeList = append(themeList, toResponse(themes[name], assetPaths(themes[name])))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -61,32 +72,76 @@ func (h *Handler) GetTheme(c *gin.Context) {
 
 	// Get theme assets
 	assets, err := h.manager.GetThemeAssets(themeName)
+	if errors.Is(err, ErrThemeNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Theme not found"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get theme assets"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get theme assets: %v", err)})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"theme": gin.H{
-			"name":             theme.Name,
-			"version":          theme.Version,
-			"description":      theme.Description,
-			"author":           theme.Author,
-			"author_uri":       theme.AuthorURI,
-			"screenshot":       theme.Screenshot,
-			"tags":             theme.Tags,
-			"min_version":      theme.MinVersion,
-			"required_plugins": theme.RequiredPlugins,
-			"templates":        theme.Templates,
-			"customization":    theme.Customization,
-			"is_active":        theme.IsActive,
-			"installed_at":     theme.InstalledAt,
-			"updated_at":       theme.UpdatedAt,
-			"assets":           assets,
-		},
+		"theme": toResponse(theme, assets),
 	})
 }
 
+// ThemeResponse is the shape every theme endpoint returns
+type ThemeResponse struct {
+	Name            string            `json:"name"`
+	Version         string            `json:"version"`
+	Description     string            `json:"description"`
+	Author          string            `json:"author"`
+	AuthorURI       string            `json:"author_uri"`
+	Screenshot      string            `json:"screenshot"`
+	Tags            []string          `json:"tags"`
+	MinVersion      string            `json:"min_version"`
+	RequiredPlugins []string          `json:"required_plugins"`
+	Templates       []Template        `json:"templates"`
+	Customization   Customization     `json:"customization"`
+	Assets          map[string]string `json:"assets"`
+	IsActive        bool              `json:"is_active"`
+	InstalledAt     time.Time         `json:"installed_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// toResponse serializes a theme. Lists are never null so clients can iterate
+// them without checks.
+func toResponse(theme *Theme, assets map[string]string) ThemeResponse {
+	response := ThemeResponse{
+		Name:            theme.Name,
+		Version:         theme.Version,
+		Description:     theme.Description,
+		Author:          theme.Author,
+		AuthorURI:       theme.AuthorURI,
+		Screenshot:      theme.Screenshot,
+		Tags:            theme.Tags,
+		MinVersion:      theme.MinVersion,
+		RequiredPlugins: theme.RequiredPlugins,
+		Templates:       theme.Templates,
+		Customization:   theme.Customization,
+		Assets:          assets,
+		IsActive:        theme.IsActive,
+		InstalledAt:     theme.InstalledAt,
+		UpdatedAt:       theme.UpdatedAt,
+	}
+
+	if response.Tags == nil {
+		response.Tags = []string{}
+	}
+	if response.RequiredPlugins == nil {
+		response.RequiredPlugins = []string{}
+	}
+	if response.Templates == nil {
+		response.Templates = []Template{}
+	}
+	if response.Assets == nil {
+		response.Assets = map[string]string{}
+	}
+
+	return response
+}
+
 // ActivateTheme activates a specific theme
 func (h *Handler) ActivateTheme(c *gin.Context) {
 	themeName := c.Param("name")
@@ -107,6 +162,14 @@ func (h *Handler) ActivateTheme(c *gin.Context) {
 	// Activate theme
 	err := h.manager.SetActiveTheme(themeName)
 	if err != nil {
+		var missingErr *MissingAssetsError
+		if errors.As(err, &missingErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   err.Error(),
+				"missing": missingErr.Missing,
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -117,20 +180,62 @@ func (h *Handler) ActivateTheme(c *gin.Context) {
 	})
 }
 
+// DeactivateTheme deactivates a theme and falls back to the default theme
+func (h *Handler) DeactivateTheme(c *gin.Context) {
+	themeName := c.Param("name")
+
+	// Get user context
+	userContext, exists := auth.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User context not found"})
+		return
+	}
+
+	// Check if user has permission (admin or super admin)
+	if userContext.Role != "admin" && userContext.Role != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return
+	}
+
+	// Deactivate theme
+	err := h.manager.DeactivateTheme(themeName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Theme deactivated successfully",
+		"active_theme": h.manager.GetActiveTheme(),
+	})
+}
+
 // GetCustomization returns theme customization settings
 func (h *Handler) GetCustomization(c *gin.Context) {
 	themeName := c.Param("name")
 
-	customization, err := h.manager.GetThemeCustomization(themeName)
+	customization, updatedAt, err := h.manager.GetThemeCustomizationWithTime(themeName)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	body := gin.H{
 		"theme":         themeName,
 		"customization": customization,
-	})
+	}
+
+	// The live customizer polls this endpoint, so let unchanged responses be revalidated
+	etag, err := httputil.ETag(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode customization"})
+		return
+	}
+	if httputil.NotModified(c, etag, updatedAt) {
+		return
+	}
+
+	c.JSON(http.StatusOK, body)
 }
 
 // UpdateCustomization updates theme customization settings
@@ -152,14 +257,48 @@ func (h *Handler) UpdateCustomization(c *gin.Context) {
 
 	var customization Customization
 	if err := c.ShouldBindJSON(&customization); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		validation.BindError(c, err)
 		return
 	}
 
 	// Update customization
 	err := h.manager.UpdateThemeCustomization(themeName, customization)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		customizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Theme customization updated successfully",
+		"theme":         themeName,
+		"customization": customization,
+	})
+}
+
+// PatchCustomization applies a JSON merge patch to the theme customization. Fields
+// absent from the body are left unchanged and fields set to null are cleared.
+func (h *Handler) PatchCustomization(c *gin.Context) {
+	themeName := c.Param("name")
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	customization, err := h.manager.GetThemeCustomization(themeName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := patch.Apply(&customization, body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.manager.UpdateThemeCustomization(themeName, customization); err != nil {
+		customizationError(c, err)
 		return
 	}
 
@@ -170,6 +309,20 @@ func (h *Handler) UpdateCustomization(c *gin.Context) {
 	})
 }
 
+// customizationError responds to a failed customization update, reporting invalid
+// colors per key as a validation failure
+func customizationError(c *gin.Context, err error) {
+	var colorsErr *InvalidColorsError
+	if errors.As(err, &colorsErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Validation failed",
+			"fields": colorsErr.Fields,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
 // InstallTheme handles theme installation
 func (h *Handler) InstallTheme(c *gin.Context) {
 	// Get user context
@@ -193,24 +346,99 @@ func (h *Handler) InstallTheme(c *gin.Context) {
 	}
 	defer file.Close()
 
+	log.Printf("[THEME_UPLOAD] Received file: %s, size: %d bytes", header.Filename, header.Size)
+
+	// Validate file size
+	if header.Size > h.maxUploadSize {
+		log.Printf("[THEME_UPLOAD] File too large: %d bytes (max: %d)", header.Size, h.maxUploadSize)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("File too large. Maximum size is %d bytes", h.maxUploadSize),
+		})
+		return
+	}
+
 	// Validate file (should be a zip file)
-	if filepath.Ext(header.Filename) != ".zip" {
+	if strings.ToLower(filepath.Ext(header.Filename)) != ".zip" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Theme must be a zip file"})
 		return
 	}
 
-	// In a real implementation, you would:
-	// 1. Extract the zip file
-	// 2. Validate theme structure
-	// 3. Install the theme
+	// Save uploaded file temporarily
+	if err := os.MkdirAll(h.tempDir, 0755); err != nil {
+		log.Printf("[THEME_UPLOAD] Failed to create temp directory: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp directory"})
+		return
+	}
+
+	tempPath := filepath.Join(h.tempDir, fmt.Sprintf("theme_%d_%s", time.Now().UnixNano(), filepath.Base(header.Filename)))
+	defer func() {
+		if err := os.Remove(tempPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to remove temp file %s: %v", tempPath, err)
+		}
+	}()
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":  "Theme installation would be implemented here",
-		"filename": header.Filename,
+	if err := saveLimited(file, tempPath, h.maxUploadSize); err != nil {
+		log.Printf("[THEME_UPLOAD] Failed to save uploaded file: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate the archive signature rather than trusting the extension
+	if err := checkZipMagic(tempPath); err != nil {
+		log.Printf("[THEME_UPLOAD] Theme validation failed: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Theme validation failed",
+			"details":  []string{err.Error()},
+			"warnings": []string{},
+		})
+		return
+	}
+
+	theme, err := h.manager.InstallThemeFromZip(tempPath)
+	if err != nil {
+		log.Printf("[THEME_UPLOAD] Theme installation failed: %v", err)
+		switch {
+		case errors.Is(err, ErrInvalidTheme):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":    "Theme validation failed",
+				"details":  []string{err.Error()},
+				"warnings": []string{},
+			})
+		case errors.Is(err, ErrThemeExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to install theme"})
+		}
+		return
+	}
+
+	log.Printf("[THEME_UPLOAD] Installed theme %s v%s", theme.Name, theme.Version)
+	httputil.Created(c, "/api/v1/themes/"+theme.Name, gin.H{
+		"message": "Theme installed successfully",
+		"theme":   toResponse(theme, assetPaths(theme)),
 	})
 }
 
-// UninstallTheme handles theme removal
+// PreviewUninstall reports what uninstalling a theme would delete, without
+// changing anything
+func (h *Handler) PreviewUninstall(c *gin.Context) {
+	themeName := c.Param("name")
+
+	preview, err := h.manager.PreviewUninstall(themeName)
+	if err != nil {
+		if errors.Is(err, ErrThemeNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Theme not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preview": preview})
+}
+
+// UninstallTheme handles theme removal. Records that reference the theme block
+// the removal unless ?cascade=true, which deletes them too.
 func (h *Handler) UninstallTheme(c *gin.Context) {
 	themeName := c.Param("name")
 
@@ -228,9 +456,22 @@ func (h *Handler) UninstallTheme(c *gin.Context) {
 	}
 
 	// Uninstall theme
-	err := h.manager.UninstallTheme(themeName)
+	err := h.manager.UninstallTheme(themeName, c.Query("cascade") == "true")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		var referencedErr *ReferencedThemeError
+		switch {
+		case errors.Is(err, ErrThemeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Theme not found"})
+		case errors.Is(err, ErrActiveTheme):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.As(err, &referencedErr):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":      "Theme is still referenced; retry with ?cascade=true to delete the references",
+				"references": referencedErr.References,
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -239,3 +480,36 @@ func (h *Handler) UninstallTheme(c *gin.Context) {
 		"theme":   themeName,
 	})
 }
+
+// saveLimited copies src to path, failing if more than maxBytes are read
+func saveLimited(src io.Reader, path string, maxBytes int64) error {
+	dst, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to save uploaded file")
+	}
+	defer dst.Close()
+
+	written, err := io.Copy(dst, io.LimitReader(src, maxBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to save uploaded file")
+	}
+	if written > maxBytes {
+		return fmt.Errorf("file too large. Maximum size is %d bytes", maxBytes)
+	}
+	return nil
+}
+
+// checkZipMagic verifies the file starts with a zip signature
+func checkZipMagic(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot read uploaded file")
+	}
+	defer f.Close()
+
+	header := make([]byte, len(zipMagic))
+	if _, err := io.ReadFull(f, header); err != nil || !bytes.Equal(header, zipMagic) {
+		return fmt.Errorf("file is not a valid zip archive")
+	}
+	return nil
+}
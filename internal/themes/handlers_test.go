@@ -0,0 +1,220 @@
+package themes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-cms/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// themeEngine serves the theme endpoints for m as a super admin
+func themeEngine(t *testing.T, m *Manager) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(m, &config.Config{TempDir: t.TempDir(), MaxUploadSize: 1 << 20})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "1")
+		c.Set("username", "root")
+		c.Set("email", "root@example.com")
+		c.Set("role", "super_admin")
+	})
+	r.GET("/themes", h.GetAll)
+	r.GET("/themes/:name", h.GetTheme)
+	r.GET("/themes/:name/customization", h.GetCustomization)
+	r.PUT("/themes/:name/customization", h.UpdateCustomization)
+	r.PATCH("/themes/:name/customization", h.PatchCustomization)
+	r.GET("/themes/:name/uninstall-preview", h.PreviewUninstall)
+	r.DELETE("/themes/:name", h.UninstallTheme)
+	return r
+}
+
+// request sends a request with an optional JSON body and extra headers
+func request(r http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// loadedManager returns a manager with the given themes installed and loaded
+func loadedManager(t *testing.T, names ...string) *Manager {
+	t.Helper()
+	root := t.TempDir()
+	for _, name := range names {
+		writeTheme(t, root, map[string]interface{}{"name": name, "version": "1.0.0"})
+	}
+	m := NewManager(root, nil)
+	if err := m.LoadThemes(); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestPatchCustomizationAbsentVersusNull(t *testing.T) {
+	m := loadedManager(t, "default")
+	r := themeEngine(t, m)
+
+	w := request(r, http.MethodPut, "/themes/default/customization",
+		`{"colors": {"primary": "#112233", "accent": "#445566"}, "fonts": {"body": "Inter"}, "custom_css": "body{}"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("put: status %d: %s", w.Code, w.Body)
+	}
+
+	// Absent fields stay, null clears, nested objects merge
+	w = request(r, http.MethodPatch, "/themes/default/customization",
+		`{"colors": {"accent": null, "text": "#000"}, "custom_css": null}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("patch: status %d: %s", w.Code, w.Body)
+	}
+
+	got, err := m.GetThemeCustomization("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Customization{
+		Colors: map[string]string{"primary": "#112233", "text": "#000000"},
+		Fonts:  map[string]string{"body": "Inter"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("customization = %+v, want %+v", got, want)
+	}
+}
+
+func TestPatchCustomizationRejectsInvalidPatches(t *testing.T) {
+	m := loadedManager(t, "default")
+	r := themeEngine(t, m)
+
+	if w := request(r, http.MethodPatch, "/themes/default/customization", `[1, 2]`); w.Code != http.StatusBadRequest {
+		t.Errorf("non-object patch: status %d, want 400", w.Code)
+	}
+	if w := request(r, http.MethodPatch, "/themes/missing/customization", `{}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown theme: status %d, want 404", w.Code)
+	}
+}
+
+func TestGetThemeResponses(t *testing.T) {
+	m := loadedManager(t, "default", "bare")
+	r := themeEngine(t, m)
+
+	if w := request(r, http.MethodGet, "/themes/missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("missing theme: status %d, want 404", w.Code)
+	}
+
+	// A theme without assets is served, with empty lists rather than nulls
+	w := request(r, http.MethodGet, "/themes/bare", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("theme without assets: status %d: %s", w.Code, w.Body)
+	}
+	var single struct {
+		Theme map[string]json.RawMessage `json:"theme"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &single); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"tags", "required_plugins", "templates", "assets"} {
+		if raw := string(single.Theme[field]); raw != "[]" && raw != "{}" {
+			t.Errorf("%s = %s, want empty", field, raw)
+		}
+	}
+
+	// The list serializes each theme exactly as the single-theme endpoint does
+	w = request(r, http.MethodGet, "/themes", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: status %d", w.Code)
+	}
+	var list struct {
+		Themes []map[string]json.RawMessage `json:"themes"`
+		Active string                       `json:"active"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Themes) != 2 || list.Active != DefaultTheme {
+		t.Fatalf("list = %d themes, active %q", len(list.Themes), list.Active)
+	}
+	if listed := list.Themes[0]; string(listed["name"]) != `"bare"` || !reflect.DeepEqual(listed, single.Theme) {
+		t.Errorf("listed theme %v differs from single theme %v", listed, single.Theme)
+	}
+}
+
+func TestUpdateCustomizationValidatesColors(t *testing.T) {
+	m := loadedManager(t, "default")
+	r := themeEngine(t, m)
+
+	w := request(r, http.MethodPut, "/themes/default/customization",
+		`{"colors": {"primary": "reddish", "accent": "#12", "text": "#FFF"}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid colors: status %d, want 400", w.Code)
+	}
+	var body struct {
+		Fields map[string]string `json:"fields"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if len(body.Fields) != 2 || body.Fields["colors.primary"] == "" || body.Fields["colors.accent"] == "" {
+		t.Errorf("fields = %v, want colors.primary and colors.accent", body.Fields)
+	}
+	if got, _ := m.GetThemeCustomization("default"); got.Colors != nil {
+		t.Errorf("rejected colors were stored: %v", got.Colors)
+	}
+
+	// Valid colors are stored with shorthand hex expanded
+	w = request(r, http.MethodPut, "/themes/default/customization",
+		`{"colors": {"primary": "#FFF", "accent": "rgba(0,0,0,0.5)", "text": "navy"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("valid colors: status %d: %s", w.Code, w.Body)
+	}
+	want := map[string]string{"primary": "#ffffff", "accent": "rgba(0,0,0,0.5)", "text": "navy"}
+	if got, _ := m.GetThemeCustomization("default"); !reflect.DeepEqual(got.Colors, want) {
+		t.Errorf("stored colors = %v, want %v", got.Colors, want)
+	}
+}
+
+func TestGetCustomizationConditional(t *testing.T) {
+	m := loadedManager(t, "default")
+	r := themeEngine(t, m)
+
+	w := request(r, http.MethodGet, "/themes/default/customization", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Header().Get("Last-Modified") == "" {
+		t.Fatalf("get: status %d, headers %v", w.Code, w.Header())
+	}
+
+	w = request(r, http.MethodGet, "/themes/default/customization", "", "If-None-Match", etag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("unchanged: status %d, %d body bytes, want 304 and none", w.Code, w.Body.Len())
+	}
+
+	// A change deep inside the layout must produce a new tag
+	if w := request(r, http.MethodPatch, "/themes/default/customization", `{"layout": {"header": {"sticky": true}}}`); w.Code != http.StatusOK {
+		t.Fatalf("patch: status %d", w.Code)
+	}
+	w = request(r, http.MethodGet, "/themes/default/customization", "", "If-None-Match", etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("after update: status %d, want 200", w.Code)
+	}
+	newTag := w.Header().Get("ETag")
+	if newTag == etag {
+		t.Error("ETag did not change after the customization changed")
+	}
+
+	if w := request(r, http.MethodPatch, "/themes/default/customization", `{"layout": {"header": {"sticky": false}}}`); w.Code != http.StatusOK {
+		t.Fatalf("second patch: status %d", w.Code)
+	}
+	if w := request(r, http.MethodGet, "/themes/default/customization", "", "If-None-Match", newTag); w.Code != http.StatusOK {
+		t.Errorf("after nested change: status %d, want 200", w.Code)
+	}
+}
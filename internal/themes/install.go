@@ -0,0 +1,185 @@
+package themes
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Hard limits on what a theme zip may extract, guarding against zip bombs
+var (
+	maxThemeFiles           = 5000
+	maxThemeTotalSize int64 = 200 << 20 // Uncompressed bytes across all files
+)
+
+var (
+	// ErrInvalidTheme is returned when an uploaded archive is not an installable theme
+	ErrInvalidTheme = errors.New("invalid theme archive")
+
+	// ErrThemeExists is returned when installing a theme whose name is already taken
+	ErrThemeExists = errors.New("theme is already installed")
+)
+
+// InstallThemeFromZip extracts a theme archive into the theme directory and loads
+// it. The archive holds metadata.json at its root or inside a single top-level
+// folder. The archive is staged first, so nothing is left behind when it is
+// invalid or its theme is already installed.
+func (m *Manager) InstallThemeFromZip(zipPath string) (*Theme, error) {
+	if err := os.MkdirAll(m.themePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create theme directory: %w", err)
+	}
+
+	// Stage on the same filesystem so the final move is a rename
+	staging, err := os.MkdirTemp(m.themePath, ".install-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if err := extractTheme(zipPath, staging); err != nil {
+		return nil, err
+	}
+
+	root, err := themeRoot(staging)
+	if err != nil {
+		return nil, err
+	}
+
+	theme, err := readMetadata(root)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTheme, err)
+	}
+	if !validThemeName(theme.Name) {
+		return nil, fmt.Errorf("%w: theme name %q must be a plain directory name", ErrInvalidTheme, theme.Name)
+	}
+
+	// A directory that failed to load still belongs to someone; never replace it
+	themeDir := filepath.Join(m.themePath, theme.Name)
+	if _, exists := m.themes[theme.Name]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrThemeExists, theme.Name)
+	}
+	if _, err := os.Stat(themeDir); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrThemeExists, theme.Name)
+	}
+
+	if err := os.Rename(root, themeDir); err != nil {
+		return nil, fmt.Errorf("failed to move theme into place: %w", err)
+	}
+	if err := m.loadTheme(themeDir); err != nil {
+		os.RemoveAll(themeDir)
+		return nil, err
+	}
+
+	m.notifyChange()
+	return m.themes[theme.Name], nil
+}
+
+// validThemeName reports whether name can be used as the theme's directory name
+func validThemeName(name string) bool {
+	return name != "" && name != "." && name != ".." &&
+		!strings.ContainsAny(name, `/\`) && !strings.HasPrefix(name, ".")
+}
+
+// extractTheme extracts every entry of the zip into dir, rejecting paths that
+// escape it and archives over the file count or total size limits
+func extractTheme(zipPath, dir string) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTheme, err)
+	}
+	defer reader.Close()
+
+	if len(reader.File) > maxThemeFiles {
+		return fmt.Errorf("%w: more than %d files", ErrInvalidTheme, maxThemeFiles)
+	}
+
+	var total int64
+	for _, file := range reader.File {
+		name := filepath.Clean(file.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%w: invalid file path %s", ErrInvalidTheme, file.Name)
+		}
+		destPath := filepath.Join(dir, name)
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(destPath, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		written, err := extractThemeFile(file, destPath, maxThemeTotalSize-total)
+		if err != nil {
+			return err
+		}
+		total += written
+	}
+
+	return nil
+}
+
+// extractThemeFile writes one zip entry to destPath, failing once more than
+// remaining bytes have been written. The size in the zip header is not trusted.
+func extractThemeFile(file *zip.File, destPath string, remaining int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return 0, err
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidTheme, err)
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	written, err := io.Copy(out, io.LimitReader(rc, remaining+1))
+	if err != nil {
+		return written, fmt.Errorf("%w: failed to extract %s: %v", ErrInvalidTheme, file.Name, err)
+	}
+	if written > remaining {
+		return written, fmt.Errorf("%w: uncompressed size exceeds %d bytes", ErrInvalidTheme, maxThemeTotalSize)
+	}
+	return written, out.Close()
+}
+
+// themeRoot returns the directory holding metadata.json: dir itself, or its only
+// folder when a folder was zipped instead of its contents
+func themeRoot(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "metadata.json")); err == nil {
+		return dir, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var folders []string
+	for _, entry := range entries {
+		// Skip metadata added by archiving tools
+		if entry.Name() == "__MACOSX" || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if !entry.IsDir() {
+			return "", fmt.Errorf("%w: metadata.json not found", ErrInvalidTheme)
+		}
+		folders = append(folders, entry.Name())
+	}
+	if len(folders) != 1 {
+		return "", fmt.Errorf("%w: metadata.json not found", ErrInvalidTheme)
+	}
+
+	root := filepath.Join(dir, folders[0])
+	if _, err := os.Stat(filepath.Join(root, "metadata.json")); err != nil {
+		return "", fmt.Errorf("%w: metadata.json not found", ErrInvalidTheme)
+	}
+	return root, nil
+}
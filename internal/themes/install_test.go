@@ -0,0 +1,221 @@
+package themes
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-cms/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeThemeZip writes a zip holding files, keyed by their path in the archive
+func writeThemeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "theme.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// metadataJSON returns a metadata.json body for a theme
+func metadataJSON(name, version string) string {
+	data, _ := json.Marshal(map[string]string{"name": name, "version": version})
+	return string(data)
+}
+
+// leftovers lists entries in the theme directory other than installed themes
+func leftovers(t *testing.T, root string, installed ...string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var extra []string
+outer:
+	for _, entry := range entries {
+		for _, name := range installed {
+			if entry.Name() == name {
+				continue outer
+			}
+		}
+		extra = append(extra, entry.Name())
+	}
+	return extra
+}
+
+func TestInstallThemeFromZip(t *testing.T) {
+	root := t.TempDir()
+	m := NewManager(root, nil)
+
+	// Zipping the folder rather than its contents leaves the theme one level down
+	zipPath := writeThemeZip(t, map[string]string{
+		"sunrise/metadata.json":  metadataJSON("sunrise", "1.2.0"),
+		"sunrise/templates/home": "<h1>home</h1>",
+		"__MACOSX/._sunrise":     "junk",
+	})
+
+	theme, err := m.InstallThemeFromZip(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Name != "sunrise" || theme.Version != "1.2.0" || theme.Path != filepath.Join(root, "sunrise") {
+		t.Errorf("installed theme = %+v", theme)
+	}
+	if _, ok := m.GetTheme("sunrise"); !ok {
+		t.Error("installed theme is not loaded")
+	}
+	if _, err := os.Stat(filepath.Join(root, "sunrise", "templates", "home")); err != nil {
+		t.Errorf("theme files not extracted: %v", err)
+	}
+	if extra := leftovers(t, root, "sunrise"); len(extra) > 0 {
+		t.Errorf("staging files left behind: %v", extra)
+	}
+
+	// Installing the same name again must not replace the installed theme
+	_, err = m.InstallThemeFromZip(writeThemeZip(t, map[string]string{"metadata.json": metadataJSON("sunrise", "2.0.0")}))
+	if !errors.Is(err, ErrThemeExists) {
+		t.Fatalf("reinstall error = %v, want ErrThemeExists", err)
+	}
+	if theme, _ := m.GetTheme("sunrise"); theme.Version != "1.2.0" {
+		t.Errorf("installed version changed to %s", theme.Version)
+	}
+}
+
+func TestInstallThemeFromZipRejectsInvalidArchives(t *testing.T) {
+	tests := map[string]map[string]string{
+		"no metadata":     {"index.html": "<html>"},
+		"bad metadata":    {"metadata.json": "{"},
+		"unnamed":         {"metadata.json": metadataJSON("", "1.0.0")},
+		"name with path":  {"metadata.json": metadataJSON("../escape", "1.0.0")},
+		"hidden name":     {"metadata.json": metadataJSON(".hidden", "1.0.0")},
+		"path traversal":  {"metadata.json": metadataJSON("ok", "1.0.0"), "../outside.txt": "x"},
+		"two top folders": {"a/metadata.json": metadataJSON("a", "1.0.0"), "b/metadata.json": metadataJSON("b", "1.0.0")},
+	}
+
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "themes")
+			m := NewManager(root, nil)
+
+			_, err := m.InstallThemeFromZip(writeThemeZip(t, files))
+			if !errors.Is(err, ErrInvalidTheme) {
+				t.Fatalf("error = %v, want ErrInvalidTheme", err)
+			}
+			if n := len(m.GetAllThemes()); n != 0 {
+				t.Errorf("%d themes loaded", n)
+			}
+			if extra := leftovers(t, root); len(extra) > 0 {
+				t.Errorf("files left behind: %v", extra)
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(root), "outside.txt")); err == nil {
+				t.Error("archive wrote outside the theme directory")
+			}
+		})
+	}
+}
+
+func TestInstallThemeFromZipRejectsOversizedArchives(t *testing.T) {
+	defer func(size int64) { maxThemeTotalSize = size }(maxThemeTotalSize)
+	maxThemeTotalSize = 4096
+
+	root := t.TempDir()
+	m := NewManager(root, nil)
+
+	// Many files each under the cap must still be refused once their total is over it
+	files := map[string]string{"metadata.json": metadataJSON("huge", "1.0.0")}
+	for i := 0; i < 10; i++ {
+		files[fmt.Sprintf("assets/%d.css", i)] = strings.Repeat("a", 1000)
+	}
+
+	_, err := m.InstallThemeFromZip(writeThemeZip(t, files))
+	if !errors.Is(err, ErrInvalidTheme) {
+		t.Fatalf("error = %v, want ErrInvalidTheme", err)
+	}
+	if extra := leftovers(t, root); len(extra) > 0 {
+		t.Errorf("files left behind: %v", extra)
+	}
+}
+
+func TestInstallThemeHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := t.TempDir()
+	cfg := &config.Config{TempDir: t.TempDir(), MaxUploadSize: 1 << 20}
+	h := NewHandler(NewManager(root, nil), cfg)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "1")
+		c.Set("username", "root")
+		c.Set("email", "root@example.com")
+		c.Set("role", "super_admin")
+	})
+	r.POST("/themes/upload", h.InstallTheme)
+
+	upload := func(filename string, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("theme", filename)
+		part.Write(content)
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/themes/upload", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	archive, err := os.ReadFile(writeThemeZip(t, map[string]string{"metadata.json": metadataJSON("sunrise", "1.0.0")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := upload("sunrise.zip", archive)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("upload: status %d: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Location"); got != "http://example.com/api/v1/themes/sunrise" {
+		t.Errorf("Location %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "sunrise", "metadata.json")); err != nil {
+		t.Errorf("theme not installed: %v", err)
+	}
+
+	if w := upload("sunrise.zip", archive); w.Code != http.StatusConflict {
+		t.Errorf("second upload: status %d, want 409", w.Code)
+	}
+	if w := upload("fake.zip", []byte("not a zip")); w.Code != http.StatusBadRequest {
+		t.Errorf("non-zip upload: status %d, want 400", w.Code)
+	}
+
+	// Uploads are removed from the temp directory however they end
+	if entries, _ := os.ReadDir(cfg.TempDir); len(entries) > 0 {
+		t.Errorf("%d temp files left behind", len(entries))
+	}
+}
@@ -3,25 +3,39 @@ package themes
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"go-cms/internal/database"
 	"go-cms/internal/database/models"
+	"go-cms/internal/events"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// DefaultTheme is the theme that is activated when another theme is deactivated
+const DefaultTheme = "default"
+
 type Manager struct {
 	themes    map[string]*Theme
 	themePath string
 	active    string
 	db        *database.DB
+	listeners []func()
+	events    *events.Bus
+
+	activationHooks []ActivationHook
 }
 
+// ErrThemeNotFound is returned when a theme name is not installed
+var ErrThemeNotFound = errors.New("theme not found")
+
 type Theme struct {
 	Name            string            `json:"name"`
 	Version         string            `json:"version"`
@@ -39,6 +53,8 @@ type Theme struct {
 	IsActive        bool              `json:"is_active"`
 	InstalledAt     time.Time         `json:"installed_at"`
 	UpdatedAt       time.Time         `json:"updated_at"`
+
+	assetIndex map[string]AssetInfo // Populated when the theme is activated
 }
 
 type Template struct {
@@ -57,12 +73,32 @@ type Customization struct {
 }
 
 func NewManager(themePath string, db *database.DB) *Manager {
-	return &Manager{
+	m := &Manager{
 		themes:    make(map[string]*Theme),
 		themePath: themePath,
-		active:    "default",
+		active:    DefaultTheme,
 		db:        db,
 	}
+
+	m.OnActivate(scanAssets)
+
+	return m
+}
+
+// SetEventBus sets the bus that theme lifecycle events are emitted on
+func (m *Manager) SetEventBus(bus *events.Bus) {
+	m.events = bus
+}
+
+// OnChange registers a callback invoked after a theme is activated, customized, installed or uninstalled
+func (m *Manager) OnChange(listener func()) {
+	m.listeners = append(m.listeners, listener)
+}
+
+func (m *Manager) notifyChange() {
+	for _, listener := range m.listeners {
+		listener()
+	}
 }
 
 func (m *Manager) LoadThemes() error {
@@ -90,15 +126,9 @@ func (m *Manager) LoadThemes() error {
 }
 
 func (m *Manager) loadTheme(path string) error {
-	metadataPath := filepath.Join(path, "metadata.json")
-	data, err := ioutil.ReadFile(metadataPath)
+	theme, err := readMetadata(path)
 	if err != nil {
-		return fmt.Errorf("failed to read metadata.json: %w", err)
-	}
-
-	var theme Theme
-	if err := json.Unmarshal(data, &theme); err != nil {
-		return fmt.Errorf("failed to parse metadata.json: %w", err)
+		return err
 	}
 
 	theme.Path = path
@@ -107,13 +137,27 @@ func (m *Manager) loadTheme(path string) error {
 
 	// Load theme settings from database if exists
 	if m.db != nil {
-		m.loadThemeFromDB(&theme)
+		m.loadThemeFromDB(theme)
 	}
 
-	m.themes[theme.Name] = &theme
+	m.themes[theme.Name] = theme
 	return nil
 }
 
+// readMetadata parses the metadata.json in a theme directory
+func readMetadata(path string) (*Theme, error) {
+	data, err := ioutil.ReadFile(filepath.Join(path, "metadata.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata.json: %w", err)
+	}
+
+	var theme Theme
+	if err := json.Unmarshal(data, &theme); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata.json: %w", err)
+	}
+	return &theme, nil
+}
+
 func (m *Manager) loadThemeFromDB(theme *Theme) error {
 	collection := m.db.Collection("themes")
 	var dbTheme models.ThemeMetadata
@@ -173,7 +217,37 @@ func (m *Manager) SetActiveTheme(name string) error {
 		return fmt.Errorf("theme requirements not met: %w", err)
 	}
 
-	// Update database
+	// Refuse structurally broken themes before anything is switched
+	if err := validateThemeStructure(theme); err != nil {
+		return err
+	}
+
+	previous := m.active
+	if err := m.switchTo(name); err != nil {
+		return err
+	}
+
+	// Run activation hooks and roll back to the previous theme if any fails
+	if err := m.runActivationHooks(theme); err != nil {
+		if _, exists := m.themes[previous]; exists && previous != name {
+			if rollbackErr := m.switchTo(previous); rollbackErr != nil {
+				return fmt.Errorf("theme activation failed: %w (rollback to %s also failed: %v)", err, previous, rollbackErr)
+			}
+		}
+		return fmt.Errorf("theme activation failed: %w", err)
+	}
+
+	m.notifyChange()
+	m.events.Emit(EventThemeActivated, map[string]interface{}{
+		"theme":    name,
+		"version":  theme.Version,
+		"previous": previous,
+	})
+	return nil
+}
+
+// switchTo records name as the active theme in the database and in memory
+func (m *Manager) switchTo(name string) error {
 	if m.db != nil {
 		if err := m.setActiveThemeInDB(name); err != nil {
 			return fmt.Errorf("failed to update database: %w", err)
@@ -181,6 +255,31 @@ func (m *Manager) SetActiveTheme(name string) error {
 	}
 
 	m.active = name
+	for themeName, t := range m.themes {
+		t.IsActive = themeName == name
+	}
+	return nil
+}
+
+// DeactivateTheme deactivates a theme by switching back to the default theme,
+// so the site is never left without an active theme
+func (m *Manager) DeactivateTheme(name string) error {
+	if _, exists := m.themes[name]; !exists {
+		return fmt.Errorf("theme %s not found", name)
+	}
+
+	if name == DefaultTheme {
+		return fmt.Errorf("cannot deactivate the default theme")
+	}
+
+	if m.active != name {
+		return fmt.Errorf("theme %s is not active", name)
+	}
+
+	if err := m.SetActiveTheme(DefaultTheme); err != nil {
+		return fmt.Errorf("failed to fall back to default theme: %w", err)
+	}
+
 	return nil
 }
 
@@ -200,17 +299,18 @@ func (m *Manager) validateThemeRequirements(theme *Theme) error {
 	return nil
 }
 
+// setActiveThemeInDB switches the active theme inside a transaction when the
+// deployment supports one, relying on compensating updates otherwise
 func (m *Manager) setActiveThemeInDB(name string) error {
-	collection := m.db.Collection("themes")
-	ctx := context.Background()
-
-	// Deactivate all themes
-	_, err := collection.UpdateMany(ctx, bson.M{}, bson.M{
-		"$set": bson.M{"is_active": false, "updated_at": time.Now()},
+	return m.db.WithTransaction(context.Background(), func(sc mongo.SessionContext) error {
+		return m.switchActiveTheme(sc, name)
 	})
-	if err != nil {
-		return err
-	}
+}
+
+// switchActiveTheme activates the new theme before deactivating the others so
+// that a failure part-way through never leaves zero active themes
+func (m *Manager) switchActiveTheme(ctx context.Context, name string) error {
+	collection := m.db.Collection("themes")
 
 	// Activate the selected theme
 	filter := bson.M{"name": name}
@@ -251,14 +351,26 @@ func (m *Manager) setActiveThemeInDB(name string) error {
 			UpdatedAt:   time.Now(),
 		}
 
-		_, err = collection.InsertOne(ctx, themeMetadata)
-		if err != nil {
+		if _, err := collection.InsertOne(ctx, themeMetadata); err != nil {
 			return err
 		}
 	}
 
+	// Deactivate all other themes
+	_, err = collection.UpdateMany(ctx, bson.M{"name": bson.M{"$ne": name}, "is_active": true}, bson.M{
+		"$set": bson.M{"is_active": false, "updated_at": time.Now()},
+	})
+	if err != nil {
+		// Compensate so the previously active theme remains the only active one
+		if m.active != name {
+			collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"is_active": false}})
+		}
+		return err
+	}
+
 	return nil
 }
+
 func (m *Manager) GetActiveTheme() string {
 	return m.active
 }
@@ -272,24 +384,86 @@ func (m *Manager) GetThemeCustomization(name string) (Customization, error) {
 	return theme.Customization, nil
 }
 
+// GetThemeCustomizationWithTime returns a theme's customization together with the
+// time the theme was last updated
+func (m *Manager) GetThemeCustomizationWithTime(name string) (Customization, time.Time, error) {
+	theme, exists := m.themes[name]
+	if !exists {
+		return Customization{}, time.Time{}, fmt.Errorf("theme not found")
+	}
+
+	return theme.Customization, theme.UpdatedAt, nil
+}
+
 func (m *Manager) UpdateThemeCustomization(name string, customization Customization) error {
 	theme, exists := m.themes[name]
 	if !exists {
 		return fmt.Errorf("theme not found")
 	}
 
+	colors, err := normalizeColors(customization.Colors)
+	if err != nil {
+		return err
+	}
+	customization.Colors = colors
+	previous := theme.Customization
+
 	// Update in memory
 	theme.Customization = customization
 	theme.UpdatedAt = time.Now()
 
 	// Update in database
 	if m.db != nil {
-		return m.updateThemeCustomizationInDB(name, customization)
+		if err := m.updateThemeCustomizationInDB(name, customization); err != nil {
+			return err
+		}
 	}
 
+	m.notifyChange()
+	go m.events.Emit(EventThemeCustomizationUpdated, map[string]interface{}{
+		"theme":          name,
+		"active":         name == m.active,
+		"changed_fields": changedFields(previous, customization),
+	})
 	return nil
 }
 
+// InvalidColorsError reports customization colors that are not valid CSS colors
+type InvalidColorsError struct {
+	Fields map[string]string
+}
+
+func (e *InvalidColorsError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return fmt.Sprintf("invalid color values: %s", strings.Join(keys, ", "))
+}
+
+// normalizeColors validates every color and returns a copy with shorthand hex
+// values expanded. All invalid keys are reported together.
+func normalizeColors(colors map[string]string) (map[string]string, error) {
+	if colors == nil {
+		return nil, nil
+	}
+
+	normalized := make(map[string]string, len(colors))
+	invalid := make(map[string]string)
+	for key, value := range colors {
+		if !models.IsValidColor(value) {
+			invalid["colors."+key] = "must be a hex, rgb()/rgba() or named CSS color"
+			continue
+		}
+		normalized[key] = models.NormalizeColor(value)
+	}
+	if len(invalid) > 0 {
+		return nil, &InvalidColorsError{Fields: invalid}
+	}
+	return normalized, nil
+}
+
 func (m *Manager) updateThemeCustomizationInDB(name string, customization Customization) error {
 	collection := m.db.Collection("themes")
 
@@ -309,67 +483,22 @@ func (m *Manager) updateThemeCustomizationInDB(name string, customization Custom
 	return err
 }
 
-func (m *Manager) InstallTheme(themePath string) error {
-	// Extract theme to themes directory
-	themeName := filepath.Base(themePath)
-	themeDir := filepath.Join(m.themePath, themeName)
-
-	// Create theme directory
-	if err := os.MkdirAll(themeDir, 0755); err != nil {
-		return fmt.Errorf("failed to create theme directory: %w", err)
-	}
-
-	// In a real implementation, you'd extract the theme package here
-	// For now, we'll assume the theme is already in the correct location
-
-	// Load the theme
-	return m.loadTheme(themeDir)
-}
-
-func (m *Manager) UninstallTheme(name string) error {
-	theme, exists := m.themes[name]
-	if !exists {
-		return fmt.Errorf("theme not found")
-	}
-
-	// Don't allow uninstalling active theme
-	if theme.IsActive {
-		return fmt.Errorf("cannot uninstall active theme")
-	}
-
-	// Remove from database
-	if m.db != nil {
-		collection := m.db.Collection("themes")
-		_, err := collection.DeleteOne(context.Background(), bson.M{"name": name})
-		if err != nil {
-			return fmt.Errorf("failed to remove theme from database: %w", err)
-		}
-	}
-
-	// Remove theme directory
-	if err := os.RemoveAll(theme.Path); err != nil {
-		return fmt.Errorf("failed to remove theme directory: %w", err)
-	}
-
-	// Remove from memory
-	delete(m.themes, name)
-
-	return nil
-}
-
+// GetThemeAssets returns the full paths of a theme's assets. A theme without
+// assets yields an empty map; ErrThemeNotFound is returned for unknown themes.
 func (m *Manager) GetThemeAssets(name string) (map[string]string, error) {
 	theme, exists := m.themes[name]
 	if !exists {
-		return nil, fmt.Errorf("theme not found")
+		return nil, fmt.Errorf("%w: %s", ErrThemeNotFound, name)
 	}
 
-	assets := make(map[string]string)
+	return assetPaths(theme), nil
+}
 
-	// Build full paths for assets
+// assetPaths joins each of the theme's asset paths onto its directory
+func assetPaths(theme *Theme) map[string]string {
+	assets := make(map[string]string, len(theme.Assets))
 	for assetType, assetPath := range theme.Assets {
-		fullPath := filepath.Join(theme.Path, assetPath)
-		assets[assetType] = fullPath
+		assets[assetType] = filepath.Join(theme.Path, assetPath)
 	}
-
-	return assets, nil
+	return assets
 }
@@ -0,0 +1,55 @@
+package themes
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTheme installs a theme with the given metadata fields under root
+func writeTheme(t *testing.T, root string, metadata map[string]interface{}) string {
+	t.Helper()
+	dir := filepath.Join(root, metadata["name"].(string))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "metadata.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadThemes(t *testing.T) {
+	root := t.TempDir()
+	writeTheme(t, root, map[string]interface{}{"name": "default", "version": "1.0.0"})
+	writeTheme(t, root, map[string]interface{}{"name": "dark", "version": "2.1.0", "author": "Jane"})
+	// A directory without metadata is skipped, not fatal
+	os.MkdirAll(filepath.Join(root, "scratch"), 0755)
+
+	m := NewManager(root, nil)
+	if err := m.LoadThemes(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.GetAllThemes()); n != 2 {
+		t.Fatalf("loaded %d themes, want 2", n)
+	}
+	dark, ok := m.GetTheme("dark")
+	if !ok || dark.Version != "2.1.0" || dark.Path != filepath.Join(root, "dark") {
+		t.Errorf("dark theme = %+v", dark)
+	}
+	if m.GetActiveTheme() != DefaultTheme {
+		t.Errorf("active theme %q, want %q", m.GetActiveTheme(), DefaultTheme)
+	}
+}
+
+func TestLoadThemesMissingDirectory(t *testing.T) {
+	m := NewManager(filepath.Join(t.TempDir(), "missing"), nil)
+	if err := m.LoadThemes(); err == nil {
+		t.Fatal("LoadThemes succeeded without a theme directory")
+	}
+}
@@ -0,0 +1,138 @@
+package themes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// themeReferenceCollections hold records keyed by theme_name that are orphaned
+// when their theme is uninstalled
+var themeReferenceCollections = []string{"theme_configs", "theme_backups", "theme_customizations"}
+
+// ErrActiveTheme is returned when uninstalling the active theme
+var ErrActiveTheme = errors.New("cannot uninstall active theme, deactivate it first")
+
+// ReferencedThemeError is returned when uninstalling a theme that other records
+// still reference without asking for them to be deleted too
+type ReferencedThemeError struct {
+	Theme      string
+	References map[string]int64
+}
+
+func (e *ReferencedThemeError) Error() string {
+	return fmt.Sprintf("theme %s is still referenced by %v; uninstall with cascade to delete them", e.Theme, e.References)
+}
+
+// UninstallPreview reports what uninstalling a theme would do
+type UninstallPreview struct {
+	Theme      string           `json:"theme"`
+	Active     bool             `json:"active"`
+	References map[string]int64 `json:"references"` // Records per collection that reference the theme
+	WillDelete []string         `json:"will_delete"`
+	Blocked    string           `json:"blocked,omitempty"` // Why the uninstall would be refused, even with cascade
+}
+
+// PreviewUninstall reports whether a theme can be uninstalled, how many records
+// reference it and what an uninstall with cascade would delete. Nothing is changed.
+func (m *Manager) PreviewUninstall(name string) (*UninstallPreview, error) {
+	theme, exists := m.themes[name]
+	if !exists {
+		return nil, ErrThemeNotFound
+	}
+
+	references, err := m.themeReferences(context.Background(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &UninstallPreview{
+		Theme:      name,
+		Active:     theme.IsActive,
+		References: references,
+		WillDelete: []string{"theme directory " + theme.Path, "themes record"},
+	}
+	for _, collection := range themeReferenceCollections {
+		if count := references[collection]; count > 0 {
+			preview.WillDelete = append(preview.WillDelete, fmt.Sprintf("%d %s records", count, collection))
+		}
+	}
+	if theme.IsActive {
+		preview.Blocked = ErrActiveTheme.Error()
+	}
+	return preview, nil
+}
+
+// UninstallTheme removes a theme's files and database record. The active theme
+// cannot be uninstalled. When other records still reference the theme, the
+// uninstall is refused with a ReferencedThemeError unless cascade is set, in
+// which case they are deleted too.
+func (m *Manager) UninstallTheme(name string, cascade bool) error {
+	theme, exists := m.themes[name]
+	if !exists {
+		return ErrThemeNotFound
+	}
+
+	// Don't allow uninstalling active theme
+	if theme.IsActive {
+		return ErrActiveTheme
+	}
+
+	// Remove from database
+	if m.db != nil {
+		ctx := context.Background()
+		references, err := m.themeReferences(ctx, name)
+		if err != nil {
+			return err
+		}
+		if len(references) > 0 && !cascade {
+			return &ReferencedThemeError{Theme: name, References: references}
+		}
+
+		for collection := range references {
+			if _, err := m.db.Collection(collection).DeleteMany(ctx, bson.M{"theme_name": name}); err != nil {
+				return fmt.Errorf("failed to remove theme records from %s: %w", collection, err)
+			}
+		}
+
+		collection := m.db.Collection("themes")
+		_, err = collection.DeleteOne(ctx, bson.M{"name": name})
+		if err != nil {
+			return fmt.Errorf("failed to remove theme from database: %w", err)
+		}
+	}
+
+	// Remove theme directory
+	if err := os.RemoveAll(theme.Path); err != nil {
+		return fmt.Errorf("failed to remove theme directory: %w", err)
+	}
+
+	// Remove from memory
+	delete(m.themes, name)
+
+	m.notifyChange()
+	return nil
+}
+
+// themeReferences counts the records referencing a theme in each collection that
+// has any
+func (m *Manager) themeReferences(ctx context.Context, name string) (map[string]int64, error) {
+	references := make(map[string]int64)
+	if m.db == nil {
+		return references, nil
+	}
+
+	for _, collection := range themeReferenceCollections {
+		count, err := m.db.Collection(collection).CountDocuments(ctx, bson.M{"theme_name": name})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s records: %w", collection, err)
+		}
+		if count > 0 {
+			references[collection] = count
+		}
+	}
+	return references, nil
+}
@@ -0,0 +1,156 @@
+package themes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-cms/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// testDB connects to MONGO_TEST_URI and returns a scratch database that is
+// dropped when the test ends. Tests using it are skipped without the variable.
+func testDB(t *testing.T) *database.DB {
+	t.Helper()
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	db, err := database.Connect(uri, fmt.Sprintf("gocms_test_%d", time.Now().UnixNano()), database.Consistency{})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		db.Database.Drop(ctx)
+		db.Disconnect(ctx)
+	})
+	return db
+}
+
+// previewOf fetches the uninstall preview of a theme
+func previewOf(t *testing.T, r http.Handler, name string) UninstallPreview {
+	t.Helper()
+	w := request(r, http.MethodGet, "/themes/"+name+"/uninstall-preview", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview %s: status %d: %s", name, w.Code, w.Body)
+	}
+	var body struct {
+		Preview UninstallPreview `json:"preview"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Preview
+}
+
+func TestUninstallActiveTheme(t *testing.T) {
+	m := loadedManager(t, "default", "dark")
+	if err := m.SetActiveTheme("dark"); err != nil {
+		t.Fatal(err)
+	}
+	r := themeEngine(t, m)
+
+	preview := previewOf(t, r, "dark")
+	if !preview.Active || preview.Blocked != ErrActiveTheme.Error() {
+		t.Errorf("preview = %+v, want active and blocked", preview)
+	}
+
+	// Even with cascade the active theme stays
+	if w := request(r, http.MethodDelete, "/themes/dark?cascade=true", ""); w.Code != http.StatusConflict {
+		t.Errorf("uninstall active: status %d, want 409", w.Code)
+	}
+	theme, exists := m.GetTheme("dark")
+	if !exists {
+		t.Fatal("active theme was removed")
+	}
+	if _, err := os.Stat(theme.Path); err != nil {
+		t.Errorf("active theme files were removed: %v", err)
+	}
+}
+
+func TestUninstallInactiveTheme(t *testing.T) {
+	m := loadedManager(t, "default", "dark")
+	r := themeEngine(t, m)
+	theme, _ := m.GetTheme("dark")
+
+	preview := previewOf(t, r, "dark")
+	if preview.Active || preview.Blocked != "" || len(preview.References) != 0 {
+		t.Errorf("preview = %+v, want an unblocked uninstall", preview)
+	}
+	if want := []string{"theme directory " + theme.Path, "themes record"}; !reflect.DeepEqual(preview.WillDelete, want) {
+		t.Errorf("will delete %v, want %v", preview.WillDelete, want)
+	}
+
+	if w := request(r, http.MethodDelete, "/themes/dark", ""); w.Code != http.StatusOK {
+		t.Fatalf("uninstall: status %d: %s", w.Code, w.Body)
+	}
+	if _, exists := m.GetTheme("dark"); exists {
+		t.Error("theme still loaded")
+	}
+	if _, err := os.Stat(theme.Path); !os.IsNotExist(err) {
+		t.Errorf("theme directory still exists: %v", err)
+	}
+
+	if w := request(r, http.MethodDelete, "/themes/dark", ""); w.Code != http.StatusNotFound {
+		t.Errorf("second uninstall: status %d, want 404", w.Code)
+	}
+	if w := request(r, http.MethodGet, "/themes/dark/uninstall-preview", ""); w.Code != http.StatusNotFound {
+		t.Errorf("preview of removed theme: status %d, want 404", w.Code)
+	}
+}
+
+func TestUninstallReferencedTheme(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	m := loadedManager(t, "default", "dark")
+	m.db = db
+	r := themeEngine(t, m)
+
+	for collection, count := range map[string]int{"theme_configs": 1, "theme_backups": 2} {
+		for i := 0; i < count; i++ {
+			if _, err := db.Collection(collection).InsertOne(ctx, bson.M{"theme_name": "dark"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Records of other themes are neither counted nor deleted
+	db.Collection("theme_backups").InsertOne(ctx, bson.M{"theme_name": "default"})
+
+	preview := previewOf(t, r, "dark")
+	if want := map[string]int64{"theme_configs": 1, "theme_backups": 2}; !reflect.DeepEqual(preview.References, want) {
+		t.Errorf("references = %v, want %v", preview.References, want)
+	}
+	if len(preview.WillDelete) != 4 {
+		t.Errorf("will delete %v, want the directory, the record and both collections", preview.WillDelete)
+	}
+
+	if w := request(r, http.MethodDelete, "/themes/dark", ""); w.Code != http.StatusConflict {
+		t.Fatalf("uninstall without cascade: status %d, want 409", w.Code)
+	}
+	if _, exists := m.GetTheme("dark"); !exists {
+		t.Fatal("refused uninstall removed the theme")
+	}
+
+	if w := request(r, http.MethodDelete, "/themes/dark?cascade=true", ""); w.Code != http.StatusOK {
+		t.Fatalf("uninstall with cascade: status %d: %s", w.Code, w.Body)
+	}
+	for _, collection := range themeReferenceCollections {
+		if n, _ := db.Collection(collection).CountDocuments(ctx, bson.M{"theme_name": "dark"}); n != 0 {
+			t.Errorf("%d %s records left", n, collection)
+		}
+	}
+	if n, _ := db.Collection("theme_backups").CountDocuments(ctx, bson.M{"theme_name": "default"}); n != 1 {
+		t.Errorf("other theme's backups: %d, want 1", n)
+	}
+}
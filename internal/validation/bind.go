@@ -0,0 +1,107 @@
+// Package validation turns request binding failures into client-friendly errors.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldErrors maps the failures in a validator error to {field: message}, keyed by
+// the snake_case JSON name of each field. It returns nil for other errors.
+func FieldErrors(err error) map[string]string {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	fields := make(map[string]string, len(validationErrors))
+	for _, fe := range validationErrors {
+		fields[toSnakeCase(fe.Field())] = fieldMessage(fe)
+	}
+	return fields
+}
+
+// BindError responds 400 with a consistent shape for a failed ShouldBindJSON:
+// {"error": ..., "fields": {...}} where fields is present for validation failures.
+// A body cut off by a size limit gets 413 with the limit in max_bytes.
+func BindError(c *gin.Context, err error) {
+	if fields := FieldErrors(err); fields != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Validation failed",
+			"fields": fields,
+		})
+		return
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var sizeErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &sizeErr):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":     "Request body too large",
+			"max_bytes": sizeErr.Limit,
+		})
+	case errors.As(err, &syntaxErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Malformed JSON body"})
+	case errors.As(err, &typeErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Validation failed",
+			"fields": map[string]string{typeErr.Field: fmt.Sprintf("must be a %s", typeErr.Type.String())},
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// fieldMessage describes a single validation failure
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fe.Kind().String() == "string" {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if fe.Kind().String() == "string" {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "len":
+		return fmt.Sprintf("must be exactly %s characters", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "url":
+		return "must be a valid URL"
+	default:
+		return "is invalid"
+	}
+}
+
+// toSnakeCase converts a Go field name such as RefreshToken to refresh_token
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Start a new word unless continuing an acronym like "ID"
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
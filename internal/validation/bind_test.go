@@ -0,0 +1,114 @@
+package validation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type signupRequest struct {
+	Email        string `json:"email" binding:"required,email"`
+	Username     string `json:"username" binding:"required,min=3,max=20"`
+	Role         string `json:"role" binding:"omitempty,oneof=user admin"`
+	Age          int    `json:"age" binding:"omitempty,min=13"`
+	RefreshToken string `json:"refresh_token" binding:"omitempty,len=4"`
+}
+
+func bind(t *testing.T, body string) (int, map[string]interface{}) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", func(c *gin.Context) {
+		var req signupRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BindError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	var decoded map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &decoded)
+	return w.Code, decoded
+}
+
+func fieldsOf(t *testing.T, body map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	fields, ok := body["fields"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("response has no fields: %v", body)
+	}
+	return fields
+}
+
+func TestBindErrorReportsEachField(t *testing.T) {
+	code, body := bind(t, `{"email":"nope","username":"ab","role":"root","age":5,"refresh_token":"x"}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", code)
+	}
+	want := map[string]string{
+		"email":         "must be a valid email address",
+		"username":      "must be at least 3 characters",
+		"role":          "must be one of: user, admin",
+		"age":           "must be at least 13",
+		"refresh_token": "must be exactly 4 characters",
+	}
+	fields := fieldsOf(t, body)
+	for field, message := range want {
+		if fields[field] != message {
+			t.Errorf("fields[%s] = %v, want %q", field, fields[field], message)
+		}
+	}
+}
+
+func TestBindErrorRequired(t *testing.T) {
+	_, body := bind(t, `{}`)
+	fields := fieldsOf(t, body)
+	if fields["email"] != "is required" || fields["username"] != "is required" {
+		t.Fatalf("fields = %v", fields)
+	}
+}
+
+func TestBindErrorMalformedJSON(t *testing.T) {
+	code, body := bind(t, `{"email":`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", code)
+	}
+	if _, hasFields := body["fields"]; hasFields {
+		t.Fatalf("malformed body reported field errors: %v", body)
+	}
+}
+
+func TestBindErrorWrongType(t *testing.T) {
+	_, body := bind(t, `{"email":"a@example.com","username":"alice","age":"old"}`)
+	fields := fieldsOf(t, body)
+	if fields["age"] != "must be a int" {
+		t.Fatalf("fields = %v", fields)
+	}
+}
+
+func TestBindSucceeds(t *testing.T) {
+	if code, body := bind(t, `{"email":"a@example.com","username":"alice"}`); code != http.StatusNoContent {
+		t.Fatalf("status %d: %v", code, body)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"Email":         "email",
+		"RefreshToken":  "refresh_token",
+		"UserID":        "user_id",
+		"IDToken":       "id_token",
+		"MaxCMSVersion": "max_cms_version",
+	} {
+		if got := toSnakeCase(in); got != want {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
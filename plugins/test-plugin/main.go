@@ -53,6 +53,8 @@ type PluginSetting struct {
 	Description string      `json:"description,omitempty"`
 	Options     []string    `json:"options,omitempty"`
 	Required    bool        `json:"required"`
+	Group       string      `json:"group,omitempty"`
+	Order       int         `json:"order,omitempty"`
 }
 
 // NewPlugin is the entry point that will be called by the plugin manager
@@ -75,7 +77,7 @@ func (p *TestPluginPlugin) GetInfo() PluginInfo {
 func (p *TestPluginPlugin) Initialize(deps *PluginDependencies) error {
 	p.deps = deps
 	p.setDefaultSettings()
-	
+
 	// Perform any initialization here
 	// You could set up database connections, load configuration, etc.
 	return nil
@@ -83,10 +85,10 @@ func (p *TestPluginPlugin) Initialize(deps *PluginDependencies) error {
 
 func (p *TestPluginPlugin) setDefaultSettings() {
 	p.settings = map[string]interface{}{
-		"enabled":      true,
-		"auto_update":  false,
-		"cache_ttl":    3600,
-		"debug_mode":   false,
+		"enabled":     true,
+		"auto_update": false,
+		"cache_ttl":   3600,
+		"debug_mode":  false,
 	}
 }
 
@@ -96,7 +98,7 @@ func (p *TestPluginPlugin) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/info", p.handleInfo)
 	router.GET("/status", p.handleStatus)
 	router.POST("/action", p.handleAction)
-	
+
 	// Admin routes (if needed)
 	adminGroup := router.Group("/admin")
 	{
@@ -141,6 +143,8 @@ func (p *TestPluginPlugin) GetSettings() []PluginSetting {
 			Value:       p.settings["enabled"],
 			Description: "Enable or disable Test Plugin functionality",
 			Required:    false,
+			Group:       "General",
+			Order:       1,
 		},
 		{
 			Key:         "auto_update",
@@ -149,6 +153,8 @@ func (p *TestPluginPlugin) GetSettings() []PluginSetting {
 			Value:       p.settings["auto_update"],
 			Description: "Automatically update plugin when new version is available",
 			Required:    false,
+			Group:       "General",
+			Order:       2,
 		},
 		{
 			Key:         "cache_ttl",
@@ -157,6 +163,8 @@ func (p *TestPluginPlugin) GetSettings() []PluginSetting {
 			Value:       p.settings["cache_ttl"],
 			Description: "Time to live for cached data in seconds",
 			Required:    true,
+			Group:       "Performance",
+			Order:       1,
 		},
 		{
 			Key:         "debug_mode",
@@ -165,6 +173,8 @@ func (p *TestPluginPlugin) GetSettings() []PluginSetting {
 			Value:       p.settings["debug_mode"],
 			Description: "Enable debug logging for this plugin",
 			Required:    false,
+			Group:       "Advanced",
+			Order:       1,
 		},
 	}
 }
@@ -178,9 +188,9 @@ func (p *TestPluginPlugin) Shutdown() error {
 
 func (p *TestPluginPlugin) handleIndex(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"plugin":     p.GetInfo().Name,
-		"version":    p.GetInfo().Version,
-		"message":    "Welcome to Test Plugin!",
+		"plugin":  p.GetInfo().Name,
+		"version": p.GetInfo().Version,
+		"message": "Welcome to Test Plugin!",
 		"endpoints": []string{
 			"/",
 			"/info",
@@ -201,19 +211,19 @@ func (p *TestPluginPlugin) handleInfo(c *gin.Context) {
 
 func (p *TestPluginPlugin) handleStatus(c *gin.Context) {
 	enabled := p.settings["enabled"].(bool)
-	
+
 	c.JSON(http.StatusOK, gin.H{
-		"plugin":    p.GetInfo().Name,
-		"version":   p.GetInfo().Version,
-		"enabled":   enabled,
-		"uptime":    time.Now().Format(time.RFC3339),
-		"status":    "healthy",
+		"plugin":  p.GetInfo().Name,
+		"version": p.GetInfo().Version,
+		"enabled": enabled,
+		"uptime":  time.Now().Format(time.RFC3339),
+		"status":  "healthy",
 	})
 }
 
 func (p *TestPluginPlugin) handleAction(c *gin.Context) {
 	var requestData struct {
-		Action string                 `json:"action"` binding:"required"`
+		Action string                 `json:"action" binding:"required"`
 		Data   map[string]interface{} `json:"data"`
 	}
 
@@ -243,8 +253,8 @@ func (p *TestPluginPlugin) handleAction(c *gin.Context) {
 
 func (p *TestPluginPlugin) handleAdminDashboard(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"plugin":  p.GetInfo().Name,
-		"title":   "Test Plugin Dashboard",
+		"plugin": p.GetInfo().Name,
+		"title":  "Test Plugin Dashboard",
 		"stats": map[string]interface{}{
 			"enabled":    p.settings["enabled"],
 			"version":    p.GetInfo().Version,
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	plugintest "go-cms/plugins/testing"
+)
+
+func TestPluginContract(t *testing.T) {
+	plugintest.Check(t, plugintest.MustAdapt(t, NewPlugin()))
+}
+
+func TestPluginRoutes(t *testing.T) {
+	h := plugintest.New(t, plugintest.MustAdapt(t, NewPlugin()))
+
+	rec := h.Request(http.MethodGet, "/status", nil)
+	plugintest.AssertStatus(t, rec, http.StatusOK)
+	if body := plugintest.DecodeJSON(t, rec); body["enabled"] != true || body["status"] != "healthy" {
+		t.Errorf("status = %v", body)
+	}
+
+	rec = h.JSON(t, http.MethodPost, "/action", map[string]string{"action": "ping"})
+	plugintest.AssertStatus(t, rec, http.StatusOK)
+	if body := plugintest.DecodeJSON(t, rec); body["message"] != "pong" {
+		t.Errorf("ping answered %v", body["message"])
+	}
+
+	plugintest.AssertStatus(t, h.JSON(t, http.MethodPost, "/action", map[string]string{}), http.StatusBadRequest)
+}
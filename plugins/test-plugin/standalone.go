@@ -0,0 +1,22 @@
+//go:build !cmsplugin
+
+package main
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// main serves the plugin's routes on its own for local testing. The host builds
+// plugins with the cmsplugin tag, which leaves this file out.
+func main() {
+	p := NewPlugin()
+	if err := p.Initialize(&PluginDependencies{}); err != nil {
+		log.Fatalf("Initialize failed: %v", err)
+	}
+
+	router := gin.Default()
+	p.RegisterRoutes(router.Group("/api/v1/plugins/test-plugin"))
+	log.Fatal(router.Run(":8081"))
+}
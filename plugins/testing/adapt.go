@@ -0,0 +1,158 @@
+package plugintest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"go-cms/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Adapt returns plugin as a plugins.Plugin. A plugin kept in a module of its own
+// cannot import the host's internal packages, so it declares its own copies of
+// PluginInfo, PluginSetting, AdminMenuItem and PluginDependencies; Adapt wraps
+// such a plugin so the harness can drive it. Info, settings and menu items are
+// converted through their JSON form, and dependencies are copied into the
+// plugin's own type field by field where the names and types match.
+//
+// Plugins that already implement plugins.Plugin are returned as they are.
+func Adapt(plugin interface{}) (plugins.Plugin, error) {
+	if native, ok := plugin.(plugins.Plugin); ok {
+		return native, nil
+	}
+
+	v := reflect.ValueOf(plugin)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("plugin is nil")
+	}
+
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	routerType := reflect.TypeOf((*gin.RouterGroup)(nil))
+	signatures := []struct {
+		name string
+		in   func(reflect.Type) bool
+		out  func(reflect.Type) bool
+	}{
+		{"GetInfo", noArgs, oneResult(nil)},
+		{"Initialize", func(t reflect.Type) bool {
+			return t.NumIn() == 1 && t.In(0).Kind() == reflect.Pointer && t.In(0).Elem().Kind() == reflect.Struct
+		}, oneResult(errorType)},
+		{"RegisterRoutes", takesRouter(routerType), noResults},
+		{"GetAdminMenuItems", noArgs, oneResult(nil)},
+		{"GetSettings", noArgs, oneResult(nil)},
+		{"Shutdown", noArgs, oneResult(errorType)},
+	}
+
+	adapted := &adaptedPlugin{v: v}
+	for _, sig := range signatures {
+		method := v.MethodByName(sig.name)
+		if !method.IsValid() {
+			return nil, fmt.Errorf("%T has no %s method", plugin, sig.name)
+		}
+		if !sig.in(method.Type()) || !sig.out(method.Type()) {
+			return nil, fmt.Errorf("%T.%s has signature %s, which does not match the plugin contract", plugin, sig.name, method.Type())
+		}
+	}
+
+	public := v.MethodByName("RegisterPublicRoutes")
+	if public.IsValid() && takesRouter(routerType)(public.Type()) && noResults(public.Type()) {
+		return &adaptedPublicPlugin{adapted}, nil
+	}
+	return adapted, nil
+}
+
+func noArgs(t reflect.Type) bool    { return t.NumIn() == 0 }
+func noResults(t reflect.Type) bool { return t.NumOut() == 0 }
+
+// oneResult matches methods returning a single value, of type want when it is not nil
+func oneResult(want reflect.Type) func(reflect.Type) bool {
+	return func(t reflect.Type) bool {
+		return t.NumOut() == 1 && (want == nil || t.Out(0) == want)
+	}
+}
+
+func takesRouter(routerType reflect.Type) func(reflect.Type) bool {
+	return func(t reflect.Type) bool { return t.NumIn() == 1 && t.In(0) == routerType }
+}
+
+// adaptedPlugin implements plugins.Plugin by calling the wrapped plugin's
+// methods through reflection. Conversion failures panic, which Validate reports
+// as a problem with the method.
+type adaptedPlugin struct {
+	v reflect.Value
+}
+
+func (p *adaptedPlugin) call(method string, args ...reflect.Value) []reflect.Value {
+	return p.v.MethodByName(method).Call(args)
+}
+
+func (p *adaptedPlugin) GetInfo() plugins.PluginInfo {
+	var info plugins.PluginInfo
+	convert("GetInfo", p.call("GetInfo")[0], &info)
+	return info
+}
+
+func (p *adaptedPlugin) Initialize(deps *plugins.PluginDependencies) error {
+	method := p.v.MethodByName("Initialize")
+	own := reflect.New(method.Type().In(0).Elem())
+	if deps != nil {
+		host := reflect.ValueOf(deps).Elem()
+		for i := 0; i < host.NumField(); i++ {
+			field := own.Elem().FieldByName(host.Type().Field(i).Name)
+			if field.IsValid() && field.CanSet() && host.Field(i).Type().AssignableTo(field.Type()) {
+				field.Set(host.Field(i))
+			}
+		}
+	}
+	return asError(method.Call([]reflect.Value{own})[0])
+}
+
+func (p *adaptedPlugin) RegisterRoutes(router *gin.RouterGroup) {
+	p.call("RegisterRoutes", reflect.ValueOf(router))
+}
+
+func (p *adaptedPlugin) GetAdminMenuItems() []plugins.AdminMenuItem {
+	var items []plugins.AdminMenuItem
+	convert("GetAdminMenuItems", p.call("GetAdminMenuItems")[0], &items)
+	return items
+}
+
+func (p *adaptedPlugin) GetSettings() []plugins.PluginSetting {
+	var settings []plugins.PluginSetting
+	convert("GetSettings", p.call("GetSettings")[0], &settings)
+	return settings
+}
+
+func (p *adaptedPlugin) Shutdown() error {
+	return asError(p.call("Shutdown")[0])
+}
+
+// adaptedPublicPlugin is an adaptedPlugin whose plugin also serves public routes
+type adaptedPublicPlugin struct {
+	*adaptedPlugin
+}
+
+func (p *adaptedPublicPlugin) RegisterPublicRoutes(router *gin.RouterGroup) {
+	p.call("RegisterPublicRoutes", reflect.ValueOf(router))
+}
+
+// convert copies a value returned by the plugin into the host type at target
+// through its JSON form
+func convert(method string, value reflect.Value, target interface{}) {
+	data, err := json.Marshal(value.Interface())
+	if err == nil {
+		err = json.Unmarshal(data, target)
+	}
+	if err != nil {
+		panic(fmt.Sprintf("%s returned a value that does not convert to the host's type: %v", method, err))
+	}
+}
+
+func asError(v reflect.Value) error {
+	if v.IsNil() {
+		return nil
+	}
+	return v.Interface().(error)
+}
@@ -0,0 +1,153 @@
+// Package plugintest helps plugin authors test a plugin against the host
+// contract without running the server. Import it as
+//
+//	import plugintest "go-cms/plugins/testing"
+//
+// A typical test:
+//
+//	func TestHelloWorld(t *testing.T) {
+//		plugintest.Check(t, plugintest.MustAdapt(t, NewPlugin()))
+//
+//		h := plugintest.New(t, plugintest.MustAdapt(t, NewPlugin()))
+//		rec := h.Request(http.MethodGet, "/hello", nil)
+//		plugintest.AssertStatus(t, rec, http.StatusOK)
+//		body := plugintest.DecodeJSON(t, rec)
+//		if body["message"] != "Hello, World!" {
+//			t.Errorf("unexpected message %v", body["message"])
+//		}
+//	}
+//
+// Check verifies the plugin's info, settings and lifecycle; New initializes it
+// with test dependencies and mounts its routes the way the host does, at
+// /api/v1/plugins/<name>, so requests use paths relative to that prefix.
+// MustAdapt lets a plugin that declares its own copies of the contract types,
+// as plugins in modules of their own do, be used with both; see Adapt.
+// plugins/test-plugin/main_test.go is a complete example.
+package plugintest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"go-cms/internal/config"
+	"go-cms/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cacheEntries bounds the in-memory cache handed to plugins under test
+const cacheEntries = 1000
+
+// Dependencies returns host dependencies suitable for tests: no database, an
+// empty config, a logger that discards output and an in-memory cache using the
+// plugin's cache_ttl setting. Tests that need more, such as a database, can set
+// the fields before passing the dependencies to Initialize.
+func Dependencies(plugin plugins.Plugin) *plugins.PluginDependencies {
+	name := plugin.GetInfo().Name
+	return &plugins.PluginDependencies{
+		Config: &config.Config{},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)).With("plugin", name),
+		Cache: plugins.NewPluginCache(
+			plugins.NewMemoryCache(cacheEntries),
+			name,
+			plugins.SettingsCacheTTL(plugins.SafeSettings(name, plugin)),
+		),
+	}
+}
+
+// MustAdapt is Adapt that fails the test when plugin does not match the contract
+func MustAdapt(t testing.TB, plugin interface{}) plugins.Plugin {
+	t.Helper()
+	adapted, err := Adapt(plugin)
+	if err != nil {
+		t.Fatalf("plugin does not match the host contract: %v", err)
+	}
+	return adapted
+}
+
+// Harness serves a plugin's routes from an in-process router
+type Harness struct {
+	Plugin plugins.Plugin
+	Deps   *plugins.PluginDependencies
+	Router *gin.Engine
+	prefix string
+}
+
+// New initializes plugin with Dependencies and registers its routes, including
+// public routes, on a test router. The plugin is shut down when the test ends.
+func New(t testing.TB, plugin plugins.Plugin) *Harness {
+	t.Helper()
+	return NewWithDependencies(t, plugin, Dependencies(plugin))
+}
+
+// NewWithDependencies is New with caller-supplied dependencies
+func NewWithDependencies(t testing.TB, plugin plugins.Plugin, deps *plugins.PluginDependencies) *Harness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	if err := plugin.Initialize(deps); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := plugin.Shutdown(); err != nil {
+			t.Errorf("Shutdown failed: %v", err)
+		}
+	})
+
+	h := &Harness{
+		Plugin: plugin,
+		Deps:   deps,
+		Router: gin.New(),
+		prefix: "/api/v1/plugins/" + plugin.GetInfo().Name,
+	}
+	plugin.RegisterRoutes(h.Router.Group(h.prefix))
+	if provider, ok := plugin.(plugins.PublicRoutesProvider); ok {
+		provider.RegisterPublicRoutes(h.Router.Group(h.prefix))
+	}
+	return h
+}
+
+// Request sends a request to path, relative to the plugin's route prefix, and
+// returns the recorded response
+func (h *Harness) Request(method, path string, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, h.prefix+path, body)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+// JSON sends payload encoded as JSON to path, relative to the plugin's route
+// prefix, and returns the recorded response
+func (h *Harness) JSON(t testing.TB, method, path string, payload interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("failed to encode request body: %v", err)
+	}
+	return h.Request(method, path, bytes.NewReader(body))
+}
+
+// AssertStatus fails the test when the response status is not want
+func AssertStatus(t testing.TB, rec *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if rec.Code != want {
+		t.Errorf("status = %d, want %d; body: %s", rec.Code, want, rec.Body.String())
+	}
+}
+
+// DecodeJSON decodes a JSON object response body, failing the test when it is not one
+func DecodeJSON(t testing.TB, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not a JSON object: %v; body: %s", err, rec.Body.String())
+	}
+	return body
+}
@@ -0,0 +1,191 @@
+package plugintest
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go-cms/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+// greeter is a well-behaved plugin with a protected and a public route
+type greeter struct {
+	settings    []plugins.PluginSetting
+	deps        *plugins.PluginDependencies
+	initPanic   bool
+	shutdownErr error
+}
+
+func newGreeter() *greeter {
+	return &greeter{settings: []plugins.PluginSetting{
+		{Key: "greeting", Label: "Greeting", Type: "text", Value: "Hello"},
+		{Key: "style", Label: "Style", Type: "select", Value: "plain", Options: []string{"plain", "loud"}},
+	}}
+}
+
+func (p *greeter) GetInfo() plugins.PluginInfo {
+	return plugins.PluginInfo{Name: "greeter", Version: "1.0.0"}
+}
+
+func (p *greeter) Initialize(deps *plugins.PluginDependencies) error {
+	if p.initPanic {
+		panic("no config")
+	}
+	p.deps = deps
+	return nil
+}
+
+func (p *greeter) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/greet", func(c *gin.Context) {
+		var body struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Hello, " + body.Name})
+	})
+}
+
+func (p *greeter) RegisterPublicRoutes(router *gin.RouterGroup) {
+	router.GET("/ping", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"message": "pong"}) })
+}
+
+func (p *greeter) GetAdminMenuItems() []plugins.AdminMenuItem { return nil }
+func (p *greeter) GetSettings() []plugins.PluginSetting       { return p.settings }
+func (p *greeter) Shutdown() error                            { return p.shutdownErr }
+
+func TestValidateAcceptsWellBehavedPlugin(t *testing.T) {
+	if problems := Validate(newGreeter()); len(problems) != 0 {
+		t.Errorf("problems = %v, want none", problems)
+	}
+}
+
+func TestValidateReportsProblems(t *testing.T) {
+	plugin := newGreeter()
+	plugin.initPanic = true
+	plugin.shutdownErr = errors.New("still running")
+	plugin.settings = append(plugin.settings,
+		plugins.PluginSetting{Key: "greeting", Type: "text"},
+		plugins.PluginSetting{Key: "color", Type: "colour"},
+		plugins.PluginSetting{Key: "size", Type: "select"},
+		plugins.PluginSetting{Key: "retries", Type: "number", Value: "three"},
+	)
+
+	var got []string
+	for _, problem := range Validate(plugin) {
+		got = append(got, problem.Error())
+	}
+	for _, want := range []string{
+		"setting greeting is declared more than once",
+		`setting color has unknown type "colour"`,
+		"select setting size has no options",
+		"setting retries has an invalid default",
+		"Initialize panicked: no config",
+		"Shutdown failed: still running",
+	} {
+		if !containsProblem(got, want) {
+			t.Errorf("problems %q do not include %q", got, want)
+		}
+	}
+}
+
+func containsProblem(problems []string, want string) bool {
+	for _, problem := range problems {
+		if strings.Contains(problem, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestHarnessServesRoutes(t *testing.T) {
+	plugin := newGreeter()
+	h := New(t, plugin)
+	if plugin.deps == nil || plugin.deps.Cache == nil || plugin.deps.Logger == nil {
+		t.Fatal("plugin was not initialized with test dependencies")
+	}
+
+	rec := h.JSON(t, http.MethodPost, "/greet", map[string]string{"name": "Ada"})
+	AssertStatus(t, rec, http.StatusOK)
+	if body := DecodeJSON(t, rec); body["message"] != "Hello, Ada" {
+		t.Errorf("message = %v", body["message"])
+	}
+
+	AssertStatus(t, h.JSON(t, http.MethodPost, "/greet", map[string]string{}), http.StatusBadRequest)
+	AssertStatus(t, h.Request(http.MethodGet, "/ping", nil), http.StatusOK)
+}
+
+// The foreign types are copies of the contract types, as a plugin in a module of
+// its own declares them
+type foreignInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type foreignSetting struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type foreignDeps struct {
+	Config interface{}
+	Logger interface{}
+	Extra  string
+}
+
+// foreignGreeter implements the contract with the foreign types
+type foreignGreeter struct {
+	deps *foreignDeps
+}
+
+func (p *foreignGreeter) GetInfo() foreignInfo { return foreignInfo{Name: "foreign", Version: "0.1.0"} }
+func (p *foreignGreeter) Initialize(deps *foreignDeps) error {
+	p.deps = deps
+	return nil
+}
+func (p *foreignGreeter) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/hello", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"message": "hi"}) })
+}
+func (p *foreignGreeter) GetAdminMenuItems() []struct{} { return nil }
+func (p *foreignGreeter) GetSettings() []foreignSetting {
+	return []foreignSetting{{Key: "loud", Type: "boolean", Value: true}}
+}
+func (p *foreignGreeter) Shutdown() error { return nil }
+
+func TestAdaptForeignPlugin(t *testing.T) {
+	if problems := Validate(MustAdapt(t, &foreignGreeter{})); len(problems) != 0 {
+		t.Errorf("problems = %v, want none", problems)
+	}
+
+	foreign := &foreignGreeter{}
+	plugin := MustAdapt(t, foreign)
+	if info := plugin.GetInfo(); info.Name != "foreign" || info.Version != "0.1.0" {
+		t.Errorf("info = %+v", info)
+	}
+	if settings := plugin.GetSettings(); len(settings) != 1 || settings[0].Value != true {
+		t.Errorf("settings = %+v", settings)
+	}
+
+	h := New(t, plugin)
+	if foreign.deps == nil || foreign.deps.Config == nil || foreign.deps.Logger == nil {
+		t.Errorf("dependencies were not copied into the plugin's own type: %+v", foreign.deps)
+	}
+	AssertStatus(t, h.Request(http.MethodGet, "/hello", nil), http.StatusOK)
+}
+
+func TestAdaptRejectsMismatchedPlugins(t *testing.T) {
+	if _, err := Adapt(struct{}{}); err == nil || !strings.Contains(err.Error(), "has no GetInfo method") {
+		t.Errorf("err = %v for a value without the contract's methods", err)
+	}
+
+	native := newGreeter()
+	if adapted, err := Adapt(native); err != nil || adapted != plugins.Plugin(native) {
+		t.Errorf("native plugin was wrapped: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package plugintest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"go-cms/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Validate checks that plugin honours the host contract and returns every
+// problem found:
+//   - GetInfo reports a name and version
+//   - settings pass plugins.CheckSettings, the host's own checks
+//   - Initialize, RegisterRoutes, GetAdminMenuItems and Shutdown do not panic
+//
+// The lifecycle calls use Dependencies, so Validate should be given a fresh
+// plugin instance.
+func Validate(plugin plugins.Plugin) []error {
+	var problems []error
+
+	info, err := call("GetInfo", func() plugins.PluginInfo { return plugin.GetInfo() })
+	if err != nil {
+		return append(problems, err)
+	}
+	if strings.TrimSpace(info.Name) == "" {
+		problems = append(problems, fmt.Errorf("GetInfo returned an empty name"))
+	}
+	if strings.TrimSpace(info.Version) == "" {
+		problems = append(problems, fmt.Errorf("GetInfo returned an empty version"))
+	}
+
+	settings, err := call("GetSettings", func() []plugins.PluginSetting { return plugin.GetSettings() })
+	if err != nil {
+		problems = append(problems, err)
+	}
+	problems = append(problems, plugins.CheckSettings(settings)...)
+
+	lifecycle := []struct {
+		name string
+		fn   func() error
+	}{
+		{"Initialize", func() error { return plugin.Initialize(Dependencies(plugin)) }},
+		{"RegisterRoutes", func() error {
+			gin.SetMode(gin.TestMode)
+			plugin.RegisterRoutes(gin.New().Group("/api/v1/plugins/" + info.Name))
+			return nil
+		}},
+		{"GetAdminMenuItems", func() error { plugin.GetAdminMenuItems(); return nil }},
+		{"Shutdown", plugin.Shutdown},
+	}
+	for _, step := range lifecycle {
+		stepErr, err := call(step.name, step.fn)
+		if err != nil {
+			problems = append(problems, err)
+		} else if stepErr != nil {
+			problems = append(problems, fmt.Errorf("%s failed: %w", step.name, stepErr))
+		}
+	}
+
+	return problems
+}
+
+// Check fails the test with every problem Validate finds
+func Check(t testing.TB, plugin plugins.Plugin) {
+	t.Helper()
+	for _, problem := range Validate(plugin) {
+		t.Error(problem)
+	}
+}
+
+// call runs fn, turning a panic into an error naming the method
+func call[T any](method string, fn func() T) (result T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s panicked: %v", method, r)
+		}
+	}()
+	return fn(), nil
+}